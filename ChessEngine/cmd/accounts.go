@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tomwatson6/chessbot/cmd/api"
+	"github.com/tomwatson6/chessbot/internal/account"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/game"
+)
+
+var accounts, _ = account.NewRegistry("")
+
+// authenticate gets the account of the token in the Authorization header, or the token query parameter for
+// clients such as browsers that cannot set headers on websockets. Requests without a token are anonymous.
+func authenticate(r *http.Request) (*account.Account, error) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+
+	if token == "" {
+		return nil, nil
+	}
+
+	a, err := accounts.Authenticate(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+func name(a *account.Account) string {
+	if a == nil {
+		return ""
+	}
+
+	return a.Name
+}
+
+func isAdmin(a *account.Account) bool {
+	return a != nil && a.Admin
+}
+
+// canView reports whether the caller can see the game, games with players can only be seen by them and admins
+func canView(a *account.Account, g *game.Game) bool {
+	if g.Player(colour.White) == "" && g.Player(colour.Black) == "" {
+		return true
+	}
+
+	return a != nil && (a.Admin || g.IsPlayer(a.Name))
+}
+
+// accountRoutes handles /accounts, registering new accounts, and /accounts/me, getting the account of the caller
+func accountRoutes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	caller, err := authenticate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	switch strings.Trim(r.URL.Path, "/") {
+	case "accounts":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req api.RegisterRequest
+		if err := getInput(r, &req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "Failed to read request with error: %s\n", err)
+			return
+		}
+
+		if req.Admin && (caller == nil || !caller.Admin) {
+			http.Error(w, "only admins can create admin accounts", http.StatusForbidden)
+			return
+		}
+
+		a, token, err := accounts.Register(req.Name, req.Admin)
+		switch {
+		case errors.Is(err, account.ErrorNameTaken):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case errors.Is(err, account.ErrorInvalidName):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case err != nil:
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, "Failed to register with error: %s\n", err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, api.RegisterResponse{Name: a.Name, Admin: a.Admin, Token: token})
+	case "accounts/me":
+		if caller == nil {
+			http.Error(w, "a token is required", http.StatusUnauthorized)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, api.AccountResponse{Name: caller.Name, Admin: caller.Admin})
+	default:
+		http.NotFound(w, r)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/cmd/api"
+	"github.com/tomwatson6/chessbot/internal/move"
+)
+
+func do(t *testing.T, srv *httptest.Server, method, path, token string, body any, out any) int {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("unexpected error encoding body: %v", err)
+		}
+	}
+
+	req, err := http.NewRequest(method, srv.URL+path, &buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil && resp.StatusCode < 300 {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("unexpected error decoding response: %v", err)
+		}
+	}
+
+	return resp.StatusCode
+}
+
+func register(t *testing.T, srv *httptest.Server, name string, admin bool, caller string) string {
+	t.Helper()
+
+	var resp api.RegisterResponse
+	if status := do(t, srv, http.MethodPost, "/accounts", caller, api.RegisterRequest{Name: name, Admin: admin}, &resp); status != http.StatusCreated {
+		t.Fatalf("want account %s to be created, got status %d", name, status)
+	}
+
+	return resp.Token
+}
+
+func TestColourOwnership(t *testing.T) {
+	srv := httptest.NewServer(newServeMux())
+	defer srv.Close()
+
+	_, admin, err := accounts.Register("ownership-admin", true)
+	if err != nil {
+		t.Fatalf("unexpected error registering admin: %v", err)
+	}
+
+	alice := register(t, srv, "ownership-alice", false, "")
+	bob := register(t, srv, "ownership-bob", false, "")
+
+	if status := do(t, srv, http.MethodPost, "/accounts", bob, api.RegisterRequest{Name: "ownership-eve", Admin: true}, nil); status != http.StatusForbidden {
+		t.Errorf("want players to be forbidden from creating admins, got status %d", status)
+	}
+
+	var g struct {
+		ID string `json:"id"`
+	}
+
+	req := api.StartGameRequest{White: "ownership-alice", Black: "ownership-bob"}
+	if status := do(t, srv, http.MethodPost, "/games", alice, req, &g); status != http.StatusCreated {
+		t.Fatalf("want game to be created, got status %d", status)
+	}
+
+	e4 := move.Move{From: move.Position{File: 4, Rank: 1}, To: move.Position{File: 4, Rank: 3}}
+
+	tcs := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		body       any
+		wantStatus int
+	}{
+		{name: "AnonymousCannotSee", method: http.MethodGet, path: "/games/" + g.ID, wantStatus: http.StatusNotFound},
+		{name: "InvalidToken", method: http.MethodGet, path: "/games/" + g.ID, token: "nope", wantStatus: http.StatusUnauthorized},
+		{name: "PlayerCanSee", method: http.MethodGet, path: "/games/" + g.ID, token: bob, wantStatus: http.StatusOK},
+		{name: "OpponentCannotMove", method: http.MethodPost, path: "/games/" + g.ID + "/move", token: bob, body: e4, wantStatus: http.StatusForbidden},
+		{name: "OwnerMoves", method: http.MethodPost, path: "/games/" + g.ID + "/move", token: alice, body: e4, wantStatus: http.StatusOK},
+		{name: "PlayerCannotAbort", method: http.MethodPost, path: "/games/" + g.ID + "/abort", token: alice, wantStatus: http.StatusForbidden},
+		{name: "AdminCanSee", method: http.MethodGet, path: "/games/" + g.ID, token: admin, wantStatus: http.StatusOK},
+		{name: "AdminAborts", method: http.MethodPost, path: "/games/" + g.ID + "/abort", token: admin, wantStatus: http.StatusOK},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if status := do(t, srv, tc.method, tc.path, tc.token, tc.body, nil); status != tc.wantStatus {
+				t.Errorf("want status %d, got %d", tc.wantStatus, status)
+			}
+		})
+	}
+}
+
+func TestCurrentGameOwnership(t *testing.T) {
+	srv := httptest.NewServer(newServeMux())
+	defer srv.Close()
+
+	alice := register(t, srv, "current-alice", false, "")
+	bob := register(t, srv, "current-bob", false, "")
+	eve := register(t, srv, "current-eve", false, "")
+
+	req := api.StartGameRequest{White: "current-alice", Black: "current-bob"}
+	if status := do(t, srv, http.MethodPost, "/start", alice, req, nil); status != http.StatusOK {
+		t.Fatalf("want game to be started, got status %d", status)
+	}
+
+	e4 := move.Move{From: move.Position{File: 4, Rank: 1}, To: move.Position{File: 4, Rank: 3}}
+
+	// The current game is private to its players, as it is through /games/{id}
+	tcs := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		body       any
+		wantStatus int
+	}{
+		{name: "AnonymousCannotSeeState", method: http.MethodGet, path: "/state", wantStatus: http.StatusNotFound},
+		{name: "OtherCannotSeeState", method: http.MethodGet, path: "/state", token: eve, wantStatus: http.StatusNotFound},
+		{name: "OtherCannotSeePower", method: http.MethodGet, path: "/power?file=4&rank=1", token: eve, wantStatus: http.StatusNotFound},
+		{name: "OtherCannotMove", method: http.MethodPost, path: "/move", token: eve, body: e4, wantStatus: http.StatusNotFound},
+		{name: "PlayerSeesState", method: http.MethodGet, path: "/state", token: bob, wantStatus: http.StatusOK},
+		{name: "PlayerSeesPower", method: http.MethodGet, path: "/power?file=4&rank=1", token: alice, wantStatus: http.StatusOK},
+		{name: "PlayerMoves", method: http.MethodPost, path: "/move", token: alice, body: e4, wantStatus: http.StatusOK},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if status := do(t, srv, tc.method, tc.path, tc.token, tc.body, nil); status != tc.wantStatus {
+				t.Errorf("want status %d, got %d", tc.wantStatus, status)
+			}
+		})
+	}
+}
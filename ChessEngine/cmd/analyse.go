@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/tomwatson6/chessbot/cmd/api"
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/engine"
+	"github.com/tomwatson6/chessbot/internal/piece"
+)
+
+const (
+	// maxAnalyseDepth is the deepest a position can be analysed through /analyse
+	maxAnalyseDepth = 8
+	// maxMultiPV is the most lines a position can be analysed for through /analyse
+	maxMultiPV = 10
+)
+
+// maxThreads is the most threads a search through the API can use, one for each CPU
+var maxThreads = runtime.NumCPU()
+
+// analyse handles /analyse?fen=&multipv=&depth=&threads=, the best moves of a position each with its score and
+// principal variation. Clients that accept text/event-stream are sent the lines of each depth as it is completed, followed by
+// a done event with the lines of the last depth.
+func analyse(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	c, err := positionFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+
+	limits := engine.Limits{Depth: engine.DefaultDepth, MultiPV: 1}
+
+	if d := query.Get("depth"); d != "" {
+		limits.Depth, err = strconv.Atoi(d)
+		if err != nil || limits.Depth < 1 || limits.Depth > maxAnalyseDepth {
+			http.Error(w, fmt.Sprintf("depth must be between 1 and %d", maxAnalyseDepth), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if n := query.Get("multipv"); n != "" {
+		limits.MultiPV, err = strconv.Atoi(n)
+		if err != nil || limits.MultiPV < 1 || limits.MultiPV > maxMultiPV {
+			http.Error(w, fmt.Sprintf("multipv must be between 1 and %d", maxMultiPV), http.StatusBadRequest)
+			return
+		}
+	}
+
+	threads := 1
+	if n := query.Get("threads"); n != "" {
+		threads, err = strconv.Atoi(n)
+		if err != nil || threads < 1 || threads > maxThreads {
+			http.Error(w, fmt.Sprintf("threads must be between 1 and %d", maxThreads), http.StatusBadRequest)
+			return
+		}
+	}
+
+	e := engine.New(engine.WithTablebase(endgames()), engine.WithThreads(threads))
+
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		res, err := e.Search(r.Context(), c, limits, nil)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		writeJSON(w, http.StatusOK, analyseResponse(c, lines(res)))
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	legal := len(c.LegalMoves())
+	if legal == 0 {
+		http.Error(w, engine.ErrorNoMoves.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	// The engine gives every line of a depth together once the depth is done, so the lines are sent as soon as they
+	// are all in. Fewer lines come when the tablebase rules out root moves, which are sent when the next depth starts.
+	want := limits.MultiPV
+	if want > legal {
+		want = legal
+	}
+
+	var pending []engine.Info
+
+	send := func(event string, infos []engine.Info) {
+		data, err := json.Marshal(analyseResponse(c, infos))
+		if err != nil {
+			return
+		}
+
+		fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", infos[0].Depth, event, data)
+		flusher.Flush()
+	}
+
+	res, err := e.Search(r.Context(), c, limits, func(info engine.Info) {
+		if info.MultiPV == 1 && len(pending) > 0 {
+			send("depth", pending)
+			pending = nil
+		}
+
+		pending = append(pending, info)
+		if len(pending) == want {
+			send("depth", pending)
+			pending = nil
+		}
+	})
+	if err != nil {
+		return
+	}
+
+	if len(pending) > 0 {
+		send("depth", pending)
+	}
+
+	send("done", lines(res))
+}
+
+// lines gets every line of the result, which only has lines of its own when more than one was searched for
+func lines(res engine.Result) []engine.Info {
+	if len(res.Lines) > 0 {
+		return res.Lines
+	}
+
+	return []engine.Info{res.Info}
+}
+
+func analyseResponse(c chess.Chess, infos []engine.Info) api.AnalyseResponse {
+	resp := api.AnalyseResponse{FEN: c.FEN(), Lines: make([]api.AnalyseLine, 0, len(infos))}
+	if len(infos) > 0 {
+		resp.Depth = infos[0].Depth
+	}
+
+	for _, info := range infos {
+		line := api.AnalyseLine{
+			MultiPV:    info.MultiPV,
+			Depth:      info.Depth,
+			Score:      info.Score,
+			Evaluation: info.Score.String(),
+			Mate:       info.Score.MateIn(),
+			Nodes:      info.Nodes,
+			SAN:        []string{},
+			UCI:        []string{},
+		}
+
+		pos := c.Copy()
+		for _, m := range info.PV {
+			san, err := pos.SAN(m, piece.PieceTypeQueen)
+			if err != nil {
+				break
+			}
+
+			line.SAN = append(line.SAN, san)
+			line.UCI = append(line.UCI, pos.UCI(m, piece.PieceTypeQueen))
+
+			if _, err := pos.MakeMoveWithPromotion(m, piece.PieceTypeQueen); err != nil {
+				break
+			}
+		}
+
+		resp.Lines = append(resp.Lines, line)
+	}
+
+	return resp
+}
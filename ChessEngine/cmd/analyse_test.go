@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/cmd/api"
+)
+
+func TestAnalyse(t *testing.T) {
+	srv := httptest.NewServer(newServeMux())
+	defer srv.Close()
+
+	fen := url.QueryEscape("6k1/5ppp/8/8/8/8/8/RR4K1 w - - 0 1")
+
+	var res api.AnalyseResponse
+	if status := do(t, srv, http.MethodGet, "/analyse?multipv=3&depth=2&fen="+fen, "", nil, &res); status != http.StatusOK {
+		t.Fatalf("want status %d, got %d", http.StatusOK, status)
+	}
+
+	if res.Depth != 2 || len(res.Lines) != 3 {
+		t.Fatalf("want 3 lines at depth 2, got %+v", res)
+	}
+
+	mates := map[string]bool{}
+	for _, line := range res.Lines[:2] {
+		if line.Mate != 1 || line.Evaluation != "#1" || len(line.SAN) != 1 {
+			t.Errorf("want a mate in one, got %+v", line)
+		}
+
+		mates[line.SAN[0]] = true
+	}
+
+	if !mates["Ra8#"] || !mates["Rb8#"] || res.Lines[2].Mate != 0 {
+		t.Errorf("want both rook mates first, got %+v", res.Lines)
+	}
+
+	if status := do(t, srv, http.MethodGet, "/analyse?depth=2&threads=1&fen="+fen, "", nil, &res); status != http.StatusOK || res.Lines[0].Mate != 1 {
+		t.Errorf("want the mate with a thread, got %+v with status %d", res, status)
+	}
+
+	for _, query := range []string{"depth=0", "depth=100", "multipv=0", "multipv=x", "fen=bad", "threads=0", "threads=1000"} {
+		if status := do(t, srv, http.MethodGet, "/analyse?"+query, "", nil, nil); status != http.StatusBadRequest {
+			t.Errorf("want status %d for %s, got %d", http.StatusBadRequest, query, status)
+		}
+	}
+}
+
+func TestAnalyse_Stream(t *testing.T) {
+	srv := httptest.NewServer(newServeMux())
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/analyse?multipv=2&depth=3", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("want event stream, got %s", ct)
+	}
+
+	r := bufio.NewReader(resp.Body)
+
+	for _, want := range []struct{ id, event string }{{"1", "depth"}, {"2", "depth"}, {"3", "depth"}, {"3", "done"}} {
+		if id, event := readEvent(t, r); id != want.id || event != want.event {
+			t.Errorf("want %s event with id %s, got %s event with id %s", want.event, want.id, event, id)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/tomwatson6/chessbot/cmd/api"
+	"github.com/tomwatson6/chessbot/internal/annotate"
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/eco"
+	"github.com/tomwatson6/chessbot/internal/engine"
+	"github.com/tomwatson6/chessbot/internal/game"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/pgn"
+	"github.com/tomwatson6/chessbot/internal/puzzle"
+)
+
+// The states of the analysis of a game
+const (
+	AnalysisQueued  = "queued"
+	AnalysisRunning = "running"
+	AnalysisDone    = "done"
+	AnalysisFailed  = "failed"
+)
+
+// analysisQueueSize is how many games can wait to be analysed before more are turned away
+const analysisQueueSize = 256
+
+// analyses annotates finished games one at a time in the background
+var analyses *analyser
+
+func init() {
+	// The analyser is created here rather than where it is declared, as analysing games refers back to the manager
+	analyses = newAnalyser(analyseGame)
+}
+
+// analyser is a worker that analyses games in the order they were queued, keeping the analysis of each game
+type analyser struct {
+	mu      sync.Mutex
+	results map[string]*api.AnalysisResponse
+	queue   chan string
+	analyse func(id string) (annotate.Analysis, pgn.Game, error)
+}
+
+func newAnalyser(analyse func(id string) (annotate.Analysis, pgn.Game, error)) *analyser {
+	a := &analyser{
+		results: make(map[string]*api.AnalysisResponse),
+		queue:   make(chan string, analysisQueueSize),
+		analyse: analyse,
+	}
+
+	go a.run()
+
+	return a
+}
+
+// enqueue queues the game to be analysed, unless it already has been or is waiting to be, getting the state of its
+// analysis. Analyses that failed are tried again.
+func (a *analyser) enqueue(id string) api.AnalysisResponse {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if r, ok := a.results[id]; ok && r.Status != AnalysisFailed {
+		return *r
+	}
+
+	r := &api.AnalysisResponse{ID: id, Status: AnalysisQueued}
+
+	select {
+	case a.queue <- id:
+	default:
+		r.Status, r.Error = AnalysisFailed, "too many games are waiting to be analysed"
+	}
+
+	a.results[id] = r
+
+	return *r
+}
+
+func (a *analyser) run() {
+	for id := range a.queue {
+		a.set(id, api.AnalysisResponse{ID: id, Status: AnalysisRunning})
+
+		an, g, err := a.analyse(id)
+		if err != nil {
+			log.Printf("Failed to analyse game %s with error: %s\n", id, err)
+			a.set(id, api.AnalysisResponse{ID: id, Status: AnalysisFailed, Error: err.Error()})
+			continue
+		}
+
+		a.set(id, api.AnalysisResponse{ID: id, Status: AnalysisDone, Analysis: &an, PGN: g.String()})
+	}
+}
+
+func (a *analyser) set(id string, r api.AnalysisResponse) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.results[id] = &r
+}
+
+// analyseGame annotates the moves of the game, getting them as PGN along with the players and result of the game.
+// The puzzles found in the game are added to those of the server.
+func analyseGame(id string) (annotate.Analysis, pgn.Game, error) {
+	g, err := manager.Get(id)
+	if err != nil {
+		return annotate.Analysis{}, pgn.Game{}, err
+	}
+
+	start, moves := g.Moves()
+
+	e := engine.New(engine.WithTablebase(endgames()))
+
+	a, err := annotate.Annotate(context.Background(), start, moves, annotate.WithEngine(e))
+	if err != nil {
+		return annotate.Analysis{}, pgn.Game{}, err
+	}
+
+	minePuzzles(id, start, moves, a, e)
+
+	p := a.PGN()
+	p.SetTag("Event", fmt.Sprintf("Game %s", id))
+	p.SetTag("Annotator", "chessbot")
+
+	for _, col := range []colour.Colour{colour.White, colour.Black} {
+		if name := g.Player(col); name != "" {
+			p.SetTag(col.String(), name)
+		}
+	}
+
+	p.SetTag("Result", result(g))
+	eco.Default().Tag(&p)
+
+	return a, p, nil
+}
+
+// minePuzzles adds the puzzles found in the game to the puzzles of the server
+func minePuzzles(id string, start chess.Chess, moves []move.Move, a annotate.Analysis, e *engine.Engine) {
+	found, err := puzzle.Mine(context.Background(), start, moves, a, puzzle.WithEngine(e))
+	if err == nil {
+		for i := range found {
+			found[i].Game = id
+		}
+
+		_, err = puzzles.Add(found...)
+	}
+
+	if err != nil {
+		log.Printf("Failed to find puzzles in game %s with error: %s\n", id, err)
+	}
+}
+
+// result gets the result of the game as it is tagged in PGN
+func result(g *game.Game) string {
+	status := g.Status()
+
+	switch w := g.Winner(); {
+	case !status.IsOver() || status == game.StatusAborted:
+		return pgn.Unfinished
+	case w == nil:
+		return pgn.Draw
+	case *w == colour.White:
+		return pgn.WhiteWins
+	default:
+		return pgn.BlackWins
+	}
+}
+
+// analysis handles /games/{id}/analysis, getting the annotation of a finished game. Games are queued to be analysed
+// when they finish, or when their analysis is first asked for if they finished before the server started.
+func analysis(w http.ResponseWriter, r *http.Request, g *game.Game) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !g.Status().IsOver() {
+		http.Error(w, "the game is not over", http.StatusConflict)
+		return
+	}
+
+	resp := analyses.enqueue(g.ID)
+
+	status := http.StatusAccepted
+	if resp.Status == AnalysisDone {
+		status = http.StatusOK
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, status, resp)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomwatson6/chessbot/cmd/api"
+	"github.com/tomwatson6/chessbot/internal/annotate"
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+)
+
+func TestAnalysis(t *testing.T) {
+	srv := httptest.NewServer(newServeMux())
+	defer srv.Close()
+
+	g, err := manager.Create(chess.New(colour.White))
+	if err != nil {
+		t.Fatalf("unexpected error creating game: %v", err)
+	}
+
+	if status := do(t, srv, http.MethodGet, "/games/"+g.ID+"/analysis", "", nil, nil); status != http.StatusConflict {
+		t.Errorf("want status %d for a game that is not over, got %d", http.StatusConflict, status)
+	}
+
+	for _, san := range []string{"f3", "e5", "g4", "Qh4#"} {
+		c := g.Chess()
+
+		m, _, err := c.ParseSAN(san)
+		if err != nil {
+			t.Fatalf("unexpected error reading move %s: %v", san, err)
+		}
+
+		if _, err := g.Move(m); err != nil {
+			t.Fatalf("unexpected error making move %s: %v", san, err)
+		}
+	}
+
+	// The game is queued to be analysed once it finishes
+	var resp api.AnalysisResponse
+	for deadline := time.Now().Add(30 * time.Second); ; time.Sleep(50 * time.Millisecond) {
+		status := do(t, srv, http.MethodGet, "/games/"+g.ID+"/analysis", "", nil, &resp)
+		if status == http.StatusOK {
+			break
+		}
+
+		if status != http.StatusAccepted || resp.Status == AnalysisFailed || time.Now().After(deadline) {
+			t.Fatalf("want the analysis to finish, got status %d and %+v", status, resp)
+		}
+	}
+
+	if resp.Status != AnalysisDone || resp.Analysis == nil || len(resp.Moves) != 4 {
+		t.Fatalf("want the analysis of the four moves, got %+v", resp)
+	}
+
+	if c := resp.Moves[2].Classification; c != annotate.Blunder {
+		t.Errorf("want g4 to be a blunder, got %s", c)
+	}
+
+	for _, want := range []string{"[Result \"0-1\"]", "[ECO \"A00\"]", "[Opening \"", "g4 $4", "Qh4# 0-1"} {
+		if !strings.Contains(resp.PGN, want) {
+			t.Errorf("want the PGN to contain %q, got:\n%s", want, resp.PGN)
+		}
+	}
+
+	// The mate that g4 allowed is added to the puzzles
+	found := false
+	for _, p := range puzzles.List() {
+		found = found || (p.Game == g.ID && p.Moves[0] == "d8h4")
+	}
+
+	if !found {
+		t.Errorf("want a puzzle of the mate from game %s, got %+v", g.ID, puzzles.List())
+	}
+}
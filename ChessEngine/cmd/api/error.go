@@ -0,0 +1,193 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/tomwatson6/chessbot/internal/account"
+	"github.com/tomwatson6/chessbot/internal/board"
+	"github.com/tomwatson6/chessbot/internal/board/rules"
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/game"
+	"github.com/tomwatson6/chessbot/internal/move"
+)
+
+type ErrorCode string
+
+const (
+	ErrorCodeInvalidRequest       ErrorCode = "INVALID_REQUEST"
+	ErrorCodeNotYourTurn          ErrorCode = "NOT_YOUR_TURN"
+	ErrorCodeUnauthenticated      ErrorCode = "UNAUTHENTICATED"
+	ErrorCodeNotYourColour        ErrorCode = "NOT_YOUR_COLOUR"
+	ErrorCodeGameOver             ErrorCode = "GAME_OVER"
+	ErrorCodeNoDrawOffer          ErrorCode = "NO_DRAW_OFFER"
+	ErrorCodePremoveOnTurn        ErrorCode = "PREMOVE_ON_TURN"
+	ErrorCodeInvalidPremove       ErrorCode = "INVALID_PREMOVE"
+	ErrorCodeOutOfBounds          ErrorCode = "OUT_OF_BOUNDS"
+	ErrorCodeNoPiece              ErrorCode = "NO_PIECE"
+	ErrorCodeFriendlyCapture      ErrorCode = "FRIENDLY_CAPTURE"
+	ErrorCodePinned               ErrorCode = "PINNED"
+	ErrorCodeLeavesKingInCheck    ErrorCode = "LEAVES_KING_IN_CHECK"
+	ErrorCodeMovesIntoCheck       ErrorCode = "MOVES_INTO_CHECK"
+	ErrorCodePathBlocked          ErrorCode = "PATH_BLOCKED"
+	ErrorCodeIllegalPieceMove     ErrorCode = "ILLEGAL_PIECE_MOVE"
+	ErrorCodeInvalidPawnCapture   ErrorCode = "INVALID_PAWN_CAPTURE"
+	ErrorCodeInvalidCastling      ErrorCode = "INVALID_CASTLING"
+	ErrorCodeCastlingThroughCheck ErrorCode = "CASTLING_THROUGH_CHECK"
+	ErrorCodeMustCapture          ErrorCode = "MUST_CAPTURE"
+	ErrorCodeIllegalMove          ErrorCode = "ILLEGAL_MOVE"
+)
+
+// MoveError is a machine readable explanation of why a move was rejected
+type MoveError struct {
+	Code    ErrorCode       `json:"code"`
+	Rule    string          `json:"rule,omitempty"`
+	Squares []move.Position `json:"squares"`
+	Message string          `json:"message"`
+}
+
+// Status gets the HTTP status code that the error should be returned with
+func (e MoveError) Status() int {
+	switch e.Code {
+	case ErrorCodeInvalidRequest, ErrorCodeInvalidPremove:
+		return http.StatusBadRequest
+	case ErrorCodeUnauthenticated:
+		return http.StatusUnauthorized
+	case ErrorCodeNotYourColour:
+		return http.StatusForbidden
+	case ErrorCodeNotYourTurn, ErrorCodeGameOver, ErrorCodeNoDrawOffer, ErrorCodePremoveOnTurn:
+		return http.StatusConflict
+	default:
+		return http.StatusUnprocessableEntity
+	}
+}
+
+// NewInvalidRequestError creates a MoveError for a request that could not be read
+func NewInvalidRequestError(err error) MoveError {
+	return MoveError{
+		Code:    ErrorCodeInvalidRequest,
+		Squares: []move.Position{},
+		Message: fmt.Sprintf("The move could not be read from the request: %s.", err),
+	}
+}
+
+// NewMoveError maps the error returned from making a move on the board provided into a MoveError,
+// the board should be the state of the board before the move was attempted
+func NewMoveError(err error, b board.Board, m move.Move) MoveError {
+	e := MoveError{
+		Code:    ErrorCodeIllegalMove,
+		Squares: []move.Position{m.From, m.To},
+	}
+
+	if rule, ok := rules.RejectedBy(err); ok {
+		e.Rule = rule
+	}
+
+	var pe *rules.PositionError
+	if errors.As(err, &pe) {
+		e.Squares = pe.Positions
+	}
+
+	moving := describe(b, m.From)
+
+	switch {
+	case errors.Is(err, game.ErrorGameOver):
+		e.Code = ErrorCodeGameOver
+		e.Squares = []move.Position{}
+		e.Message = "The game is over, no more moves can be made."
+	case errors.Is(err, game.ErrorNoDrawOffer):
+		e.Code = ErrorCodeNoDrawOffer
+		e.Squares = []move.Position{}
+		e.Message = "There is no draw offer from the opponent to respond to."
+	case errors.Is(err, account.ErrorInvalidToken):
+		e.Code = ErrorCodeUnauthenticated
+		e.Squares = []move.Position{}
+		e.Message = "The token provided is not valid."
+	case errors.Is(err, game.ErrorNotPlayer):
+		e.Code = ErrorCodeNotYourColour
+		e.Squares = []move.Position{}
+		e.Message = "The colour is held by another player."
+	case errors.Is(err, game.ErrorPremoveOnTurn):
+		e.Code = ErrorCodePremoveOnTurn
+		e.Squares = []move.Position{}
+		e.Message = "It is your turn, make a move rather than setting premoves."
+	case errors.Is(err, game.ErrorInvalidPremove):
+		e.Code = ErrorCodeInvalidPremove
+		e.Squares = []move.Position{}
+		e.Message = "Each premove line must be pairs of an opponent move followed by the reply to it."
+	case errors.Is(err, chess.ErrorNotYourTurn):
+		e.Code = ErrorCodeNotYourTurn
+		e.Squares = []move.Position{m.From}
+		if p, ok := b.Pieces[m.From]; ok {
+			e.Message = fmt.Sprintf("It is not %s's turn to move.", p.Colour)
+		} else {
+			e.Message = "It is not your turn to move."
+		}
+	case errors.Is(err, rules.ErrorIsOutOfBoundsOfBoard):
+		e.Code = ErrorCodeOutOfBounds
+		e.Message = "The move goes outside of the board."
+	case errors.Is(err, chess.ErrorPieceNotInStartPosition), errors.Is(err, rules.ErrorPieceNotInStartPosition):
+		e.Code = ErrorCodeNoPiece
+		e.Squares = []move.Position{m.From}
+		e.Message = fmt.Sprintf("There is no piece on %s to move.", m.From.Notation())
+	case errors.Is(err, rules.ErrorIsFriendlyCapture):
+		e.Code = ErrorCodeFriendlyCapture
+		e.Message = fmt.Sprintf("The %s cannot capture the %s as they are the same colour.", moving, describe(b, m.To))
+	case errors.Is(err, rules.ErrorIsPinned):
+		e.Code = ErrorCodePinned
+		e.Message = fmt.Sprintf("The %s is pinned to its king by the %s.", moving, describeFirst(b, e.Squares))
+	case errors.Is(err, rules.ErrorIsInCheck), errors.Is(err, rules.ErrorResultsInCheck):
+		e.Code = ErrorCodeLeavesKingInCheck
+		e.Message = fmt.Sprintf("Moving the %s would leave the king in check.", moving)
+	case errors.Is(err, rules.ErrorIsMovingIntoDanger):
+		e.Code = ErrorCodeMovesIntoCheck
+		e.Message = fmt.Sprintf("The king cannot move to %s as it is attacked by the %s.", m.To.Notation(), describeFirst(b, e.Squares))
+	case errors.Is(err, rules.ErrorLineIsNotClear), errors.Is(err, rules.ErrorIsNotValidPawnCapture):
+		e.Code = ErrorCodePathBlocked
+		if !errors.As(err, &pe) {
+			e.Squares = []move.Position{m.To}
+		}
+		e.Message = fmt.Sprintf("The path of the %s is blocked by the %s.", moving, describeFirst(b, e.Squares))
+	case errors.Is(err, rules.ErrorIsNotValidDiagonalPawnCapture):
+		e.Code = ErrorCodeInvalidPawnCapture
+		e.Message = fmt.Sprintf("The %s can only move diagonally to %s when capturing.", moving, m.To.Notation())
+	case errors.Is(err, rules.ErrorIsCastlingThroughCheck):
+		e.Code = ErrorCodeCastlingThroughCheck
+		e.Message = fmt.Sprintf("The king cannot castle through check, as the %s attacks the squares it passes through.", describeFirst(b, e.Squares))
+	case errors.Is(err, rules.ErrorInvalidCastlingMove):
+		e.Code = ErrorCodeInvalidCastling
+		e.Message = "Castling is not possible, the king and rook must not have moved and have a clear path between them."
+	case errors.Is(err, rules.ErrorMustCapture):
+		e.Code = ErrorCodeMustCapture
+		e.Message = "A capture is available and must be made."
+	case errors.Is(err, board.ErrorInvalidPawnMove),
+		errors.Is(err, board.ErrorInvalidKnightMove),
+		errors.Is(err, board.ErrorInvalidBishopMove),
+		errors.Is(err, board.ErrorInvalidRookMove),
+		errors.Is(err, board.ErrorInvalidQueenMove),
+		errors.Is(err, board.ErrorInvalidKingMove):
+		e.Code = ErrorCodeIllegalPieceMove
+		e.Message = fmt.Sprintf("The %s cannot move from %s to %s.", moving, m.From.Notation(), m.To.Notation())
+	default:
+		e.Message = fmt.Sprintf("The move from %s to %s is not allowed: %s.", m.From.Notation(), m.To.Notation(), err)
+	}
+
+	return e
+}
+
+func describe(b board.Board, pos move.Position) string {
+	if p, ok := b.Pieces[pos]; ok {
+		return fmt.Sprintf("%s on %s", p, pos.Notation())
+	}
+
+	return fmt.Sprintf("piece on %s", pos.Notation())
+}
+
+func describeFirst(b board.Board, ps []move.Position) string {
+	if len(ps) == 0 {
+		return "piece"
+	}
+
+	return describe(b, ps[0])
+}
@@ -0,0 +1,207 @@
+package api_test
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/cmd/api"
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/piece"
+	"github.com/tomwatson6/chessbot/testing/payloads"
+)
+
+func TestNewMoveError(t *testing.T) {
+	b := payloads.NewStandardBoard(
+		payloads.BoardWithPiece(&piece.Piece{
+			Colour:       colour.White,
+			Position:     move.Position{File: 7, Rank: 4},
+			PieceDetails: piece.NewBishop(),
+		}),
+		payloads.BoardWithPiece(&piece.Piece{
+			Colour:       colour.Black,
+			Position:     move.Position{File: 5, Rank: 6},
+			PieceDetails: piece.NewBishop(),
+		}),
+	)
+
+	// Visualisation of the board
+	// 8 bR bN bB bQ bK bB bN bR
+	// 7 bP bP bP bP bP bB bP bP
+	// 6 ## ## ## ## ## ## ## ##
+	// 5 ## ## ## ## ## ## ## wB
+	// 4 ## ## ## ## ## ## ## ##
+	// 3 ## ## ## ## ## ## ## ##
+	// 2 wP wP wP wP wP wP wP wP
+	// 1 wR wN wB wQ wK wB wN wR
+	//    A  B  C  D  E  F  G  H
+
+	tcs := []struct {
+		name        string
+		turn        colour.Colour
+		m           move.Move
+		wantCode    api.ErrorCode
+		wantSquares []move.Position
+		wantStatus  int
+	}{
+		{
+			name:        "Pinned",
+			turn:        colour.Black,
+			m:           move.Move{From: move.Position{File: 5, Rank: 6}, To: move.Position{File: 4, Rank: 5}},
+			wantCode:    api.ErrorCodePinned,
+			wantSquares: []move.Position{{File: 7, Rank: 4}, {File: 4, Rank: 7}},
+			wantStatus:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "NotYourTurn",
+			turn:        colour.White,
+			m:           move.Move{From: move.Position{File: 0, Rank: 6}, To: move.Position{File: 0, Rank: 5}},
+			wantCode:    api.ErrorCodeNotYourTurn,
+			wantSquares: []move.Position{{File: 0, Rank: 6}},
+			wantStatus:  http.StatusConflict,
+		},
+		{
+			name:        "PathBlocked",
+			turn:        colour.White,
+			m:           move.Move{From: move.Position{File: 0, Rank: 0}, To: move.Position{File: 0, Rank: 3}},
+			wantCode:    api.ErrorCodePathBlocked,
+			wantSquares: []move.Position{{File: 0, Rank: 1}},
+			wantStatus:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "OutOfBounds",
+			turn:        colour.White,
+			m:           move.Move{From: move.Position{File: 7, Rank: 4}, To: move.Position{File: 8, Rank: 5}},
+			wantCode:    api.ErrorCodeOutOfBounds,
+			wantSquares: []move.Position{{File: 7, Rank: 4}, {File: 8, Rank: 5}},
+			wantStatus:  http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			c := payloads.NewStandardChessGame(
+				payloads.ChessGameWithBoard(b),
+				payloads.ChessGameWithTurn(tc.turn),
+			)
+
+			_, err := c.MakeMove(tc.m)
+			if err == nil {
+				t.Fatalf("MakeMove(%v) returned no error", tc.m)
+			}
+
+			got := api.NewMoveError(err, c.Board, tc.m)
+
+			if got.Code != tc.wantCode {
+				t.Errorf("NewMoveError(%s) code => %s, want %s", err, got.Code, tc.wantCode)
+			}
+
+			if !reflect.DeepEqual(got.Squares, tc.wantSquares) {
+				t.Errorf("NewMoveError(%s) squares => %v, want %v", err, got.Squares, tc.wantSquares)
+			}
+
+			if got.Status() != tc.wantStatus {
+				t.Errorf("NewMoveError(%s) status => %d, want %d", err, got.Status(), tc.wantStatus)
+			}
+
+			if got.Message == "" {
+				t.Errorf("NewMoveError(%s) has no message", err)
+			}
+		})
+	}
+}
+
+func TestNewMoveError_LeavesKingInCheck(t *testing.T) {
+	tcs := []struct {
+		name        string
+		fen         string
+		m           move.Move
+		wantSquares []move.Position
+	}{
+		{
+			// The rook on e7 checks the king, moving the other rook does nothing about it
+			name:        "IgnoresCheck",
+			fen:         "4k3/4r3/8/8/8/8/8/R3K3 w - - 0 1",
+			m:           move.Move{From: move.Position{File: 0, Rank: 0}, To: move.Position{File: 0, Rank: 1}},
+			wantSquares: []move.Position{{File: 4, Rank: 6}, {File: 4, Rank: 0}},
+		},
+		{
+			// Taking en passant takes both pawns off the fifth rank, opening it to the rook
+			name:        "EnPassantPin",
+			fen:         "4k3/8/8/KPp4r/8/8/8/8 w - c6 0 2",
+			m:           move.Move{From: move.Position{File: 1, Rank: 4}, To: move.Position{File: 2, Rank: 5}},
+			wantSquares: []move.Position{{File: 7, Rank: 4}, {File: 0, Rank: 4}},
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := chess.FromFEN(tc.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			before := c.Board.Copy()
+
+			_, err = c.MakeMove(tc.m)
+			if err == nil {
+				t.Fatalf("MakeMove(%v) returned no error", tc.m)
+			}
+
+			got := api.NewMoveError(err, before, tc.m)
+
+			if got.Code != api.ErrorCodeLeavesKingInCheck {
+				t.Errorf("NewMoveError(%s) code => %s, want %s", err, got.Code, api.ErrorCodeLeavesKingInCheck)
+			}
+
+			if !reflect.DeepEqual(got.Squares, tc.wantSquares) {
+				t.Errorf("NewMoveError(%s) squares => %v, want %v", err, got.Squares, tc.wantSquares)
+			}
+		})
+	}
+}
+
+func TestNewMoveError_Attacker(t *testing.T) {
+	b := payloads.NewEmptyBoard(
+		payloads.BoardWithPieces([]*piece.Piece{
+			{Colour: colour.White, Position: move.Position{File: 4, Rank: 0}, PieceDetails: piece.NewKing()},
+			{Colour: colour.Black, Position: move.Position{File: 4, Rank: 7}, PieceDetails: piece.NewKing()},
+			{Colour: colour.Black, Position: move.Position{File: 0, Rank: 1}, PieceDetails: piece.NewRook()},
+			{Colour: colour.Black, Position: move.Position{File: 3, Rank: 2}, PieceDetails: piece.NewBishop()},
+		}),
+	)
+
+	// Visualisation of the board
+	// 8 ## ## ## ## bK ## ## ##
+	// 3 ## ## ## bB ## ## ## ##
+	// 2 bR ## ## ## ## ## ## ##
+	// 1 ## ## ## ## wK ## ## ##
+	//    A  B  C  D  E  F  G  H
+
+	// The rook attacks d2 from across the board, the bishop is next to d2 but does not attack it
+	m := move.Move{From: move.Position{File: 4, Rank: 0}, To: move.Position{File: 3, Rank: 1}}
+	want := []move.Position{{File: 0, Rank: 1}, {File: 3, Rank: 1}}
+
+	// The pieces are looked through in no set order, so the move is tried enough times to find any other attacker
+	for i := 0; i < 20; i++ {
+		c := payloads.NewStandardChessGame(
+			payloads.ChessGameWithBoard(b.Copy()),
+			payloads.ChessGameWithTurn(colour.White),
+		)
+
+		_, err := c.MakeMove(m)
+		if err == nil {
+			t.Fatalf("MakeMove(%v) returned no error", m)
+		}
+
+		got := api.NewMoveError(err, c.Board, m)
+
+		if got.Code != api.ErrorCodeMovesIntoCheck || !reflect.DeepEqual(got.Squares, want) {
+			t.Fatalf("NewMoveError(%s) => %s %v, want %s %v", err, got.Code, got.Squares, api.ErrorCodeMovesIntoCheck, want)
+		}
+	}
+}
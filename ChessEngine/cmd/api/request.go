@@ -2,8 +2,108 @@ package api
 
 import (
 	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
 )
 
 type StartGameRequest struct {
+	Colour         colour.Colour          `json:"colour"`
+	Variant        string                 `json:"variant"`
+	Clock          *ClockRequest          `json:"clock,omitempty"`
+	Correspondence *CorrespondenceRequest `json:"correspondence,omitempty"`
+	White          string                 `json:"white,omitempty"`
+	Black          string                 `json:"black,omitempty"`
+	Bot            *BotRequest            `json:"bot,omitempty"`
+}
+
+// BotRequest has the engine play a colour of a game, thinking on the time of its opponent unless Ponder is false.
+// Depth limits how deep it searches, the clock of the game limits how long.
+type BotRequest struct {
+	Colour colour.Colour `json:"colour"`
+	Depth  int           `json:"depth,omitempty"`
+	Ponder *bool         `json:"ponder,omitempty"`
+}
+
+// RegisterRequest creates an account, only admins can create admin accounts
+type RegisterRequest struct {
+	Name  string `json:"name"`
+	Admin bool   `json:"admin,omitempty"`
+}
+
+// TournamentRequest creates a tournament between the players provided, who are seeded in the order given.
+// Rounds is only used by Swiss tournaments.
+type TournamentRequest struct {
+	Name    string        `json:"name"`
+	Format  string        `json:"format"`
+	Players []string      `json:"players"`
+	Rounds  int           `json:"rounds,omitempty"`
+	Clock   *ClockRequest `json:"clock,omitempty"`
+}
+
+// ClockRequest is the time control of a game, the initial time and increment are in seconds
+type ClockRequest struct {
+	Initial   int `json:"initial"`
+	Increment int `json:"increment"`
+}
+
+// CorrespondenceRequest is the time control of a correspondence game, in days
+type CorrespondenceRequest struct {
+	DaysPerMove  int `json:"daysPerMove"`
+	VacationDays int `json:"vacationDays"`
+}
+
+// PremoveRequest sets the conditional moves of a colour, each line is pairs of an opponent move followed by a reply
+type PremoveRequest struct {
+	Colour colour.Colour `json:"colour"`
+	Lines  [][]move.Move `json:"lines"`
+}
+
+const (
+	StreamRequestMove        = "move"
+	StreamRequestOfferDraw   = "offer-draw"
+	StreamRequestAcceptDraw  = "accept-draw"
+	StreamRequestDeclineDraw = "decline-draw"
+	StreamRequestResign      = "resign"
+)
+
+// StreamRequest is a message sent by a client over the websocket of a game
+type StreamRequest struct {
+	Type   string        `json:"type"`
+	Move   *move.Move    `json:"move,omitempty"`
 	Colour colour.Colour `json:"colour"`
 }
+
+// PuzzleAttemptRequest is an attempt at a puzzle, the moves of the solver without the replies of the opponent
+type PuzzleAttemptRequest struct {
+	Moves []move.Move `json:"moves"`
+}
+
+// SessionRequest starts an analysis session of the position of the FEN, the starting position when it is empty,
+// searching for the best MultiPV moves with the number of threads provided, 1 of each when they are not set
+type SessionRequest struct {
+	FEN     string `json:"fen"`
+	MultiPV int    `json:"multipv"`
+	Threads int    `json:"threads"`
+}
+
+// PuzzleTrainingRequest starts training on puzzles with the theme, or any theme when it is empty
+type PuzzleTrainingRequest struct {
+	Theme string `json:"theme"`
+}
+
+// PuzzleRushRequest starts a rush of puzzles with the theme lasting the seconds provided, three minutes by default
+type PuzzleRushRequest struct {
+	Theme   string `json:"theme"`
+	Seconds int    `json:"seconds"`
+}
+
+// PuzzleMoveRequest is a move of the solver in the puzzle being trained on or the puzzle of a rush
+type PuzzleMoveRequest struct {
+	Move move.Move `json:"move"`
+}
+
+// SolveRequest is a problem to solve in the position of the FEN, written as #n for a mate, s#n for a self-mate and
+// h#n for a help-mate in n moves
+type SolveRequest struct {
+	FEN     string `json:"fen"`
+	Problem string `json:"problem"`
+}
@@ -1,8 +1,168 @@
 package api
 
-import "github.com/tomwatson6/chessbot/internal/move"
+import (
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/annotate"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/eco"
+	"github.com/tomwatson6/chessbot/internal/engine"
+	"github.com/tomwatson6/chessbot/internal/explorer"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/problem"
+	"github.com/tomwatson6/chessbot/internal/puzzle"
+	"github.com/tomwatson6/chessbot/internal/tablebase"
+	"github.com/tomwatson6/chessbot/internal/tactics"
+)
+
+const (
+	StreamMessageState = "state"
+	StreamMessageError = "error"
+)
 
 type MoveResponse struct {
 	Moves []move.Move `json:"moves"`
 	Err   string      `json:"err"`
+	Error *MoveError  `json:"error,omitempty"`
+}
+
+// RegisterResponse holds the token of a new account, which is not shown again
+type RegisterResponse struct {
+	Name  string `json:"name"`
+	Admin bool   `json:"admin"`
+	Token string `json:"token"`
+}
+
+type AccountResponse struct {
+	Name  string `json:"name"`
+	Admin bool   `json:"admin"`
+}
+
+// StreamMessage is a message sent to clients over the websocket of a game that is not a game event
+type StreamMessage struct {
+	Type  string     `json:"type"`
+	Data  any        `json:"data,omitempty"`
+	Error *MoveError `json:"error,omitempty"`
+}
+
+// ExplorerResponse is a position of the opening explorer, along with its opening if it has one
+type ExplorerResponse struct {
+	explorer.Position
+	Opening *eco.Opening `json:"opening,omitempty"`
+}
+
+// EvalResponse is the evaluation of a position from the point of view of the colour to move, with its result in the
+// tablebase when it is covered by it
+type EvalResponse struct {
+	FEN        string             `json:"fen"`
+	Score      engine.Score       `json:"score"`
+	Evaluation string             `json:"evaluation"`
+	Tablebase  *TablebaseResponse `json:"tablebase,omitempty"`
+}
+
+type TablebaseResponse struct {
+	tablebase.Result
+	MateIn   int    `json:"mateIn"`
+	BestMove string `json:"bestMove,omitempty"`
+	SAN      string `json:"san,omitempty"`
+}
+
+// SolveResponse is the solution of a problem, which is cooked when it has more than one key
+type SolveResponse struct {
+	problem.Solution
+	Solved bool `json:"solved"`
+	Cooked bool `json:"cooked"`
+}
+
+// AnalysisResponse is the state of the analysis of a game, with the analysis and the annotated game once it is done
+type AnalysisResponse struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	*annotate.Analysis
+	PGN string `json:"pgn,omitempty"`
+}
+
+// AnalyseResponse is the best lines of a position at the depth provided, best first
+type AnalyseResponse struct {
+	FEN   string        `json:"fen"`
+	Depth int           `json:"depth"`
+	Lines []AnalyseLine `json:"lines"`
+}
+
+// AnalyseLine is a line of the analysis of a position, its score is from the point of view of the colour to move,
+// with Mate being the moves to mate when it is a forced mate. The principal variation is given in SAN and UCI.
+type AnalyseLine struct {
+	MultiPV    int          `json:"multipv"`
+	Depth      int          `json:"depth"`
+	Score      engine.Score `json:"score"`
+	Evaluation string       `json:"evaluation"`
+	Mate       int          `json:"mate,omitempty"`
+	Nodes      int64        `json:"nodes"`
+	SAN        []string     `json:"san"`
+	UCI        []string     `json:"uci"`
+}
+
+// SessionResponse is the state of an analysis session, with the lines of the last depth it completed
+type SessionResponse struct {
+	ID      string    `json:"id"`
+	Status  string    `json:"status"`
+	MultiPV int       `json:"multipv"`
+	Threads int       `json:"threads"`
+	Started time.Time `json:"started"`
+	AnalyseResponse
+}
+
+// PuzzleResponse is a puzzle without its solution, Moves being how many moves the solver has to find
+type PuzzleResponse struct {
+	ID     string        `json:"id"`
+	FEN    string        `json:"fen"`
+	Turn   colour.Colour `json:"turn"`
+	Moves  int           `json:"moves"`
+	Themes []string      `json:"themes"`
+	Rating int           `json:"rating"`
+	Game   string        `json:"game,omitempty"`
+}
+
+// PuzzleStepResponse is what happened after a move in a puzzle being trained on, with the puzzle rating of the
+// player once the attempt is over
+type PuzzleStepResponse struct {
+	puzzle.Step
+	Rating *PuzzleRatingResponse `json:"rating,omitempty"`
+}
+
+// PuzzleRatingResponse is the puzzle rating of a player
+type PuzzleRatingResponse struct {
+	Name       string  `json:"name"`
+	Rating     float64 `json:"rating"`
+	Deviation  float64 `json:"deviation"`
+	Volatility float64 `json:"volatility"`
+	Attempts   int     `json:"attempts"`
+	Solved     int     `json:"solved"`
+}
+
+// PuzzleSetResponse is a set of puzzles to train on and how many puzzles it has
+type PuzzleSetResponse struct {
+	Theme   string `json:"theme"`
+	Puzzles int    `json:"puzzles"`
+}
+
+// PuzzleRushResponse is the state of a rush, with the puzzle being solved until it is over and what happened after
+// the last move when there was one
+type PuzzleRushResponse struct {
+	ID      string          `json:"id"`
+	Theme   string          `json:"theme,omitempty"`
+	Ends    time.Time       `json:"ends"`
+	Score   int             `json:"score"`
+	Strikes int             `json:"strikes"`
+	Over    bool            `json:"over"`
+	Puzzle  *PuzzleResponse `json:"puzzle,omitempty"`
+	Step    *puzzle.Step    `json:"step,omitempty"`
+}
+
+// TacticsResponse is the tactical motifs of a position, or those created by the move when one was given
+type TacticsResponse struct {
+	FEN    string          `json:"fen"`
+	Move   string          `json:"move,omitempty"`
+	Motifs []tactics.Motif `json:"motifs"`
 }
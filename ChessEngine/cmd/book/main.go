@@ -0,0 +1,60 @@
+// Command book builds a Polyglot opening book from collections of games in Portable Game Notation, e.g.
+//
+//	book -out book.bin -depth 16 -min-games 3 games.pgn more.pgn
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/tomwatson6/chessbot/internal/pgn"
+	"github.com/tomwatson6/chessbot/internal/polyglot"
+)
+
+func main() {
+	out := flag.String("out", "book.bin", "the file to write the book to")
+	depth := flag.Int("depth", polyglot.DefaultBuildDepth, "the number of half moves of each game to add")
+	minGames := flag.Int("min-games", 1, "leave out moves played in fewer games than this")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		log.Fatal("expected at least one PGN file")
+	}
+
+	b := polyglot.NewBuilder(polyglot.WithDepth(*depth), polyglot.WithMinGames(*minGames))
+
+	for _, path := range flag.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		skipped, err := b.AddAll(pgn.NewReader(f))
+		f.Close()
+
+		if err != nil {
+			log.Fatalf("%s: %s", path, err)
+		}
+
+		if skipped > 0 {
+			log.Printf("%s: skipped %d games with illegal moves", path, skipped)
+		}
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := b.Write(f); err != nil {
+		f.Close()
+		log.Fatal(err)
+	}
+
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("wrote %d entries from %d games to %s", len(b.Entries()), b.Games(), *out)
+}
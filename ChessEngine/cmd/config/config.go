@@ -4,8 +4,19 @@ import (
 	"github.com/tomwatson6/chessbot/internal/colour"
 	"github.com/tomwatson6/chessbot/internal/move"
 	"github.com/tomwatson6/chessbot/internal/piece"
+	"github.com/tomwatson6/chessbot/internal/variant"
 )
 
+// GetStartingPieces gets the pieces in their starting positions for the variant specified
+func GetStartingPieces(v variant.Variant) []*piece.Piece {
+	switch v {
+	case variant.Horde:
+		return GetHordePieces()
+	default:
+		return GetStandardPieces()
+	}
+}
+
 func GetStandardPieces() []*piece.Piece {
 	standardPieces := []*piece.Piece{
 		{Colour: colour.White, Position: move.Position{File: 0, Rank: 0}, PieceDetails: piece.NewRook()},
@@ -45,6 +56,38 @@ func GetStandardPieces() []*piece.Piece {
 	return standardPieces
 }
 
+// GetHordePieces gets the starting pieces for horde chess, where white has 36 pawns and no king against black's standard pieces
+func GetHordePieces() []*piece.Piece {
+	var hordePieces []*piece.Piece
+
+	for _, p := range GetStandardPieces() {
+		if p.Colour == colour.Black {
+			hordePieces = append(hordePieces, p)
+		}
+	}
+
+	for r := 0; r < 5; r++ {
+		for f := 0; f < 8; f++ {
+			// The fifth rank only has pawns on the b, c, f and g files
+			if r == 4 && (f == 0 || f == 3 || f == 4 || f == 7) {
+				continue
+			}
+
+			hordePieces = append(hordePieces, &piece.Piece{
+				Colour:   colour.White,
+				Position: move.Position{File: f, Rank: r},
+				PieceDetails: piece.NewPawn(
+					piece.PawnWithColour(colour.White),
+					// Only pawns on the first and second rank are able to move two squares
+					piece.PawnWithHasMoved(r > 1),
+				),
+			})
+		}
+	}
+
+	return hordePieces
+}
+
 func GetBoardDimensions() (int, int) {
 	return 8, 8
 }
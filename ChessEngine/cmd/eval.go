@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/tomwatson6/chessbot/cmd/api"
+	"github.com/tomwatson6/chessbot/internal/engine"
+	"github.com/tomwatson6/chessbot/internal/tablebase"
+)
+
+// tablebases are the endgame tables of the server, empty unless a file of them is loaded with the -tablebase flag
+var tablebases = tablebase.New()
+
+// syzygy are the Syzygy tables of the server, nil unless a directory of them is given with the -syzygy flag
+var syzygy *tablebase.Syzygy
+
+// endgames probes the tablebases first, which know the distance to mate, then the Syzygy tables
+func endgames() tablebase.Prober {
+	if syzygy == nil {
+		return tablebases
+	}
+
+	return tablebase.Chain{tablebases, syzygy}
+}
+
+// eval handles /eval?fen=, the evaluation of a position from the point of view of the colour to move, which is
+// exact when the position is covered by the tablebase
+func eval(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	c, err := positionFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := api.EvalResponse{FEN: c.FEN(), Score: engine.Evaluate(c)}
+
+	tables := endgames()
+
+	res, err := tables.Probe(c)
+	switch {
+	case err == nil:
+		switch {
+		case res.WDL == tablebase.Draw:
+			resp.Score = 0
+		case res.DTZ == 0:
+			resp.Score = engine.MateScore(res.MateIn())
+		case res.WDL == tablebase.Win:
+			resp.Score = engine.TablebaseWin
+		default:
+			resp.Score = -engine.TablebaseWin
+		}
+
+		tb := &api.TablebaseResponse{Result: res, MateIn: res.MateIn()}
+
+		if m, promotion, _, err := tablebase.BestMove(tables, c); err == nil {
+			tb.BestMove = c.UCI(m, promotion)
+			tb.SAN, _ = c.SAN(m, promotion)
+		}
+
+		resp.Tablebase = tb
+	case errors.Is(err, tablebase.ErrorTableNotFound), errors.Is(err, tablebase.ErrorTooManyPieces),
+		errors.Is(err, tablebase.ErrorNotApplicable):
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp.Evaluation = resp.Score.String()
+
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, http.StatusOK, resp)
+}
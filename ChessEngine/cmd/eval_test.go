@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/cmd/api"
+	"github.com/tomwatson6/chessbot/internal/engine"
+	"github.com/tomwatson6/chessbot/internal/tablebase"
+)
+
+func TestEval(t *testing.T) {
+	srv := httptest.NewServer(newServeMux())
+	defer srv.Close()
+
+	if err := tablebases.Generate("KQvK"); err != nil {
+		t.Fatal(err)
+	}
+
+	var resp api.EvalResponse
+	fen := url.QueryEscape("7k/8/6K1/8/8/8/8/1Q6 w - - 0 1")
+	if status := do(t, srv, http.MethodGet, "/eval?fen="+fen, "", nil, &resp); status != http.StatusOK {
+		t.Fatalf("want evaluation, got status %d", status)
+	}
+
+	if resp.Tablebase == nil || resp.Tablebase.WDL != tablebase.Win || resp.Tablebase.SAN != "Qb8#" || resp.Evaluation != "#1" {
+		t.Errorf("want Qb8# mating in one from the tablebase, got %+v", resp)
+	}
+
+	resp = api.EvalResponse{}
+	if status := do(t, srv, http.MethodGet, "/eval", "", nil, &resp); status != http.StatusOK {
+		t.Fatalf("want evaluation of the start position, got status %d", status)
+	}
+
+	if resp.Tablebase != nil {
+		t.Errorf("want the start position not to be in the tablebase, got %+v", resp.Tablebase)
+	}
+
+	if status := do(t, srv, http.MethodPost, "/eval", "", nil, nil); status != http.StatusMethodNotAllowed {
+		t.Errorf("want method not allowed, got status %d", status)
+	}
+}
+
+func TestEvalSyzygy(t *testing.T) {
+	srv := httptest.NewServer(newServeMux())
+	defer srv.Close()
+
+	tables, err := tablebase.OpenSyzygy(filepath.Join("..", "internal", "tablebase", "testdata", "syzygy"))
+	if errors.Is(err, tablebase.ErrorTableNotFound) {
+		t.Skip("the Syzygy files of the tablebase testdata are missing")
+	} else if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tables.Close()
+
+	syzygy = tables
+	defer func() { syzygy = nil }()
+
+	var resp api.EvalResponse
+	fen := url.QueryEscape("8/8/8/8/8/4P1k1/8/1K6 w - - 0 1")
+	if status := do(t, srv, http.MethodGet, "/eval?fen="+fen, "", nil, &resp); status != http.StatusOK {
+		t.Fatalf("want evaluation, got status %d", status)
+	}
+
+	if resp.Tablebase == nil || resp.Tablebase.WDL != tablebase.Win || resp.Tablebase.DTZ == 0 || resp.Tablebase.SAN != "Kc2" {
+		t.Fatalf("want Kc2 winning from the Syzygy tables, got %+v", resp)
+	}
+
+	if resp.Score != engine.TablebaseWin || resp.Tablebase.MateIn != 0 {
+		t.Errorf("want a tablebase win without a mate, got score %s mating in %d", resp.Score, resp.Tablebase.MateIn)
+	}
+}
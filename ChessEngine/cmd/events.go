@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/game"
+)
+
+// keepAliveInterval is how often a comment is sent to idle event streams so proxies do not close them
+const keepAliveInterval = 15 * time.Second
+
+// events streams the events of the game to the client as server-sent events. A client that reconnects with
+// the Last-Event-ID header, or the lastEventId query parameter, receives the events it missed first.
+func events(w http.ResponseWriter, r *http.Request, g *game.Game) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("lastEventId")
+	}
+
+	from := 0
+	if lastID != "" {
+		id, err := strconv.Atoi(lastID)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid last event id: %s", lastID), http.StatusBadRequest)
+			return
+		}
+
+		from = id
+	}
+
+	missed, ch, unsubscribe := g.SubscribeFrom(from)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for _, e := range missed {
+		if err := writeEvent(w, e); err != nil {
+			return
+		}
+	}
+
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case e, ok := <-ch:
+			if !ok {
+				// The subscription has been dropped, the client reconnects with the last event id it received
+				return
+			}
+
+			if err := writeEvent(w, e); err != nil {
+				return
+			}
+		}
+
+		flusher.Flush()
+	}
+}
+
+func writeEvent(w http.ResponseWriter, e game.Event) error {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
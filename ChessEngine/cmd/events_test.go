@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+)
+
+// readEvent reads the next event from a server-sent event stream, returning its id and type
+func readEvent(t *testing.T, r *bufio.Reader) (string, string) {
+	t.Helper()
+
+	var id, event string
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("unexpected error reading event: %v", err)
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "" && id != "":
+			return id, event
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		}
+	}
+}
+
+func TestEvents_Resume(t *testing.T) {
+	srv := httptest.NewServer(newServeMux())
+	defer srv.Close()
+
+	g, err := manager.Create(chess.New(colour.White))
+	if err != nil {
+		t.Fatalf("unexpected error creating game: %v", err)
+	}
+
+	moves := []move.Move{
+		{From: move.Position{File: 4, Rank: 1}, To: move.Position{File: 4, Rank: 3}},
+		{From: move.Position{File: 4, Rank: 6}, To: move.Position{File: 4, Rank: 4}},
+	}
+
+	for _, m := range moves {
+		if _, err := g.Move(m); err != nil {
+			t.Fatalf("unexpected error making move %v: %v", m, err)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/games/"+g.ID+"/events", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req.Header.Set("Last-Event-ID", "1")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("want event stream, got %s", ct)
+	}
+
+	r := bufio.NewReader(resp.Body)
+
+	// The second move is replayed as it was missed
+	if id, event := readEvent(t, r); id != "2" || event != "move" {
+		t.Errorf("want missed move event with id 2, got %s event with id %s", event, id)
+	}
+
+	if _, err := g.Move(move.Move{From: move.Position{File: 3, Rank: 0}, To: move.Position{File: 7, Rank: 4}}); err != nil {
+		t.Fatalf("unexpected error making move: %v", err)
+	}
+
+	if id, event := readEvent(t, r); id != "3" || event != "move" {
+		t.Errorf("want live move event with id 3, got %s event with id %s", event, id)
+	}
+}
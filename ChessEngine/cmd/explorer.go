@@ -0,0 +1,123 @@
+package main
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/tomwatson6/chessbot/cmd/api"
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/eco"
+	"github.com/tomwatson6/chessbot/internal/explorer"
+	"github.com/tomwatson6/chessbot/internal/pgn"
+)
+
+var explorerTree = explorer.New()
+
+// loadArchive adds the games of the PGN files in the directory to the explorer, which can be queried while they
+// are being added
+func loadArchive(dir string) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pgn"))
+	if err != nil {
+		log.Printf("Failed to find PGN files in %s with error: %s\n", dir, err)
+		return
+	}
+
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Printf("Failed to open %s with error: %s\n", path, err)
+			continue
+		}
+
+		skipped, err := explorerTree.AddAll(pgn.NewReader(f))
+		f.Close()
+
+		if err != nil {
+			log.Printf("Failed to read %s with error: %s\n", path, err)
+		}
+
+		if skipped > 0 {
+			log.Printf("Skipped %d games of %s with illegal moves\n", skipped, path)
+		}
+	}
+
+	log.Printf("Added %d games to the explorer\n", explorerTree.Games())
+}
+
+// positionFromQuery gets the position of the fen query parameter, the starting position when there is not one
+func positionFromQuery(r *http.Request) (chess.Chess, error) {
+	fen := r.URL.Query().Get("fen")
+	if fen == "" {
+		fen = chess.StartingFEN
+	}
+
+	return chess.FromFEN(fen)
+}
+
+// explore handles /explorer?fen=, the moves played from a position in the archive along with its opening
+func explore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	c, err := positionFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := api.ExplorerResponse{Position: explorerTree.Lookup(c)}
+	if o, ok := eco.Default().Position(c); ok {
+		resp.Opening = &o
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// classify handles /eco, classifying the position of the fen query parameter on GET or the game in the PGN body
+// of a POST
+func classify(w http.ResponseWriter, r *http.Request) {
+	var o eco.Opening
+	var ok bool
+
+	switch r.Method {
+	case http.MethodGet:
+		c, err := positionFromQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		o, ok = eco.Default().Position(c)
+	case http.MethodPost:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		g, err := pgn.Parse(string(body))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		o, ok = eco.Default().Game(g)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !ok {
+		http.Error(w, "the opening is not in the ECO table", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, http.StatusOK, o)
+}
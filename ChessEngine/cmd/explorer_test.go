@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/cmd/api"
+	"github.com/tomwatson6/chessbot/internal/eco"
+	"github.com/tomwatson6/chessbot/internal/pgn"
+)
+
+func TestExplorer(t *testing.T) {
+	srv := httptest.NewServer(newServeMux())
+	defer srv.Close()
+
+	if _, err := explorerTree.AddAll(pgn.NewReader(strings.NewReader(`[Result "1-0"] 1. e4 c5 1-0`))); err != nil {
+		t.Fatal(err)
+	}
+
+	var resp api.ExplorerResponse
+	fen := url.QueryEscape("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1")
+	if status := do(t, srv, http.MethodGet, "/explorer?fen="+fen, "", nil, &resp); status != http.StatusOK {
+		t.Fatalf("want explorer position, got status %d", status)
+	}
+
+	if len(resp.Moves) == 0 || resp.Moves[0].SAN != "c5" || resp.Opening == nil || resp.Opening.ECO != "B00" {
+		t.Errorf("want c5 to be played from the King's Pawn Game, got %+v", resp)
+	}
+
+	if status := do(t, srv, http.MethodGet, "/explorer?fen=nonsense", "", nil, nil); status != http.StatusBadRequest {
+		t.Errorf("want bad request for an invalid FEN, got status %d", status)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	srv := httptest.NewServer(newServeMux())
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/eco", "application/x-chess-pgn", strings.NewReader("1. e4 e6 2. d4 d5 3. Nc3 Bb4 *"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("want the game to be classified, got status %d", resp.StatusCode)
+	}
+
+	var o eco.Opening
+	if status := do(t, srv, http.MethodGet, "/eco?fen="+url.QueryEscape("8/8/8/8/8/8/8/K6k w - - 0 1"), "", nil, &o); status != http.StatusNotFound {
+		t.Errorf("want an unknown position not to be found, got status %d", status)
+	}
+}
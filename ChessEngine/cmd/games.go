@@ -0,0 +1,308 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tomwatson6/chessbot/cmd/api"
+	"github.com/tomwatson6/chessbot/internal/account"
+	"github.com/tomwatson6/chessbot/internal/bot"
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/engine"
+	"github.com/tomwatson6/chessbot/internal/game"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/polyglot"
+	"github.com/tomwatson6/chessbot/internal/variant"
+)
+
+// book is the opening book bots play from, nil unless one is loaded with the -book flag
+var book *polyglot.Book
+
+// newGame gets the starting state and options of the game requested
+func newGame(req api.StartGameRequest) (chess.Chess, []game.Option, error) {
+	v, err := variant.Parse(req.Variant)
+	if err != nil {
+		return chess.Chess{}, nil, err
+	}
+
+	var opts []game.Option
+
+	if req.Clock != nil {
+		if req.Clock.Initial <= 0 || req.Clock.Increment < 0 {
+			return chess.Chess{}, nil, fmt.Errorf("invalid clock: %+v", *req.Clock)
+		}
+
+		opts = append(opts, game.WithClock(
+			time.Duration(req.Clock.Initial)*time.Second,
+			time.Duration(req.Clock.Increment)*time.Second,
+		))
+	}
+
+	if req.Correspondence != nil {
+		if req.Correspondence.DaysPerMove <= 0 || req.Correspondence.VacationDays < 0 {
+			return chess.Chess{}, nil, fmt.Errorf("invalid correspondence: %+v", *req.Correspondence)
+		}
+
+		opts = append(opts, game.WithCorrespondence(
+			time.Duration(req.Correspondence.DaysPerMove)*day,
+			time.Duration(req.Correspondence.VacationDays)*day,
+		))
+	}
+
+	if req.White != "" || req.Black != "" {
+		opts = append(opts, game.WithPlayers(req.White, req.Black))
+	}
+
+	if req.Bot != nil {
+		if req.Bot.Depth < 0 || req.Bot.Depth > maxAnalyseDepth {
+			return chess.Chess{}, nil, fmt.Errorf("bot depth must be between 1 and %d", maxAnalyseDepth)
+		}
+
+		if (req.Bot.Colour == colour.White && req.White != "") || (req.Bot.Colour == colour.Black && req.Black != "") {
+			return chess.Chess{}, nil, fmt.Errorf("the bot cannot play the colour of a player")
+		}
+
+		opts = append(opts, game.WithBot(game.Bot{
+			Colour: req.Bot.Colour,
+			Depth:  req.Bot.Depth,
+			Ponder: req.Bot.Ponder == nil || *req.Bot.Ponder,
+		}))
+	}
+
+	return chess.New(req.Colour, chess.WithVariant(v)), opts, nil
+}
+
+const day = 24 * time.Hour
+
+// games handles /games, listing the games on the server or creating a new one.
+// Listing with ?player= gets the inbox of the player, the games waiting for them to move.
+func games(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	caller, err := authenticate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		player := r.URL.Query().Get("player")
+		if player != "" && (caller == nil || (!caller.Admin && caller.Name != player)) {
+			http.Error(w, "only the player or an admin can see their inbox", http.StatusForbidden)
+			return
+		}
+
+		list := []*game.Game{}
+		for _, g := range manager.List() {
+			if !canView(caller, g) || (player != "" && !g.AwaitingMove(player)) {
+				continue
+			}
+
+			list = append(list, g)
+		}
+
+		writeJSON(w, http.StatusOK, list)
+	case http.MethodPost:
+		var req api.StartGameRequest
+		if err := getInput(r, &req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "Failed to read request with error: %s\n", err)
+			return
+		}
+
+		if status, err := checkPlayers(caller, req); err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		c, opts, err := newGame(req)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "Failed to start game with error: %s\n", err)
+			return
+		}
+
+		g, err := manager.Create(c, opts...)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, "Failed to start game with error: %s\n", err)
+			return
+		}
+
+		if b, ok := g.Bot(); ok {
+			startBot(g, b)
+		}
+
+		writeJSON(w, http.StatusCreated, g)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// startBot starts the engine playing its colour of the game, with the tablebases and opening book of the server. The
+// bot stops by itself once the game is over or deleted.
+func startBot(g *game.Game, b game.Bot) *bot.Bot {
+	opts := []bot.Option{
+		bot.WithEngineOptions(engine.WithTablebase(endgames())),
+		bot.WithBook(book),
+		bot.WithPonder(b.Ponder),
+	}
+
+	if b.Depth > 0 {
+		opts = append(opts, bot.WithDepth(b.Depth))
+	}
+
+	return bot.Start(g, b.Colour, opts...)
+}
+
+// startBots starts the bots of the games loaded when the server starts, other than those of games that are over
+func startBots() {
+	for _, g := range manager.List() {
+		if b, ok := g.Bot(); ok && !g.Status().IsOver() {
+			startBot(g, b)
+		}
+	}
+}
+
+// checkPlayers checks that the players named in the request have accounts, and that the caller is one of them
+func checkPlayers(caller *account.Account, req api.StartGameRequest) (int, error) {
+	if req.White == "" && req.Black == "" {
+		return http.StatusOK, nil
+	}
+
+	if caller == nil {
+		return http.StatusUnauthorized, fmt.Errorf("a token is required to start a game between players")
+	}
+
+	if !caller.Admin && caller.Name != req.White && caller.Name != req.Black {
+		return http.StatusForbidden, fmt.Errorf("only admins can start games that they do not play in")
+	}
+
+	for _, p := range []string{req.White, req.Black} {
+		if p == "" {
+			continue
+		}
+
+		if _, err := accounts.Get(p); err != nil {
+			return http.StatusBadRequest, fmt.Errorf("%w: %s", err, p)
+		}
+	}
+
+	return http.StatusOK, nil
+}
+
+// gameRoutes handles /games/{id} and the resources of a game below it
+func gameRoutes(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/games/"), "/"), "/")
+
+	caller, err := authenticate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	g, err := manager.Get(parts[0])
+	if err != nil || !canView(caller, g) {
+		// Games the caller cannot see are reported as not found so that their ids are not given away
+		http.Error(w, game.ErrorGameNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	resource := ""
+	if len(parts) > 1 {
+		resource = parts[1]
+	}
+
+	switch resource {
+	case "":
+		if r.Method == http.MethodDelete {
+			if !isAdmin(caller) {
+				http.Error(w, "only admins can delete games", http.StatusForbidden)
+				return
+			}
+
+			if err := manager.Delete(g.ID); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		writeJSON(w, http.StatusOK, g)
+	case "move":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		status, resp := makeMove(g, r)
+		writeJSON(w, status, resp)
+	case "abort":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		if !isAdmin(caller) {
+			http.Error(w, "only admins can abort games", http.StatusForbidden)
+			return
+		}
+
+		if err := g.Abort(); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		writeJSON(w, http.StatusOK, g)
+	case "premoves":
+		premoves(w, r, g, name(caller))
+	case "ws":
+		stream(w, r, g, name(caller))
+	case "events":
+		events(w, r, g)
+	case "analysis":
+		analysis(w, r, g)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// premoves sets the conditional moves of a colour in the game
+func premoves(w http.ResponseWriter, r *http.Request, g *game.Game, caller string) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	var req api.PremoveRequest
+	if err := getInput(r, &req); err != nil {
+		moveErr := api.NewInvalidRequestError(err)
+		writeJSON(w, moveErr.Status(), moveErr)
+		return
+	}
+
+	if err := g.Authorise(caller, req.Colour); err != nil {
+		moveErr := api.NewMoveError(err, g.Chess().Board, move.Move{})
+		writeJSON(w, moveErr.Status(), moveErr)
+		return
+	}
+
+	if err := g.SetPremoves(req.Colour, req.Lines); err != nil {
+		moveErr := api.NewMoveError(err, g.Chess().Board, move.Move{})
+		writeJSON(w, moveErr.Status(), moveErr)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, g.Premoves(req.Colour))
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tomwatson6/chessbot/cmd/api"
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/game"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/storage"
+)
+
+func TestGameBot(t *testing.T) {
+	srv := httptest.NewServer(newServeMux())
+	defer srv.Close()
+
+	var g struct {
+		ID string `json:"id"`
+	}
+
+	req := api.StartGameRequest{Clock: &api.ClockRequest{Initial: 60}, Bot: &api.BotRequest{Colour: colour.Black, Depth: 2}}
+	if status := do(t, srv, http.MethodPost, "/games", "", req, &g); status != http.StatusCreated {
+		t.Fatalf("want game to be created, got status %d", status)
+	}
+
+	created, err := manager.Get(g.ID)
+	if err != nil {
+		t.Fatalf("unexpected error getting game: %v", err)
+	}
+
+	events, unsubscribe := created.Subscribe()
+	defer unsubscribe()
+
+	e4 := move.Move{From: move.Position{File: 4, Rank: 1}, To: move.Position{File: 4, Rank: 3}}
+	if status := do(t, srv, http.MethodPost, "/games/"+g.ID+"/move", "", e4, nil); status != http.StatusOK {
+		t.Fatalf("want move to be made, got status %d", status)
+	}
+
+	// The bot replies on its own, then ponders until the game is deleted
+	timeout := time.After(5 * time.Second)
+	for replied := false; !replied; {
+		select {
+		case e := <-events:
+			me, ok := e.Data.(game.MoveEvent)
+			replied = ok && me.Colour == colour.Black.String()
+		case <-timeout:
+			t.Fatal("bot did not reply")
+		}
+	}
+
+	if created.Chess().Turn != colour.White {
+		t.Errorf("want white to move after the bot, got %s", created.Chess().Turn)
+	}
+
+	if err := manager.Delete(g.ID); err != nil {
+		t.Errorf("unexpected error deleting game: %v", err)
+	}
+
+	alice := register(t, srv, "bot-alice", false, "")
+
+	tcs := []struct {
+		name string
+		req  api.StartGameRequest
+	}{
+		{name: "TooDeep", req: api.StartGameRequest{Bot: &api.BotRequest{Depth: maxAnalyseDepth + 1}}},
+		{name: "PlayersColour", req: api.StartGameRequest{White: "bot-alice", Bot: &api.BotRequest{Colour: colour.White}}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if status := do(t, srv, http.MethodPost, "/games", alice, tc.req, nil); status != http.StatusBadRequest {
+				t.Errorf("want game to be rejected, got status %d", status)
+			}
+		})
+	}
+}
+
+func TestGameBot_Restart(t *testing.T) {
+	store := storage.NewMemory()
+
+	previous := manager
+	defer func() { manager = previous }()
+
+	manager = game.NewManager(game.WithStore(store))
+
+	g, err := manager.Create(chess.New(colour.White), game.WithBot(game.Bot{Colour: colour.Black, Depth: 1}))
+	if err != nil {
+		t.Fatalf("unexpected error creating game: %v", err)
+	}
+
+	// The server restarts, loading the game and starting its bot again
+	manager = game.NewManager(game.WithStore(store))
+	if err := manager.Load(); err != nil {
+		t.Fatalf("unexpected error loading games: %v", err)
+	}
+
+	startBots()
+
+	restored, err := manager.Get(g.ID)
+	if err != nil {
+		t.Fatalf("unexpected error getting game: %v", err)
+	}
+
+	defer manager.Delete(g.ID)
+
+	events, unsubscribe := restored.Subscribe()
+	defer unsubscribe()
+
+	if _, err := restored.Move(move.Move{From: move.Position{File: 4, Rank: 1}, To: move.Position{File: 4, Rank: 3}}); err != nil {
+		t.Fatalf("unexpected error making move: %v", err)
+	}
+
+	timeout := time.After(5 * time.Second)
+	for replied := false; !replied; {
+		select {
+		case e := <-events:
+			me, ok := e.Data.(game.MoveEvent)
+			replied = ok && me.Colour == colour.Black.String()
+		case <-timeout:
+			t.Fatal("bot of the loaded game did not reply")
+		}
+	}
+}
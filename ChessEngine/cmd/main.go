@@ -2,20 +2,42 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"path/filepath"
+	"runtime"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/tomwatson6/chessbot/cmd/api"
 	"github.com/tomwatson6/chessbot/generation"
+	"github.com/tomwatson6/chessbot/internal/account"
+	"github.com/tomwatson6/chessbot/internal/board"
 	"github.com/tomwatson6/chessbot/internal/chess"
 	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/engine"
+	"github.com/tomwatson6/chessbot/internal/game"
 	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/polyglot"
+	"github.com/tomwatson6/chessbot/internal/puzzle"
+	"github.com/tomwatson6/chessbot/internal/rating"
+	"github.com/tomwatson6/chessbot/internal/session"
+	"github.com/tomwatson6/chessbot/internal/storage"
+	"github.com/tomwatson6/chessbot/internal/tablebase"
+	"github.com/tomwatson6/chessbot/internal/tournament"
 )
 
-var c chess.Chess
+var manager = game.NewManager(game.WithGameOptions(game.WithFinishHook(gameFinished)))
+
+// current is the game used by the endpoints that do not specify a game id
+var (
+	currentMu sync.Mutex
+	current   *game.Game
+)
 
 // Known bugs:
 // - pawn promotion to queen
@@ -36,88 +58,190 @@ func getInput(r *http.Request, obj any) error {
 
 func getMove(r *http.Request) (move.Move, error) {
 	var move move.Move
-	getInput(r, &move)
+	if err := getInput(r, &move); err != nil {
+		return move, err
+	}
 
 	return move, nil
 }
 
-func startGame(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	var startGameInput api.StartGameRequest
-	getInput(r, &startGameInput)
+// getCurrent gets the current game, starting a standard game if there is not one yet
+func getCurrent() (*game.Game, error) {
+	currentMu.Lock()
+	defer currentMu.Unlock()
 
-	c = chess.New(startGameInput.Colour)
+	if current == nil {
+		g, err := manager.Create(chess.New(colour.White))
+		if err != nil {
+			return nil, err
+		}
 
-	state(w, r)
+		current = g
+	}
+
+	return current, nil
 }
 
-func movePiece(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	resp := api.MoveResponse{}
-	move, err := getMove(r)
+// viewCurrent gets the current game for the caller, writing the error when there is no game or the caller cannot see
+// it. Games the caller cannot see are reported as not found, as with /games/{id}.
+func viewCurrent(w http.ResponseWriter, r *http.Request) (*game.Game, bool) {
+	caller, err := authenticate(r)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		resp.Err = fmt.Sprintf("%s", err)
-		fmt.Fprint(w, resp)
-		return
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return nil, false
 	}
 
-	moves, err := c.MakeMove(move)
+	g, err := getCurrent()
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		resp.Err = fmt.Sprintf("%s", err)
+		fmt.Fprintf(w, "Failed to get game with error: %s\n", err)
+		return nil, false
 	}
 
-	resp.Moves = moves
+	if !canView(caller, g) {
+		http.Error(w, game.ErrorGameNotFound.Error(), http.StatusNotFound)
+		return nil, false
+	}
 
-	jsonResponse, err := json.Marshal(resp)
+	return g, true
+}
+
+func setCurrent(g *game.Game) {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+
+	current = g
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	jsonResponse, err := json.Marshal(v)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		fmt.Fprintf(w, "Failed to marshal json with error: %s\n", err)
 		return
 	}
 
+	w.WriteHeader(status)
+
 	_, err = w.Write(jsonResponse)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Failed to write json with error: %s\n", err)
+		log.Printf("Failed to write json with error: %s\n", err)
 	}
 }
 
-func state(w http.ResponseWriter, r *http.Request) {
+func startGame(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	jsonResponse, err := json.Marshal(c)
+	var startGameInput api.StartGameRequest
+	getInput(r, &startGameInput)
+
+	caller, err := authenticate(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Failed to marshal json with error: %s\n", err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
 
-	_, err = w.Write(jsonResponse)
+	if status, err := checkPlayers(caller, startGameInput); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	c, opts, err := newGame(startGameInput)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Failed to start game with error: %s\n", err)
+		return
+	}
+
+	g, err := manager.Create(c, opts...)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Failed to write json with error: %s\n", err)
+		fmt.Fprintf(w, "Failed to start game with error: %s\n", err)
+		return
+	}
+
+	setCurrent(g)
+
+	state(w, r)
+}
+
+func movePiece(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	g, ok := viewCurrent(w, r)
+	if !ok {
+		return
 	}
+
+	status, resp := makeMove(g, r)
+
+	writeJSON(w, status, resp)
+}
+
+// makeMove makes the move in the body of the request on the game provided, on behalf of the caller
+func makeMove(g *game.Game, r *http.Request) (int, api.MoveResponse) {
+	resp := api.MoveResponse{}
+
+	caller, err := authenticate(r)
+	if err != nil {
+		moveErr := api.NewMoveError(err, board.Board{}, move.Move{})
+		resp.Err = fmt.Sprintf("%s", err)
+		resp.Error = &moveErr
+
+		return moveErr.Status(), resp
+	}
+
+	move, err := getMove(r)
+	if err != nil {
+		moveErr := api.NewInvalidRequestError(err)
+		resp.Err = fmt.Sprintf("%s", err)
+		resp.Error = &moveErr
+
+		return moveErr.Status(), resp
+	}
+
+	before := g.Chess()
+
+	moves, err := g.MoveAs(name(caller), move)
+	if err != nil {
+		moveErr := api.NewMoveError(err, before.Board, move)
+		resp.Err = fmt.Sprintf("%s", err)
+		resp.Error = &moveErr
+
+		return moveErr.Status(), resp
+	}
+
+	resp.Moves = moves
+
+	return http.StatusOK, resp
+}
+
+func state(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	g, ok := viewCurrent(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, g.Chess())
 }
 
 func startRandom(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	c = chess.New(colour.White)
+	c := chess.New(colour.White)
 	b := generation.NewBoard(10)
 	c.Board = b
 
-	jsonResponse, err := json.Marshal(c)
+	g, err := manager.Create(c)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Failed to marshal json with error: %s\n", err)
+		fmt.Fprintf(w, "Failed to start game with error: %s\n", err)
 		return
 	}
 
-	_, err = w.Write(jsonResponse)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Failed to write json with error: %s\n", err)
-	}
+	setCurrent(g)
+
+	writeJSON(w, http.StatusOK, c)
 }
 
 func power(w http.ResponseWriter, r *http.Request) {
@@ -127,43 +251,160 @@ func power(w http.ResponseWriter, r *http.Request) {
 	fileStr := queryParams.Get("file")
 	file, err := strconv.Atoi(fileStr)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Failed to marshal json with error: %s\n", err)
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Failed to read file with error: %s\n", err)
 		return
 	}
 
 	rankStr := queryParams.Get("rank")
 	rank, err := strconv.Atoi(rankStr)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Failed to marshal json with error: %s\n", err)
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Failed to read rank with error: %s\n", err)
 		return
 	}
 
-	p := c.Board.Power(file, rank)
-	jsonResponse, err := json.Marshal(p)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Failed to marshal json with error: %s\n", err)
+	g, ok := viewCurrent(w, r)
+	if !ok {
 		return
 	}
 
-	_, err = w.Write(jsonResponse)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Failed to write json with error: %s\n", err)
-	}
+	p := g.Chess().Board.Power(file, rank)
+
+	writeJSON(w, http.StatusOK, p)
+}
+
+func newServeMux() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/start", startGame)
+	mux.HandleFunc("/startRandom", startRandom)
+	mux.HandleFunc("/move", movePiece)
+	mux.HandleFunc("/state", state)
+	mux.HandleFunc("/power", power)
+	mux.HandleFunc("/accounts", accountRoutes)
+	mux.HandleFunc("/accounts/", accountRoutes)
+	mux.HandleFunc("/ratings", ratingRoutes)
+	mux.HandleFunc("/ratings/", ratingRoutes)
+	mux.HandleFunc("/tournaments", tournamentRoutes)
+	mux.HandleFunc("/tournaments/", tournamentRoutes)
+	mux.HandleFunc("/explorer", explore)
+	mux.HandleFunc("/eco", classify)
+	mux.HandleFunc("/eval", eval)
+	mux.HandleFunc("/analyse", analyse)
+	mux.HandleFunc("/sessions", sessionRoutes)
+	mux.HandleFunc("/sessions/", sessionRoutes)
+	mux.HandleFunc("/solve", solve)
+	mux.HandleFunc("/tactics", findTactics)
+	mux.HandleFunc("/puzzles/", puzzleRoutes)
+	mux.HandleFunc("/games", games)
+	mux.HandleFunc("/games/", gameRoutes)
+
+	return mux
 }
 
 func main() {
-	http.HandleFunc("/start", startGame)
-	http.HandleFunc("/startRandom", startRandom)
-	http.HandleFunc("/move", movePiece)
-	http.HandleFunc("/state", state)
-	http.HandleFunc("/power", power)
+	dataDir := flag.String("data", "data", "the directory that games are saved in")
+	ratingSystem := flag.String("rating-system", rating.Glicko2.String(), "the rating system, glicko2 or elo")
+	ratingPeriod := flag.Duration("rating-period", 24*time.Hour, "how often the games of a glicko2 rating period are rated")
+	archiveDir := flag.String("archive", "", "a directory of PGN files to build the opening explorer from")
+	tablebasePath := flag.String("tablebase", "", "a file of endgame tables made by the tablebase command")
+	syzygyDir := flag.String("syzygy", "", "a directory of Syzygy WDL and DTZ files")
+	bookPath := flag.String("book", "", "a Polyglot opening book for bots to play from")
+	sessionWorkers := flag.Int("session-workers", runtime.NumCPU(), "how many threads analysis sessions search with at once")
+	sessionIdle := flag.Duration("session-idle", session.DefaultIdleTimeout, "how long an analysis session is left unread before it is stopped")
+	flag.Parse()
+
+	system, err := rating.ParseSystem(*ratingSystem)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ratings, err = rating.NewTable(rating.WithSystem(system), rating.WithFile(filepath.Join(*dataDir, "ratings.json")))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	go func() {
+		for range time.Tick(*ratingPeriod) {
+			if err := ratings.ClosePeriod(); err != nil {
+				log.Printf("Failed to close rating period with error: %s\n", err)
+			}
+		}
+	}()
+
+	if *tablebasePath != "" {
+		if tablebases, err = tablebase.Open(*tablebasePath); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *syzygyDir != "" {
+		if syzygy, err = tablebase.OpenSyzygy(*syzygyDir); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *bookPath != "" {
+		if book, err = polyglot.Open(*bookPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	sessions = session.NewManager(
+		session.WithEngineOptions(engine.WithTablebase(endgames())),
+		session.WithWorkers(*sessionWorkers),
+		session.WithIdleTimeout(*sessionIdle),
+	)
+
+	store, err := storage.NewFile(*dataDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	manager = game.NewManager(game.WithStore(store), game.WithGameOptions(game.WithFinishHook(gameFinished)))
+
+	tournaments, err = tournament.NewOrganiser(startPairing, tournament.WithFile(filepath.Join(*dataDir, "tournaments.json")))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	accounts, err = account.NewRegistry(filepath.Join(*dataDir, "accounts.json"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	puzzles, err = puzzle.NewCollection(filepath.Join(*dataDir, "puzzles.json"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	trainer, err = puzzle.NewTrainer(puzzles, puzzle.WithFile(filepath.Join(*dataDir, "puzzle-ratings.json")))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if !accounts.HasAdmin() {
+		_, token, err := accounts.Register("admin", true)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Printf("Created the admin account with token: %s\n", token)
+	}
+
+	if err := manager.Load(); err != nil {
+		log.Fatal(err)
+	}
+
+	startBots()
+
+	if *archiveDir != "" {
+		go loadArchive(*archiveDir)
+	}
 
 	fmt.Println("Listening on :8000...")
-	err := http.ListenAndServe(":8000", nil)
+	err = http.ListenAndServe(":8000", newServeMux())
 	if err != nil {
 		log.Fatal(err)
 	}
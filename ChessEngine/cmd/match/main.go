@@ -0,0 +1,318 @@
+// Command match plays games between two engines and reports the difference in their strength, e.g.
+//
+//	match -first depth=3,eval=standard -second depth=3,eval=material -games 200 -tc 10+0.1 -sprt 0,10
+//	match -first uci=/usr/bin/stockfish,option.Threads=1 -second depth=4 -openings openings.txt -pgn match.pgn
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/eco"
+	"github.com/tomwatson6/chessbot/internal/engine"
+	"github.com/tomwatson6/chessbot/internal/match"
+	"github.com/tomwatson6/chessbot/internal/polyglot"
+	"github.com/tomwatson6/chessbot/internal/tablebase"
+)
+
+// parsePlayer creates the players described by the spec, a comma separated list of key=value settings. Engines of
+// this repository take name, depth, threads, eval, tb for the path of a file of endgame tables and book for the path of a
+// Polyglot book, with book-depth for the number of half moves it is played for and book-select for weighted or best.
+// UCI engines take name, uci for the path of the binary and option.<Name> for each option to set.
+func parsePlayer(spec, defaultName string) (match.Factory, error) {
+	settings := make(map[string]string)
+	options := make(map[string]string)
+
+	for _, kv := range strings.Split(spec, ",") {
+		if kv == "" {
+			continue
+		}
+
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid setting %q, expected key=value", kv)
+		}
+
+		if strings.HasPrefix(k, "option.") {
+			options[strings.TrimPrefix(k, "option.")] = v
+			continue
+		}
+
+		settings[k] = v
+	}
+
+	name := settings["name"]
+
+	if path, ok := settings["uci"]; ok {
+		return func() (match.Player, error) {
+			return match.NewUCIPlayer(name, path, options)
+		}, nil
+	}
+
+	var opts []engine.Option
+
+	if d, ok := settings["depth"]; ok {
+		depth, err := strconv.Atoi(d)
+		if err != nil || depth <= 0 {
+			return nil, fmt.Errorf("invalid depth %q", d)
+		}
+
+		opts = append(opts, engine.WithMaxDepth(depth))
+	}
+
+	if t, ok := settings["threads"]; ok {
+		threads, err := strconv.Atoi(t)
+		if err != nil || threads <= 0 {
+			return nil, fmt.Errorf("invalid threads %q", t)
+		}
+
+		opts = append(opts, engine.WithThreads(threads))
+	}
+
+	if e, ok := settings["eval"]; ok {
+		eval, err := engine.ParseEvaluator(e)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, engine.WithEvaluator(eval))
+	}
+
+	if path, ok := settings["tb"]; ok {
+		// As with books, the tables are loaded once and shared by every game
+		tables, err := tablebase.Open(path)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, engine.WithTablebase(tables))
+	}
+
+	var playerOpts []match.EnginePlayerOption
+
+	if path, ok := settings["book"]; ok {
+		var bookOpts []polyglot.Option
+
+		if d, ok := settings["book-depth"]; ok {
+			depth, err := strconv.Atoi(d)
+			if err != nil || depth < 0 {
+				return nil, fmt.Errorf("invalid book-depth %q", d)
+			}
+
+			bookOpts = append(bookOpts, polyglot.WithMaxPly(depth))
+		}
+
+		if s, ok := settings["book-select"]; ok {
+			sel, err := polyglot.ParseSelection(s)
+			if err != nil {
+				return nil, err
+			}
+
+			bookOpts = append(bookOpts, polyglot.WithSelection(sel))
+		}
+
+		// The book is read once and shared by every game, it is safe for concurrent use
+		book, err := polyglot.Open(path, bookOpts...)
+		if err != nil {
+			return nil, err
+		}
+
+		playerOpts = append(playerOpts, match.WithBook(book))
+	}
+
+	if name == "" {
+		name = defaultName
+	}
+
+	return func() (match.Player, error) {
+		return match.NewEnginePlayer(name, engine.New(opts...), playerOpts...), nil
+	}, nil
+}
+
+// parseTimeControl reads a time control of the initial time and increment in seconds e.g. 60+0.5
+func parseTimeControl(s string) (time.Duration, time.Duration, error) {
+	if s == "" {
+		return 0, 0, nil
+	}
+
+	initial, increment, _ := strings.Cut(s, "+")
+
+	i, err := strconv.ParseFloat(initial, 64)
+	if err != nil || i <= 0 {
+		return 0, 0, fmt.Errorf("invalid time control %q", s)
+	}
+
+	var inc float64
+	if increment != "" {
+		if inc, err = strconv.ParseFloat(increment, 64); err != nil || inc < 0 {
+			return 0, 0, fmt.Errorf("invalid time control %q", s)
+		}
+	}
+
+	return time.Duration(i * float64(time.Second)), time.Duration(inc * float64(time.Second)), nil
+}
+
+// parseSPRT reads the Elo bounds of an SPRT e.g. 0,5
+func parseSPRT(s string, alpha, beta float64) (*match.SPRT, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	lower, upper, ok := strings.Cut(s, ",")
+	if !ok {
+		return nil, fmt.Errorf("invalid sprt %q, expected elo0,elo1", s)
+	}
+
+	elo0, err := strconv.ParseFloat(lower, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	elo1, err := strconv.ParseFloat(upper, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	if elo1 <= elo0 {
+		return nil, fmt.Errorf("elo1 must be above elo0 in sprt %q", s)
+	}
+
+	return &match.SPRT{Elo0: elo0, Elo1: elo1, Alpha: alpha, Beta: beta}, nil
+}
+
+func main() {
+	firstSpec := flag.String("first", "depth=3,eval=standard", "the first engine")
+	secondSpec := flag.String("second", "depth=3,eval=material", "the second engine")
+	games := flag.Int("games", 0, "the number of games to play, each opening twice by default")
+	openingsPath := flag.String("openings", "", "a file of openings, one FEN or line of UCI moves per line")
+	concurrency := flag.Int("concurrency", runtime.NumCPU(), "the number of games to play at once")
+	tc := flag.String("tc", "", "the clock of each player in seconds, initial+increment e.g. 60+0.5")
+	moveTime := flag.Duration("movetime", 0, "the time for each move when not playing on a clock")
+	depth := flag.Int("depth", 0, "the depth of each search, overriding the depth of the engines")
+	nodes := flag.Int64("nodes", 0, "the nodes of each search")
+	pgnPath := flag.String("pgn", "", "a file to append the games to")
+	sprtSpec := flag.String("sprt", "", "stop once an SPRT of elo0,elo1 has accepted either hypothesis")
+	alpha := flag.Float64("alpha", 0.05, "the false positive rate of the SPRT")
+	beta := flag.Float64("beta", 0.05, "the false negative rate of the SPRT")
+	maxMoves := flag.Int("max-moves", 200, "draw games that reach this many moves, 0 to play on")
+	resignScore := flag.Int("resign-score", 1000, "the centipawns both engines must agree a side is lost by")
+	resignMoves := flag.Int("resign-moves", 3, "the moves both engines must agree for a resignation, 0 to turn off")
+	drawScore := flag.Int("draw-score", 10, "the centipawns both engines must stay within for a draw")
+	drawMoves := flag.Int("draw-moves", 8, "the moves both engines must agree for a draw, 0 to turn off")
+	drawAfter := flag.Int("draw-after", 40, "the move from which games can be adjudicated drawn")
+	flag.Parse()
+
+	first, err := parsePlayer(*firstSpec, "first")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	second, err := parsePlayer(*secondSpec, "second")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	initial, increment, err := parseTimeControl(*tc)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sprt, err := parseSPRT(*sprtSpec, *alpha, *beta)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var openings []match.Opening
+	if *openingsPath != "" {
+		f, err := os.Open(*openingsPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		openings, err = match.ReadOpenings(f)
+		f.Close()
+
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var pgnMu sync.Mutex
+	var pgnFile *os.File
+
+	if *pgnPath != "" {
+		pgnFile, err = os.OpenFile(*pgnPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer pgnFile.Close()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cfg := match.Config{
+		Games:       *games,
+		Openings:    openings,
+		Concurrency: *concurrency,
+		TimeControl: match.TimeControl{
+			Initial:   initial,
+			Increment: increment,
+			MoveTime:  *moveTime,
+			Depth:     *depth,
+			Nodes:     *nodes,
+		},
+		Adjudication: match.Adjudication{
+			ResignScore: engine.Score(*resignScore),
+			ResignMoves: *resignMoves,
+			DrawScore:   engine.Score(*drawScore),
+			DrawMoves:   *drawMoves,
+			DrawAfter:   *drawAfter,
+			MaxMoves:    *maxMoves,
+		},
+		SPRT: sprt,
+		OnGame: func(r match.GameResult, s match.Stats) {
+			fmt.Printf("Game %d (%s vs %s): %s {%s}\n", r.Round, r.White, r.Black, r.PGN.Result(), r.Reason)
+			fmt.Printf("Score after %d games: %s\n", s.Games(), s)
+
+			if pgnFile == nil {
+				return
+			}
+
+			pgnMu.Lock()
+			defer pgnMu.Unlock()
+
+			r.PGN.SetTag("Event", "Engine match")
+			r.PGN.SetTag("Site", "local")
+			r.PGN.SetTag("Date", time.Now().Format("2006.01.02"))
+			eco.Default().Tag(&r.PGN)
+
+			if err := r.PGN.Write(pgnFile); err != nil {
+				log.Printf("Failed to write game %d with error: %s\n", r.Round, err)
+			}
+		},
+	}
+
+	report, err := match.Run(ctx, first, second, cfg)
+
+	fmt.Printf("\n%s vs %s after %s\n", report.First, report.Second, report.Duration.Round(time.Second))
+	fmt.Println(report.Stats)
+
+	if sprt != nil {
+		lower, upper := sprt.Bounds()
+		fmt.Printf("SPRT elo0=%g elo1=%g: LLR %.2f [%.2f, %.2f], %s\n", sprt.Elo0, sprt.Elo1, report.LLR, lower, upper, report.Decision)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeControl(t *testing.T) {
+	tests := []struct {
+		tc                 string
+		initial, increment time.Duration
+		err                bool
+	}{
+		{tc: "", initial: 0, increment: 0},
+		{tc: "60", initial: time.Minute},
+		{tc: "10+0.1", initial: 10 * time.Second, increment: 100 * time.Millisecond},
+		{tc: "0+1", err: true},
+		{tc: "ten", err: true},
+		{tc: "10+-1", err: true},
+	}
+
+	for _, tt := range tests {
+		initial, increment, err := parseTimeControl(tt.tc)
+		if (err != nil) != tt.err || initial != tt.initial || increment != tt.increment {
+			t.Errorf("parseTimeControl(%q) = %s, %s, %v", tt.tc, initial, increment, err)
+		}
+	}
+}
+
+func TestParsePlayer(t *testing.T) {
+	tests := []struct {
+		spec string
+		name string
+		err  bool
+	}{
+		{spec: "depth=2,eval=material", name: "first"},
+		{spec: "name=candidate,depth=4", name: "candidate"},
+		{spec: "depth=2,threads=4", name: "first"},
+		{spec: "depth=0", err: true},
+		{spec: "threads=0", err: true},
+		{spec: "eval=unknown", err: true},
+		{spec: "depth", err: true},
+		{spec: "book=missing.bin", err: true},
+		{spec: "book=missing.bin,book-depth=-1", err: true},
+	}
+
+	for _, tt := range tests {
+		factory, err := parsePlayer(tt.spec, "first")
+		if (err != nil) != tt.err {
+			t.Errorf("parsePlayer(%q) returned error %v", tt.spec, err)
+			continue
+		}
+
+		if err != nil {
+			continue
+		}
+
+		p, err := factory()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if p.Name() != tt.name {
+			t.Errorf("parsePlayer(%q) created %s, want %s", tt.spec, p.Name(), tt.name)
+		}
+	}
+}
+
+func TestParseSPRT(t *testing.T) {
+	for _, s := range []string{"5", "5,0", "a,b"} {
+		if _, err := parseSPRT(s, 0.05, 0.05); err == nil {
+			t.Errorf("parseSPRT(%q) did not return an error", s)
+		}
+	}
+
+	sprt, err := parseSPRT("0,5", 0.05, 0.1)
+	if err != nil || sprt.Elo0 != 0 || sprt.Elo1 != 5 || sprt.Beta != 0.1 {
+		t.Errorf("parseSPRT(0,5) = %+v, %v", sprt, err)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/cmd/api"
+)
+
+func TestSolve(t *testing.T) {
+	srv := httptest.NewServer(newServeMux())
+	defer srv.Close()
+
+	var resp api.SolveResponse
+	req := api.SolveRequest{FEN: "7k/8/5K2/8/8/8/8/R7 w - - 0 1", Problem: "#2"}
+	if status := do(t, srv, http.MethodPost, "/solve", "", req, &resp); status != http.StatusOK {
+		t.Fatalf("want the problem to be solved, got status %d", status)
+	}
+
+	if !resp.Solved || !resp.Cooked || len(resp.Keys) != 2 {
+		t.Errorf("want a cooked solution with two keys, got %+v", resp)
+	}
+
+	req.Problem = "#1"
+	resp = api.SolveResponse{}
+	if status := do(t, srv, http.MethodPost, "/solve", "", req, &resp); status != http.StatusOK || resp.Solved {
+		t.Errorf("want no mate in one, got status %d and %+v", status, resp)
+	}
+
+	req.Problem = "mate in two"
+	if status := do(t, srv, http.MethodPost, "/solve", "", req, nil); status != http.StatusBadRequest {
+		t.Errorf("want bad request for an unreadable problem, got status %d", status)
+	}
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tomwatson6/chessbot/cmd/api"
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/puzzle"
+)
+
+// puzzles are the puzzles found in the games of the server, kept in memory unless the server is started with a
+// directory to save them in
+var puzzles, _ = puzzle.NewCollection("")
+
+// trainer rates the players training on the puzzles, keeping the ratings in memory unless the server is started
+// with a directory to save them in
+var trainer, _ = puzzle.NewTrainer(puzzles)
+
+// puzzleRoutes handles /puzzles/next, getting the puzzle after the one given by ?after=, /puzzles/{id} and
+// /puzzles/{id}/attempt, checking the moves of an attempt at solving the puzzle. Training, rushes, ratings and
+// sets are handled by trainingRoutes.
+func puzzleRoutes(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/puzzles/"), "/"), "/")
+
+	switch parts[0] {
+	case "training", "rush", "rating", "sets":
+		trainingRoutes(w, r, parts)
+		return
+	}
+
+	var p puzzle.Puzzle
+	var err error
+
+	if parts[0] == "next" {
+		p, err = puzzles.Next(r.URL.Query().Get("after"))
+	} else {
+		p, err = puzzles.Get(parts[0])
+	}
+
+	switch {
+	case errors.Is(err, puzzle.ErrorNoPuzzles), errors.Is(err, puzzle.ErrorPuzzleNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resource := ""
+	if len(parts) > 1 {
+		resource = parts[1]
+	}
+
+	switch {
+	case resource == "" && r.Method == http.MethodGet:
+		res, err := puzzleResponse(p)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		writeJSON(w, http.StatusOK, res)
+	case resource == "attempt" && parts[0] != "next" && r.Method == http.MethodPost:
+		attempt(w, r, p)
+	case resource == "" || resource == "attempt":
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// attempt checks the moves of the solver against the solution of the puzzle, the replies of the opponent are
+// played between them
+func attempt(w http.ResponseWriter, r *http.Request, p puzzle.Puzzle) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req api.PuzzleAttemptRequest
+	if err := getInput(r, &req); err != nil {
+		moveErr := api.NewInvalidRequestError(err)
+		writeJSON(w, moveErr.Status(), moveErr)
+		return
+	}
+
+	res, err := p.Check(req.Moves)
+
+	var illegal *puzzle.IllegalMoveError
+	if errors.As(err, &illegal) {
+		moveErr := api.NewMoveError(illegal.Err, illegal.Board, illegal.Move)
+		writeJSON(w, moveErr.Status(), moveErr)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// puzzleResponse gets the puzzle without its solution
+func puzzleResponse(p puzzle.Puzzle) (api.PuzzleResponse, error) {
+	c, err := chess.FromFEN(p.FEN)
+	if err != nil {
+		return api.PuzzleResponse{}, err
+	}
+
+	return api.PuzzleResponse{
+		ID:     p.ID,
+		FEN:    p.FEN,
+		Turn:   c.Turn,
+		Moves:  (len(p.Moves) + 1) / 2,
+		Themes: p.Themes,
+		Rating: p.Rating,
+		Game:   p.Game,
+	}, nil
+}
+
+// trainingRoutes handles the training of the caller on puzzles:
+//
+//	POST /puzzles/training         gives the caller the puzzle nearest their rating with the theme of the body
+//	POST /puzzles/training/move    plays a move in the puzzle, rating the caller once it is solved or failed
+//	POST /puzzles/rush             starts a rush of puzzles getting harder until time runs out or three are failed
+//	GET  /puzzles/rush/{id}        gets the state of a rush
+//	POST /puzzles/rush/{id}/move   plays a move in the puzzle of a rush
+//	GET  /puzzles/rating           gets the puzzle rating of the caller
+//	GET  /puzzles/sets             gets the themed sets of puzzles to train on
+func trainingRoutes(w http.ResponseWriter, r *http.Request, parts []string) {
+	if parts[0] == "sets" && len(parts) == 1 {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		sets := make([]api.PuzzleSetResponse, 0, len(puzzle.Sets))
+		for _, theme := range puzzle.Sets {
+			set := api.PuzzleSetResponse{Theme: theme}
+			for _, p := range puzzles.List() {
+				if p.HasTheme(theme) {
+					set.Puzzles++
+				}
+			}
+
+			sets = append(sets, set)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		writeJSON(w, http.StatusOK, sets)
+		return
+	}
+
+	caller, err := authenticate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if caller == nil {
+		http.Error(w, "a token is required", http.StatusUnauthorized)
+		return
+	}
+
+	// The id of a rush is taken out of the route so that it can be matched
+	route := strings.Join(parts, "/")
+	if parts[0] == "rush" && len(parts) > 1 {
+		route = strings.Join(append([]string{"rush", "{id}"}, parts[2:]...), "/")
+	}
+
+	methods := map[string]string{
+		"training":       http.MethodPost,
+		"training/move":  http.MethodPost,
+		"rush":           http.MethodPost,
+		"rush/{id}":      http.MethodGet,
+		"rush/{id}/move": http.MethodPost,
+		"rating":         http.MethodGet,
+	}
+
+	method, ok := methods[route]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method != method {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch route {
+	case "training":
+		var req api.PuzzleTrainingRequest
+		if !readPuzzleRequest(w, r, &req) {
+			return
+		}
+
+		p, err := trainer.Next(caller.Name, req.Theme)
+		writePuzzle(w, p, err)
+	case "training/move":
+		var req api.PuzzleMoveRequest
+		if !readPuzzleRequest(w, r, &req) {
+			return
+		}
+
+		step, err := trainer.Move(caller.Name, req.Move)
+		if !puzzleError(w, err) {
+			return
+		}
+
+		res := api.PuzzleStepResponse{Step: step}
+		if step.Over {
+			rating := ratingResponse(caller.Name)
+			res.Rating = &rating
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		writeJSON(w, http.StatusOK, res)
+	case "rush":
+		var req api.PuzzleRushRequest
+		if !readPuzzleRequest(w, r, &req) {
+			return
+		}
+
+		rush, err := trainer.StartRush(caller.Name, req.Theme, time.Duration(req.Seconds)*time.Second)
+		writeRush(w, http.StatusCreated, rush, nil, err)
+	case "rush/{id}":
+		rush, err := trainer.Rush(parts[1], caller.Name)
+		writeRush(w, http.StatusOK, rush, nil, err)
+	case "rush/{id}/move":
+		var req api.PuzzleMoveRequest
+		if !readPuzzleRequest(w, r, &req) {
+			return
+		}
+
+		rush, step, err := trainer.RushMove(parts[1], caller.Name, req.Move)
+		writeRush(w, http.StatusOK, rush, &step, err)
+	case "rating":
+		w.Header().Set("Content-Type", "application/json")
+		writeJSON(w, http.StatusOK, ratingResponse(caller.Name))
+	}
+}
+
+// readPuzzleRequest reads the body of the request, writing the error and reporting false when it is invalid
+func readPuzzleRequest(w http.ResponseWriter, r *http.Request, req any) bool {
+	if err := getInput(r, req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		moveErr := api.NewInvalidRequestError(err)
+		writeJSON(w, moveErr.Status(), moveErr)
+		return false
+	}
+
+	return true
+}
+
+// puzzleError writes the error of the trainer with the status it maps to, reporting false when there was one
+func puzzleError(w http.ResponseWriter, err error) bool {
+	var illegal *puzzle.IllegalMoveError
+
+	switch {
+	case err == nil:
+		return true
+	case errors.As(err, &illegal):
+		w.Header().Set("Content-Type", "application/json")
+		moveErr := api.NewMoveError(illegal.Err, illegal.Board, illegal.Move)
+		writeJSON(w, moveErr.Status(), moveErr)
+	case errors.Is(err, puzzle.ErrorNoPuzzles), errors.Is(err, puzzle.ErrorRushNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, puzzle.ErrorNoAttempt), errors.Is(err, puzzle.ErrorRushOver):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+
+	return false
+}
+
+func writePuzzle(w http.ResponseWriter, p puzzle.Puzzle, err error) {
+	if !puzzleError(w, err) {
+		return
+	}
+
+	res, err := puzzleResponse(p)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, http.StatusOK, res)
+}
+
+// writeRush writes the rush without the solution of its puzzle, along with the step of the move when one was made
+func writeRush(w http.ResponseWriter, status int, rush puzzle.Rush, step *puzzle.Step, err error) {
+	if !puzzleError(w, err) {
+		return
+	}
+
+	res := api.PuzzleRushResponse{
+		ID:      rush.ID,
+		Theme:   rush.Theme,
+		Ends:    rush.Ends,
+		Score:   rush.Score,
+		Strikes: rush.Strikes,
+		Over:    rush.Over,
+		Step:    step,
+	}
+
+	if rush.Puzzle != nil {
+		p, err := puzzleResponse(*rush.Puzzle)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		res.Puzzle = &p
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, status, res)
+}
+
+func ratingResponse(name string) api.PuzzleRatingResponse {
+	p := trainer.Player(name)
+
+	return api.PuzzleRatingResponse{
+		Name:       name,
+		Rating:     p.Rating.Rating,
+		Deviation:  p.Deviation,
+		Volatility: p.Volatility,
+		Attempts:   p.Attempts,
+		Solved:     p.Solved,
+	}
+}
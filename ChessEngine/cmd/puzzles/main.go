@@ -0,0 +1,125 @@
+// Command puzzles finds puzzles in collections of games in Portable Game Notation, adding them to a puzzle file that
+// the server can be started with, e.g.
+//
+//	puzzles -out data/puzzles.json -depth 3 games.pgn more.pgn
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/tomwatson6/chessbot/internal/annotate"
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/engine"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/pgn"
+	"github.com/tomwatson6/chessbot/internal/puzzle"
+)
+
+func main() {
+	out := flag.String("out", "puzzles.json", "the puzzle file to add the puzzles to")
+	depth := flag.Int("depth", engine.DefaultDepth, "how deep the positions of each game are searched")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		log.Fatal("expected at least one PGN file")
+	}
+
+	puzzles, err := puzzle.NewCollection(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	e := engine.New(engine.WithMaxDepth(*depth))
+	games, found := 0, 0
+
+	for _, path := range flag.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		r := pgn.NewReader(f)
+
+		for {
+			g, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				f.Close()
+				log.Fatalf("%s: %s", path, err)
+			}
+
+			games++
+
+			n, err := mine(g, e, puzzles)
+			if err != nil {
+				log.Printf("%s: skipped game %d: %s", path, games, err)
+				continue
+			}
+
+			found += n
+		}
+
+		f.Close()
+	}
+
+	log.Printf("added %d puzzles from %d games to %s", found, games, *out)
+}
+
+// mine adds the puzzles found in the game to the collection, getting how many were added
+func mine(g pgn.Game, e *engine.Engine, puzzles *puzzle.Collection) (int, error) {
+	start, moves, err := replay(g)
+	if err != nil {
+		return 0, err
+	}
+
+	a, err := annotate.Annotate(context.Background(), start, moves, annotate.WithEngine(e))
+	if err != nil {
+		return 0, err
+	}
+
+	found, err := puzzle.Mine(context.Background(), start, moves, a, puzzle.WithEngine(e))
+	if err != nil {
+		return 0, err
+	}
+
+	return puzzles.Add(found...)
+}
+
+// replay reads the moves of the game from the position it starts in
+func replay(g pgn.Game) (chess.Chess, []move.Move, error) {
+	fen := g.Tag("FEN")
+	if fen == "" {
+		fen = chess.StartingFEN
+	}
+
+	start, err := chess.FromFEN(fen)
+	if err != nil {
+		return chess.Chess{}, nil, err
+	}
+
+	c := start.Copy()
+
+	var moves []move.Move
+	for i, pm := range g.Moves {
+		m, promotion, err := c.ParseSAN(pm.SAN)
+		if err != nil {
+			return chess.Chess{}, nil, fmt.Errorf("move %d: %w", i/2+1, err)
+		}
+
+		if _, err := c.MakeMoveWithPromotion(m, promotion); err != nil {
+			return chess.Chess{}, nil, fmt.Errorf("move %d: %w", i/2+1, err)
+		}
+
+		moves = append(moves, m)
+	}
+
+	return start, moves, nil
+}
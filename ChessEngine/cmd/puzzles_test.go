@@ -0,0 +1,168 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/cmd/api"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/puzzle"
+)
+
+func mv(t *testing.T, s string) move.Move {
+	t.Helper()
+
+	m, _, err := move.ParseUCI(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+func TestPuzzles(t *testing.T) {
+	srv := httptest.NewServer(newServeMux())
+	defer srv.Close()
+
+	p := puzzle.Puzzle{
+		ID:     "fork",
+		FEN:    "2q1k3/8/8/8/2N5/8/PP4PP/4K3 w - - 0 1",
+		Moves:  []string{"c4d6", "e8d8", "d6c8"},
+		Themes: []string{"fork"},
+		Rating: 1300,
+	}
+
+	if _, err := puzzles.Add(p); err != nil {
+		t.Fatal(err)
+	}
+
+	var next api.PuzzleResponse
+	if status := do(t, srv, http.MethodGet, "/puzzles/next", "", nil, &next); status != http.StatusOK {
+		t.Fatalf("want status %d, got %d", http.StatusOK, status)
+	}
+
+	var got api.PuzzleResponse
+	if status := do(t, srv, http.MethodGet, "/puzzles/fork", "", nil, &got); status != http.StatusOK {
+		t.Fatalf("want status %d, got %d", http.StatusOK, status)
+	}
+
+	if got.FEN != p.FEN || got.Turn != colour.White || got.Moves != 2 || got.Rating != 1300 {
+		t.Errorf("unexpected puzzle %+v", got)
+	}
+
+	tests := []struct {
+		name   string
+		moves  []move.Move
+		status int
+		solved bool
+	}{
+		{name: "solved", moves: []move.Move{mv(t, "c4d6"), mv(t, "d6c8")}, status: http.StatusOK, solved: true},
+		{name: "wrong", moves: []move.Move{mv(t, "c4e5")}, status: http.StatusOK},
+		{name: "illegal", moves: []move.Move{mv(t, "c4c6")}, status: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var res puzzle.Result
+			status := do(t, srv, http.MethodPost, "/puzzles/fork/attempt", "", api.PuzzleAttemptRequest{Moves: tt.moves}, &res)
+			if status != tt.status {
+				t.Fatalf("want status %d, got %d", tt.status, status)
+			}
+
+			if res.Solved != tt.solved {
+				t.Errorf("want solved %v, got %+v", tt.solved, res)
+			}
+		})
+	}
+
+	if status := do(t, srv, http.MethodGet, "/puzzles/missing", "", nil, nil); status != http.StatusNotFound {
+		t.Errorf("want status %d for a missing puzzle, got %d", http.StatusNotFound, status)
+	}
+}
+
+func TestPuzzleTraining(t *testing.T) {
+	srv := httptest.NewServer(newServeMux())
+	defer srv.Close()
+
+	p := puzzle.Puzzle{
+		ID:     "fork",
+		FEN:    "2q1k3/8/8/8/2N5/8/PP4PP/4K3 w - - 0 1",
+		Moves:  []string{"c4d6", "e8d8", "d6c8"},
+		Themes: []string{"fork"},
+		Rating: 1300,
+	}
+
+	if _, err := puzzles.Add(p); err != nil {
+		t.Fatal(err)
+	}
+
+	token := register(t, srv, "training-alice", false, "")
+
+	if status := do(t, srv, http.MethodPost, "/puzzles/training", "", api.PuzzleTrainingRequest{Theme: "fork"}, nil); status != http.StatusUnauthorized {
+		t.Errorf("want status %d without a token, got %d", http.StatusUnauthorized, status)
+	}
+
+	var sets []api.PuzzleSetResponse
+	if status := do(t, srv, http.MethodGet, "/puzzles/sets", "", nil, &sets); status != http.StatusOK || len(sets) != len(puzzle.Sets) {
+		t.Fatalf("want the sets of puzzles, got %+v with status %d", sets, status)
+	}
+
+	var got api.PuzzleResponse
+	if status := do(t, srv, http.MethodPost, "/puzzles/training", token, api.PuzzleTrainingRequest{Theme: "fork"}, &got); status != http.StatusOK || got.ID != "fork" {
+		t.Fatalf("want the fork, got %+v with status %d", got, status)
+	}
+
+	var step api.PuzzleStepResponse
+	if status := do(t, srv, http.MethodPost, "/puzzles/training/move", token, api.PuzzleMoveRequest{Move: mv(t, "c4d6")}, &step); status != http.StatusOK {
+		t.Fatalf("want status %d, got %d", http.StatusOK, status)
+	}
+
+	if step.Reply != "e8d8" || step.Over || step.Solution != nil || step.Rating != nil {
+		t.Errorf("want the reply without the solution, got %+v", step)
+	}
+
+	step = api.PuzzleStepResponse{}
+	if status := do(t, srv, http.MethodPost, "/puzzles/training/move", token, api.PuzzleMoveRequest{Move: mv(t, "d6c8")}, &step); status != http.StatusOK {
+		t.Fatalf("want status %d, got %d", http.StatusOK, status)
+	}
+
+	if !step.Solved || step.Rating == nil || step.Rating.Solved != 1 {
+		t.Errorf("want the puzzle solved and the rating of the player, got %+v", step)
+	}
+
+	if status := do(t, srv, http.MethodPost, "/puzzles/training/move", token, api.PuzzleMoveRequest{Move: mv(t, "c4d6")}, nil); status != http.StatusConflict {
+		t.Errorf("want status %d once the attempt is over, got %d", http.StatusConflict, status)
+	}
+
+	var rush api.PuzzleRushResponse
+	if status := do(t, srv, http.MethodPost, "/puzzles/rush", token, api.PuzzleRushRequest{Theme: "fork", Seconds: 60}, &rush); status != http.StatusCreated {
+		t.Fatalf("want status %d, got %d", http.StatusCreated, status)
+	}
+
+	if rush.Puzzle == nil || rush.Puzzle.ID != "fork" || rush.Over {
+		t.Fatalf("want the rush to start with the fork, got %+v", rush)
+	}
+
+	if status := do(t, srv, http.MethodGet, "/puzzles/rush/"+rush.ID, "", nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("want status %d without a token, got %d", http.StatusUnauthorized, status)
+	}
+
+	if status := do(t, srv, http.MethodPost, "/puzzles/rush/"+rush.ID+"/move", token, api.PuzzleMoveRequest{Move: mv(t, "c4e5")}, &rush); status != http.StatusOK {
+		t.Fatalf("want status %d, got %d", http.StatusOK, status)
+	}
+
+	if rush.Strikes != 1 || rush.Step == nil || !rush.Step.Over || rush.Step.Solved {
+		t.Errorf("want a strike for the wrong move, got %+v", rush)
+	}
+
+	if status := do(t, srv, http.MethodGet, "/puzzles/rush/missing", token, nil, nil); status != http.StatusNotFound {
+		t.Errorf("want status %d for a missing rush, got %d", http.StatusNotFound, status)
+	}
+
+	var r api.PuzzleRatingResponse
+	if status := do(t, srv, http.MethodGet, "/puzzles/rating", token, nil, &r); status != http.StatusOK || r.Attempts != 1 {
+		t.Errorf("want the rating of the player, got %+v with status %d", r, status)
+	}
+}
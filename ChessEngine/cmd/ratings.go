@@ -0,0 +1,76 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/game"
+	"github.com/tomwatson6/chessbot/internal/rating"
+)
+
+var ratings, _ = rating.NewTable()
+
+// recordResult rates a finished game between two players or a player and a bot, aborted games and games without a
+// player on each side are not rated
+func recordResult(o game.Outcome) {
+	if o.White == "" || o.Black == "" || o.White == o.Black || o.Status == game.StatusAborted {
+		return
+	}
+
+	score := 0.5
+	if o.Winner != nil {
+		score = 0
+		if *o.Winner == colour.White {
+			score = 1
+		}
+	}
+
+	pool := rating.PoolFor(o.Initial, o.Increment, o.Correspondence)
+
+	if err := ratings.Record(pool, o.White, o.Black, score); err != nil {
+		log.Printf("Failed to rate game %s with error: %s\n", o.ID, err)
+	}
+}
+
+// ratingRoutes handles /ratings?pool=, the leaderboard of a pool, and /ratings/{player}, the ratings of a player
+func ratingRoutes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	player := strings.Trim(strings.TrimPrefix(r.URL.Path, "/ratings"), "/")
+	if player != "" {
+		pools := make(map[rating.Pool]rating.Player)
+		for _, p := range ratings.Pools(player) {
+			pools[p] = ratings.Get(p, player)
+		}
+
+		writeJSON(w, http.StatusOK, pools)
+		return
+	}
+
+	query := r.URL.Query()
+
+	pool, err := rating.ParsePool(query.Get("pool"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit := 0
+	if l := query.Get("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 0 {
+			http.Error(w, "limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, ratings.Leaderboard(pool, limit))
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/cmd/api"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/rating"
+)
+
+func TestLeaderboard(t *testing.T) {
+	srv := httptest.NewServer(newServeMux())
+	defer srv.Close()
+
+	carol := register(t, srv, "ratings-carol", false, "")
+	register(t, srv, "ratings-dave", false, "")
+
+	var g struct {
+		ID string `json:"id"`
+	}
+
+	req := api.StartGameRequest{White: "ratings-carol", Black: "ratings-dave", Clock: &api.ClockRequest{Initial: 300}}
+	if status := do(t, srv, http.MethodPost, "/games", carol, req, &g); status != http.StatusCreated {
+		t.Fatalf("want game to be created, got status %d", status)
+	}
+
+	created, err := manager.Get(g.ID)
+	if err != nil {
+		t.Fatalf("unexpected error getting game: %v", err)
+	}
+
+	// Dave resigns, so carol wins
+	if err := created.Resign(colour.Black); err != nil {
+		t.Fatalf("unexpected error resigning: %v", err)
+	}
+
+	if err := ratings.ClosePeriod(); err != nil {
+		t.Fatalf("unexpected error closing period: %v", err)
+	}
+
+	var board []rating.Standing
+	if status := do(t, srv, http.MethodGet, "/ratings?pool=blitz", "", nil, &board); status != http.StatusOK {
+		t.Fatalf("want leaderboard, got status %d", status)
+	}
+
+	var carolRank, daveRank = -1, -1
+	for i, s := range board {
+		switch s.Player {
+		case "ratings-carol":
+			carolRank = i
+		case "ratings-dave":
+			daveRank = i
+		}
+	}
+
+	if carolRank < 0 || daveRank < 0 || carolRank > daveRank {
+		t.Errorf("want carol to be ranked above dave, got %+v", board)
+	}
+
+	if status := do(t, srv, http.MethodGet, "/ratings?pool=hyperbullet", "", nil, nil); status != http.StatusBadRequest {
+		t.Errorf("want unknown pools to be rejected, got status %d", status)
+	}
+
+	var pools map[rating.Pool]rating.Player
+	if status := do(t, srv, http.MethodGet, "/ratings/ratings-carol", "", nil, &pools); status != http.StatusOK {
+		t.Fatalf("want ratings of carol, got status %d", status)
+	}
+
+	if p, ok := pools[rating.PoolBlitz]; !ok || p.Games != 1 {
+		t.Errorf("want carol to have a blitz rating from one game, got %+v", pools)
+	}
+
+}
+
+func TestBotRatings(t *testing.T) {
+	srv := httptest.NewServer(newServeMux())
+	defer srv.Close()
+
+	erin := register(t, srv, "ratings-erin", false, "")
+
+	var g struct {
+		ID string `json:"id"`
+	}
+
+	req := api.StartGameRequest{
+		White: "ratings-erin",
+		Clock: &api.ClockRequest{Initial: 300},
+		Bot:   &api.BotRequest{Colour: colour.Black, Depth: 1},
+	}
+	if status := do(t, srv, http.MethodPost, "/games", erin, req, &g); status != http.StatusCreated {
+		t.Fatalf("want game to be created, got status %d", status)
+	}
+
+	created, err := manager.Get(g.ID)
+	if err != nil {
+		t.Fatalf("unexpected error getting game: %v", err)
+	}
+
+	// Erin resigns, so the bot wins
+	if err := created.Resign(colour.White); err != nil {
+		t.Fatalf("unexpected error resigning: %v", err)
+	}
+
+	if err := ratings.ClosePeriod(); err != nil {
+		t.Fatalf("unexpected error closing period: %v", err)
+	}
+
+	for _, player := range []string{"ratings-erin", "bot:depth-1"} {
+		var pools map[rating.Pool]rating.Player
+		if status := do(t, srv, http.MethodGet, "/ratings/"+player, "", nil, &pools); status != http.StatusOK {
+			t.Fatalf("want ratings of %s, got status %d", player, status)
+		}
+
+		if p, ok := pools[rating.PoolBlitz]; !ok || p.Games != 1 {
+			t.Errorf("want %s to have a blitz rating from one game, got %+v", player, pools)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tomwatson6/chessbot/cmd/api"
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/engine"
+	"github.com/tomwatson6/chessbot/internal/session"
+)
+
+// sessions runs the analysis sessions of the server, searching with as many threads at once as there are CPUs unless
+// the server is started with -session-workers
+var sessions = session.NewManager(session.WithEngineOptions(engine.WithTablebase(endgames())))
+
+// sessionRoutes handles /sessions, starting an infinite analysis of the position in the body, and /sessions/{id},
+// getting the lines of the last depth completed on GET and stopping the analysis on DELETE
+func sessionRoutes(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/sessions"), "/")
+
+	switch {
+	case id == "" && r.Method == http.MethodPost:
+		startSession(w, r)
+	case id == "":
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	case strings.Contains(id, "/"):
+		http.NotFound(w, r)
+	case r.Method == http.MethodGet:
+		s, err := sessions.Get(id)
+		writeSession(w, http.StatusOK, s, err)
+	case r.Method == http.MethodDelete:
+		s, err := sessions.Stop(id)
+		writeSession(w, http.StatusOK, s, err)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func startSession(w http.ResponseWriter, r *http.Request) {
+	var req api.SessionRequest
+	if err := getInput(r, &req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		moveErr := api.NewInvalidRequestError(err)
+		writeJSON(w, moveErr.Status(), moveErr)
+		return
+	}
+
+	if req.FEN == "" {
+		req.FEN = chess.StartingFEN
+	}
+
+	c, err := chess.FromFEN(req.FEN)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.MultiPV == 0 {
+		req.MultiPV = 1
+	}
+
+	if req.MultiPV < 1 || req.MultiPV > maxMultiPV {
+		http.Error(w, fmt.Sprintf("multipv must be between 1 and %d", maxMultiPV), http.StatusBadRequest)
+		return
+	}
+
+	if req.Threads == 0 {
+		req.Threads = 1
+	}
+
+	if req.Threads < 1 || req.Threads > maxThreads {
+		http.Error(w, fmt.Sprintf("threads must be between 1 and %d", maxThreads), http.StatusBadRequest)
+		return
+	}
+
+	s, err := sessions.Start(c, req.MultiPV, req.Threads)
+	if errors.Is(err, engine.ErrorNoMoves) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	writeSession(w, http.StatusCreated, s, err)
+}
+
+func writeSession(w http.ResponseWriter, status int, s session.Snapshot, err error) {
+	switch {
+	case errors.Is(err, session.ErrorSessionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	c, err := chess.FromFEN(s.FEN)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, status, api.SessionResponse{
+		ID:              s.ID,
+		Status:          s.Status,
+		MultiPV:         s.MultiPV,
+		Threads:         s.Threads,
+		Started:         s.Started,
+		AnalyseResponse: analyseResponse(c, s.Lines),
+	})
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tomwatson6/chessbot/cmd/api"
+	"github.com/tomwatson6/chessbot/internal/session"
+)
+
+func TestSessions(t *testing.T) {
+	srv := httptest.NewServer(newServeMux())
+	defer srv.Close()
+
+	var started api.SessionResponse
+	if status := do(t, srv, http.MethodPost, "/sessions", "", api.SessionRequest{MultiPV: 2}, &started); status != http.StatusCreated {
+		t.Fatalf("want status %d, got %d", http.StatusCreated, status)
+	}
+
+	defer sessions.Stop(started.ID)
+
+	var got api.SessionResponse
+	for deadline := time.Now().Add(5 * time.Second); got.Depth < 2; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("want the session to reach depth 2, got %+v", got)
+		}
+
+		if status := do(t, srv, http.MethodGet, "/sessions/"+started.ID, "", nil, &got); status != http.StatusOK {
+			t.Fatalf("want status %d, got %d", http.StatusOK, status)
+		}
+	}
+
+	if len(got.Lines) != 2 || len(got.Lines[0].SAN) == 0 {
+		t.Errorf("want two lines with their moves in SAN, got %+v", got)
+	}
+
+	var stopped api.SessionResponse
+	if status := do(t, srv, http.MethodDelete, "/sessions/"+started.ID, "", nil, &stopped); status != http.StatusOK || stopped.Status != session.Stopped {
+		t.Errorf("want the session stopped, got %+v with status %d", stopped, status)
+	}
+
+	if status := do(t, srv, http.MethodGet, "/sessions/"+started.ID, "", nil, nil); status != http.StatusNotFound {
+		t.Errorf("want status %d once the session is stopped, got %d", http.StatusNotFound, status)
+	}
+
+	tests := []struct {
+		name   string
+		req    api.SessionRequest
+		status int
+	}{
+		{name: "invalid fen", req: api.SessionRequest{FEN: "bad"}, status: http.StatusBadRequest},
+		{name: "too many lines", req: api.SessionRequest{MultiPV: maxMultiPV + 1}, status: http.StatusBadRequest},
+		{name: "too many threads", req: api.SessionRequest{Threads: maxThreads + 1}, status: http.StatusBadRequest},
+		{name: "checkmate", req: api.SessionRequest{FEN: "R5k1/5ppp/8/8/8/8/8/6K1 b - - 0 1"}, status: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := do(t, srv, http.MethodPost, "/sessions", "", tt.req, nil); status != tt.status {
+				t.Errorf("want status %d, got %d", tt.status, status)
+			}
+		})
+	}
+}
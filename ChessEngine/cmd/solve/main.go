@@ -0,0 +1,88 @@
+// Command solve proves or refutes a chess problem and prints its solution tree, e.g.
+//
+//	solve -fen "7k/8/5K2/8/8/8/8/R7 w - - 0 1" "#2"
+//
+// Problems are written as #n for a mate, s#n for a self-mate and h#n for a help-mate in n moves.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/problem"
+)
+
+func main() {
+	fen := flag.String("fen", "", "the position of the problem")
+	timeout := flag.Duration("timeout", 0, "give up after this long, 0 to never give up")
+	flag.Parse()
+
+	if *fen == "" || flag.NArg() != 1 {
+		log.Fatal("expected a position with -fen and a problem e.g. #2")
+	}
+
+	c, err := chess.FromFEN(*fen)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	p, err := problem.Parse(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+
+	sol, err := problem.Solve(ctx, c, p)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if !sol.Solved() {
+		fmt.Printf("%s has no solution\n", p)
+		os.Exit(1)
+	}
+
+	write(os.Stdout, sol.Keys, 1, true, 0)
+
+	if cooks := sol.Cooks(); len(cooks) > 0 {
+		fmt.Printf("cooked, %d keys\n", len(sol.Keys))
+	}
+
+	log.Printf("solved %s in %s, searching %d positions", p, time.Since(start).Round(time.Millisecond), sol.Nodes)
+}
+
+// write prints the moves of the tree, each followed by the moves that answer it indented beneath it. Moves are
+// numbered from the first move of the problem, whichever colour makes it.
+func write(w io.Writer, nodes []*problem.Node, number int, first bool, depth int) {
+	for _, n := range nodes {
+		indent := strings.Repeat("    ", depth)
+
+		if first {
+			key := ""
+			if depth == 0 {
+				key = "!"
+			}
+
+			fmt.Fprintf(w, "%s%d. %s%s\n", indent, number, n.SAN, key)
+			write(w, n.Children, number, false, depth+1)
+		} else {
+			fmt.Fprintf(w, "%s%d... %s\n", indent, number, n.SAN)
+			write(w, n.Children, number+1, true, depth+1)
+		}
+	}
+}
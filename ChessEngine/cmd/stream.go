@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/tomwatson6/chessbot/cmd/api"
+	"github.com/tomwatson6/chessbot/internal/game"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/pkg/websocket"
+)
+
+// stream upgrades the request to a websocket that pushes the events of the game to the client,
+// and accepts moves, draw offers and resignations from it on behalf of the caller
+func stream(w http.ResponseWriter, r *http.Request, g *game.Game, caller string) {
+	conn, err := websocket.Upgrade(w, r)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	events, unsubscribe := g.Subscribe()
+	defer unsubscribe()
+
+	if err := conn.WriteJSON(api.StreamMessage{Type: api.StreamMessageState, Data: g}); err != nil {
+		return
+	}
+
+	go func() {
+		for e := range events {
+			if err := conn.WriteJSON(e); err != nil {
+				return
+			}
+		}
+
+		// The subscription has been dropped, so close the connection to let the client reconnect
+		conn.Close()
+	}()
+
+	for {
+		var req api.StreamRequest
+		if err := conn.ReadJSON(&req); err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, websocket.ErrorClosed) {
+				log.Printf("Failed to read from websocket of game %s with error: %s\n", g.ID, err)
+			}
+
+			return
+		}
+
+		if moveErr := handleStreamRequest(g, caller, req); moveErr != nil {
+			if err := conn.WriteJSON(api.StreamMessage{Type: api.StreamMessageError, Error: moveErr}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func handleStreamRequest(g *game.Game, caller string, req api.StreamRequest) *api.MoveError {
+	var err error
+	var m move.Move
+
+	before := g.Chess()
+
+	if req.Type != api.StreamRequestMove {
+		if err := g.Authorise(caller, req.Colour); err != nil {
+			moveErr := api.NewMoveError(err, before.Board, m)
+			return &moveErr
+		}
+	}
+
+	switch req.Type {
+	case api.StreamRequestMove:
+		if req.Move == nil {
+			moveErr := api.NewInvalidRequestError(fmt.Errorf("no move provided"))
+			return &moveErr
+		}
+
+		m = *req.Move
+		_, err = g.MoveAs(caller, m)
+	case api.StreamRequestOfferDraw:
+		err = g.OfferDraw(req.Colour)
+	case api.StreamRequestAcceptDraw:
+		err = g.AcceptDraw(req.Colour)
+	case api.StreamRequestDeclineDraw:
+		err = g.DeclineDraw(req.Colour)
+	case api.StreamRequestResign:
+		err = g.Resign(req.Colour)
+	default:
+		moveErr := api.NewInvalidRequestError(fmt.Errorf("unknown request type: %s", req.Type))
+		return &moveErr
+	}
+
+	if err != nil {
+		moveErr := api.NewMoveError(err, before.Board, m)
+		return &moveErr
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/cmd/api"
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/pkg/websocket"
+)
+
+type streamMessage struct {
+	ID    int            `json:"id"`
+	Type  string         `json:"type"`
+	Error *api.MoveError `json:"error"`
+}
+
+func dialGame(t *testing.T, srv *httptest.Server, id string) *websocket.Conn {
+	t.Helper()
+
+	conn, err := websocket.Dial("ws" + strings.TrimPrefix(srv.URL, "http") + "/games/" + id + "/ws")
+	if err != nil {
+		t.Fatalf("unexpected error dialing: %v", err)
+	}
+
+	var msg streamMessage
+	if err := conn.ReadJSON(&msg); err != nil || msg.Type != api.StreamMessageState {
+		t.Fatalf("want initial state message, got %+v with error %v", msg, err)
+	}
+
+	return conn
+}
+
+func TestStream(t *testing.T) {
+	srv := httptest.NewServer(newServeMux())
+	defer srv.Close()
+
+	g, err := manager.Create(chess.New(colour.White))
+	if err != nil {
+		t.Fatalf("unexpected error creating game: %v", err)
+	}
+
+	white := dialGame(t, srv, g.ID)
+	defer white.Close()
+
+	black := dialGame(t, srv, g.ID)
+	defer black.Close()
+
+	m := move.Move{From: move.Position{File: 4, Rank: 1}, To: move.Position{File: 4, Rank: 3}}
+	if err := white.WriteJSON(api.StreamRequest{Type: api.StreamRequestMove, Move: &m}); err != nil {
+		t.Fatalf("unexpected error writing move: %v", err)
+	}
+
+	for _, conn := range []*websocket.Conn{white, black} {
+		var msg streamMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("unexpected error reading: %v", err)
+		}
+
+		if msg.Type != "move" || msg.ID != 1 {
+			t.Errorf("want move event with id 1, got %+v", msg)
+		}
+	}
+
+	// White tries to move again out of turn
+	m = move.Move{From: move.Position{File: 3, Rank: 1}, To: move.Position{File: 3, Rank: 3}}
+	if err := white.WriteJSON(api.StreamRequest{Type: api.StreamRequestMove, Move: &m}); err != nil {
+		t.Fatalf("unexpected error writing move: %v", err)
+	}
+
+	var msg streamMessage
+	if err := white.ReadJSON(&msg); err != nil {
+		t.Fatalf("unexpected error reading: %v", err)
+	}
+
+	if msg.Type != api.StreamMessageError || msg.Error == nil || msg.Error.Code != api.ErrorCodeNotYourTurn {
+		t.Errorf("want %s error, got %+v", api.ErrorCodeNotYourTurn, msg)
+	}
+}
@@ -0,0 +1,52 @@
+// Command tablebase generates endgame tables for the materials listed and adds them to a file, e.g.
+//
+//	tablebase -out endgames.tb KQvK KRvK KPvK KBNvK KQvKR
+package main
+
+import (
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/tablebase"
+)
+
+func main() {
+	out := flag.String("out", "endgames.tb", "the file to add the tables to, created if it does not exist")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		log.Fatal("expected at least one material e.g. KQvK")
+	}
+
+	s, err := tablebase.Open(*out)
+	if errors.Is(err, os.ErrNotExist) {
+		s = tablebase.New()
+	} else if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, name := range flag.Args() {
+		start := time.Now()
+
+		if err := s.Generate(name); err != nil {
+			log.Fatalf("%s: %s", name, err)
+		}
+
+		longest, err := s.Longest(name)
+		if err != nil {
+			log.Fatalf("%s: %s", name, err)
+		}
+
+		log.Printf("generated %s in %s, the longest mate is %d half moves", name,
+			time.Since(start).Round(time.Millisecond), longest)
+	}
+
+	if err := s.SaveFile(*out); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("wrote %d tables to %s", len(s.Materials()), *out)
+}
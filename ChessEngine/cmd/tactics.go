@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/tomwatson6/chessbot/cmd/api"
+	"github.com/tomwatson6/chessbot/internal/tactics"
+)
+
+// findTactics handles /tactics?fen=, the tactical motifs of a position, or with move= the motifs created by a move
+// in UCI notation from it
+func findTactics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	c, err := positionFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := api.TacticsResponse{FEN: c.FEN(), Motifs: []tactics.Motif{}}
+
+	if s := r.URL.Query().Get("move"); s != "" {
+		m, promotion, err := c.ParseUCI(s)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if resp.Move, err = c.SAN(m, promotion); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if resp.Motifs, err = tactics.Move(c, m, promotion); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		resp.Motifs = append(resp.Motifs, tactics.Find(c)...)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, http.StatusOK, resp)
+}
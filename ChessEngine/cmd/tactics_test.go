@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/cmd/api"
+	"github.com/tomwatson6/chessbot/internal/tactics"
+)
+
+func TestTactics(t *testing.T) {
+	srv := httptest.NewServer(newServeMux())
+	defer srv.Close()
+
+	fen := url.QueryEscape("r3k3/8/8/1N6/8/8/8/4K3 w - - 0 1")
+
+	var resp api.TacticsResponse
+	if status := do(t, srv, http.MethodGet, "/tactics?fen="+fen+"&move=b5c7", "", nil, &resp); status != http.StatusOK {
+		t.Fatalf("want motifs of the move, got status %d", status)
+	}
+
+	found := false
+	for _, m := range resp.Motifs {
+		found = found || m.Kind == tactics.Fork
+	}
+
+	if resp.Move != "Nc7+" || !found {
+		t.Errorf("want Nc7+ to fork the king and rook, got %+v", resp)
+	}
+
+	resp = api.TacticsResponse{}
+	if status := do(t, srv, http.MethodGet, "/tactics", "", nil, &resp); status != http.StatusOK || len(resp.Motifs) != 0 {
+		t.Errorf("want no motifs in the starting position, got status %d and %+v", status, resp)
+	}
+
+	if status := do(t, srv, http.MethodGet, "/tactics?fen="+fen+"&move=b5b6", "", nil, nil); status != http.StatusBadRequest {
+		t.Errorf("want bad request for an illegal move, got status %d", status)
+	}
+}
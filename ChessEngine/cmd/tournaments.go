@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tomwatson6/chessbot/cmd/api"
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/game"
+	"github.com/tomwatson6/chessbot/internal/tournament"
+)
+
+var tournaments *tournament.Organiser
+
+func init() {
+	// The organiser is created here rather than where it is declared, as starting games refers back to the manager
+	tournaments, _ = tournament.NewOrganiser(startPairing)
+}
+
+// startPairing starts the game of a tournament pairing between the two players
+func startPairing(t tournament.Tournament, p tournament.Pairing) (string, error) {
+	opts := []game.Option{game.WithPlayers(p.White, p.Black)}
+
+	if t.TimeControl.Initial > 0 {
+		opts = append(opts, game.WithClock(t.TimeControl.Initial, t.TimeControl.Increment))
+	}
+
+	g, err := manager.Create(chess.New(colour.White), opts...)
+	if err != nil {
+		return "", err
+	}
+
+	return g.ID, nil
+}
+
+// gameFinished rates the game, reports its result to the tournament it is part of and queues it to be analysed
+func gameFinished(o game.Outcome) {
+	recordResult(o)
+	reportResult(o)
+
+	if o.Status != game.StatusAborted {
+		analyses.enqueue(o.ID)
+	}
+}
+
+// reportResult reports the result of a tournament game, aborted games are started again
+func reportResult(o game.Outcome) {
+	var err error
+
+	if o.Status == game.StatusAborted {
+		err = tournaments.Restart(o.ID)
+	} else {
+		score := 0.5
+		if o.Winner != nil {
+			score = 0
+			if *o.Winner == colour.White {
+				score = 1
+			}
+		}
+
+		err = tournaments.Report(o.ID, score)
+	}
+
+	if err != nil && !errors.Is(err, tournament.ErrorGameNotFound) {
+		log.Printf("Failed to report the result of game %s with error: %s\n", o.ID, err)
+	}
+}
+
+// tournamentRoutes handles /tournaments, listing the tournaments or creating a new one, and /tournaments/{id}
+// along with its standings and starting it
+func tournamentRoutes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	caller, err := authenticate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/tournaments"), "/"), "/")
+
+	if parts[0] == "" {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, tournaments.List())
+		case http.MethodPost:
+			if !isAdmin(caller) {
+				http.Error(w, "only admins can create tournaments", http.StatusForbidden)
+				return
+			}
+
+			createTournament(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+
+		return
+	}
+
+	t, err := tournaments.Get(parts[0])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	resource := ""
+	if len(parts) > 1 {
+		resource = parts[1]
+	}
+
+	switch resource {
+	case "":
+		writeJSON(w, http.StatusOK, t)
+	case "standings":
+		writeJSON(w, http.StatusOK, t.Standings())
+	case "start":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		if !isAdmin(caller) {
+			http.Error(w, "only admins can start tournaments", http.StatusForbidden)
+			return
+		}
+
+		t, err := tournaments.Start(t.ID)
+		if errors.Is(err, tournament.ErrorFinished) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, t)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func createTournament(w http.ResponseWriter, r *http.Request) {
+	var req api.TournamentRequest
+	if err := getInput(r, &req); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read request with error: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	f, err := tournament.ParseFormat(req.Format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, p := range req.Players {
+		if _, err := accounts.Get(p); err != nil {
+			http.Error(w, fmt.Sprintf("%s: %s", err, p), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var tc tournament.TimeControl
+	if req.Clock != nil {
+		if req.Clock.Initial <= 0 || req.Clock.Increment < 0 {
+			http.Error(w, fmt.Sprintf("invalid clock: %+v", *req.Clock), http.StatusBadRequest)
+			return
+		}
+
+		tc = tournament.TimeControl{
+			Initial:   time.Duration(req.Clock.Initial) * time.Second,
+			Increment: time.Duration(req.Clock.Increment) * time.Second,
+		}
+	}
+
+	t, err := tournaments.Create(req.Name, f, req.Players, req.Rounds, tc)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, t)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/cmd/api"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/tournament"
+)
+
+func TestTournament(t *testing.T) {
+	srv := httptest.NewServer(newServeMux())
+	defer srv.Close()
+
+	_, admin, err := accounts.Register("tournament-admin", true)
+	if err != nil {
+		t.Fatalf("unexpected error registering admin: %v", err)
+	}
+
+	erin := register(t, srv, "tournament-erin", false, "")
+	register(t, srv, "tournament-frank", false, "")
+	register(t, srv, "tournament-grace", false, "")
+
+	req := api.TournamentRequest{
+		Name:    "Club championship",
+		Format:  string(tournament.FormatRoundRobin),
+		Players: []string{"tournament-erin", "tournament-frank", "tournament-grace"},
+	}
+
+	if status := do(t, srv, http.MethodPost, "/tournaments", erin, req, nil); status != http.StatusForbidden {
+		t.Errorf("want players to be forbidden from creating tournaments, got status %d", status)
+	}
+
+	var tr tournament.Tournament
+	if status := do(t, srv, http.MethodPost, "/tournaments", admin, req, &tr); status != http.StatusCreated {
+		t.Fatalf("want tournament to be created, got status %d", status)
+	}
+
+	if status := do(t, srv, http.MethodPost, "/tournaments/"+tr.ID+"/start", admin, nil, &tr); status != http.StatusOK {
+		t.Fatalf("want tournament to be started, got status %d", status)
+	}
+
+	// White resigns every game, so each round is paired as soon as the last has finished
+	for round := 1; round <= 3; round++ {
+		if len(tr.Rounds) != round {
+			t.Fatalf("want round %d to be paired, got %d rounds", round, len(tr.Rounds))
+		}
+
+		for _, p := range tr.Rounds[round-1].Pairings {
+			if p.IsBye() {
+				continue
+			}
+
+			g, err := manager.Get(p.GameID)
+			if err != nil {
+				t.Fatalf("want game of %s v %s to be started: %v", p.White, p.Black, err)
+			}
+
+			if err := g.Resign(colour.White); err != nil {
+				t.Fatalf("unexpected error resigning: %v", err)
+			}
+		}
+
+		if status := do(t, srv, http.MethodGet, "/tournaments/"+tr.ID, "", nil, &tr); status != http.StatusOK {
+			t.Fatalf("want tournament, got status %d", status)
+		}
+	}
+
+	if tr.Status != tournament.StatusFinished {
+		t.Errorf("want tournament to be finished, got %s", tr.Status)
+	}
+
+	var standings []tournament.Standing
+	if status := do(t, srv, http.MethodGet, "/tournaments/"+tr.ID+"/standings", "", nil, &standings); status != http.StatusOK {
+		t.Fatalf("want standings, got status %d", status)
+	}
+
+	if len(standings) != 3 {
+		t.Fatalf("want 3 standings, got %+v", standings)
+	}
+
+	for _, s := range standings {
+		// Every player won with black, lost with white and had a bye
+		if s.Score != 2 {
+			t.Errorf("want every player to score 2, got %+v", s)
+		}
+	}
+}
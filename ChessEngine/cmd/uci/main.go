@@ -0,0 +1,70 @@
+// Command uci runs the engine as a UCI engine on standard input and output, for use with chess GUIs, e.g.
+//
+//	uci -tablebase endgames.tb -syzygy syzygy -book book.bin
+//
+// The number of threads, size of the hash and number of lines are set by the GUI through the Threads, Hash and
+// MultiPV options.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/tomwatson6/chessbot/internal/engine"
+	"github.com/tomwatson6/chessbot/internal/polyglot"
+	"github.com/tomwatson6/chessbot/internal/tablebase"
+	"github.com/tomwatson6/chessbot/internal/uci"
+)
+
+func main() {
+	tablebasePath := flag.String("tablebase", "", "a file of endgame tables made by the tablebase command")
+	syzygyDir := flag.String("syzygy", "", "a directory of Syzygy WDL and DTZ files")
+	bookPath := flag.String("book", "", "a Polyglot opening book to play from before searching")
+	flag.Parse()
+
+	var opts []engine.Option
+	var serverOpts []uci.ServerOption
+
+	// The generated tables are probed before the Syzygy files, as they know the distance to mate
+	var tables tablebase.Chain
+
+	if *tablebasePath != "" {
+		set, err := tablebase.Open(*tablebasePath)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		tables = append(tables, set)
+	}
+
+	if *syzygyDir != "" {
+		syzygy, err := tablebase.OpenSyzygy(*syzygyDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		defer syzygy.Close()
+
+		tables = append(tables, syzygy)
+	}
+
+	if len(tables) > 0 {
+		opts = append(opts, engine.WithTablebase(tables))
+	}
+
+	if *bookPath != "" {
+		book, err := polyglot.Open(*bookPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		serverOpts = append(serverOpts, uci.WithBook(book))
+	}
+
+	serverOpts = append(serverOpts, uci.WithEngineOptions(opts...))
+
+	if err := uci.NewServer(os.Stdout, serverOpts...).Run(os.Stdin); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,192 @@
+// Package account keeps the players of the server and the API tokens they authenticate with
+package account
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/storage"
+)
+
+var (
+	// ErrorInvalidToken is thrown when a token does not belong to any account
+	ErrorInvalidToken = errors.New("the token provided is not valid")
+	// ErrorNameTaken is thrown when registering a name that already has an account
+	ErrorNameTaken = errors.New("an account with the name provided already exists")
+	// ErrorInvalidName is thrown when registering a name that is empty or contains characters other than letters, digits, - and _
+	ErrorInvalidName = errors.New("names must be 1 to 32 letters, digits, - or _")
+	// ErrorAccountNotFound is thrown when there is no account with the name provided
+	ErrorAccountNotFound = errors.New("there is no account with the name provided")
+)
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// Account is a player of the server, admins can also spectate and abort any game
+type Account struct {
+	Name      string    `json:"name"`
+	Admin     bool      `json:"admin"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	// TokenHash is the SHA-256 of the token of the account, the token itself is only known to its owner
+	TokenHash string `json:"tokenHash"`
+}
+
+// Registry holds the accounts of the server, saving them to a file when it has one
+type Registry struct {
+	mu       sync.RWMutex
+	path     string
+	accounts map[string]Account
+	now      func() time.Time
+}
+
+// NewRegistry loads the accounts saved in the file at the path provided, keeping the accounts in memory when
+// the path is empty
+func NewRegistry(path string) (*Registry, error) {
+	r := &Registry{
+		path:     path,
+		accounts: make(map[string]Account),
+		now:      time.Now,
+	}
+
+	if path == "" {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return r, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts []Account
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return nil, err
+	}
+
+	for _, a := range accounts {
+		r.accounts[a.Name] = a
+	}
+
+	return r, nil
+}
+
+// Register creates an account with the name provided, returning the token to authenticate as it
+func (r *Registry) Register(name string, admin bool) (Account, string, error) {
+	if !namePattern.MatchString(name) {
+		return Account{}, "", ErrorInvalidName
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.accounts[name]; ok {
+		return Account{}, "", ErrorNameTaken
+	}
+
+	token, err := storage.NewID(32)
+	if err != nil {
+		return Account{}, "", err
+	}
+
+	a := Account{
+		Name:      name,
+		Admin:     admin,
+		CreatedAt: r.now(),
+		TokenHash: hashToken(token),
+	}
+
+	r.accounts[name] = a
+
+	if err := r.save(); err != nil {
+		delete(r.accounts, name)
+		return Account{}, "", err
+	}
+
+	return a, token, nil
+}
+
+// Authenticate gets the account that the token belongs to
+func (r *Registry) Authenticate(token string) (Account, error) {
+	if token == "" {
+		return Account{}, ErrorInvalidToken
+	}
+
+	hash := hashToken(token)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, a := range r.accounts {
+		if subtle.ConstantTimeCompare([]byte(a.TokenHash), []byte(hash)) == 1 {
+			return a, nil
+		}
+	}
+
+	return Account{}, ErrorInvalidToken
+}
+
+func (r *Registry) Get(name string) (Account, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	a, ok := r.accounts[name]
+	if !ok {
+		return Account{}, ErrorAccountNotFound
+	}
+
+	return a, nil
+}
+
+// HasAdmin reports whether any account is an admin
+func (r *Registry) HasAdmin() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, a := range r.accounts {
+		if a.Admin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// save writes the accounts to the file of the registry, sorted by name. r.mu must be held by the caller.
+func (r *Registry) save() error {
+	if r.path == "" {
+		return nil
+	}
+
+	accounts := make([]Account, 0, len(r.accounts))
+	for _, a := range r.accounts {
+		accounts = append(accounts, a)
+	}
+
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Name < accounts[j].Name
+	})
+
+	data, err := json.MarshalIndent(accounts, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return storage.WriteFile(r.path, data, 0o600)
+}
+
+func hashToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,64 @@
+package account_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/internal/account"
+)
+
+func TestRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "accounts.json")
+
+	r, err := account.NewRegistry(path)
+	if err != nil {
+		t.Fatalf("unexpected error creating registry: %v", err)
+	}
+
+	alice, token, err := r.Register("alice", false)
+	if err != nil {
+		t.Fatalf("unexpected error registering: %v", err)
+	}
+
+	tcs := []struct {
+		name    string
+		reg     string
+		wantErr error
+	}{
+		{name: "NameTaken", reg: "alice", wantErr: account.ErrorNameTaken},
+		{name: "EmptyName", reg: "", wantErr: account.ErrorInvalidName},
+		{name: "InvalidName", reg: "../alice", wantErr: account.ErrorInvalidName},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, _, err := r.Register(tc.reg, false); !errors.Is(err, tc.wantErr) {
+				t.Errorf("want %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+
+	// The accounts are kept when the server restarts
+	r, err = account.NewRegistry(path)
+	if err != nil {
+		t.Fatalf("unexpected error loading registry: %v", err)
+	}
+
+	got, err := r.Authenticate(token)
+	if err != nil {
+		t.Fatalf("unexpected error authenticating: %v", err)
+	}
+
+	if got.Name != alice.Name || got.Admin != alice.Admin || got.TokenHash != alice.TokenHash {
+		t.Errorf("want %+v, got %+v", alice, got)
+	}
+
+	if _, err := r.Authenticate(token + "0"); !errors.Is(err, account.ErrorInvalidToken) {
+		t.Errorf("want %v, got %v", account.ErrorInvalidToken, err)
+	}
+
+	if r.HasAdmin() {
+		t.Errorf("want no admin accounts")
+	}
+}
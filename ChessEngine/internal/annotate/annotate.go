@@ -0,0 +1,313 @@
+// Package annotate analyses the moves of a game with the engine, classifying each one by how much worse it was
+// than the best move, and scoring the accuracy of each player
+package annotate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/engine"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/piece"
+)
+
+// Classification is how good a move was compared to the best move of the position
+type Classification int
+
+const (
+	Best Classification = iota
+	Good
+	Inaccuracy
+	Mistake
+	Blunder
+	MissedMate
+)
+
+var classifications = []string{
+	Best:       "best",
+	Good:       "good",
+	Inaccuracy: "inaccuracy",
+	Mistake:    "mistake",
+	Blunder:    "blunder",
+	MissedMate: "missed-mate",
+}
+
+func (c Classification) String() string {
+	if c < 0 || int(c) >= len(classifications) {
+		return "unknown"
+	}
+
+	return classifications[c]
+}
+
+func (c Classification) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+func (c *Classification) UnmarshalText(text []byte) error {
+	for i, name := range classifications {
+		if name == string(text) {
+			*c = Classification(i)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown classification %q", text)
+}
+
+// The centipawns lost by a move from which it is classified as an inaccuracy, a mistake or a blunder
+const (
+	InaccuracyLoss = 50
+	MistakeLoss    = 100
+	BlunderLoss    = 300
+)
+
+// ceiling caps evaluations when working out how much a move lost, as there is little difference between winning by
+// a lot and winning by a bit more, and so that mates can be compared with other evaluations
+const ceiling = 1000
+
+// Move is the analysis of a move of the game
+type Move struct {
+	Colour colour.Colour `json:"colour"`
+	SAN    string        `json:"san"`
+	UCI    string        `json:"uci"`
+	// Before and After are the evaluations of the positions before and after the move, from the point of view of
+	// white
+	Before engine.Score `json:"before"`
+	After  engine.Score `json:"after"`
+	// Best is the move the engine would have played, in Standard Algebraic Notation
+	Best    string `json:"best"`
+	BestUCI string `json:"bestUci"`
+	// Loss is how many centipawns worse the move was than the best move
+	Loss           int            `json:"loss"`
+	Accuracy       float64        `json:"accuracy"`
+	Classification Classification `json:"classification"`
+}
+
+// Player is the summary of the moves of a colour
+type Player struct {
+	// Accuracy is the average accuracy of the moves of the player as a percentage
+	Accuracy     float64 `json:"accuracy"`
+	AverageLoss  int     `json:"averageLoss"`
+	Inaccuracies int     `json:"inaccuracies"`
+	Mistakes     int     `json:"mistakes"`
+	Blunders     int     `json:"blunders"`
+	MissedMates  int     `json:"missedMates"`
+}
+
+// Analysis is the annotation of a game from the position it started in
+type Analysis struct {
+	FEN   string `json:"fen"`
+	Moves []Move `json:"moves"`
+	White Player `json:"white"`
+	Black Player `json:"black"`
+}
+
+type Option func(o *options)
+
+type options struct {
+	engine *engine.Engine
+	depth  int
+}
+
+// WithEngine analyses the game with the engine provided, rather than one with the default evaluation
+func WithEngine(e *engine.Engine) Option {
+	return func(o *options) {
+		o.engine = e
+	}
+}
+
+// WithDepth sets how deep each position of the game is searched, the maximum depth of the engine by default
+func WithDepth(depth int) Option {
+	return func(o *options) {
+		o.depth = depth
+	}
+}
+
+// Annotate analyses the moves made from the position provided. Each position of the game is searched once, the
+// evaluation after a move being the evaluation of the position that follows it. Pawns reaching the last rank are
+// promoted to queens.
+func Annotate(ctx context.Context, start chess.Chess, moves []move.Move, opts ...Option) (Analysis, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.engine == nil {
+		o.engine = engine.New()
+	}
+
+	a := Analysis{FEN: start.FEN(), Moves: make([]Move, 0, len(moves))}
+
+	c := start.Copy()
+
+	before, best, err := o.evaluate(ctx, c)
+	if err != nil {
+		return Analysis{}, err
+	}
+
+	for i, m := range moves {
+		mv := Move{Colour: c.Turn, UCI: c.UCI(m, piece.PieceTypeQueen)}
+
+		if mv.SAN, err = c.SAN(m, piece.PieceTypeQueen); err != nil {
+			return Analysis{}, fmt.Errorf("move %d %s: %w", i+1, m, err)
+		}
+
+		mv.BestUCI = c.UCI(best, piece.PieceTypeQueen)
+		if mv.Best, err = c.SAN(best, piece.PieceTypeQueen); err != nil {
+			return Analysis{}, err
+		}
+
+		next := c.Copy()
+		if _, err := next.MakeMoveWithPromotion(m, piece.PieceTypeQueen); err != nil {
+			return Analysis{}, fmt.Errorf("move %d %s: %w", i+1, m, err)
+		}
+
+		after, nextBest, err := o.evaluate(ctx, next)
+		if err != nil {
+			return Analysis{}, err
+		}
+
+		// The evaluation of the next position is from the point of view of the opponent
+		classify(&mv, before, -after, m == best)
+
+		mv.Before, mv.After = before, -after
+		if c.Turn == colour.Black {
+			mv.Before, mv.After = -mv.Before, -mv.After
+		}
+
+		a.Moves = append(a.Moves, mv)
+
+		c, before, best = next, after, nextBest
+	}
+
+	a.White = summarise(a.Moves, colour.White)
+	a.Black = summarise(a.Moves, colour.Black)
+
+	return a, nil
+}
+
+// evaluate searches the position, getting its score from the point of view of the colour to move and the best move,
+// which is not set when the game is over
+func (o options) evaluate(ctx context.Context, c chess.Chess) (engine.Score, move.Move, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, move.Move{}, err
+	}
+
+	res, err := o.engine.Search(ctx, c, engine.Limits{Depth: o.depth}, nil)
+	if errors.Is(err, engine.ErrorNoMoves) {
+		return over(c), move.Move{}, nil
+	}
+
+	if err != nil {
+		return 0, move.Move{}, err
+	}
+
+	return res.Score, res.Move, nil
+}
+
+// over scores a position where the colour to move cannot move, as a loss when it is checkmate and a win when losing
+// every move wins the variant
+func over(c chess.Chess) engine.Score {
+	switch {
+	case !c.Board.Variant.HasRoyalKing():
+		return engine.Mate
+	case c.Board.InCheck(c.Turn):
+		return -engine.Mate
+	default:
+		return 0
+	}
+}
+
+// classify sets how much the move lost and how good it was, from the evaluations before and after it from the point
+// of view of the colour that made it
+func classify(mv *Move, before, after engine.Score, best bool) {
+	if !best {
+		mv.Loss = centipawns(before) - centipawns(after)
+		if mv.Loss < 0 {
+			mv.Loss = 0
+		}
+	}
+
+	mv.Accuracy = accuracy(winChance(before), winChance(after))
+	if best {
+		mv.Accuracy = 100
+	}
+
+	switch {
+	case best:
+		mv.Classification = Best
+	case before.IsMate() && before > 0 && !(after.IsMate() && after > 0):
+		mv.Classification = MissedMate
+	case mv.Loss >= BlunderLoss:
+		mv.Classification = Blunder
+	case mv.Loss >= MistakeLoss:
+		mv.Classification = Mistake
+	case mv.Loss >= InaccuracyLoss:
+		mv.Classification = Inaccuracy
+	default:
+		mv.Classification = Good
+	}
+}
+
+// centipawns gets the score capped at the ceiling, mates being worth the ceiling
+func centipawns(s engine.Score) int {
+	switch {
+	case s > ceiling:
+		return ceiling
+	case s < -ceiling:
+		return -ceiling
+	default:
+		return int(s)
+	}
+}
+
+// winChance gets the chance of winning as a percentage from a score, following the curve lichess fits to the
+// results of its games
+func winChance(s engine.Score) float64 {
+	return 50 + 50*(2/(1+math.Exp(-0.00368208*float64(centipawns(s))))-1)
+}
+
+// accuracy gets the accuracy of a move as a percentage from the chances of winning before and after it
+func accuracy(before, after float64) float64 {
+	a := 103.1668*math.Exp(-0.04354*(before-after)) - 3.1669
+
+	return math.Max(0, math.Min(100, a))
+}
+
+func summarise(moves []Move, col colour.Colour) Player {
+	var p Player
+	var n, loss int
+
+	for _, mv := range moves {
+		if mv.Colour != col {
+			continue
+		}
+
+		n++
+		loss += mv.Loss
+		p.Accuracy += mv.Accuracy
+
+		switch mv.Classification {
+		case Inaccuracy:
+			p.Inaccuracies++
+		case Mistake:
+			p.Mistakes++
+		case Blunder:
+			p.Blunders++
+		case MissedMate:
+			p.MissedMates++
+		}
+	}
+
+	if n > 0 {
+		p.Accuracy = math.Round(p.Accuracy/float64(n)*10) / 10
+		p.AverageLoss = loss / n
+	}
+
+	return p
+}
@@ -0,0 +1,134 @@
+package annotate_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/internal/annotate"
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+)
+
+// moves reads the moves of a game in Standard Algebraic Notation from the position provided
+func moves(t *testing.T, c chess.Chess, sans ...string) []move.Move {
+	t.Helper()
+
+	c = c.Copy()
+
+	var ms []move.Move
+	for _, san := range sans {
+		m, promotion, err := c.ParseSAN(san)
+		if err != nil {
+			t.Fatalf("failed to read move %s: %v", san, err)
+		}
+
+		if _, err := c.MakeMoveWithPromotion(m, promotion); err != nil {
+			t.Fatalf("failed to make move %s: %v", san, err)
+		}
+
+		ms = append(ms, m)
+	}
+
+	return ms
+}
+
+func TestAnnotate(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		moves []string
+		want  []annotate.Classification
+	}{
+		{
+			name:  "missed mate",
+			fen:   "7k/8/6K1/8/8/8/8/1Q6 w - - 0 1",
+			moves: []string{"Qc1"},
+			want:  []annotate.Classification{annotate.MissedMate},
+		},
+		{
+			name:  "mate",
+			fen:   "7k/8/6K1/8/8/8/8/1Q6 w - - 0 1",
+			moves: []string{"Qb8#"},
+			want:  []annotate.Classification{annotate.Best},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := chess.FromFEN(tt.fen)
+			if err != nil {
+				t.Fatalf("failed to read FEN: %v", err)
+			}
+
+			a, err := annotate.Annotate(context.Background(), c, moves(t, c, tt.moves...), annotate.WithDepth(2))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(a.Moves) != len(tt.moves) {
+				t.Fatalf("got %d moves, want %d", len(a.Moves), len(tt.moves))
+			}
+
+			for i, mv := range a.Moves {
+				if mv.SAN != tt.moves[i] {
+					t.Errorf("move %d: got %s, want %s", i+1, mv.SAN, tt.moves[i])
+				}
+
+				if mv.Classification != tt.want[i] {
+					t.Errorf("move %d %s: got %s, want %s", i+1, mv.SAN, mv.Classification, tt.want[i])
+				}
+
+				if mv.Classification == annotate.Best && (mv.Loss != 0 || mv.Accuracy != 100 || mv.Best != mv.SAN) {
+					t.Errorf("move %d %s: want the best move to lose nothing, got %+v", i+1, mv.SAN, mv)
+				}
+			}
+		})
+	}
+}
+
+func TestAnnotate_FoolsMate(t *testing.T) {
+	c := chess.New(colour.White)
+
+	a, err := annotate.Annotate(context.Background(), c, moves(t, c, "f3", "e5", "g4", "Qh4#"), annotate.WithDepth(2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	g4 := a.Moves[2]
+	if g4.After.MateIn() != -1 || g4.Best == "g4" || g4.Loss < annotate.BlunderLoss {
+		t.Errorf("want g4 to allow mate in one, got %+v", g4)
+	}
+
+	if a.White.Blunders != 1 || a.Black.Blunders != 0 {
+		t.Errorf("want white to have blundered once, got white %+v and black %+v", a.White, a.Black)
+	}
+
+	if a.White.Accuracy >= a.Black.Accuracy {
+		t.Errorf("want black to be more accurate, got white %.1f and black %.1f", a.White.Accuracy, a.Black.Accuracy)
+	}
+
+	pgn := a.PGN().String()
+	for _, want := range []string{"g4 $4 {(", "Blunder.", "Qh4# *"} {
+		if !strings.Contains(pgn, want) {
+			t.Errorf("want the PGN to contain %q, got:\n%s", want, pgn)
+		}
+	}
+
+	if strings.Contains(pgn, "[FEN") {
+		t.Errorf("want no FEN tag for a game from the starting position, got:\n%s", pgn)
+	}
+}
+
+func TestAnnotate_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c := chess.New(colour.White)
+
+	if _, err := annotate.Annotate(ctx, c, moves(t, c, "e4")); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+}
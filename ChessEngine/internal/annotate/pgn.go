@@ -0,0 +1,69 @@
+package annotate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/engine"
+	"github.com/tomwatson6/chessbot/internal/pgn"
+)
+
+// The Numeric Annotation Glyphs of the classifications that are marked in PGN
+var nags = map[Classification]int{
+	Inaccuracy: 6, // ?!
+	Mistake:    2, // ?
+	Blunder:    4, // ??
+	MissedMate: 2, // ?
+}
+
+// PGN gets the game with the evaluation after each move in a comment, along with the best move for those that were
+// not good enough. The tags of the game, apart from those of the position it started in, are left for the caller.
+func (a Analysis) PGN() pgn.Game {
+	var g pgn.Game
+
+	if a.FEN != chess.New(colour.White).FEN() {
+		g.SetTag("SetUp", "1")
+		g.SetTag("FEN", a.FEN)
+	}
+
+	for _, mv := range a.Moves {
+		m := pgn.Move{SAN: mv.SAN}
+
+		// There is nothing to evaluate once the game has been won by checkmate
+		if !mv.After.IsMate() || mv.After.MateIn() != 0 {
+			m.Comment = fmt.Sprintf("[%%eval %s]", pawns(mv.After))
+		}
+
+		if nag, ok := nags[mv.Classification]; ok {
+			m.NAGs = []int{nag}
+			m.Comment = fmt.Sprintf("(%s → %s) %s. %s was best. %s", pawns(mv.Before), pawns(mv.After),
+				title(mv.Classification), mv.Best, m.Comment)
+			m.Comment = strings.TrimSpace(m.Comment)
+		}
+
+		g.Moves = append(g.Moves, m)
+	}
+
+	return g
+}
+
+// pawns formats the score in pawns e.g. 0.35 or -1.20, or moves to mate e.g. #3
+func pawns(s engine.Score) string {
+	if s.IsMate() {
+		return s.String()
+	}
+
+	return fmt.Sprintf("%.2f", float64(s)/100)
+}
+
+func title(c Classification) string {
+	switch c {
+	case MissedMate:
+		return "Missed mate"
+	default:
+		s := c.String()
+		return string(s[0]-'a'+'A') + s[1:]
+	}
+}
@@ -0,0 +1,152 @@
+package board
+
+import (
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/piece"
+)
+
+var (
+	knightSteps   = [][2]int{{1, 2}, {2, 1}, {2, -1}, {1, -2}, {-1, -2}, {-2, -1}, {-2, 1}, {-1, 2}}
+	kingSteps     = [][2]int{{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1}}
+	straightSteps = [][2]int{{1, 0}, {0, 1}, {-1, 0}, {0, -1}}
+	diagonalSteps = [][2]int{{1, 1}, {-1, 1}, {-1, -1}, {1, -1}}
+)
+
+// IsAttacked reports whether any piece of the colour provided attacks the position, whether or not the piece
+// could legally move there
+func (b Board) IsAttacked(pos move.Position, by colour.Colour) bool {
+	is := func(f, r int, types ...piece.PieceType) bool {
+		p, ok := b.Pieces[move.Position{File: f, Rank: r}]
+		if !ok || p.Colour != by {
+			return false
+		}
+
+		for _, t := range types {
+			if p.GetPieceType() == t {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	// Pawns attack diagonally forwards, so an attacking pawn stands diagonally behind the position
+	dir := -1
+	if by == colour.Black {
+		dir = 1
+	}
+
+	if is(pos.File-1, pos.Rank+dir, piece.PieceTypePawn) || is(pos.File+1, pos.Rank+dir, piece.PieceTypePawn) {
+		return true
+	}
+
+	for _, s := range knightSteps {
+		if is(pos.File+s[0], pos.Rank+s[1], piece.PieceTypeKnight) {
+			return true
+		}
+	}
+
+	for _, s := range kingSteps {
+		if is(pos.File+s[0], pos.Rank+s[1], piece.PieceTypeKing) {
+			return true
+		}
+	}
+
+	slide := func(steps [][2]int, types ...piece.PieceType) bool {
+		for _, s := range steps {
+			f, r := pos.File+s[0], pos.Rank+s[1]
+
+			for f >= 0 && f < b.Width && r >= 0 && r < b.Height {
+				if _, ok := b.Pieces[move.Position{File: f, Rank: r}]; ok {
+					if is(f, r, types...) {
+						return true
+					}
+
+					break
+				}
+
+				f, r = f+s[0], r+s[1]
+			}
+		}
+
+		return false
+	}
+
+	return slide(straightSteps, piece.PieceTypeRook, piece.PieceTypeQueen) ||
+		slide(diagonalSteps, piece.PieceTypeBishop, piece.PieceTypeQueen)
+}
+
+// Attacks gets the squares the piece on the position attacks, whether or not it could legally move to them. Pieces
+// that slide attack up to and including the first piece in each direction, of either colour.
+func (b Board) Attacks(pos move.Position) []move.Position {
+	p, ok := b.Pieces[pos]
+	if !ok {
+		return nil
+	}
+
+	var squares []move.Position
+
+	inBounds := func(f, r int) bool {
+		return f >= 0 && f < b.Width && r >= 0 && r < b.Height
+	}
+
+	step := func(steps [][2]int) {
+		for _, s := range steps {
+			if f, r := pos.File+s[0], pos.Rank+s[1]; inBounds(f, r) {
+				squares = append(squares, move.Position{File: f, Rank: r})
+			}
+		}
+	}
+
+	slide := func(steps [][2]int) {
+		for _, s := range steps {
+			for f, r := pos.File+s[0], pos.Rank+s[1]; inBounds(f, r); f, r = f+s[0], r+s[1] {
+				sq := move.Position{File: f, Rank: r}
+				squares = append(squares, sq)
+
+				if _, ok := b.Pieces[sq]; ok {
+					break
+				}
+			}
+		}
+	}
+
+	switch p.GetPieceType() {
+	case piece.PieceTypePawn:
+		dir := 1
+		if p.Colour == colour.Black {
+			dir = -1
+		}
+
+		step([][2]int{{-1, dir}, {1, dir}})
+	case piece.PieceTypeKnight:
+		step(knightSteps)
+	case piece.PieceTypeKing:
+		step(kingSteps)
+	case piece.PieceTypeBishop:
+		slide(diagonalSteps)
+	case piece.PieceTypeRook:
+		slide(straightSteps)
+	case piece.PieceTypeQueen:
+		slide(straightSteps)
+		slide(diagonalSteps)
+	}
+
+	return squares
+}
+
+// InCheck reports whether the king of the colour provided is attacked, false for variants without a royal king
+// and for sides without a king
+func (b Board) InCheck(c colour.Colour) bool {
+	if !b.Variant.HasRoyalKing() {
+		return false
+	}
+
+	k, err := b.GetKing(c)
+	if err != nil {
+		return false
+	}
+
+	return b.IsAttacked(k.Position, c.Opposite())
+}
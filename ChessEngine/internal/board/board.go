@@ -10,6 +10,7 @@ import (
 	"github.com/tomwatson6/chessbot/internal/colour"
 	"github.com/tomwatson6/chessbot/internal/move"
 	"github.com/tomwatson6/chessbot/internal/piece"
+	"github.com/tomwatson6/chessbot/internal/variant"
 )
 
 var ErrorIsCheckMate = errors.New("king is in check mate, game over")
@@ -21,6 +22,33 @@ type Board struct {
 	Squares []move.Position                `json:"-"`
 	Pieces  map[move.Position]*piece.Piece `json:"pieces"`
 	History []Turn                         `json:"history"`
+	Variant variant.Variant                `json:"variant"`
+	Rules   rules.RuleSet                  `json:"-"`
+
+	startingPieces []*piece.Piece
+}
+
+type Option func(b *Board)
+
+// WithVariant sets the variant of chess that the board validates moves against
+func WithVariant(v variant.Variant) Option {
+	return func(b *Board) {
+		b.Variant = v
+	}
+}
+
+// WithRuleSet overrides the rules that moves on the board are validated against
+func WithRuleSet(rs rules.RuleSet) Option {
+	return func(b *Board) {
+		b.Rules = rs
+	}
+}
+
+// WithPieces starts the board with the pieces provided instead of the starting pieces of the variant
+func WithPieces(ps []*piece.Piece) Option {
+	return func(b *Board) {
+		b.startingPieces = ps
+	}
 }
 
 // TODO - Refactor the history in this once translation is tested, needs to return moves in notation as a list
@@ -54,12 +82,14 @@ func (b *Board) MarshalJSON() ([]byte, error) {
 		Pieces  map[string]string `json:"pieces"`
 		History []string          `json:"history"`
 		Power   map[string]string `json:"power"`
+		Variant string            `json:"variant"`
 	}{
 		Width:   b.Width,
 		Height:  b.Height,
 		Pieces:  pieces,
 		History: history,
 		Power:   power,
+		Variant: b.Variant.String(),
 	}
 
 	// Marshal the anonymous struct to JSON.
@@ -79,12 +109,20 @@ func (t Turn) String() string {
 }
 
 // New makes a new instance of a board with a default state
-func New(w, h int) Board {
+func New(w, h int, opts ...Option) Board {
 	var b Board
 
 	b.Width = w
 	b.Height = h
 
+	for _, opt := range opts {
+		opt(&b)
+	}
+
+	if b.Rules.IsEmpty() {
+		b.Rules = RuleSetForVariant(b.Variant)
+	}
+
 	for r := 0; r < b.Height; r++ {
 		for f := 0; f < b.Width; f++ {
 			b.Squares = append(b.Squares, move.Position{File: f, Rank: r})
@@ -93,35 +131,49 @@ func New(w, h int) Board {
 
 	b.Pieces = make(map[move.Position]*piece.Piece)
 
-	for _, p := range config.GetStandardPieces() {
+	if b.startingPieces == nil {
+		b.startingPieces = config.GetStartingPieces(b.Variant)
+	}
+
+	for _, p := range b.startingPieces {
 		b.Pieces[p.Position] = p
 	}
 
+	b.startingPieces = nil
+
 	b.History = append(b.History, make(Turn))
 
 	return b
 }
 
+// IsValidMove checks the move against the rule set of the board, returning a rules.RuleError naming the rule that rejected it
 func (b Board) IsValidMove(m move.Move) error {
-	// Firstly check the rules that will always need to be checked
-	rs := rules.Assert(
-		rules.InBoundsOfBoard(b.Width, b.Height, m),
-		rules.IsPieceInStartPosition(b.Pieces, m.From),
-		rules.IsNotPinned(b.Width, b.Height, b.Pieces, m),
-		rules.IsNotFriendlyCapture(b.Pieces, m),
-	)
+	return b.ruleSet().Assert(b.ruleContext(m))
+}
 
-	if err := rs(); err != nil {
-		return err
-	}
+// Copy makes a deep copy of the board, so that moves can be made on the copy without changing the original
+func (b Board) Copy() Board {
+	output := b
 
-	p := b.Pieces[m.From]
+	output.Squares = make([]move.Position, len(b.Squares))
+	copy(output.Squares, b.Squares)
 
-	if err := b.ValidatePieceMove(*p, m); err != nil {
-		return err
+	output.Pieces = make(map[move.Position]*piece.Piece, len(b.Pieces))
+	for pos, p := range b.Pieces {
+		p2 := *p
+		output.Pieces[pos] = &p2
 	}
 
-	return nil
+	output.History = make([]Turn, len(b.History))
+	for i, t := range b.History {
+		output.History[i] = make(Turn, len(t))
+		for c, m := range t {
+			m2 := *m
+			output.History[i][c] = &m2
+		}
+	}
+
+	return output
 }
 
 // TODO: look at logic for this - en passant is currently being considered for any piece moving without taking
@@ -148,12 +200,13 @@ func (b *Board) Move(m move.Move) ([]move.Move, error) {
 
 		if _, ok := b.Pieces[m.To]; ok {
 			b.Pieces[m.To] = p
-			delete(b.Pieces, m.From)
+			toDelete = m.From
 		} else {
 			// En passant
 			dx := m.To.File - m.From.File
 
 			b.Pieces[m.To] = p
+			delete(b.Pieces, m.From)
 			toDelete = move.Position{File: m.From.File + dx, Rank: m.From.Rank}
 		}
 	} else if p.GetPieceType() == piece.PieceTypeKing {
@@ -211,6 +264,10 @@ func (b *Board) Promote(m move.Move, pd piece.PieceDetails) error {
 			return fmt.Errorf("the piece being promoted is not moving to the 1st rank")
 		}
 
+		if pd.GetPieceType() == piece.PieceTypeKing && !b.Variant.CanPromoteToKing() {
+			return fmt.Errorf("a pawn cannot be promoted to a king in %s chess", b.Variant)
+		}
+
 		b.Pieces[m.To] = &piece.Piece{
 			Colour:       p.Colour,
 			Position:     m.To,
@@ -226,6 +283,8 @@ func (b *Board) Promote(m move.Move, pd piece.PieceDetails) error {
 func (b Board) GetValidMoves() []move.Move {
 	var moves []move.Move
 
+	isValidMove := b.moveValidator()
+
 	for _, s := range b.Squares {
 		for _, p := range b.Pieces {
 			m := move.Move{
@@ -233,7 +292,29 @@ func (b Board) GetValidMoves() []move.Move {
 				To:   s,
 			}
 
-			if err := b.IsValidMove(m); err == nil {
+			if err := isValidMove(m); err == nil {
+				moves = append(moves, m)
+			}
+		}
+	}
+
+	return moves
+}
+
+// GetValidMovesForColour gets all the valid moves that can be made by the pieces of the colour provided
+func (b Board) GetValidMovesForColour(c colour.Colour) []move.Move {
+	var moves []move.Move
+
+	isValidMove := b.moveValidator()
+
+	for _, p := range b.getRemainingPieces(c) {
+		for _, s := range b.Squares {
+			m := move.Move{
+				From: p.Position,
+				To:   s,
+			}
+
+			if err := isValidMove(m); err == nil {
 				moves = append(moves, m)
 			}
 		}
@@ -285,7 +366,16 @@ func (b Board) GetAttackingPiecesForColour(dest move.Position, col colour.Colour
 }
 
 func (b Board) IsCheck(c colour.Colour) (*piece.Piece, bool, error) {
-	k, err := b.getKing(c)
+	if !b.Variant.HasRoyalKing() {
+		return nil, false, nil
+	}
+
+	k, err := b.GetKing(c)
+	if errors.Is(err, rules.ErrorKingNotFound) {
+		// A side without a king can never be in check
+		return nil, false, nil
+	}
+
 	if err != nil {
 		return nil, false, err
 	}
@@ -308,7 +398,7 @@ func (b Board) IsCheck(c colour.Colour) (*piece.Piece, bool, error) {
 
 func (b Board) IsCheckMate(c colour.Colour) (bool, error) {
 	if p, check, err := b.IsCheck(c); check && err == nil {
-		k, err := b.getKing(c)
+		k, err := b.GetKing(c)
 		if err != nil {
 			return false, err
 		}
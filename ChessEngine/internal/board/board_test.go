@@ -880,3 +880,95 @@ func TestPawnPromotion(t *testing.T) {
 		})
 	}
 }
+
+func TestEnPassant(t *testing.T) {
+	pos := func(file, rank int) move.Position {
+		return move.Position{File: file, Rank: rank}
+	}
+
+	tcs := []struct {
+		name     string
+		moves    []move.Move
+		capture  move.Move
+		captured move.Position
+	}{
+		{
+			// Visualisation of the board before the capture
+			// 8 bR bN bB bQ bK bB bN bR
+			// 7 ## bP bP ## bP bP bP bP
+			// 6 bP ## ## ## ## ## ## ##
+			// 5 ## ## ## bP wP ## ## ##
+			// 4 ## ## ## ## ## ## ## ##
+			// 3 ## ## ## ## ## ## ## ##
+			// 2 wP wP wP wP ## wP wP wP
+			// 1 wR wN wB wQ wK wB wN wR
+			//    A  B  C  D  E  F  G  H
+
+			name: "WhiteToMove",
+			moves: []move.Move{
+				{From: pos(4, 1), To: pos(4, 3)},
+				{From: pos(0, 6), To: pos(0, 5)},
+				{From: pos(4, 3), To: pos(4, 4)},
+				{From: pos(3, 6), To: pos(3, 4)},
+			},
+			capture:  move.Move{From: pos(4, 4), To: pos(3, 5)},
+			captured: pos(3, 4),
+		},
+		{
+			// Visualisation of the board before the capture
+			// 8 bR bN bB bQ bK bB bN bR
+			// 7 bP bP bP ## bP bP bP bP
+			// 6 ## ## ## ## ## ## ## ##
+			// 5 ## ## ## ## ## ## ## ##
+			// 4 wP ## ## bP wP ## ## ##
+			// 3 ## ## ## ## ## ## ## ##
+			// 2 ## wP wP wP ## wP wP wP
+			// 1 wR wN wB wQ wK wB wN wR
+			//    A  B  C  D  E  F  G  H
+
+			name: "BlackToMove",
+			moves: []move.Move{
+				{From: pos(0, 1), To: pos(0, 2)},
+				{From: pos(3, 6), To: pos(3, 4)},
+				{From: pos(0, 2), To: pos(0, 3)},
+				{From: pos(3, 4), To: pos(3, 3)},
+				{From: pos(4, 1), To: pos(4, 3)},
+			},
+			capture:  move.Move{From: pos(3, 3), To: pos(4, 2)},
+			captured: pos(4, 3),
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := payloads.NewStandardBoard()
+
+			for _, m := range tc.moves {
+				if _, err := b.Move(m); err != nil {
+					t.Fatalf("Move(%v) => %v", m, err)
+				}
+			}
+
+			capturing := b.Pieces[tc.capture.From]
+
+			if _, err := b.Move(tc.capture); err != nil {
+				t.Fatalf("Move(%v) => %v, want en passant to be allowed", tc.capture, err)
+			}
+
+			if p, ok := b.Pieces[tc.capture.To]; !ok || p.Colour != capturing.Colour {
+				t.Errorf("the capturing pawn is not on %v", tc.capture.To)
+			}
+
+			if _, ok := b.Pieces[tc.capture.From]; ok {
+				t.Errorf("a piece was left on %v", tc.capture.From)
+			}
+
+			if _, ok := b.Pieces[tc.captured]; ok {
+				t.Errorf("the captured pawn was left on %v", tc.captured)
+			}
+		})
+	}
+}
@@ -14,13 +14,15 @@ func (b Board) Power(file, rank int) []move.Position {
 		return []move.Position{}
 	}
 
+	isValidMove := b.moveValidator()
+
 	for _, s := range b.Squares {
 		m := move.Move{
 			From: start,
 			To:   s,
 		}
 
-		if err := b.IsValidMove(m); err == nil {
+		if err := isValidMove(m); err == nil {
 			power = append(power, s)
 		}
 	}
@@ -53,3 +53,68 @@ func TestPower(t *testing.T) {
 		})
 	}
 }
+
+func TestAttacks(t *testing.T) {
+	b := payloads.NewEmptyBoard(
+		payloads.BoardWithPiece(
+			&piece.Piece{
+				Colour:       colour.White,
+				Position:     move.Position{File: 0, Rank: 0},
+				PieceDetails: piece.NewRook(),
+			},
+		),
+		payloads.BoardWithPiece(
+			&piece.Piece{
+				Colour:       colour.White,
+				Position:     move.Position{File: 0, Rank: 3},
+				PieceDetails: piece.NewPawn(piece.PawnWithColour(colour.White)),
+			},
+		),
+		payloads.BoardWithPiece(
+			&piece.Piece{
+				Colour:       colour.Black,
+				Position:     move.Position{File: 3, Rank: 0},
+				PieceDetails: piece.NewKnight(),
+			},
+		),
+	)
+
+	tcs := []struct {
+		name     string
+		pos      move.Position
+		expected int
+	}{
+		{
+			// Up the file to the pawn and along the rank to the knight, including the squares of both
+			name:     "Rook Attacks Up To The First Piece Each Way",
+			pos:      move.Position{File: 0, Rank: 0},
+			expected: 6,
+		},
+		{
+			name:     "Pawn Attacks Diagonally Forwards Only",
+			pos:      move.Position{File: 0, Rank: 3},
+			expected: 1,
+		},
+		{
+			name:     "Knight Attacks Squares On The Board",
+			pos:      move.Position{File: 3, Rank: 0},
+			expected: 4,
+		},
+		{
+			name:     "Empty Square Attacks Nothing",
+			pos:      move.Position{File: 4, Rank: 4},
+			expected: 0,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if a := b.Attacks(tc.pos); len(a) != tc.expected {
+				t.Errorf("length of attacks does not match expected, expected: %d, got: %d\n", tc.expected, len(a))
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package rules
 
 import (
+	"errors"
 	"math"
 	"reflect"
 
@@ -43,7 +44,7 @@ func IsLineClear(ps map[move.Position]*piece.Piece, m move.Move) func() error {
 				continue
 			}
 			if _, ok := ps[pos]; ok {
-				return ErrorLineIsNotClear
+				return &PositionError{Err: ErrorLineIsNotClear, Positions: []move.Position{pos}}
 			}
 		}
 
@@ -69,6 +70,11 @@ func IsNotPinned(w, h int, ps map[move.Position]*piece.Piece, m move.Move) func(
 	return func() error {
 		p := ps[m.From]
 		k, err := getKing(ps, p.Colour)
+		if errors.Is(err, ErrorKingNotFound) {
+			// A piece cannot be pinned to a king that does not exist
+			return nil
+		}
+
 		if err != nil {
 			return err
 		}
@@ -113,7 +119,7 @@ func IsNotPinned(w, h int, ps map[move.Position]*piece.Piece, m move.Move) func(
 							}
 						}
 
-						return ErrorIsPinned
+						return &PositionError{Err: ErrorIsPinned, Positions: []move.Position{p2.Position, k.Position}}
 					}
 
 					return nil
@@ -157,6 +163,10 @@ func IsValidIfPawnCapture(ps map[move.Position]*piece.Piece, whiteMove, blackMov
 				lastMove = whiteMove
 			}
 
+			if lastMove == nil {
+				return ErrorIsNotValidDiagonalPawnCapture
+			}
+
 			dy := lastMove.To.Rank - lastMove.From.Rank
 
 			// If the last move was the attacked pawns move and that the move was a 2 space move
@@ -196,6 +206,10 @@ func IsNotInCheck(ps map[move.Position]*piece.Piece, movingPiece *piece.Piece, m
 		col := movingPiece.Colour
 
 		k, err := getKing(ps, col)
+		if errors.Is(err, ErrorKingNotFound) {
+			return nil
+		}
+
 		if err != nil {
 			return err
 		}
@@ -212,7 +226,7 @@ func IsNotInCheck(ps map[move.Position]*piece.Piece, movingPiece *piece.Piece, m
 			}
 
 			if err := p2.IsValidMove(move.Move{From: p2.Position, To: attackedPosition}); err == nil {
-				return ErrorIsInCheck
+				return &PositionError{Err: ErrorIsInCheck, Positions: []move.Position{p2.Position, attackedPosition}}
 			}
 		}
 
@@ -269,8 +283,8 @@ func IsValidIfCastlingMove(width, height int, ps map[move.Position]*piece.Piece,
 		}
 
 		for _, pos := range line[:len(line)-1] {
-			if isThreatened(ps, k, pos) {
-				return ErrorInvalidCastlingMove
+			if attacker, ok := threatenedBy(ps, k, pos); ok {
+				return &PositionError{Err: ErrorIsCastlingThroughCheck, Positions: []move.Position{attacker.Position, pos}}
 			}
 
 			// Only need to check if king is castling through check, not the rook
@@ -287,33 +301,131 @@ func IsNotMovingIntoDanger(ps map[move.Position]*piece.Piece, m move.Move) func(
 	return func() error {
 		p := ps[m.From]
 
-		if isThreatened(ps, p, m.To) {
-			return ErrorIsMovingIntoDanger
+		if attacker, ok := threatenedBy(ps, p, m.To); ok {
+			return &PositionError{Err: ErrorIsMovingIntoDanger, Positions: []move.Position{attacker.Position, m.To}}
+		}
+
+		return nil
+	}
+}
+
+// IsNotLeavingKingInCheck checks that the king of the colour moving is not under threat once the move is made, taking
+// the pawn captured en passant off the board too
+func IsNotLeavingKingInCheck(ps map[move.Position]*piece.Piece, m move.Move) func() error {
+	return func() error {
+		p := ps[m.From]
+
+		after := make(map[move.Position]*piece.Piece, len(ps))
+		for pos, pi := range ps {
+			if pos != m.From {
+				after[pos] = pi
+			}
+		}
+
+		if _, ok := ps[m.To]; !ok && p.GetPieceType() == piece.PieceTypePawn && m.To.File != m.From.File {
+			delete(after, move.Position{File: m.To.File, Rank: m.From.Rank})
+		}
+
+		moved := *p
+		moved.Position = m.To
+		after[m.To] = &moved
+
+		k, err := getKing(after, p.Colour)
+		if errors.Is(err, ErrorKingNotFound) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if attacker, ok := threatenedBy(after, k, k.Position); ok {
+			return &PositionError{Err: ErrorResultsInCheck, Positions: []move.Position{attacker.Position, k.Position}}
+		}
+
+		return nil
+	}
+}
+
+func IsCaptureIfAvailable(ps map[move.Position]*piece.Piece, m move.Move, captureAvailable func() bool) func() error {
+	return func() error {
+		if IsCapture(ps, m) {
+			return nil
+		}
+
+		if captureAvailable() {
+			return ErrorMustCapture
 		}
 
 		return nil
 	}
 }
 
-func isThreatened(ps map[move.Position]*piece.Piece, p *piece.Piece, pos move.Position) bool {
+// IsCapture checks if the move specified takes a piece, including pawns capturing en passant
+func IsCapture(ps map[move.Position]*piece.Piece, m move.Move) bool {
+	p, ok := ps[m.From]
+	if !ok {
+		return false
+	}
+
+	if p2, ok := ps[m.To]; ok {
+		return p.Colour != p2.Colour
+	}
+
+	// A pawn can only move diagonally when it is capturing
+	return p.GetPieceType() == piece.PieceTypePawn && m.To.File != m.From.File
+}
+
+// threatenedBy gets a piece of the opposite colour to the piece provided that threatens the position provided. The
+// piece provided is the one moving there, so it does not shield the position from pieces behind it.
+func threatenedBy(ps map[move.Position]*piece.Piece, p *piece.Piece, pos move.Position) (*piece.Piece, bool) {
 	for _, pi := range ps {
 		if pi.Colour == p.Colour {
 			continue
 		}
 
-		attack := move.Move{From: pi.Position, To: pos}
-		if err := p.IsValidMove(attack); err == nil {
-			if p.GetPieceType() == piece.PieceTypeKnight {
-				return true
+		// Pawns only threaten the squares diagonally in front of them, which are not the squares they move to
+		if pi.GetPieceType() == piece.PieceTypePawn {
+			dir := 1
+			if pi.Colour == colour.Black {
+				dir = -1
 			}
 
-			if err := IsLineClear(ps, attack)(); err == nil {
-				return true
+			if pos.Rank-pi.Position.Rank == dir && math.Abs(float64(pos.File-pi.Position.File)) == 1 {
+				return pi, true
 			}
+
+			continue
+		}
+
+		attack := move.Move{From: pi.Position, To: pos}
+		if err := pi.IsValidMove(attack); err != nil {
+			continue
+		}
+
+		if pi.GetPieceType() == piece.PieceTypeKnight {
+			return pi, true
+		}
+
+		line, err := getLine(attack)
+		if err != nil {
+			continue
+		}
+
+		clear := true
+		for _, l := range line {
+			if _, ok := ps[l]; ok && l != pi.Position && l != p.Position {
+				clear = false
+				break
+			}
+		}
+
+		if clear {
+			return pi, true
 		}
 	}
 
-	return false
+	return nil, false
 }
 
 func getKing(ps map[move.Position]*piece.Piece, c colour.Colour) (*piece.Piece, error) {
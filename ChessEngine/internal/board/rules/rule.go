@@ -1,6 +1,10 @@
 package rules
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/tomwatson6/chessbot/internal/move"
+)
 
 var (
 	// ErrorIsOutOfBoundsOfBoard is thrown when the move is out of bounds
@@ -27,10 +31,29 @@ var (
 	ErrorResultsInCheck = errors.New("the move specified results in having the friendly king in check")
 	// ErrorInvalidCastlingMove is thrown when the move specified is not a valid castling move
 	ErrorInvalidCastlingMove = errors.New("the move specified is not a valid castling move")
+	// ErrorIsCastlingThroughCheck is thrown when the king would pass through or land on a square that is under threat while castling
+	ErrorIsCastlingThroughCheck = errors.New("the move specified is a castling move where the king passes through a square that is under threat")
 	// ErrorIsMovingIntoDanger is thrown when a king with the move specified moves it into a position of danger, which is illegal in chess
 	ErrorIsMovingIntoDanger = errors.New("the move specified is a move that moves the king into a square where it is under threat, and so it is moving into check")
+	// ErrorMustCapture is thrown when the move specified is not a capture, but the variant being played requires a capture to be made
+	ErrorMustCapture = errors.New("the move specified is not a capture, but a capture is available and must be made")
 )
 
+// PositionError attaches the positions on the board involved in a rule failing to the error returned,
+// such as the pinning piece and the king for ErrorIsPinned
+type PositionError struct {
+	Err       error
+	Positions []move.Position
+}
+
+func (e *PositionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PositionError) Unwrap() error {
+	return e.Err
+}
+
 type Assertion func() error
 
 // Assert chains all assertions together into one assertion
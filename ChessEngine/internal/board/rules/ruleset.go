@@ -0,0 +1,295 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/piece"
+)
+
+const (
+	RuleInBounds             = "in-bounds"
+	RulePieceInStartPosition = "piece-in-start-position"
+	RuleNotPinned            = "not-pinned"
+	RuleNotFriendlyCapture   = "not-friendly-capture"
+	RulePieceMovement        = "piece-movement"
+	RuleLineClear            = "line-clear"
+	RulePawnPush             = "pawn-push"
+	RulePawnCapture          = "pawn-capture"
+	RuleCastling             = "castling"
+	RuleNoCastling           = "no-castling"
+	RuleKingSafety           = "king-safety"
+	RuleMustCapture          = "must-capture"
+)
+
+// Context holds the state of the board that a rule is asserted against
+type Context struct {
+	Width, Height int
+	Pieces        map[move.Position]*piece.Piece
+	// WhiteMove and BlackMove are the last moves made by each colour, nil if they have not moved this turn
+	WhiteMove, BlackMove *move.Move
+	Move                 move.Move
+	// CaptureAvailable reports whether the colour provided is able to make a capture
+	CaptureAvailable func(c colour.Colour) bool
+}
+
+// Piece gets the piece that is being moved
+func (ctx Context) Piece() *piece.Piece {
+	return ctx.Pieces[ctx.Move.From]
+}
+
+// Rule is a named assertion that a move must pass to be valid
+type Rule struct {
+	Name   string
+	Assert func(ctx Context) error
+}
+
+// RuleError is returned when a rule in a RuleSet rejects a move
+type RuleError struct {
+	Rule string
+	Err  error
+}
+
+func (e *RuleError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Rule, e.Err)
+}
+
+func (e *RuleError) Unwrap() error {
+	return e.Err
+}
+
+// RejectedBy gets the name of the rule that rejected a move from the error returned by a RuleSet
+func RejectedBy(err error) (string, bool) {
+	var re *RuleError
+	if errors.As(err, &re) {
+		return re.Rule, true
+	}
+
+	return "", false
+}
+
+// RuleSet is an ordered collection of rules that all must pass for a move to be valid
+type RuleSet struct {
+	rules []Rule
+}
+
+func NewRuleSet(rs ...Rule) RuleSet {
+	return RuleSet{}.With(rs...)
+}
+
+// With returns a copy of the rule set with the rules provided added, replacing any rules with the same name
+func (s RuleSet) With(rs ...Rule) RuleSet {
+	output := RuleSet{rules: make([]Rule, len(s.rules), len(s.rules)+len(rs))}
+	copy(output.rules, s.rules)
+
+Outer:
+	for _, r := range rs {
+		for i, existing := range output.rules {
+			if existing.Name == r.Name {
+				output.rules[i] = r
+				continue Outer
+			}
+		}
+
+		output.rules = append(output.rules, r)
+	}
+
+	return output
+}
+
+// Without returns a copy of the rule set with the rules of the names provided disabled
+func (s RuleSet) Without(names ...string) RuleSet {
+	output := RuleSet{}
+
+Outer:
+	for _, r := range s.rules {
+		for _, name := range names {
+			if r.Name == name {
+				continue Outer
+			}
+		}
+
+		output.rules = append(output.rules, r)
+	}
+
+	return output
+}
+
+func (s RuleSet) Has(name string) bool {
+	for _, r := range s.rules {
+		if r.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s RuleSet) Names() []string {
+	names := make([]string, len(s.rules))
+
+	for i, r := range s.rules {
+		names[i] = r.Name
+	}
+
+	return names
+}
+
+func (s RuleSet) IsEmpty() bool {
+	return len(s.rules) == 0
+}
+
+// Assert checks each rule in order, returning a RuleError for the first rule that rejects the move
+func (s RuleSet) Assert(ctx Context) error {
+	for _, r := range s.rules {
+		if err := r.Assert(ctx); err != nil {
+			return &RuleError{Rule: r.Name, Err: err}
+		}
+	}
+
+	return nil
+}
+
+func InBoundsRule() Rule {
+	return Rule{
+		Name: RuleInBounds,
+		Assert: func(ctx Context) error {
+			return InBoundsOfBoard(ctx.Width, ctx.Height, ctx.Move)()
+		},
+	}
+}
+
+func PieceInStartPositionRule() Rule {
+	return Rule{
+		Name: RulePieceInStartPosition,
+		Assert: func(ctx Context) error {
+			return IsPieceInStartPosition(ctx.Pieces, ctx.Move.From)()
+		},
+	}
+}
+
+func NotPinnedRule() Rule {
+	return Rule{
+		Name: RuleNotPinned,
+		Assert: func(ctx Context) error {
+			return IsNotPinned(ctx.Width, ctx.Height, ctx.Pieces, ctx.Move)()
+		},
+	}
+}
+
+func NotFriendlyCaptureRule() Rule {
+	return Rule{
+		Name: RuleNotFriendlyCapture,
+		Assert: func(ctx Context) error {
+			return IsNotFriendlyCapture(ctx.Pieces, ctx.Move)()
+		},
+	}
+}
+
+// LineClearRule asserts there are no pieces between the start and end of the move, knights are able to jump and castling moves check their own line
+func LineClearRule() Rule {
+	return Rule{
+		Name: RuleLineClear,
+		Assert: func(ctx Context) error {
+			p := ctx.Piece()
+
+			if p.GetPieceType() == piece.PieceTypeKnight || isCastlingMove(p, ctx.Move) {
+				return nil
+			}
+
+			return IsLineClear(ctx.Pieces, ctx.Move)()
+		},
+	}
+}
+
+// PawnPushRule asserts a pawn moving forward does not move onto another piece
+func PawnPushRule() Rule {
+	return Rule{
+		Name: RulePawnPush,
+		Assert: func(ctx Context) error {
+			if ctx.Piece().GetPieceType() != piece.PieceTypePawn || ctx.Move.To.File != ctx.Move.From.File {
+				return nil
+			}
+
+			return IsNotPieceInEndPosition(ctx.Pieces, ctx.Move.To)()
+		},
+	}
+}
+
+// PawnCaptureRule asserts a pawn moving diagonally captures a piece, either directly or en passant
+func PawnCaptureRule() Rule {
+	return Rule{
+		Name: RulePawnCapture,
+		Assert: func(ctx Context) error {
+			if ctx.Piece().GetPieceType() != piece.PieceTypePawn {
+				return nil
+			}
+
+			m := ctx.Move
+
+			return IsValidIfPawnCapture(ctx.Pieces, ctx.WhiteMove, ctx.BlackMove, &m)()
+		},
+	}
+}
+
+func CastlingRule() Rule {
+	return Rule{
+		Name: RuleCastling,
+		Assert: func(ctx Context) error {
+			if !isCastlingMove(ctx.Piece(), ctx.Move) {
+				return nil
+			}
+
+			return IsValidIfCastlingMove(ctx.Width, ctx.Height, ctx.Pieces, ctx.Move)()
+		},
+	}
+}
+
+// NoCastlingRule rejects all castling moves, for variants where castling is not allowed
+func NoCastlingRule() Rule {
+	return Rule{
+		Name: RuleNoCastling,
+		Assert: func(ctx Context) error {
+			if isCastlingMove(ctx.Piece(), ctx.Move) {
+				return ErrorInvalidCastlingMove
+			}
+
+			return nil
+		},
+	}
+}
+
+// KingSafetyRule asserts the king does not move into a square under threat, and that moves of other pieces do not
+// leave it in check, whether it is in check already or the move uncovers an attack on it
+func KingSafetyRule() Rule {
+	return Rule{
+		Name: RuleKingSafety,
+		Assert: func(ctx Context) error {
+			if ctx.Piece().GetPieceType() != piece.PieceTypeKing {
+				return IsNotLeavingKingInCheck(ctx.Pieces, ctx.Move)()
+			}
+
+			return IsNotMovingIntoDanger(ctx.Pieces, ctx.Move)()
+		},
+	}
+}
+
+// MustCaptureRule asserts a capture is made whenever one is available
+func MustCaptureRule() Rule {
+	return Rule{
+		Name: RuleMustCapture,
+		Assert: func(ctx Context) error {
+			c := ctx.Piece().Colour
+
+			return IsCaptureIfAvailable(ctx.Pieces, ctx.Move, func() bool {
+				return ctx.CaptureAvailable != nil && ctx.CaptureAvailable(c)
+			})()
+		},
+	}
+}
+
+func isCastlingMove(p *piece.Piece, m move.Move) bool {
+	return p.GetPieceType() == piece.PieceTypeKing && m.Distance() == 2
+}
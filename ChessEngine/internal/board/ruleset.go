@@ -0,0 +1,108 @@
+package board
+
+import (
+	"github.com/tomwatson6/chessbot/internal/board/rules"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/variant"
+)
+
+// PieceMovementRule asserts the move is one the piece is able to make, using the rules in internal/piece/rules
+func PieceMovementRule() rules.Rule {
+	return rules.Rule{
+		Name: rules.RulePieceMovement,
+		Assert: func(ctx rules.Context) error {
+			return ValidatePieceMove(*ctx.Piece(), ctx.Move)
+		},
+	}
+}
+
+// StandardRuleSet gets the rules that a move must pass in standard chess
+func StandardRuleSet() rules.RuleSet {
+	return rules.NewRuleSet(
+		rules.InBoundsRule(),
+		rules.PieceInStartPositionRule(),
+		rules.NotPinnedRule(),
+		rules.NotFriendlyCaptureRule(),
+		PieceMovementRule(),
+		rules.LineClearRule(),
+		rules.PawnPushRule(),
+		rules.PawnCaptureRule(),
+		rules.CastlingRule(),
+		rules.KingSafetyRule(),
+	)
+}
+
+// AntichessRuleSet gets the rules for antichess, where the king is an ordinary piece and captures are compulsory
+func AntichessRuleSet() rules.RuleSet {
+	return StandardRuleSet().
+		Without(rules.RuleNotPinned, rules.RuleCastling, rules.RuleKingSafety).
+		With(rules.NoCastlingRule(), rules.MustCaptureRule())
+}
+
+// RuleSetForVariant gets the rules that a move must pass for the variant specified
+func RuleSetForVariant(v variant.Variant) rules.RuleSet {
+	switch v {
+	case variant.Antichess:
+		return AntichessRuleSet()
+	default:
+		return StandardRuleSet()
+	}
+}
+
+// ruleSet gets the rules of the board, falling back to the rules of its variant when none have been set
+func (b Board) ruleSet() rules.RuleSet {
+	if b.Rules.IsEmpty() {
+		return RuleSetForVariant(b.Variant)
+	}
+
+	return b.Rules
+}
+
+func (b Board) ruleContext(m move.Move) rules.Context {
+	ctx := rules.Context{
+		Width:            b.Width,
+		Height:           b.Height,
+		Pieces:           b.Pieces,
+		Move:             m,
+		CaptureAvailable: b.hasCaptureAvailable,
+	}
+
+	if len(b.History) > 0 {
+		lastMove := b.History[len(b.History)-1]
+		ctx.WhiteMove = lastMove[colour.White]
+		ctx.BlackMove = lastMove[colour.Black]
+
+		// When white is to move the last turn is empty, so black's last move is in the turn before it
+		if ctx.WhiteMove == nil && ctx.BlackMove == nil && len(b.History) > 1 {
+			ctx.BlackMove = b.History[len(b.History)-2][colour.Black]
+		}
+	}
+
+	return ctx
+}
+
+// moveValidator gets a check of moves made in the current position of the board. Whether each colour has a capture
+// available is worked out at most once and shared between the moves checked, so checking every move of a position
+// does not scan the board again for each of them
+func (b Board) moveValidator() func(m move.Move) error {
+	rs := b.ruleSet()
+	available := make(map[colour.Colour]bool)
+
+	captureAvailable := func(c colour.Colour) bool {
+		has, ok := available[c]
+		if !ok {
+			has = b.hasCaptureAvailable(c)
+			available[c] = has
+		}
+
+		return has
+	}
+
+	return func(m move.Move) error {
+		ctx := b.ruleContext(m)
+		ctx.CaptureAvailable = captureAvailable
+
+		return rs.Assert(ctx)
+	}
+}
@@ -0,0 +1,137 @@
+package board_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/internal/board"
+	"github.com/tomwatson6/chessbot/internal/board/rules"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/piece"
+	"github.com/tomwatson6/chessbot/testing/payloads"
+)
+
+func TestRuleSetReportsRejectingRule(t *testing.T) {
+	t.Parallel()
+
+	b := payloads.NewStandardBoard()
+
+	tcs := []struct {
+		name string
+		m    move.Move
+		want string
+	}{
+		{
+			name: "OutOfBounds",
+			m:    move.Move{From: move.Position{File: 0, Rank: 0}, To: move.Position{File: -1, Rank: 0}},
+			want: rules.RuleInBounds,
+		},
+		{
+			name: "EmptySquare",
+			m:    move.Move{From: move.Position{File: 3, Rank: 3}, To: move.Position{File: 3, Rank: 4}},
+			want: rules.RulePieceInStartPosition,
+		},
+		{
+			name: "FriendlyCapture",
+			m:    move.Move{From: move.Position{File: 0, Rank: 0}, To: move.Position{File: 0, Rank: 1}},
+			want: rules.RuleNotFriendlyCapture,
+		},
+		{
+			name: "KnightMovingInALine",
+			m:    move.Move{From: move.Position{File: 1, Rank: 0}, To: move.Position{File: 1, Rank: 2}},
+			want: rules.RulePieceMovement,
+		},
+		{
+			name: "RookThroughPawn",
+			m:    move.Move{From: move.Position{File: 0, Rank: 0}, To: move.Position{File: 0, Rank: 4}},
+			want: rules.RuleLineClear,
+		},
+		{
+			name: "PawnDiagonalWithoutCapture",
+			m:    move.Move{From: move.Position{File: 0, Rank: 1}, To: move.Position{File: 1, Rank: 2}},
+			want: rules.RulePawnCapture,
+		},
+	}
+
+	for _, tc := range tcs {
+		err := b.IsValidMove(tc.m)
+
+		got, ok := rules.RejectedBy(err)
+		if !ok {
+			t.Errorf("%s: IsValidMove(%v) => %v, want rejection by rule %s", tc.name, tc.m, err, tc.want)
+			continue
+		}
+
+		if got != tc.want {
+			t.Errorf("%s: IsValidMove(%v) rejected by %s, want %s", tc.name, tc.m, got, tc.want)
+		}
+	}
+}
+
+func TestRuleSetCustomRules(t *testing.T) {
+	t.Parallel()
+
+	errNoQueenMoves := errors.New("queens are not allowed to move")
+
+	noQueenMoves := rules.Rule{
+		Name: "no-queen-moves",
+		Assert: func(ctx rules.Context) error {
+			if ctx.Piece().GetPieceType() == piece.PieceTypeQueen {
+				return errNoQueenMoves
+			}
+
+			return nil
+		},
+	}
+
+	rs := board.StandardRuleSet().
+		Without(rules.RuleNotPinned, rules.RuleKingSafety).
+		With(noQueenMoves)
+
+	b := board.New(8, 8, board.WithRuleSet(rs))
+	delete(b.Pieces, move.Position{File: 3, Rank: 1})
+	delete(b.Pieces, move.Position{File: 4, Rank: 1})
+	b.Pieces[move.Position{File: 4, Rank: 2}] = &piece.Piece{
+		Colour:       colour.Black,
+		Position:     move.Position{File: 4, Rank: 2},
+		PieceDetails: piece.NewRook(),
+	}
+
+	// Visualisation of the board
+	// 8 bR bN bB bQ bK bB bN bR
+	// 7 bP bP bP bP bP bP bP bP
+	// 6 ## ## ## ## ## ## ## ##
+	// 5 ## ## ## ## ## ## ## ##
+	// 4 ## ## ## ## ## ## ## ##
+	// 3 ## ## ## ## bR ## ## ##
+	// 2 wP wP wP ## ## wP wP wP
+	// 1 wR wN wB wQ wK wB wN wR
+	//    A  B  C  D  E  F  G  H
+
+	if rs.Has(rules.RuleNotPinned) || !rs.Has("no-queen-moves") {
+		t.Fatalf("rule set has incorrect rules: %v", rs.Names())
+	}
+
+	queenMove := move.Move{From: move.Position{File: 3, Rank: 0}, To: move.Position{File: 3, Rank: 3}}
+
+	if err := b.IsValidMove(queenMove); !errors.Is(err, errNoQueenMoves) {
+		t.Errorf("IsValidMove(%v) => %v, want %v", queenMove, err, errNoQueenMoves)
+	}
+
+	// Without the king safety rule the king is able to move next to the rook
+	kingMove := move.Move{From: move.Position{File: 4, Rank: 0}, To: move.Position{File: 4, Rank: 1}}
+
+	if err := b.IsValidMove(kingMove); err != nil {
+		t.Errorf("IsValidMove(%v) => %v, want nil", kingMove, err)
+	}
+
+	standard := payloads.NewStandardBoard()
+	delete(standard.Pieces, move.Position{File: 3, Rank: 1})
+	delete(standard.Pieces, move.Position{File: 4, Rank: 1})
+	standard.Pieces[move.Position{File: 4, Rank: 2}] = b.Pieces[move.Position{File: 4, Rank: 2}]
+
+	if err := standard.IsValidMove(kingMove); err == nil {
+		t.Errorf("IsValidMove(%v) => nil, want the standard rules to stop the king moving into check", kingMove)
+	}
+}
@@ -10,7 +10,7 @@ package board
 // IsCheckMate checks for the state of the board being check mate for the colour provided
 // TODO: Look into making this concurrent
 // func (b Board) IsCheckMate(c colour.Colour) bool {
-// 	king, err := b.getKing(c)
+// 	king, err := b.GetKing(c)
 // 	if err != nil {
 // 		return false
 // 	}
@@ -119,7 +119,7 @@ package board
 // }
 
 //func (b Board) IsCheck(c colour.Colour) bool {
-//	king, err := b.getKing(c)
+//	king, err := b.GetKing(c)
 //	if err != nil {
 //		return false
 //	}
@@ -130,7 +130,7 @@ package board
 //// TODO: Try to make this concurrent
 //// IsCheckMate checks to see if the colour specified is in check mate
 //func (b Board) IsCheckMate(c colour.Colour) bool {
-//	k, err := b.getKing(c)
+//	k, err := b.GetKing(c)
 //	if err != nil {
 //		return false
 //	}
@@ -236,8 +236,8 @@ package board
 //	return Board{}, fmt.Errorf("cannot make move: %v, as you are putting the king in check", m)
 //}
 
-// getKing gets the king piece for the colour provided
-//func (b Board) getKing(c colour.Colour) (*piece.Piece, error) {
+// GetKing gets the king piece for the colour provided
+//func (b Board) GetKing(c colour.Colour) (*piece.Piece, error) {
 //	for _, p := range b.Pieces {
 //		if p.Colour == c && p.GetPieceType() == piece.PieceTypeKing {
 //			return p, nil
@@ -1,9 +1,9 @@
 package board
 
 import (
-	"fmt"
 	"math"
 
+	"github.com/tomwatson6/chessbot/internal/board/rules"
 	"github.com/tomwatson6/chessbot/internal/colour"
 	"github.com/tomwatson6/chessbot/internal/move"
 	"github.com/tomwatson6/chessbot/internal/piece"
@@ -108,13 +108,13 @@ func (b Board) getRemainingPieces(c colour.Colour) []*piece.Piece {
 	return pieces
 }
 
-// getKing gets the king piece for the colour provided
-func (b Board) getKing(c colour.Colour) (*piece.Piece, error) {
+// GetKing gets the king piece for the colour provided
+func (b Board) GetKing(c colour.Colour) (*piece.Piece, error) {
 	for _, p := range b.Pieces {
 		if p.Colour == c && p.GetPieceType() == piece.PieceTypeKing {
 			return p, nil
 		}
 	}
 
-	return &piece.Piece{}, fmt.Errorf("cannot find king")
+	return &piece.Piece{}, rules.ErrorKingNotFound
 }
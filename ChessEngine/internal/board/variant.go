@@ -0,0 +1,55 @@
+package board
+
+import (
+	"github.com/tomwatson6/chessbot/internal/board/rules"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/variant"
+)
+
+// HasWon checks if the colour provided has met the win condition of the variant being played
+func (b Board) HasWon(c colour.Colour) (bool, error) {
+	switch b.Variant {
+	case variant.Antichess:
+		// A player wins by losing all of their pieces, or by having no moves left to make
+		if len(b.getRemainingPieces(c)) == 0 {
+			return true, nil
+		}
+
+		return len(b.GetValidMovesForColour(c)) == 0, nil
+	case variant.Horde:
+		// The horde has no king, so black wins by capturing every white piece
+		if c == colour.Black && len(b.getRemainingPieces(colour.White)) == 0 {
+			return true, nil
+		}
+
+		return b.IsCheckMate(c.Opposite())
+	default:
+		return b.IsCheckMate(c.Opposite())
+	}
+}
+
+// GetCaptures gets all valid captures that can be made by the colour provided
+func (b Board) GetCaptures(c colour.Colour) []move.Move {
+	var moves []move.Move
+
+	for _, p := range b.getRemainingPieces(c) {
+		for _, s := range b.Squares {
+			m := move.Move{From: p.Position, To: s}
+
+			if !rules.IsCapture(b.Pieces, m) {
+				continue
+			}
+
+			if err := b.IsValidMove(m); err == nil {
+				moves = append(moves, m)
+			}
+		}
+	}
+
+	return moves
+}
+
+func (b Board) hasCaptureAvailable(c colour.Colour) bool {
+	return len(b.GetCaptures(c)) > 0
+}
@@ -0,0 +1,302 @@
+package board_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/internal/board"
+	"github.com/tomwatson6/chessbot/internal/board/rules"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/piece"
+	"github.com/tomwatson6/chessbot/internal/variant"
+	"github.com/tomwatson6/chessbot/testing/payloads"
+)
+
+func TestAntichessCompulsoryCapture(t *testing.T) {
+	b := payloads.NewEmptyBoard(
+		payloads.BoardWithVariant(variant.Antichess),
+		payloads.BoardWithPiece(&piece.Piece{
+			Colour:       colour.White,
+			Position:     move.Position{File: 0, Rank: 0},
+			PieceDetails: piece.NewRook(),
+		}),
+		payloads.BoardWithPiece(&piece.Piece{
+			Colour:       colour.White,
+			Position:     move.Position{File: 4, Rank: 0},
+			PieceDetails: piece.NewKing(),
+		}),
+		payloads.BoardWithPiece(&piece.Piece{
+			Colour:       colour.Black,
+			Position:     move.Position{File: 0, Rank: 5},
+			PieceDetails: piece.NewKnight(),
+		}),
+	)
+
+	// Visualisation of the board
+	// 8 ## ## ## ## ## ## ## ##
+	// 7 ## ## ## ## ## ## ## ##
+	// 6 bN ## ## ## ## ## ## ##
+	// 5 ## ## ## ## ## ## ## ##
+	// 4 ## ## ## ## ## ## ## ##
+	// 3 ## ## ## ## ## ## ## ##
+	// 2 ## ## ## ## ## ## ## ##
+	// 1 wR ## ## ## wK ## ## ##
+	//    A  B  C  D  E  F  G  H
+
+	tcs := []struct {
+		name string
+		m    move.Move
+		want error
+	}{
+		{
+			name: "RookCapturesKnight",
+			m:    move.Move{From: move.Position{File: 0, Rank: 0}, To: move.Position{File: 0, Rank: 5}},
+			want: nil,
+		},
+		{
+			name: "RookDoesNotCapture",
+			m:    move.Move{From: move.Position{File: 0, Rank: 0}, To: move.Position{File: 0, Rank: 3}},
+			want: rules.ErrorMustCapture,
+		},
+		{
+			name: "KingDoesNotCapture",
+			m:    move.Move{From: move.Position{File: 4, Rank: 0}, To: move.Position{File: 4, Rank: 1}},
+			want: rules.ErrorMustCapture,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := b.IsValidMove(tc.m); !errors.Is(err, tc.want) {
+				t.Errorf("IsValidMove(%v) => %v, want %v", tc.m, err, tc.want)
+			}
+		})
+	}
+
+	if got := b.GetValidMovesForColour(colour.White); len(got) != 1 {
+		t.Errorf("GetValidMovesForColour(%v) returned %d moves, want %d", colour.White, len(got), 1)
+	}
+}
+
+func BenchmarkAntichessGetValidMovesForColour(b *testing.B) {
+	bo := board.New(8, 8, board.WithVariant(variant.Antichess))
+
+	for i := 0; i < b.N; i++ {
+		if moves := bo.GetValidMovesForColour(colour.White); len(moves) != 20 {
+			b.Fatalf("Got incorrect number of moves, got: %d, expected: %d\n", len(moves), 20)
+		}
+	}
+}
+
+func TestAntichessNoCaptureAvailable(t *testing.T) {
+	t.Parallel()
+
+	b := board.New(8, 8, board.WithVariant(variant.Antichess))
+
+	// Without a capture available every move can be made
+	if got := b.GetValidMovesForColour(colour.White); len(got) != 20 {
+		t.Errorf("GetValidMovesForColour(%v) returned %d moves, want %d", colour.White, len(got), 20)
+	}
+}
+
+func TestAntichessKingIsNotRoyal(t *testing.T) {
+	t.Parallel()
+
+	b := payloads.NewEmptyBoard(
+		payloads.BoardWithVariant(variant.Antichess),
+		payloads.BoardWithPiece(&piece.Piece{
+			Colour:       colour.White,
+			Position:     move.Position{File: 4, Rank: 0},
+			PieceDetails: piece.NewKing(piece.KingWithHasMoved(true)),
+		}),
+		payloads.BoardWithPiece(&piece.Piece{
+			Colour:       colour.Black,
+			Position:     move.Position{File: 5, Rank: 7},
+			PieceDetails: piece.NewRook(),
+		}),
+	)
+
+	m := move.Move{From: move.Position{File: 4, Rank: 0}, To: move.Position{File: 5, Rank: 0}}
+
+	if err := b.IsValidMove(m); err != nil {
+		t.Errorf("IsValidMove(%v) => %v, want the king to be able to move into danger", m, err)
+	}
+
+	if _, check, err := b.IsCheck(colour.White); check || err != nil {
+		t.Errorf("IsCheck(%v) => %v, %v, want false, nil", colour.White, check, err)
+	}
+}
+
+func TestAntichessPromoteToKing(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name    string
+		v       variant.Variant
+		wantErr bool
+	}{
+		{"Standard", variant.Standard, true},
+		{"Antichess", variant.Antichess, false},
+	}
+
+	for _, tc := range tcs {
+		b := payloads.NewEmptyBoard(
+			payloads.BoardWithVariant(tc.v),
+			payloads.BoardWithPiece(&piece.Piece{
+				Colour:       colour.White,
+				Position:     move.Position{File: 3, Rank: 6},
+				PieceDetails: piece.NewPawn(piece.PawnWithHasMoved(true)),
+			}),
+		)
+
+		m := move.Move{From: move.Position{File: 3, Rank: 6}, To: move.Position{File: 3, Rank: 7}}
+
+		err := b.Promote(m, piece.NewKing())
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: Promote(%v, King) => %v, want error: %t", tc.name, m, err, tc.wantErr)
+		}
+	}
+}
+
+func TestAntichessHasWon(t *testing.T) {
+	t.Parallel()
+
+	b := payloads.NewEmptyBoard(
+		payloads.BoardWithVariant(variant.Antichess),
+		payloads.BoardWithPiece(&piece.Piece{
+			Colour:       colour.White,
+			Position:     move.Position{File: 0, Rank: 3},
+			PieceDetails: piece.NewPawn(piece.PawnWithHasMoved(true)),
+		}),
+		payloads.BoardWithPiece(&piece.Piece{
+			Colour:       colour.Black,
+			Position:     move.Position{File: 0, Rank: 4},
+			PieceDetails: piece.NewPawn(piece.PawnWithColour(colour.Black), piece.PawnWithHasMoved(true)),
+		}),
+		payloads.BoardWithPiece(&piece.Piece{
+			Colour:       colour.Black,
+			Position:     move.Position{File: 7, Rank: 7},
+			PieceDetails: piece.NewRook(),
+		}),
+	)
+
+	// Visualisation of the board
+	// 8 ## ## ## ## ## ## ## bR
+	// 7 ## ## ## ## ## ## ## ##
+	// 6 ## ## ## ## ## ## ## ##
+	// 5 bP ## ## ## ## ## ## ##
+	// 4 wP ## ## ## ## ## ## ##
+	// 3 ## ## ## ## ## ## ## ##
+	// 2 ## ## ## ## ## ## ## ##
+	// 1 ## ## ## ## ## ## ## ##
+	//    A  B  C  D  E  F  G  H
+
+	tcs := []struct {
+		colour colour.Colour
+		want   bool
+	}{
+		{colour.White, true},
+		{colour.Black, false},
+	}
+
+	for _, tc := range tcs {
+		got, err := b.HasWon(tc.colour)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != tc.want {
+			t.Errorf("HasWon(%v) => %v, want %v", tc.colour, got, tc.want)
+		}
+	}
+}
+
+func TestHordeStartPosition(t *testing.T) {
+	t.Parallel()
+
+	b := board.New(8, 8, board.WithVariant(variant.Horde))
+
+	pawns := 0
+
+	for _, p := range b.Pieces {
+		if p.Colour != colour.White {
+			continue
+		}
+
+		if p.GetPieceType() != piece.PieceTypePawn {
+			t.Fatalf("white should only have pawns in horde, got: %s", p)
+		}
+
+		pawns++
+	}
+
+	if pawns != 36 {
+		t.Errorf("white has %d pawns, want %d", pawns, 36)
+	}
+
+	if _, check, err := b.IsCheck(colour.White); check || err != nil {
+		t.Errorf("IsCheck(%v) => %v, %v, want false, nil", colour.White, check, err)
+	}
+
+	m := move.Move{From: move.Position{File: 1, Rank: 4}, To: move.Position{File: 1, Rank: 5}}
+
+	if err := b.IsValidMove(m); err != nil {
+		t.Errorf("IsValidMove(%v) => %v, want nil", m, err)
+	}
+}
+
+func TestHordeFirstRankPawnDoubleStep(t *testing.T) {
+	t.Parallel()
+
+	b := board.New(8, 8, board.WithVariant(variant.Horde))
+	delete(b.Pieces, move.Position{File: 0, Rank: 1})
+	delete(b.Pieces, move.Position{File: 0, Rank: 2})
+
+	m := move.Move{From: move.Position{File: 0, Rank: 0}, To: move.Position{File: 0, Rank: 2}}
+
+	if err := b.IsValidMove(m); err != nil {
+		t.Errorf("IsValidMove(%v) => %v, want nil", m, err)
+	}
+
+	m = move.Move{From: move.Position{File: 0, Rank: 3}, To: move.Position{File: 0, Rank: 5}}
+
+	if err := b.IsValidMove(m); err == nil {
+		t.Errorf("IsValidMove(%v) => nil, want an error as only first and second rank pawns can move two squares", m)
+	}
+}
+
+func TestHordeHasWon(t *testing.T) {
+	t.Parallel()
+
+	b := payloads.NewEmptyBoard(
+		payloads.BoardWithVariant(variant.Horde),
+		payloads.BoardWithPiece(&piece.Piece{
+			Colour:       colour.Black,
+			Position:     move.Position{File: 4, Rank: 7},
+			PieceDetails: piece.NewKing(),
+		}),
+	)
+
+	tcs := []struct {
+		colour colour.Colour
+		want   bool
+	}{
+		{colour.White, false},
+		{colour.Black, true},
+	}
+
+	for _, tc := range tcs {
+		got, err := b.HasWon(tc.colour)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != tc.want {
+			t.Errorf("HasWon(%v) => %v, want %v", tc.colour, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+// Package bot plays the engine against people in the games of the server. A bot thinks on the time of its opponent
+// (pondering), searching the position after the reply it expects while it waits for them to move.
+package bot
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/engine"
+	"github.com/tomwatson6/chessbot/internal/game"
+	"github.com/tomwatson6/chessbot/internal/piece"
+	"github.com/tomwatson6/chessbot/internal/polyglot"
+)
+
+// Stats counts how often the opponent played the reply the bot pondered on. A hit plays the move of the ponder
+// search, which has searched for as long as the opponent thought, while a miss throws the search away.
+type Stats struct {
+	Hits   int `json:"hits"`
+	Misses int `json:"misses"`
+}
+
+// Bot plays a colour of a game until the game is over or deleted
+type Bot struct {
+	game   *game.Game
+	colour colour.Colour
+	engine *engine.Engine
+	limits engine.Limits
+	ponder bool
+	book   *polyglot.Book
+
+	mu    sync.Mutex
+	stats Stats
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type Option func(b *Bot)
+
+// WithEngineOptions searches with an engine made with the options provided, such as a tablebase
+func WithEngineOptions(opts ...engine.Option) Option {
+	return func(b *Bot) {
+		b.engine = engine.New(opts...)
+	}
+}
+
+// WithDepth limits how deep the bot searches, the depth of the engine by default. The clock of the game limits how
+// long it searches for.
+func WithDepth(depth int) Option {
+	return func(b *Bot) {
+		b.limits.Depth = depth
+	}
+}
+
+// WithBook plays moves from the opening book while it has them, before searching
+func WithBook(book *polyglot.Book) Option {
+	return func(b *Bot) {
+		b.book = book
+	}
+}
+
+// WithPonder sets whether the bot thinks on the time of its opponent, which it does by default
+func WithPonder(ponder bool) Option {
+	return func(b *Bot) {
+		b.ponder = ponder
+	}
+}
+
+// Start starts the bot playing the colour provided in the game, moving straight away if it is its turn
+func Start(g *game.Game, c colour.Colour, opts ...Option) *Bot {
+	b := &Bot{
+		game:   g,
+		colour: c,
+		engine: engine.New(),
+		ponder: true,
+		done:   make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+
+	// The bot subscribes before looking at the game, so that no move is missed in between
+	events, unsubscribe := g.Subscribe()
+
+	go func() {
+		defer close(b.done)
+		defer unsubscribe()
+
+		b.run(ctx, events)
+	}()
+
+	return b
+}
+
+// Colour gets the colour the bot plays
+func (b *Bot) Colour() colour.Colour {
+	return b.colour
+}
+
+// Stats gets how often the bot has pondered on the move its opponent went on to play
+func (b *Bot) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.stats
+}
+
+// Stop stops the bot, waiting for any search it is running to stop
+func (b *Bot) Stop() {
+	b.cancel()
+	<-b.done
+}
+
+// Done is closed once the bot has stopped, as the game is over or it was stopped
+func (b *Bot) Done() <-chan struct{} {
+	return b.done
+}
+
+// search is a search running in the background, the result being sent once it is done. key is the position it
+// searches, which for a ponder search is the position after the reply expected.
+type search struct {
+	key    uint64
+	hit    chan struct{}
+	cancel context.CancelFunc
+	result chan engine.Result
+}
+
+// stop cancels the search, waiting for it to return so that no search outlives the move it was for
+func (s *search) stop() {
+	if s == nil {
+		return
+	}
+
+	s.cancel()
+	<-s.result
+}
+
+// run plays the game as its moves come in. Every move cancels the search running, other than the move the bot was
+// pondering on, which lets the ponder search go on to find the move of the bot.
+func (b *Bot) run(ctx context.Context, events <-chan game.Event) {
+	var thinking, pondering *search
+	var last engine.Result
+
+	defer func() {
+		thinking.stop()
+		pondering.stop()
+	}()
+
+	played := -1
+
+	// update catches up with the moves made since it last ran, returning false once the game is over
+	update := func() bool {
+		if b.game.Status().IsOver() {
+			return false
+		}
+
+		_, moves := b.game.Moves()
+		if len(moves) == played {
+			return true
+		}
+
+		played = len(moves)
+		c := b.game.Chess()
+
+		// The move was not made by the bot, as it is not thinking once it has moved
+		thinking.stop()
+		thinking = nil
+
+		if c.Turn != b.colour {
+			pondering.stop()
+			pondering = nil
+
+			// The line is only pondered on when the move was the one the bot played from it
+			if len(moves) > 0 && moves[len(moves)-1] == last.Move {
+				pondering = b.ponderOn(ctx, c, last)
+			}
+
+			return true
+		}
+
+		if pondering != nil && pondering.key == polyglot.Key(c) {
+			b.count(true)
+			close(pondering.hit)
+			thinking, pondering = pondering, nil
+
+			return true
+		}
+
+		if pondering != nil {
+			b.count(false)
+			pondering.stop()
+			pondering = nil
+		}
+
+		thinking = b.think(ctx, c, nil)
+
+		return true
+	}
+
+	if !update() {
+		return
+	}
+
+	for {
+		var result chan engine.Result
+		if thinking != nil {
+			result = thinking.result
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-events:
+			// The channel is closed when the game is deleted
+			if !ok || !update() {
+				return
+			}
+		case res := <-result:
+			thinking = nil
+			last = res
+
+			if _, err := b.game.Move(res.Move); err != nil {
+				log.Printf("Bot failed to move %s in game %s with error: %s\n", res.Move.UCI(), b.game.ID, err)
+				return
+			}
+		}
+	}
+}
+
+// ponderOn starts searching the position after the reply expected in the line the bot played, nil if there is no
+// reply to expect
+func (b *Bot) ponderOn(ctx context.Context, c chess.Chess, last engine.Result) *search {
+	if !b.ponder || len(last.PV) < 2 {
+		return nil
+	}
+
+	next := c.Copy()
+	if _, err := next.MakeMoveWithPromotion(last.PV[1], piece.PieceTypeQueen); err != nil {
+		return nil
+	}
+
+	if len(next.LegalMoves()) == 0 {
+		return nil
+	}
+
+	return b.think(ctx, next, make(chan struct{}))
+}
+
+// think starts searching the position for the move of the bot, a ponder search when hit is not nil. A move from the
+// book is the result straight away, a ponder search on a book move being ready once it is hit.
+func (b *Bot) think(ctx context.Context, c chess.Chess, hit chan struct{}) *search {
+	sctx, cancel := context.WithCancel(ctx)
+
+	s := &search{key: polyglot.Key(c), hit: hit, cancel: cancel, result: make(chan engine.Result, 1)}
+
+	if b.book != nil {
+		if m, ok := b.book.Probe(c); ok {
+			s.result <- engine.Result{Move: m.Move}
+			return s
+		}
+	}
+
+	l := b.limits
+	l.Ponder = hit
+
+	if left, inc, ok := b.game.TimeLeft(colour.White); ok {
+		l.WhiteTime, l.WhiteIncrement = left, inc
+	}
+
+	if left, inc, ok := b.game.TimeLeft(colour.Black); ok {
+		l.BlackTime, l.BlackIncrement = left, inc
+	}
+
+	go func() {
+		// The bot only thinks in positions with moves to make, so the search cannot fail
+		res, _ := b.engine.Search(sctx, c, l, nil)
+		s.result <- res
+	}()
+
+	return s
+}
+
+func (b *Bot) count(hit bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if hit {
+		b.stats.Hits++
+	} else {
+		b.stats.Misses++
+	}
+}
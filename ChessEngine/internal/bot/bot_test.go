@@ -0,0 +1,197 @@
+package bot_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/bot"
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/engine"
+	"github.com/tomwatson6/chessbot/internal/game"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/piece"
+	"github.com/tomwatson6/chessbot/internal/polyglot"
+)
+
+// await waits for the colour provided to move in the game
+func await(t *testing.T, events <-chan game.Event, c colour.Colour) {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				t.Fatal("events closed before the move")
+			}
+
+			if me, ok := e.Data.(game.MoveEvent); ok && me.Colour == c.String() {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("%s did not move", c)
+		}
+	}
+}
+
+// expected gets the reply the bot expects to its move, as it searches the same as the engine does
+func expected(t *testing.T, g *game.Game) move.Move {
+	t.Helper()
+
+	start, moves := g.Moves()
+
+	c := start.Copy()
+	for _, m := range moves[:len(moves)-1] {
+		if _, err := c.MakeMoveWithPromotion(m, piece.PieceTypeQueen); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := engine.New().Search(context.Background(), c, engine.Limits{Depth: 2}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Move != moves[len(moves)-1] || len(res.PV) < 2 {
+		t.Fatalf("got line %v, want it to start with the move of the bot", res.PV)
+	}
+
+	return res.PV[1]
+}
+
+func TestBot_Ponder(t *testing.T) {
+	g := game.New("test", chess.New(colour.White))
+
+	events, unsubscribe := g.Subscribe()
+	defer unsubscribe()
+
+	b := bot.Start(g, colour.White, bot.WithDepth(2))
+	defer b.Stop()
+
+	await(t, events, colour.White)
+
+	// Playing the reply the bot expects is a ponderhit
+	if _, err := g.Move(expected(t, g)); err != nil {
+		t.Fatal(err)
+	}
+
+	await(t, events, colour.Black)
+	await(t, events, colour.White)
+
+	if got := b.Stats(); got != (bot.Stats{Hits: 1}) {
+		t.Errorf("got stats %+v, want a hit", got)
+	}
+
+	// Any other reply is a miss, the bot searching again from the move played
+	reply := expected(t, g)
+
+	c := g.Chess()
+	for _, m := range c.LegalMoves() {
+		if m == reply {
+			continue
+		}
+
+		if _, err := g.Move(m); err != nil {
+			t.Fatal(err)
+		}
+
+		break
+	}
+
+	await(t, events, colour.Black)
+	await(t, events, colour.White)
+
+	if got := b.Stats(); got != (bot.Stats{Hits: 1, Misses: 1}) {
+		t.Errorf("got stats %+v, want a hit and a miss", got)
+	}
+}
+
+func TestBot_WithoutPonder(t *testing.T) {
+	g := game.New("test", chess.New(colour.White))
+
+	events, unsubscribe := g.Subscribe()
+	defer unsubscribe()
+
+	b := bot.Start(g, colour.Black, bot.WithDepth(1), bot.WithPonder(false))
+	defer b.Stop()
+
+	if _, err := g.Move(move.Move{From: move.Position{File: 4, Rank: 1}, To: move.Position{File: 4, Rank: 3}}); err != nil {
+		t.Fatal(err)
+	}
+
+	await(t, events, colour.White)
+	await(t, events, colour.Black)
+
+	if got := b.Stats(); got != (bot.Stats{}) {
+		t.Errorf("got stats %+v, want no pondering", got)
+	}
+}
+
+func TestBot_Book(t *testing.T) {
+	start := chess.New(colour.White)
+	d4 := move.Move{From: move.Position{File: 3, Rank: 1}, To: move.Position{File: 3, Rank: 3}}
+
+	book := polyglot.New([]polyglot.Entry{{Key: polyglot.Key(start), Move: polyglot.EncodeMove(start, d4, 0), Weight: 1}})
+
+	g := game.New("test", start)
+
+	events, unsubscribe := g.Subscribe()
+	defer unsubscribe()
+
+	// The bot is left thinking far deeper than it could search in the test, so the move must come from the book
+	b := bot.Start(g, colour.White, bot.WithDepth(20), bot.WithBook(book), bot.WithPonder(false))
+	defer b.Stop()
+
+	await(t, events, colour.White)
+
+	if _, moves := g.Moves(); len(moves) != 1 || moves[0] != d4 {
+		t.Errorf("got moves %v, want the book move d2d4", moves)
+	}
+}
+
+func TestBot_StopsWhenGameEnds(t *testing.T) {
+	tests := []struct {
+		name string
+		end  func(m *game.Manager, g *game.Game) error
+	}{
+		{name: "resigned", end: func(m *game.Manager, g *game.Game) error { return g.Resign(colour.White) }},
+		{name: "aborted", end: func(m *game.Manager, g *game.Game) error { return g.Abort() }},
+		{name: "deleted", end: func(m *game.Manager, g *game.Game) error { return m.Delete(g.ID) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := game.NewManager()
+
+			g, err := m.Create(chess.New(colour.White))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			events, unsubscribe := g.Subscribe()
+			defer unsubscribe()
+
+			// The bot is left thinking far deeper than it could search in the test
+			b := bot.Start(g, colour.Black, bot.WithDepth(20))
+
+			if _, err := g.Move(move.Move{From: move.Position{File: 4, Rank: 1}, To: move.Position{File: 4, Rank: 3}}); err != nil {
+				t.Fatal(err)
+			}
+
+			await(t, events, colour.White)
+
+			if err := tt.end(m, g); err != nil {
+				t.Fatal(err)
+			}
+
+			select {
+			case <-b.Done():
+			case <-time.After(5 * time.Second):
+				t.Fatal("bot did not stop once the game was over")
+			}
+		})
+	}
+}
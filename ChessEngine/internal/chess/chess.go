@@ -12,11 +12,14 @@ import (
 	"github.com/tomwatson6/chessbot/internal/board"
 	"github.com/tomwatson6/chessbot/internal/colour"
 	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/variant"
 )
 
 var (
 	// ErrorPieceNotInStartPosition is thrown when there is no piece in the start position provided
 	ErrorPieceNotInStartPosition = errors.New("there is no piece in the start position provided")
+	// ErrorNotYourTurn is thrown when the piece being moved is not the colour of the current turn
+	ErrorNotYourTurn = errors.New("the piece being moved does not belong to the colour of the current turn")
 )
 
 type Chess struct {
@@ -24,11 +27,29 @@ type Chess struct {
 	Turn  colour.Colour `json:"turn"`
 }
 
-func New(col colour.Colour) Chess {
+type Option func(o *options)
+
+type options struct {
+	variant variant.Variant
+}
+
+// WithVariant sets the variant of chess being played
+func WithVariant(v variant.Variant) Option {
+	return func(o *options) {
+		o.variant = v
+	}
+}
+
+func New(col colour.Colour, opts ...Option) Chess {
 	var c Chess
+	var o options
+
+	for _, opt := range opts {
+		opt(&o)
+	}
 
 	width, height := config.GetBoardDimensions()
-	c.Board = board.New(width, height)
+	c.Board = board.New(width, height, board.WithVariant(o.variant))
 	c.Turn = col
 
 	return c
@@ -88,7 +109,7 @@ func (c *Chess) MakeMove(m move.Move) ([]move.Move, error) {
 	}
 
 	if c.Board.Pieces[m.From].Colour != c.Turn {
-		return []move.Move{}, fmt.Errorf("invalid move for current turn: %v", m)
+		return []move.Move{}, fmt.Errorf("invalid move for current turn: %v, err: %w", m, ErrorNotYourTurn)
 	}
 
 	moves, err := c.Board.Move(m)
@@ -101,6 +122,14 @@ func (c *Chess) MakeMove(m move.Move) ([]move.Move, error) {
 	return moves, nil
 }
 
+// Copy makes a deep copy of the game, so that moves can be made on the copy without changing the original
+func (c Chess) Copy() Chess {
+	return Chess{
+		Board: c.Board.Copy(),
+		Turn:  c.Turn,
+	}
+}
+
 func (c *Chess) NextTurn() {
 	c.Turn = c.Turn.Opposite()
 }
@@ -0,0 +1,305 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/tomwatson6/chessbot/cmd/config"
+	"github.com/tomwatson6/chessbot/internal/board"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/piece"
+)
+
+// StartingFEN is the starting position of standard chess in Forsyth-Edwards Notation
+const StartingFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// ErrorInvalidFEN is thrown when a position cannot be read from the FEN provided
+var ErrorInvalidFEN = errors.New("invalid FEN")
+
+var pieceTypes = map[rune]piece.PieceType{
+	'p': piece.PieceTypePawn,
+	'n': piece.PieceTypeKnight,
+	'b': piece.PieceTypeBishop,
+	'r': piece.PieceTypeRook,
+	'q': piece.PieceTypeQueen,
+	'k': piece.PieceTypeKing,
+}
+
+// FromFEN sets up a game from the position in Forsyth-Edwards Notation. The board does not track the halfmove
+// clock, so it is ignored, and the fullmove number is only used to number the history of the board.
+func FromFEN(fen string, opts ...Option) (Chess, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	fields := strings.Fields(fen)
+	if len(fields) < 4 {
+		return Chess{}, fmt.Errorf("%w: expected at least 4 fields: %q", ErrorInvalidFEN, fen)
+	}
+
+	width, height := config.GetBoardDimensions()
+
+	rows := strings.Split(fields[0], "/")
+	if len(rows) != height {
+		return Chess{}, fmt.Errorf("%w: expected %d ranks: %q", ErrorInvalidFEN, height, fen)
+	}
+
+	type placed struct {
+		t   piece.PieceType
+		c   colour.Colour
+		pos move.Position
+	}
+
+	var ps []placed
+
+	for i, row := range rows {
+		rank := height - 1 - i
+		file := 0
+
+		for _, r := range row {
+			if unicode.IsDigit(r) {
+				file += int(r - '0')
+				continue
+			}
+
+			t, ok := pieceTypes[unicode.ToLower(r)]
+			if !ok {
+				return Chess{}, fmt.Errorf("%w: unknown piece %q", ErrorInvalidFEN, r)
+			}
+
+			c := colour.Black
+			if unicode.IsUpper(r) {
+				c = colour.White
+			}
+
+			ps = append(ps, placed{t: t, c: c, pos: move.Position{File: file, Rank: rank}})
+			file++
+		}
+
+		if file != width {
+			return Chess{}, fmt.Errorf("%w: rank %d has %d files", ErrorInvalidFEN, rank+1, file)
+		}
+	}
+
+	var turn colour.Colour
+	switch fields[1] {
+	case "w":
+		turn = colour.White
+	case "b":
+		turn = colour.Black
+	default:
+		return Chess{}, fmt.Errorf("%w: unknown colour %q", ErrorInvalidFEN, fields[1])
+	}
+
+	castling := fields[2]
+
+	// Kings and rooks that can still castle are the only pieces that have not moved, along with pawns on their
+	// starting rank
+	unmoved := make(map[move.Position]bool)
+	for _, r := range castling {
+		switch r {
+		case 'K':
+			unmoved[move.Position{File: 4, Rank: 0}], unmoved[move.Position{File: 7, Rank: 0}] = true, true
+		case 'Q':
+			unmoved[move.Position{File: 4, Rank: 0}], unmoved[move.Position{File: 0, Rank: 0}] = true, true
+		case 'k':
+			unmoved[move.Position{File: 4, Rank: 7}], unmoved[move.Position{File: 7, Rank: 7}] = true, true
+		case 'q':
+			unmoved[move.Position{File: 4, Rank: 7}], unmoved[move.Position{File: 0, Rank: 7}] = true, true
+		case '-':
+		default:
+			return Chess{}, fmt.Errorf("%w: unknown castling right %q", ErrorInvalidFEN, r)
+		}
+	}
+
+	pieces := make([]*piece.Piece, 0, len(ps))
+
+	for _, p := range ps {
+		hasMoved := !unmoved[p.pos]
+		if p.t == piece.PieceTypePawn {
+			start := 1
+			if p.c == colour.Black {
+				start = height - 2
+			}
+
+			hasMoved = p.pos.Rank != start
+		}
+
+		pd, err := piece.NewPieceDetails(p.t, p.c, hasMoved)
+		if err != nil {
+			return Chess{}, err
+		}
+
+		pieces = append(pieces, &piece.Piece{Colour: p.c, Position: p.pos, PieceDetails: pd})
+	}
+
+	b := board.New(width, height, board.WithVariant(o.variant), board.WithPieces(pieces))
+
+	if fullmove, err := strconv.Atoi(fieldOr(fields, 5, "1")); err == nil && fullmove > 1 {
+		b.History = make([]board.Turn, fullmove)
+		for i := range b.History {
+			b.History[i] = make(board.Turn)
+		}
+	}
+
+	// The double pawn push that allows en passant is the last move in the history of the board
+	if fields[3] != "-" {
+		target, err := move.ParsePosition(fields[3])
+		if err != nil {
+			return Chess{}, fmt.Errorf("%w: %s", ErrorInvalidFEN, err)
+		}
+
+		dir := 1
+		if turn == colour.White {
+			dir = -1
+		}
+
+		push := move.Move{
+			From: move.Position{File: target.File, Rank: target.Rank - dir},
+			To:   move.Position{File: target.File, Rank: target.Rank + dir},
+		}
+
+		if turn == colour.White {
+			// Black's move is in the turn before the one white is about to move in
+			if len(b.History) < 2 {
+				b.History = append(b.History, make(board.Turn))
+			}
+
+			b.History[len(b.History)-2][colour.Black] = &push
+		} else {
+			b.History[len(b.History)-1][colour.White] = &push
+		}
+	}
+
+	return Chess{Board: b, Turn: turn}, nil
+}
+
+func fieldOr(fields []string, i int, def string) string {
+	if i < len(fields) {
+		return fields[i]
+	}
+
+	return def
+}
+
+// FEN gets the position in Forsyth-Edwards Notation. The halfmove clock is always 0 as the board does not track it.
+func (c Chess) FEN() string {
+	b := c.Board
+
+	var sb strings.Builder
+
+	for rank := b.Height - 1; rank >= 0; rank-- {
+		empty := 0
+
+		for file := 0; file < b.Width; file++ {
+			p, ok := b.Pieces[move.Position{File: file, Rank: rank}]
+			if !ok {
+				empty++
+				continue
+			}
+
+			if empty > 0 {
+				sb.WriteString(strconv.Itoa(empty))
+				empty = 0
+			}
+
+			sb.WriteRune(Letter(p))
+		}
+
+		if empty > 0 {
+			sb.WriteString(strconv.Itoa(empty))
+		}
+
+		if rank > 0 {
+			sb.WriteByte('/')
+		}
+	}
+
+	turn := "w"
+	if c.Turn == colour.Black {
+		turn = "b"
+	}
+
+	fullmove := len(b.History)
+	if fullmove == 0 {
+		fullmove = 1
+	}
+
+	return fmt.Sprintf("%s %s %s %s 0 %d", sb.String(), turn, c.castling(), c.enPassant(), fullmove)
+}
+
+// Letter gets the FEN letter of the piece, upper case for white and lower case for black
+func Letter(p *piece.Piece) rune {
+	l := rune(p.GetPieceLetter())
+	if p.Colour == colour.Black {
+		return unicode.ToLower(l)
+	}
+
+	return l
+}
+
+func (c Chess) castling() string {
+	unmoved := func(pos move.Position, t piece.PieceType) bool {
+		p, ok := c.Board.Pieces[pos]
+		return ok && p.GetPieceType() == t && !p.HasMoved()
+	}
+
+	rights := ""
+	for _, r := range []struct {
+		right      string
+		king, rook move.Position
+	}{
+		{right: "K", king: move.Position{File: 4, Rank: 0}, rook: move.Position{File: 7, Rank: 0}},
+		{right: "Q", king: move.Position{File: 4, Rank: 0}, rook: move.Position{File: 0, Rank: 0}},
+		{right: "k", king: move.Position{File: 4, Rank: 7}, rook: move.Position{File: 7, Rank: 7}},
+		{right: "q", king: move.Position{File: 4, Rank: 7}, rook: move.Position{File: 0, Rank: 7}},
+	} {
+		if unmoved(r.king, piece.PieceTypeKing) && unmoved(r.rook, piece.PieceTypeRook) {
+			rights += r.right
+		}
+	}
+
+	if rights == "" {
+		return "-"
+	}
+
+	return rights
+}
+
+// enPassant gets the square behind a pawn that has just moved two squares, or - if there is not one
+func (c Chess) enPassant() string {
+	last := c.LastMove()
+	if last == nil {
+		return "-"
+	}
+
+	p, ok := c.Board.Pieces[last.To]
+	if !ok || p.GetPieceType() != piece.PieceTypePawn || last.From.File != last.To.File {
+		return "-"
+	}
+
+	if d := last.To.Rank - last.From.Rank; d != 2 && d != -2 {
+		return "-"
+	}
+
+	return move.Position{File: last.To.File, Rank: (last.From.Rank + last.To.Rank) / 2}.Notation()
+}
+
+// LastMove gets the last move made by the opponent of the colour to move, nil if they have not moved
+func (c Chess) LastMove() *move.Move {
+	h := c.Board.History
+
+	switch {
+	case c.Turn == colour.Black && len(h) > 0:
+		return h[len(h)-1][colour.White]
+	case c.Turn == colour.White && len(h) > 1:
+		return h[len(h)-2][colour.Black]
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,302 @@
+package chess_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/piece"
+)
+
+func TestFEN(t *testing.T) {
+	tests := []struct {
+		name  string
+		moves []string
+		want  string
+	}{
+		{
+			name: "starting position",
+			want: chess.StartingFEN,
+		},
+		{
+			name:  "en passant square after a double pawn push",
+			moves: []string{"e2e4"},
+			want:  "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+		},
+		{
+			name:  "castling rights lost when the king moves",
+			moves: []string{"e2e4", "e7e5", "e1e2"},
+			want:  "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPPKPPP/RNBQ1BNR b kq - 0 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := chess.New(colour.White)
+
+			for _, s := range tt.moves {
+				m, _, err := move.ParseUCI(s)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if _, err := c.MakeMove(m); err != nil {
+					t.Fatalf("MakeMove(%s) returned error: %s", s, err)
+				}
+			}
+
+			if got := c.FEN(); got != tt.want {
+				t.Errorf("FEN() = %s, want %s", got, tt.want)
+			}
+
+			// Reading the FEN back gets the same position
+			c2, err := chess.FromFEN(tt.want)
+			if err != nil {
+				t.Fatalf("FromFEN() returned error: %s", err)
+			}
+
+			if got := c2.FEN(); got != tt.want {
+				t.Errorf("FromFEN(%s).FEN() = %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFromFENInvalid(t *testing.T) {
+	for _, fen := range []string{
+		"",
+		"8/8/8/8/8/8/8 w - - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNX w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR x KQkq - 0 1",
+		"rnbqkbnr/pppppppp/9/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+	} {
+		if _, err := chess.FromFEN(fen); err == nil {
+			t.Errorf("FromFEN(%q) did not return an error", fen)
+		}
+	}
+}
+
+func TestSAN(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		move string
+		want string
+	}{
+		{name: "pawn push", fen: chess.StartingFEN, move: "e2e4", want: "e4"},
+		{name: "knight move", fen: chess.StartingFEN, move: "g1f3", want: "Nf3"},
+		{
+			name: "pawn capture",
+			fen:  "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2",
+			move: "e4d5",
+			want: "exd5",
+		},
+		{
+			name: "knights disambiguated by file",
+			fen:  "4k3/8/8/8/8/8/8/1N2KN2 w - - 0 1",
+			move: "b1d2",
+			want: "Nbd2",
+		},
+		{
+			name: "rooks disambiguated by rank",
+			fen:  "4k3/8/8/R7/8/8/8/R3K3 w - - 0 1",
+			move: "a1a3",
+			want: "R1a3",
+		},
+		{
+			name: "castling",
+			fen:  "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1",
+			move: "e1g1",
+			want: "O-O",
+		},
+		{
+			name: "promotion with check",
+			fen:  "4k3/1P6/8/8/8/8/8/4K3 w - - 0 1",
+			move: "b7b8",
+			want: "b8=Q+",
+		},
+		{
+			name: "checkmate",
+			fen:  "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1",
+			move: "a1a8",
+			want: "Ra8#",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := chess.FromFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			m, _, err := move.ParseUCI(tt.move)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := c.SAN(m, piece.PieceTypeQueen)
+			if err != nil {
+				t.Fatalf("SAN() returned error: %s", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("SAN(%s) = %s, want %s", tt.move, got, tt.want)
+			}
+
+			// Reading the notation back gets the same move
+			parsed, _, err := c.ParseSAN(got)
+			if err != nil {
+				t.Fatalf("ParseSAN(%s) returned error: %s", got, err)
+			}
+
+			if parsed != m {
+				t.Errorf("ParseSAN(%s) = %s, want %s", got, parsed.UCI(), tt.move)
+			}
+		})
+	}
+}
+
+func TestParseSAN(t *testing.T) {
+	tests := []struct {
+		name      string
+		fen       string
+		san       string
+		want      string
+		promotion piece.PieceType
+		err       error
+	}{
+		{name: "annotations are ignored", fen: chess.StartingFEN, san: "Nf3!?", want: "g1f3", promotion: piece.PieceTypePawn},
+		{name: "castling with zeroes", fen: "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", san: "0-0-0", want: "e8c8", promotion: piece.PieceTypePawn},
+		{name: "underpromotion", fen: "4k3/1P6/8/8/8/8/8/4K3 w - - 0 1", san: "b8=N", want: "b7b8", promotion: piece.PieceTypeKnight},
+		{name: "promotion without =", fen: "4k3/1P6/8/8/8/8/8/4K3 w - - 0 1", san: "b8Q", want: "b7b8", promotion: piece.PieceTypeQueen},
+		{name: "en passant", fen: "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 2", san: "exd6", want: "e5d6", promotion: piece.PieceTypePawn},
+		{name: "illegal move", fen: chess.StartingFEN, san: "Nf4", err: chess.ErrorIllegalMove},
+		{name: "ambiguous move", fen: "4k3/8/8/8/8/8/8/1N2KN2 w - - 0 1", san: "Nd2", err: chess.ErrorAmbiguousMove},
+		{name: "not notation", fen: chess.StartingFEN, san: "Zz9", err: chess.ErrorInvalidSAN},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := chess.FromFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			m, promotion, err := c.ParseSAN(tt.san)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseSAN(%s) returned error %v, want %v", tt.san, err, tt.err)
+			}
+
+			if tt.err != nil {
+				return
+			}
+
+			if m.UCI() != tt.want || promotion != tt.promotion {
+				t.Errorf("ParseSAN(%s) = %s %v, want %s %v", tt.san, m.UCI(), promotion, tt.want, tt.promotion)
+			}
+		})
+	}
+}
+
+func TestEnPassantRemovesCapturedPawn(t *testing.T) {
+	c, err := chess.FromFEN("4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, _, err := c.ParseSAN("exd6")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.MakeMove(m); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := c.FEN(), "4k3/8/3P4/8/8/8/8/4K3 b - - 0 2"; got != want {
+		t.Errorf("FEN() = %s, want %s", got, want)
+	}
+}
+
+func TestParseUCI(t *testing.T) {
+	c, err := chess.FromFEN("4k3/1P6/8/8/8/8/8/4K3 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		uci       string
+		promotion piece.PieceType
+		err       error
+	}{
+		{uci: "e1e2", promotion: piece.PieceTypePawn},
+		{uci: "b7b8n", promotion: piece.PieceTypeKnight},
+		{uci: "b7b8", promotion: piece.PieceTypeQueen},
+		{uci: "e1e3", err: chess.ErrorIllegalMove},
+	}
+
+	for _, tt := range tests {
+		_, promotion, err := c.ParseUCI(tt.uci)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("ParseUCI(%s) got error %v, want %v", tt.uci, err, tt.err)
+			continue
+		}
+
+		if err == nil && promotion != tt.promotion {
+			t.Errorf("ParseUCI(%s) got promotion %d, want %d", tt.uci, promotion, tt.promotion)
+		}
+	}
+}
+
+func TestPawnCaptureKeepsOtherPieces(t *testing.T) {
+	// The rook on a1 used to be taken off the board along with the captured pawn
+	c, err := chess.FromFEN("4k3/8/8/3p4/4P3/8/8/R3K3 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, _, err := c.ParseSAN("exd5")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.MakeMove(m); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := c.FEN(), "4k3/8/8/3P4/8/8/8/R3K3 b - - 0 1"; got != want {
+		t.Errorf("FEN() = %s, want %s", got, want)
+	}
+}
+
+func TestKingMovesNextToUnprotectedSquares(t *testing.T) {
+	// The rook checks along the rank, but h8 and h6 are only next to it, not attacked by it
+	c, err := chess.FromFEN("8/6Rk/5K2/8/8/8/8/8 b - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, m := range c.LegalMoves() {
+		got = append(got, m.UCI())
+	}
+
+	if len(got) != 2 || got[0] != "h7h6" || got[1] != "h7h8" {
+		t.Errorf("got legal moves %v, want h7h6 and h7h8", got)
+	}
+}
+
+func TestLegalMovesInCheckmate(t *testing.T) {
+	// The black king is checked along the back rank, so it must step off it
+	c, err := chess.FromFEN("R5k1/5ppp/8/8/8/8/8/6K1 b - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if moves := c.LegalMoves(); len(moves) != 0 {
+		t.Errorf("got legal moves %v in checkmate", moves)
+	}
+}
@@ -0,0 +1,341 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/piece"
+)
+
+var (
+	// ErrorInvalidSAN is thrown when a move cannot be read from the Standard Algebraic Notation provided
+	ErrorInvalidSAN = errors.New("invalid SAN")
+	// ErrorIllegalMove is thrown when a move is not one of the legal moves of the position
+	ErrorIllegalMove = errors.New("illegal move")
+	// ErrorAmbiguousMove is thrown when more than one legal move matches the notation provided
+	ErrorAmbiguousMove = errors.New("ambiguous move")
+)
+
+// LegalMoves gets the moves the colour to move can make, ordered by the square moved from then the square moved to
+// so that the order does not depend on the iteration order of the board. The moves allowed by the rules of the
+// board are only kept if they do not leave the king in check, nor castle out of or through check.
+func (c Chess) LegalMoves() []move.Move {
+	var moves []move.Move
+
+	for _, m := range c.Board.GetValidMovesForColour(c.Turn) {
+		if c.leavesKingSafe(m) {
+			moves = append(moves, m)
+		}
+	}
+
+	sort.Slice(moves, func(i, j int) bool {
+		return less(moves[i], moves[j])
+	})
+
+	return moves
+}
+
+func (c Chess) leavesKingSafe(m move.Move) bool {
+	b := c.Board
+	if !b.Variant.HasRoyalKing() {
+		return true
+	}
+
+	p := b.Pieces[m.From]
+	opp := c.Turn.Opposite()
+
+	if p.GetPieceType() == piece.PieceTypeKing && (m.To.File-m.From.File == 2 || m.From.File-m.To.File == 2) {
+		passed := move.Position{File: (m.From.File + m.To.File) / 2, Rank: m.From.Rank}
+		if b.IsAttacked(m.From, opp) || b.IsAttacked(passed, opp) {
+			return false
+		}
+	}
+
+	next := c.Copy()
+	if _, err := next.MakeMove(m); err != nil {
+		return false
+	}
+
+	return !next.Board.InCheck(c.Turn)
+}
+
+func less(a, b move.Move) bool {
+	if a.From != b.From {
+		return a.From.Rank < b.From.Rank || (a.From.Rank == b.From.Rank && a.From.File < b.From.File)
+	}
+
+	return a.To.Rank < b.To.Rank || (a.To.Rank == b.To.Rank && a.To.File < b.To.File)
+}
+
+// IsPromotion reports whether the move takes a pawn to the last rank
+func (c Chess) IsPromotion(m move.Move) bool {
+	p, ok := c.Board.Pieces[m.From]
+	if !ok || p.GetPieceType() != piece.PieceTypePawn {
+		return false
+	}
+
+	return m.To.Rank == 0 || m.To.Rank == c.Board.Height-1
+}
+
+// MakeMoveWithPromotion makes the move, promoting a pawn that reaches the last rank to the piece type provided.
+// Pawns are promoted to queens when the type is not one a pawn can be promoted to.
+func (c *Chess) MakeMoveWithPromotion(m move.Move, t piece.PieceType) ([]move.Move, error) {
+	promotion := c.IsPromotion(m)
+	col := c.Turn
+
+	moves, err := c.MakeMove(m)
+	if err != nil || !promotion {
+		return moves, err
+	}
+
+	if t == piece.PieceTypePawn || (t == piece.PieceTypeKing && !c.Board.Variant.CanPromoteToKing()) {
+		t = piece.PieceTypeQueen
+	}
+
+	pd, err := piece.NewPieceDetails(t, col, true)
+	if err != nil {
+		return moves, err
+	}
+
+	c.Board.Pieces[m.To].PieceDetails = pd
+
+	return moves, nil
+}
+
+// UCI gets the move in the long algebraic notation of the Universal Chess Interface, with the lower case letter of
+// the piece promoted to when it is a promotion e.g. e7e8q
+func (c Chess) UCI(m move.Move, promotion piece.PieceType) string {
+	if !c.IsPromotion(m) {
+		return m.UCI()
+	}
+
+	if promotion == piece.PieceTypePawn {
+		promotion = piece.PieceTypeQueen
+	}
+
+	pd, err := piece.NewPieceDetails(promotion, c.Turn, true)
+	if err != nil {
+		return m.UCI()
+	}
+
+	return m.UCI() + strings.ToLower(string(rune(pd.GetPieceLetter())))
+}
+
+// ParseUCI reads a legal move of the colour to move from the long algebraic notation of the Universal Chess Interface,
+// along with the type of piece promoted to, which is pawn when the move is not a promotion
+func (c Chess) ParseUCI(s string) (move.Move, piece.PieceType, error) {
+	m, letter, err := move.ParseUCI(s)
+	if err != nil {
+		return move.Move{}, 0, err
+	}
+
+	legal := false
+	for _, l := range c.LegalMoves() {
+		legal = legal || l == m
+	}
+
+	if !legal {
+		return move.Move{}, 0, fmt.Errorf("%w: %s", ErrorIllegalMove, s)
+	}
+
+	promotion := piece.PieceTypePawn
+	if c.IsPromotion(m) {
+		promotion = piece.PieceTypeQueen
+
+		if t, ok := pieceTypes[unicode.ToLower(letter)]; ok {
+			promotion = t
+		}
+	}
+
+	return m, promotion, nil
+}
+
+// SAN gets the move in Standard Algebraic Notation e.g. Nbd7, exd5, O-O or e8=Q+, promoting to the piece type
+// provided. It must be called before the move is made.
+func (c Chess) SAN(m move.Move, promotion piece.PieceType) (string, error) {
+	p, ok := c.Board.Pieces[m.From]
+	if !ok {
+		return "", ErrorPieceNotInStartPosition
+	}
+
+	var sb strings.Builder
+
+	switch {
+	case p.GetPieceType() == piece.PieceTypeKing && m.To.File-m.From.File == 2:
+		sb.WriteString("O-O")
+	case p.GetPieceType() == piece.PieceTypeKing && m.From.File-m.To.File == 2:
+		sb.WriteString("O-O-O")
+	default:
+		_, capture := c.Board.Pieces[m.To]
+
+		if p.GetPieceType() == piece.PieceTypePawn {
+			if m.From.File != m.To.File {
+				capture = true
+				sb.WriteByte(byte('a' + m.From.File))
+			}
+		} else {
+			sb.WriteRune(rune(p.GetPieceLetter()))
+			sb.WriteString(c.disambiguate(p, m))
+		}
+
+		if capture {
+			sb.WriteByte('x')
+		}
+
+		sb.WriteString(m.To.Notation())
+
+		if c.IsPromotion(m) {
+			if promotion == piece.PieceTypePawn {
+				promotion = piece.PieceTypeQueen
+			}
+
+			pd, err := piece.NewPieceDetails(promotion, p.Colour, true)
+			if err != nil {
+				return "", err
+			}
+
+			sb.WriteString("=" + string(rune(pd.GetPieceLetter())))
+		}
+	}
+
+	next := c.Copy()
+	if _, err := next.MakeMoveWithPromotion(m, promotion); err != nil {
+		return "", fmt.Errorf("failed to make move %s: %w", m, err)
+	}
+
+	if next.Board.InCheck(next.Turn) {
+		if len(next.LegalMoves()) == 0 {
+			sb.WriteByte('#')
+		} else {
+			sb.WriteByte('+')
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// disambiguate gets the file, rank or square needed to tell the piece apart from others of its type that can
+// move to the same square
+func (c Chess) disambiguate(p *piece.Piece, m move.Move) string {
+	sameFile, sameRank, others := false, false, false
+
+	for _, o := range c.Board.Pieces {
+		if o == p || o.Colour != p.Colour || o.GetPieceType() != p.GetPieceType() {
+			continue
+		}
+
+		if err := c.Board.IsValidMove(move.Move{From: o.Position, To: m.To}); err != nil {
+			continue
+		}
+
+		others = true
+		sameFile = sameFile || o.Position.File == m.From.File
+		sameRank = sameRank || o.Position.Rank == m.From.Rank
+	}
+
+	switch {
+	case !others:
+		return ""
+	case !sameFile:
+		return string(rune('a' + m.From.File))
+	case !sameRank:
+		return string(rune('1' + m.From.Rank))
+	default:
+		return m.From.Notation()
+	}
+}
+
+// ParseSAN reads a move in Standard Algebraic Notation e.g. Nbd7, exd5, O-O or e8=Q+, returning the type of piece
+// promoted to, which is a pawn when the move is not a promotion. Check and annotation suffixes are ignored.
+func (c Chess) ParseSAN(san string) (move.Move, piece.PieceType, error) {
+	s := strings.TrimRight(san, "+#!?")
+
+	kingside, queenside := s == "O-O" || s == "0-0", s == "O-O-O" || s == "0-0-0"
+
+	t, promotion := piece.PieceTypePawn, piece.PieceTypePawn
+	fromFile, fromRank := -1, -1
+	var to move.Position
+
+	if !kingside && !queenside {
+		if i := strings.IndexByte(s, '='); i >= 0 {
+			p, ok := pieceTypes[unicode.ToLower(rune(s[len(s)-1]))]
+			if !ok || i != len(s)-2 {
+				return move.Move{}, 0, fmt.Errorf("%w: %q", ErrorInvalidSAN, san)
+			}
+
+			promotion, s = p, s[:i]
+		} else if n := len(s); n > 2 && strings.IndexByte("QRBN", s[n-1]) >= 0 {
+			// Some programs leave out the = e.g. e8Q
+			promotion, s = pieceTypes[unicode.ToLower(rune(s[n-1]))], s[:n-1]
+		}
+
+		if len(s) > 0 && unicode.IsUpper(rune(s[0])) {
+			p, ok := pieceTypes[unicode.ToLower(rune(s[0]))]
+			if !ok || p == piece.PieceTypePawn {
+				return move.Move{}, 0, fmt.Errorf("%w: %q", ErrorInvalidSAN, san)
+			}
+
+			t, s = p, s[1:]
+		}
+
+		if len(s) < 2 {
+			return move.Move{}, 0, fmt.Errorf("%w: %q", ErrorInvalidSAN, san)
+		}
+
+		var err error
+		if to, err = move.ParsePosition(s[len(s)-2:]); err != nil {
+			return move.Move{}, 0, fmt.Errorf("%w: %q", ErrorInvalidSAN, san)
+		}
+
+		for _, r := range strings.TrimSuffix(s[:len(s)-2], "x") {
+			switch {
+			case r >= 'a' && r <= 'z':
+				fromFile = int(r - 'a')
+			case r >= '1' && r <= '9':
+				fromRank = int(r - '1')
+			default:
+				return move.Move{}, 0, fmt.Errorf("%w: %q", ErrorInvalidSAN, san)
+			}
+		}
+	}
+
+	var found []move.Move
+
+	for _, m := range c.LegalMoves() {
+		p := c.Board.Pieces[m.From]
+		isCastle := p.GetPieceType() == piece.PieceTypeKing && (m.To.File-m.From.File == 2 || m.From.File-m.To.File == 2)
+
+		switch {
+		case kingside:
+			if isCastle && m.To.File > m.From.File {
+				found = append(found, m)
+			}
+		case queenside:
+			if isCastle && m.To.File < m.From.File {
+				found = append(found, m)
+			}
+		case p.GetPieceType() == t && m.To == to && !isCastle &&
+			(fromFile < 0 || m.From.File == fromFile) && (fromRank < 0 || m.From.Rank == fromRank):
+			found = append(found, m)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return move.Move{}, 0, fmt.Errorf("%w: %s", ErrorIllegalMove, san)
+	case 1:
+		if !c.IsPromotion(found[0]) {
+			promotion = piece.PieceTypePawn
+		} else if promotion == piece.PieceTypePawn {
+			promotion = piece.PieceTypeQueen
+		}
+
+		return found[0], promotion, nil
+	default:
+		return move.Move{}, 0, fmt.Errorf("%w: %s", ErrorAmbiguousMove, san)
+	}
+}
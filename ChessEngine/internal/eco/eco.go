@@ -0,0 +1,153 @@
+// Package eco classifies games and positions by their opening, using the codes of the Encyclopaedia of Chess
+// Openings
+package eco
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/pgn"
+	"github.com/tomwatson6/chessbot/internal/polyglot"
+)
+
+//go:embed eco.tsv
+var bundled string
+
+// Opening is a named opening and the moves that reach it from the starting position
+type Opening struct {
+	ECO   string   `json:"eco"`
+	Name  string   `json:"name"`
+	Moves []string `json:"moves"`
+}
+
+// Table finds openings by the hash of their position, so that openings reached by transposition are found too
+type Table struct {
+	openings map[uint64]Opening
+	maxPly   int
+}
+
+// Read reads a table with an opening on each line of the ECO code, name and UCI moves separated by tabs. Blank
+// lines and lines starting with # are skipped. An opening replaces any earlier opening with the same position.
+func Read(r io.Reader) (*Table, error) {
+	t := &Table{openings: make(map[uint64]Opening)}
+
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected the code, name and moves separated by tabs", line)
+		}
+
+		o := Opening{ECO: fields[0], Name: fields[1], Moves: strings.Fields(fields[2])}
+
+		c := chess.New(colour.White)
+		for _, s := range o.Moves {
+			m, _, err := move.ParseUCI(s)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+
+			if _, err := c.MakeMove(m); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+
+		t.openings[polyglot.Key(c)] = o
+
+		if len(o.Moves) > t.maxPly {
+			t.maxPly = len(o.Moves)
+		}
+	}
+
+	return t, s.Err()
+}
+
+var (
+	defaultOnce  sync.Once
+	defaultTable *Table
+)
+
+// Default gets the table bundled with the repository
+func Default() *Table {
+	defaultOnce.Do(func() {
+		t, err := Read(strings.NewReader(bundled))
+		if err != nil {
+			panic(fmt.Sprintf("failed to read the bundled ECO table: %s", err))
+		}
+
+		defaultTable = t
+	})
+
+	return defaultTable
+}
+
+// Len gets the number of openings in the table
+func (t *Table) Len() int {
+	return len(t.openings)
+}
+
+// Position gets the opening of the position, false if it is not in the table
+func (t *Table) Position(c chess.Chess) (Opening, bool) {
+	o, ok := t.openings[polyglot.Key(c)]
+	return o, ok
+}
+
+// Game gets the opening of the game, the last position of the game found in the table. Only the moves up to the
+// longest line of the table are looked at, as later positions cannot be in it without wasting moves.
+func (t *Table) Game(g pgn.Game) (Opening, bool) {
+	fen := g.Tag("FEN")
+	if fen == "" {
+		fen = chess.StartingFEN
+	}
+
+	c, err := chess.FromFEN(fen)
+	if err != nil {
+		return Opening{}, false
+	}
+
+	found, ok := t.Position(c)
+
+	for i, pm := range g.Moves {
+		if i >= t.maxPly {
+			break
+		}
+
+		m, promotion, err := c.ParseSAN(pm.SAN)
+		if err != nil {
+			break
+		}
+
+		if _, err := c.MakeMoveWithPromotion(m, promotion); err != nil {
+			break
+		}
+
+		if o, ok2 := t.Position(c); ok2 {
+			found, ok = o, true
+		}
+	}
+
+	return found, ok
+}
+
+// Tag sets the ECO and Opening tags of the game from its opening, leaving the game untouched if it is not found
+func (t *Table) Tag(g *pgn.Game) {
+	o, ok := t.Game(*g)
+	if !ok {
+		return
+	}
+
+	g.SetTag("ECO", o.ECO)
+	g.SetTag("Opening", o.Name)
+}
@@ -0,0 +1,67 @@
+package eco_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/eco"
+	"github.com/tomwatson6/chessbot/internal/pgn"
+)
+
+func TestGame(t *testing.T) {
+	tests := []struct {
+		name  string
+		moves string
+		eco   string
+		open  string
+	}{
+		{name: "main line", moves: "1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 4. Ba4 Nf6 5. O-O Be7", eco: "C84", open: "Ruy Lopez: Closed"},
+		{name: "moves past the table keep the last opening", moves: "1. d4 d5 2. c4 c6 3. Nf3 Nf6 4. e3", eco: "D10", open: "Slav Defense"},
+		{name: "transposition", moves: "1. Nf3 d5 2. d4", eco: "D02", open: "Queen's Pawn Game: Zukertort Variation"},
+		{name: "unclassified", moves: "1. a3 a6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := pgn.Parse(tt.moves + " *")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			eco.Default().Tag(&g)
+
+			if g.Tag("ECO") != tt.eco || g.Tag("Opening") != tt.open {
+				t.Errorf("got ECO %q and opening %q, want %q and %q", g.Tag("ECO"), g.Tag("Opening"), tt.eco, tt.open)
+			}
+		})
+	}
+}
+
+func TestPosition(t *testing.T) {
+	c, err := chess.FromFEN("rnbqkbnr/pp1ppppp/8/2p5/4P3/8/PPPP1PPP/RNBQKBNR w KQkq c6 0 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if o, ok := eco.Default().Position(c); !ok || o.ECO != "B20" {
+		t.Errorf("Position() = %+v, want the Sicilian Defense", o)
+	}
+}
+
+func TestRead(t *testing.T) {
+	table, err := eco.Read(strings.NewReader("# comment\n\nX00\tTest\te2e4 e7e5\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if table.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", table.Len())
+	}
+
+	for _, s := range []string{"X00\tno moves", "X00\tIllegal\te2e5"} {
+		if _, err := eco.Read(strings.NewReader(s)); err == nil {
+			t.Errorf("Read(%q) returned no error", s)
+		}
+	}
+}
@@ -0,0 +1,400 @@
+package engine_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/engine"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/tablebase"
+)
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		depth int
+		want  string
+		mate  int
+	}{
+		{
+			// The rook mates on the back rank behind the pawns
+			name:  "mate in one",
+			fen:   "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1",
+			depth: 2,
+			want:  "a1a8",
+			mate:  1,
+		},
+		{
+			name:  "wins the hanging queen",
+			fen:   "4k3/8/8/3q4/8/8/3R4/4K3 w - - 0 1",
+			depth: 1,
+			want:  "d2d5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := chess.FromFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := engine.New().Search(context.Background(), c, engine.Limits{Depth: tt.depth}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := res.Move.UCI(); got != tt.want {
+				t.Errorf("got move %s, want %s", got, tt.want)
+			}
+
+			if got := res.Score.MateIn(); got != tt.mate {
+				t.Errorf("got mate in %d (score %s), want %d", got, res.Score, tt.mate)
+			}
+		})
+	}
+}
+
+func TestSearchStopsAtMoveTime(t *testing.T) {
+	c := chess.New(0)
+
+	start := time.Now()
+
+	res, err := engine.New().Search(context.Background(), c, engine.Limits{Depth: 10, MoveTime: 200 * time.Millisecond}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("search took %s with a move time of 200ms", elapsed)
+	}
+
+	if res.Depth < 1 || len(res.PV) == 0 {
+		t.Errorf("got result %+v, want at least one depth to be completed", res)
+	}
+}
+
+func TestSearchWithTablebase(t *testing.T) {
+	tables := tablebase.New()
+	if err := tables.Generate("KRvK"); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := chess.FromFEN("8/8/8/4k3/8/8/8/R3K3 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := tables.Probe(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The mate is far beyond the depth searched, it is only found through the tablebase
+	res, err := engine.New(engine.WithTablebase(tables)).Search(context.Background(), c, engine.Limits{Depth: 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := res.Score.MateIn(); got != want.MateIn() {
+		t.Errorf("got mate in %d (score %s), want %d", got, res.Score, want.MateIn())
+	}
+}
+
+func TestSearchFiltersRootMovesWithTablebase(t *testing.T) {
+	tables := tablebase.New()
+	if err := tables.Generate("KPvK"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pushing the pawn lets the king catch it, only bringing the king over wins
+	c, err := chess.FromFEN("8/8/8/8/8/4P1k1/8/1K6 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := engine.New(engine.WithTablebase(tables)).Search(context.Background(), c, engine.Limits{Depth: 4}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// With a single move left to search there is no need to go deeper than the first depth
+	if res.Move.UCI() != "b1c2" || res.Depth != 1 {
+		t.Errorf("got move %s at depth %d, want b1c2 at depth 1", res.Move.UCI(), res.Depth)
+	}
+}
+
+func TestSearchWithSyzygy(t *testing.T) {
+	tables, err := tablebase.OpenSyzygy(filepath.Join("..", "tablebase", "testdata", "syzygy"))
+	if errors.Is(err, tablebase.ErrorTableNotFound) {
+		t.Skip("the Syzygy files of the tablebase testdata are missing")
+	} else if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tables.Close()
+
+	c, err := chess.FromFEN("8/8/8/8/8/4P1k1/8/1K6 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := engine.New(engine.WithTablebase(tables)).Search(context.Background(), c, engine.Limits{Depth: 4}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Syzygy tables win without the distance to mate, which scores below every mate
+	if res.Move.UCI() != "b1c2" || res.Score <= engine.TablebaseWin-100 || res.Score.IsMate() {
+		t.Errorf("got move %s scoring %s, want b1c2 scoring a tablebase win", res.Move.UCI(), res.Score)
+	}
+}
+
+func TestSearchMoves(t *testing.T) {
+	c, err := chess.FromFEN("4k3/8/8/3q4/8/8/3R4/4K3 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without taking the queen white is losing
+	search := []move.Move{{From: move.Position{File: 4, Rank: 0}, To: move.Position{File: 5, Rank: 0}}}
+
+	res, err := engine.New().Search(context.Background(), c, engine.Limits{Depth: 2, SearchMoves: search}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Move != search[0] || res.Score >= 0 {
+		t.Errorf("got move %s scoring %s, want e1f1 scoring below 0", res.Move.UCI(), res.Score)
+	}
+
+	// Moves that are not legal are not searched
+	search = []move.Move{{From: move.Position{File: 4, Rank: 0}, To: move.Position{File: 4, Rank: 2}}}
+
+	if _, err := engine.New().Search(context.Background(), c, engine.Limits{Depth: 1, SearchMoves: search}, nil); err != engine.ErrorNoMoves {
+		t.Errorf("got error %v, want %v", err, engine.ErrorNoMoves)
+	}
+}
+
+func TestSearchMultiPV(t *testing.T) {
+	// Either rook mates on the back rank
+	c, err := chess.FromFEN("6k1/5ppp/8/8/8/8/8/RR4K1 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var infos []engine.Info
+
+	res, err := engine.New().Search(context.Background(), c, engine.Limits{Depth: 2, MultiPV: 3}, func(i engine.Info) {
+		infos = append(infos, i)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Lines) != 3 || res.Info.MultiPV != 1 || res.Move != res.Lines[0].PV[0] {
+		t.Fatalf("got result %+v, want 3 lines with the best first", res)
+	}
+
+	seen := make(map[move.Move]bool)
+	for i, line := range res.Lines {
+		if line.MultiPV != i+1 || line.Depth != 2 || seen[line.PV[0]] {
+			t.Errorf("got line %d %+v, want rank %d at depth 2 with a move of its own", i, line, i+1)
+		}
+
+		seen[line.PV[0]] = true
+	}
+
+	if res.Lines[0].Score.MateIn() != 1 || res.Lines[1].Score.MateIn() != 1 || res.Lines[2].Score.IsMate() {
+		t.Errorf("got scores %s %s %s, want the two mates first", res.Lines[0].Score, res.Lines[1].Score, res.Lines[2].Score)
+	}
+
+	if len(infos) != 6 {
+		t.Errorf("got %d updates, want one for each line at each depth", len(infos))
+	}
+}
+
+func TestSearchThreads(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		want string
+		mate int
+	}{
+		{name: "mate in one", fen: "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", want: "a1a8", mate: 1},
+		{name: "wins the hanging queen", fen: "4k3/8/8/3q4/8/8/3R4/4K3 w - - 0 1", want: "d2d5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := chess.FromFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			single, err := engine.New().Search(context.Background(), c, engine.Limits{Depth: 3}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := engine.New(engine.WithThreads(4)).Search(context.Background(), c, engine.Limits{Depth: 3}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.want != "" && res.Move.UCI() != tt.want {
+				t.Errorf("got move %s, want %s", res.Move.UCI(), tt.want)
+			}
+
+			if res.Score.MateIn() != tt.mate || res.Score != single.Score {
+				t.Errorf("got score %s, want %s as searched by a single thread", res.Score, single.Score)
+			}
+		})
+	}
+}
+
+func TestSearchThreads_Nodes(t *testing.T) {
+	res, err := engine.New(engine.WithThreads(4)).Search(context.Background(), chess.New(0), engine.Limits{Depth: 10, Nodes: 2000}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The threads share the limit, though they can each go a node past it
+	if res.Depth < 1 || res.Nodes > 2000+4 {
+		t.Errorf("got result %+v, want a depth completed within 2000 nodes", res)
+	}
+}
+
+func TestSearchPonder(t *testing.T) {
+	hit := make(chan struct{})
+	done := make(chan engine.Result, 1)
+
+	go func() {
+		res, _ := engine.New().Search(context.Background(), chess.New(0), engine.Limits{Depth: 1, Ponder: hit}, nil)
+		done <- res
+	}()
+
+	// The depth is soon searched, but the move is held until the ponderhit
+	select {
+	case res := <-done:
+		t.Fatalf("got result %+v before the ponderhit", res)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(hit)
+
+	select {
+	case res := <-done:
+		if res.Depth != 1 || len(res.PV) == 0 {
+			t.Errorf("got result %+v, want the depth searched while pondering", res)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("search did not return after the ponderhit")
+	}
+}
+
+func TestSearchPonder_Miss(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		_, _ = engine.New().Search(ctx, chess.New(0), engine.Limits{Depth: 1, Ponder: make(chan struct{})}, nil)
+	}()
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("search did not return once cancelled")
+	}
+}
+
+func TestSearchPonder_BudgetsFromPonderhit(t *testing.T) {
+	hit := make(chan struct{})
+	done := make(chan struct{})
+
+	// Six seconds on the clock budget 200ms for the move, which only start once the ponder hits
+	l := engine.Limits{Depth: 20, WhiteTime: 6 * time.Second, Ponder: hit}
+
+	go func() {
+		defer close(done)
+		_, _ = engine.New().Search(context.Background(), chess.New(0), l, nil)
+	}()
+
+	time.Sleep(300 * time.Millisecond)
+
+	select {
+	case <-done:
+		t.Fatal("search stopped before the ponderhit")
+	default:
+	}
+
+	start := time.Now()
+	close(hit)
+
+	select {
+	case <-done:
+		if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+			t.Errorf("search stopped %s after the ponderhit, want the 200ms budgeted", elapsed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("search did not stop once the time budgeted after the ponderhit had passed")
+	}
+}
+
+// BenchmarkSearch searches a middlegame to a fixed depth with more and more threads, the time of each op being the
+// time to depth
+func BenchmarkSearch(b *testing.B) {
+	c, err := chess.FromFEN("r1bq1rk1/pp2bppp/2n1pn2/3p4/2PP4/2N2N2/PP2BPPP/R2QKB1R w KQ - 0 9")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, threads := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("threads=%d", threads), func(b *testing.B) {
+			e := engine.New(engine.WithThreads(threads))
+
+			var nodes int64
+			start := time.Now()
+
+			for i := 0; i < b.N; i++ {
+				res, err := e.Search(context.Background(), c, engine.Limits{Depth: 3}, nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				nodes += res.Nodes
+			}
+
+			b.ReportMetric(float64(nodes)/time.Since(start).Seconds(), "nps")
+			b.ReportMetric(float64(nodes)/float64(b.N), "nodes/op")
+		})
+	}
+}
+
+func TestScoreString(t *testing.T) {
+	tests := []struct {
+		score engine.Score
+		want  string
+	}{
+		{score: 35, want: "+35"},
+		{score: -120, want: "-120"},
+		{score: engine.Mate - 1, want: "#1"},
+		{score: engine.Mate - 5, want: "#3"},
+		{score: -engine.Mate + 2, want: "#-1"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.score.String(); got != tt.want {
+			t.Errorf("Score(%d).String() = %s, want %s", int(tt.score), got, tt.want)
+		}
+	}
+}
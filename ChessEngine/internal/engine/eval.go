@@ -0,0 +1,138 @@
+// Package engine searches positions for the best move, scoring them with an evaluation of the pieces on the board
+package engine
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/piece"
+)
+
+// Evaluator scores a position in centipawns from the point of view of the colour to move
+type Evaluator func(c chess.Chess) Score
+
+// Evaluators are the evaluations an engine can be configured with, by name
+var Evaluators = map[string]Evaluator{
+	"material": Material,
+	"standard": Evaluate,
+}
+
+// ParseEvaluator gets the evaluation with the name provided
+func ParseEvaluator(name string) (Evaluator, error) {
+	if e, ok := Evaluators[name]; ok {
+		return e, nil
+	}
+
+	names := make([]string, 0, len(Evaluators))
+	for n := range Evaluators {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	return nil, fmt.Errorf("unknown evaluation %q, expected one of %v", name, names)
+}
+
+// Value gets the worth of a piece of the type provided in centipawns, kings are not counted
+func Value(t piece.PieceType) Score {
+	switch t {
+	case piece.PieceTypePawn:
+		return 100
+	case piece.PieceTypeKnight:
+		return 320
+	case piece.PieceTypeBishop:
+		return 330
+	case piece.PieceTypeRook:
+		return 500
+	case piece.PieceTypeQueen:
+		return 900
+	default:
+		return 0
+	}
+}
+
+// Material scores the position by the worth of the pieces each side has
+func Material(c chess.Chess) Score {
+	var s Score
+
+	for _, p := range c.Board.Pieces {
+		if p.Colour == c.Turn {
+			s += Value(p.GetPieceType())
+		} else {
+			s -= Value(p.GetPieceType())
+		}
+	}
+
+	return s
+}
+
+// Evaluate scores the position by material, with bonuses for pieces on central squares and for advanced pawns
+func Evaluate(c chess.Chess) Score {
+	var s Score
+
+	for _, p := range c.Board.Pieces {
+		v := Value(p.GetPieceType()) + placement(c, p)
+
+		if p.Colour == c.Turn {
+			s += v
+		} else {
+			s -= v
+		}
+	}
+
+	return s
+}
+
+// placement gets the bonus for where the piece stands
+func placement(c chess.Chess, p *piece.Piece) Score {
+	w, h := c.Board.Width, c.Board.Height
+
+	// centre is 0 on the edge of the board, rising towards the middle
+	centre := Score(min(p.Position.File, w-1-p.Position.File) + min(p.Position.Rank, h-1-p.Position.Rank))
+
+	advance := p.Position.Rank
+	if p.Colour == colour.Black {
+		advance = h - 1 - p.Position.Rank
+	}
+
+	switch p.GetPieceType() {
+	case piece.PieceTypePawn:
+		return Score(advance*advance) * 2
+	case piece.PieceTypeKnight:
+		return centre * 8
+	case piece.PieceTypeBishop:
+		return centre * 4
+	case piece.PieceTypeQueen:
+		return centre * 2
+	case piece.PieceTypeKing:
+		// The king is safest on its own back rank while there is material to attack it
+		return -Score(advance) * 10
+	default:
+		return 0
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+// capturedValue gets the worth of the piece captured by the move, 0 if it is not a capture
+func capturedValue(c chess.Chess, m move.Move) Score {
+	if p, ok := c.Board.Pieces[m.To]; ok {
+		return Value(p.GetPieceType())
+	}
+
+	// A pawn moving diagonally to an empty square captures en passant
+	if p := c.Board.Pieces[m.From]; p.GetPieceType() == piece.PieceTypePawn && m.From.File != m.To.File {
+		return Value(piece.PieceTypePawn)
+	}
+
+	return 0
+}
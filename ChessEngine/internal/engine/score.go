@@ -0,0 +1,52 @@
+package engine
+
+import "fmt"
+
+// Score is the worth of a position in centipawns, from the point of view of the colour to move
+type Score int
+
+const (
+	// Mate is the score of delivering checkmate, mates further away score less so that the shortest is preferred
+	Mate Score = 100000
+	// TablebaseWin is the score of a position a tablebase wins without knowing the distance to mate, below every mate
+	TablebaseWin Score = Mate - 2*maxPly
+	// Infinity is above every score a position can have
+	Infinity Score = 1000000
+
+	maxPly = 1000
+)
+
+// IsMate reports whether the score is a forced mate for either side
+func (s Score) IsMate() bool {
+	return s > Mate-maxPly || s < -Mate+maxPly
+}
+
+// MateIn gets the number of moves until mate, negative when the colour to move is being mated
+func (s Score) MateIn() int {
+	switch {
+	case s > Mate-maxPly:
+		return (int(Mate-s) + 1) / 2
+	case s < -Mate+maxPly:
+		return -(int(Mate+s) + 1) / 2
+	default:
+		return 0
+	}
+}
+
+// String gets the score as centipawns e.g. +35, or moves to mate e.g. #3 or #-2
+func (s Score) String() string {
+	if s.IsMate() {
+		return fmt.Sprintf("#%d", s.MateIn())
+	}
+
+	return fmt.Sprintf("%+d", int(s))
+}
+
+// MateScore gets the score of mate in the number of moves provided, negative when the colour to move is mated
+func MateScore(moves int) Score {
+	if moves > 0 {
+		return Mate - Score(2*moves-1)
+	}
+
+	return -Mate + Score(-2*moves)
+}
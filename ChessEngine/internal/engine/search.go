@@ -0,0 +1,611 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/piece"
+	"github.com/tomwatson6/chessbot/internal/polyglot"
+	"github.com/tomwatson6/chessbot/internal/tablebase"
+)
+
+var (
+	// ErrorNoMoves is thrown when searching a position where the colour to move has no moves to make
+	ErrorNoMoves = errors.New("there are no moves to search")
+
+	errStopped = errors.New("search stopped")
+)
+
+// DefaultDepth is how deep an engine searches when it is not limited by time, nodes or a depth of its own
+const DefaultDepth = 3
+
+// Limits bounds how long a search runs. With no limits the engine searches to its maximum depth.
+type Limits struct {
+	Depth    int
+	Nodes    int64
+	MoveTime time.Duration
+	// The time left on the clock of each colour and their increments, used to budget the time of the search
+	WhiteTime, BlackTime           time.Duration
+	WhiteIncrement, BlackIncrement time.Duration
+	// Infinite searches until the context is cancelled
+	Infinite bool
+	// SearchMoves restricts the search to these moves of the root position, every legal move is searched when empty
+	SearchMoves []move.Move
+	// MultiPV is how many of the best moves are searched for, each with its own line, 1 when not set
+	MultiPV int
+	// Ponder searches on the time of the opponent, expecting them to play into the position, until the channel is
+	// closed when they do (a ponderhit). The time of the search is budgeted from the ponderhit, and the search does
+	// not return before it unless the context is cancelled, as it is when the opponent plays another move.
+	Ponder <-chan struct{}
+}
+
+// budget gets how long the colour provided should spend on the move, 0 if the search is not timed
+func (l Limits) budget(c colour.Colour) time.Duration {
+	if l.MoveTime > 0 {
+		return l.MoveTime
+	}
+
+	left, inc := l.WhiteTime, l.WhiteIncrement
+	if c == colour.Black {
+		left, inc = l.BlackTime, l.BlackIncrement
+	}
+
+	if left <= 0 {
+		return 0
+	}
+
+	b := left/30 + inc/2
+	if b > left/2 {
+		b = left / 2
+	}
+
+	return b
+}
+
+// Info is the progress of a search after each depth has been completed. MultiPV is the rank of the line among the
+// best moves, 1 for the best.
+type Info struct {
+	MultiPV int           `json:"multipv,omitempty"`
+	Depth   int           `json:"depth"`
+	Score   Score         `json:"score"`
+	Nodes   int64         `json:"nodes"`
+	Time    time.Duration `json:"time"`
+	PV      []move.Move   `json:"pv"`
+}
+
+// Result is the move found by a search along with the last depth completed. Lines holds every line of the last
+// depth completed, best first, when more than one was searched for.
+type Result struct {
+	Move move.Move `json:"move"`
+	Info
+	Lines []Info `json:"lines,omitempty"`
+}
+
+// Engine searches positions with iterative deepening alpha-beta, safe for concurrent use. Each search has a
+// transposition table of its own, shared by every thread of the search.
+type Engine struct {
+	eval      Evaluator
+	maxDepth  int
+	tablebase tablebase.Prober
+	threads   int
+	hash      int
+}
+
+type Option func(e *Engine)
+
+// WithEvaluator scores positions with the evaluation provided instead of Evaluate
+func WithEvaluator(eval Evaluator) Option {
+	return func(e *Engine) {
+		e.eval = eval
+	}
+}
+
+// WithMaxDepth sets how deep the engine searches when the limits of a search do not say otherwise
+func WithMaxDepth(depth int) Option {
+	return func(e *Engine) {
+		e.maxDepth = depth
+	}
+}
+
+// WithTablebase scores positions the tablebase covers with their exact result instead of searching them, and at the
+// root only searches the moves that keep the best result it gives
+func WithTablebase(p tablebase.Prober) Option {
+	return func(e *Engine) {
+		e.tablebase = p
+	}
+}
+
+// WithThreads searches with the number of threads provided, the threads other than the first searching the same
+// positions to fill the transposition table for it (Lazy SMP). A single thread is used by default.
+func WithThreads(n int) Option {
+	return func(e *Engine) {
+		if n > 0 {
+			e.threads = n
+		}
+	}
+}
+
+// WithHash sets the size in megabytes of the transposition table of each search, DefaultHash by default
+func WithHash(mb int) Option {
+	return func(e *Engine) {
+		if mb > 0 {
+			e.hash = mb
+		}
+	}
+}
+
+func New(opts ...Option) *Engine {
+	e := &Engine{
+		eval:     Evaluate,
+		maxDepth: DefaultDepth,
+		threads:  1,
+		hash:     DefaultHash,
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// Threads gets how many threads the engine searches with
+func (e *Engine) Threads() int {
+	return e.threads
+}
+
+// search is the state of a thread of a search, the nodes and transposition table being shared by every thread
+type search struct {
+	ctx       context.Context
+	eval      Evaluator
+	tablebase tablebase.Prober
+	limit     int64
+	nodes     *int64
+	table     *table
+	pv        []move.Move
+	// root is the moves searched from the root position
+	root []move.Move
+}
+
+// Search finds the best move in the position, calling onInfo, if not nil, for each line each time a depth has been
+// completed. The first depth is always completed so that there is a move to play, even if the context is cancelled
+// first. The nodes of every thread count towards the limit on nodes.
+func (e *Engine) Search(ctx context.Context, c chess.Chess, l Limits, onInfo func(Info)) (Result, error) {
+	moves := restrict(c.LegalMoves(), l.SearchMoves)
+	if len(moves) == 0 {
+		return Result{}, ErrorNoMoves
+	}
+
+	if e.tablebase != nil {
+		moves = filterRoot(c, moves, e.tablebase)
+	}
+
+	depth := l.Depth
+	if depth <= 0 {
+		depth = e.maxDepth
+	}
+
+	if l.Infinite {
+		depth = maxPly
+	}
+
+	if b := l.budget(c.Turn); l.Ponder != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+
+		go ponder(ctx, cancel, l.Ponder, b)
+	} else if b > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b)
+		defer cancel()
+	}
+
+	lines := l.MultiPV
+	if lines < 1 {
+		lines = 1
+	}
+
+	if lines > len(moves) {
+		lines = len(moves)
+	}
+
+	start := time.Now()
+	s := &search{ctx: ctx, eval: e.eval, tablebase: e.tablebase, limit: l.Nodes, nodes: new(int64), table: newTable(e.hash)}
+
+	// The helper threads are stopped once the first thread is done, as only its result is used
+	hctx, stop := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+
+	for i := 1; i < e.threads; i++ {
+		h := &search{ctx: hctx, eval: e.eval, tablebase: e.tablebase, limit: l.Nodes, nodes: s.nodes, table: s.table}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			h.help(c, moves, depth, i, start)
+		}(i)
+	}
+
+	var res Result
+	var last []Info
+
+	for d := 1; d <= depth; d++ {
+		// The first depth is searched without stopping, so that a move is always found
+		sctx := s.ctx
+		if d == 1 {
+			s.ctx = context.Background()
+		}
+
+		infos, err := s.lines(c, d, moves, lines, last, start)
+		s.ctx = sctx
+
+		if err != nil {
+			break
+		}
+
+		last = infos
+		res = Result{Move: infos[0].PV[0], Info: infos[0]}
+		if lines > 1 {
+			res.Lines = infos
+		}
+
+		if onInfo != nil {
+			for _, info := range infos {
+				onInfo(info)
+			}
+		}
+
+		// There is no point searching deeper once every line is a mate, or when there is only one move
+		mated := true
+		for _, info := range infos {
+			mated = mated && info.Score.IsMate()
+		}
+
+		if (mated || len(moves) == 1) && !l.Infinite {
+			break
+		}
+	}
+
+	if l.Infinite {
+		// An infinite search only returns once it is stopped, even after searching as deep as it can
+		<-ctx.Done()
+	} else if l.Ponder != nil {
+		// The move found while pondering is only played once the opponent has made the move expected
+		select {
+		case <-l.Ponder:
+		case <-ctx.Done():
+		}
+	}
+
+	stop()
+	wg.Wait()
+
+	return res, nil
+}
+
+// ponder waits for the ponderhit, then stops the search once the time budgeted for it has passed
+func ponder(ctx context.Context, cancel context.CancelFunc, hit <-chan struct{}, budget time.Duration) {
+	select {
+	case <-hit:
+	case <-ctx.Done():
+		return
+	}
+
+	if budget <= 0 {
+		return
+	}
+
+	t := time.NewTimer(budget)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		cancel()
+	case <-ctx.Done():
+	}
+}
+
+// help searches the position as a helper thread, filling the transposition table for the first thread. Every other
+// helper searches a depth ahead, so that the threads are not all searching the same depth.
+func (s *search) help(c chess.Chess, moves []move.Move, depth, thread int, start time.Time) {
+	var last []Info
+
+	for d := 1 + thread%2; d <= depth; d++ {
+		infos, err := s.lines(c, d, moves, 1, last, start)
+		if err != nil {
+			return
+		}
+
+		last = infos
+	}
+}
+
+// lines searches the position to the depth provided for the best n moves, each line searching the moves the lines
+// before it did not pick. The lines of the last depth, if any, are searched first.
+func (s *search) lines(c chess.Chess, depth int, moves []move.Move, n int, last []Info, start time.Time) ([]Info, error) {
+	infos := make([]Info, 0, n)
+	left := moves
+
+	for i := 0; i < n; i++ {
+		s.root = left
+		s.pv = nil
+		if i < len(last) {
+			s.pv = last[i].PV
+		}
+
+		var pv []move.Move
+
+		score, err := s.negamax(c, depth, 0, -Infinity, Infinity, &pv)
+		if err != nil {
+			return nil, err
+		}
+
+		infos = append(infos, Info{
+			MultiPV: i + 1,
+			Depth:   depth,
+			Score:   score,
+			Nodes:   atomic.LoadInt64(s.nodes),
+			Time:    time.Since(start),
+			PV:      pv,
+		})
+
+		left = without(left, pv[0])
+	}
+
+	return infos, nil
+}
+
+// negamax scores the position to the depth provided, filling pv with the best line found
+func (s *search) negamax(c chess.Chess, depth, ply int, alpha, beta Score, pv *[]move.Move) (Score, error) {
+	n := atomic.AddInt64(s.nodes, 1)
+
+	if s.limit > 0 && n > s.limit {
+		return 0, errStopped
+	}
+
+	if err := s.ctx.Err(); err != nil {
+		return 0, errStopped
+	}
+
+	if over, score := terminal(c, ply); over {
+		return score, nil
+	}
+
+	// The root is searched as usual so that there is a move to play, its moves are scored by the tablebase
+	if s.tablebase != nil && ply > 0 {
+		if r, err := s.tablebase.Probe(c); err == nil {
+			return tablebaseScore(r, ply), nil
+		}
+	}
+
+	if depth == 0 {
+		return s.eval(c), nil
+	}
+
+	// The root is not looked up, as its moves can be restricted and every line of it is wanted
+	var key uint64
+	var hashMove *move.Move
+
+	if ply > 0 {
+		key = polyglot.Key(c)
+
+		if e, ok := s.table.probe(key); ok {
+			if e.hasMove {
+				hashMove = &e.move
+			}
+
+			score := fromTable(e.score, ply)
+			if e.depth >= depth && (e.bound == exact || e.bound == lower && score >= beta || e.bound == upper && score <= alpha) {
+				*pv = nil
+				if hashMove != nil {
+					*pv = []move.Move{*hashMove}
+				}
+
+				return score, nil
+			}
+		}
+	}
+
+	var moves []move.Move
+	if ply == 0 {
+		moves = append(moves, s.root...)
+	} else {
+		moves = c.LegalMoves()
+	}
+
+	if len(moves) == 0 {
+		return noMoves(c, ply), nil
+	}
+
+	s.order(c, moves, ply, hashMove)
+
+	best, start := -Infinity, alpha
+
+	for _, m := range moves {
+		next := c.Copy()
+		if _, err := next.MakeMoveWithPromotion(m, piece.PieceTypeQueen); err != nil {
+			continue
+		}
+
+		var line []move.Move
+
+		score, err := s.negamax(next, depth-1, ply+1, -beta, -alpha, &line)
+		if err != nil {
+			return 0, err
+		}
+
+		score = -score
+
+		if score > best {
+			best = score
+			*pv = append([]move.Move{m}, line...)
+		}
+
+		if score > alpha {
+			alpha = score
+		}
+
+		if alpha >= beta {
+			break
+		}
+	}
+
+	if ply > 0 && len(*pv) > 0 {
+		e := entry{score: toTable(best, ply), depth: depth, bound: exact, move: (*pv)[0], hasMove: true}
+
+		switch {
+		case best >= beta:
+			e.bound = lower
+		case best <= start:
+			e.bound = upper
+		}
+
+		s.table.store(key, e)
+	}
+
+	return best, nil
+}
+
+// order sorts the moves so that the move of the last principal variation is searched first, then the best move of
+// the transposition table, followed by captures of the most valuable pieces by the least valuable attackers
+func (s *search) order(c chess.Chess, moves []move.Move, ply int, hashMove *move.Move) {
+	var pvMove *move.Move
+	if ply < len(s.pv) {
+		pvMove = &s.pv[ply]
+	}
+
+	key := func(m move.Move) Score {
+		if pvMove != nil && m == *pvMove {
+			return Infinity
+		}
+
+		if hashMove != nil && m == *hashMove {
+			return Infinity - 1
+		}
+
+		if v := capturedValue(c, m); v > 0 {
+			return v*10 - Value(c.Board.Pieces[m.From].GetPieceType())/10
+		}
+
+		return 0
+	}
+
+	sort.SliceStable(moves, func(i, j int) bool {
+		return key(moves[i]) > key(moves[j])
+	})
+}
+
+// restrict keeps the moves that are searched, all of them when there are no moves to search
+func restrict(moves, search []move.Move) []move.Move {
+	if len(search) == 0 {
+		return moves
+	}
+
+	var kept []move.Move
+	for _, m := range moves {
+		for _, s := range search {
+			if m == s {
+				kept = append(kept, m)
+				break
+			}
+		}
+	}
+
+	return kept
+}
+
+// without gets the moves other than the move provided
+func without(moves []move.Move, m move.Move) []move.Move {
+	kept := make([]move.Move, 0, len(moves))
+	for _, o := range moves {
+		if o != m {
+			kept = append(kept, o)
+		}
+	}
+
+	return kept
+}
+
+// filterRoot keeps the moves that lead to the best result in the tablebase, so that the search cannot throw away a
+// won or drawn position by misjudging it, nor put off mating or zeroing forever while winning. Every move is kept
+// unless the tablebase covers the positions after all of them.
+func filterRoot(c chess.Chess, moves []move.Move, tb tablebase.Prober) []move.Move {
+	results := make([]tablebase.Result, len(moves))
+	best := -1
+
+	for i, m := range moves {
+		r, err := tablebase.ProbeMove(tb, c, m, piece.PieceTypeQueen)
+		if err != nil {
+			return moves
+		}
+
+		results[i] = r
+		if best < 0 || r.Better(results[best]) {
+			best = i
+		}
+	}
+
+	var kept []move.Move
+	for i, m := range moves {
+		if !results[best].Better(results[i]) {
+			kept = append(kept, m)
+		}
+	}
+
+	return kept
+}
+
+// tablebaseScore gets the score of a result of the tablebase, mates are scored from the root like those found by
+// searching. Results without the distance to mate score as tablebase wins, which are not mates so the distance to
+// zeroing is taken off rather than the ply.
+func tablebaseScore(r tablebase.Result, ply int) Score {
+	switch {
+	case r.WDL == tablebase.Win && r.DTZ > 0:
+		return TablebaseWin - Score(r.DTZ)
+	case r.WDL == tablebase.Loss && r.DTZ > 0:
+		return -TablebaseWin + Score(r.DTZ)
+	case r.WDL == tablebase.Win:
+		return Mate - Score(ply+r.DTM)
+	case r.WDL == tablebase.Loss:
+		return -Mate + Score(ply+r.DTM)
+	default:
+		return 0
+	}
+}
+
+// terminal checks whether the game is already over in the position, as the colour to move has no pieces left
+func terminal(c chess.Chess, ply int) (bool, Score) {
+	for _, p := range c.Board.Pieces {
+		if p.Colour == c.Turn {
+			return false, 0
+		}
+	}
+
+	if !c.Board.Variant.HasRoyalKing() {
+		// Losing every piece wins antichess
+		return true, Mate - Score(ply)
+	}
+
+	return true, -Mate + Score(ply)
+}
+
+// noMoves scores a position where the colour to move cannot move: checkmate, stalemate or an antichess win
+func noMoves(c chess.Chess, ply int) Score {
+	if !c.Board.Variant.HasRoyalKing() {
+		return Mate - Score(ply)
+	}
+
+	if c.Board.InCheck(c.Turn) {
+		return -Mate + Score(ply)
+	}
+
+	return 0
+}
@@ -0,0 +1,144 @@
+package engine
+
+import (
+	"sync/atomic"
+
+	"github.com/tomwatson6/chessbot/internal/move"
+)
+
+// DefaultHash is the size in megabytes of the transposition table of a search when the engine does not set its own
+const DefaultHash = 4
+
+// bound is what the score of an entry says about the score of its position
+type bound uint64
+
+const (
+	exact bound = iota
+	// lower is a score the search failed high on, the position scores at least as much
+	lower
+	// upper is a score the search failed low on, the position scores at most as much
+	upper
+)
+
+// entry is what a search found for a position, the best move being the one that scored the most
+type entry struct {
+	score   Score
+	depth   int
+	bound   bound
+	move    move.Move
+	hasMove bool
+}
+
+// slot is an entry of the table packed into data, with check being the key of its position xored with data
+type slot struct {
+	check uint64
+	data  uint64
+}
+
+// table is a transposition table shared by the threads of a search without locks. As each slot is written as two
+// words, a slot torn by two threads writing at once fails the check of its key rather than giving the entry of one
+// position for another.
+type table struct {
+	slots []slot
+	mask  uint64
+}
+
+// newTable makes a table of the size in megabytes provided, rounded down to a power of two slots
+func newTable(mb int) *table {
+	if mb < 1 {
+		mb = DefaultHash
+	}
+
+	n := uint64(1)
+	for n*2*16 <= uint64(mb)<<20 {
+		n *= 2
+	}
+
+	return &table{slots: make([]slot, n), mask: n - 1}
+}
+
+func (t *table) probe(key uint64) (entry, bool) {
+	s := &t.slots[key&t.mask]
+
+	data := atomic.LoadUint64(&s.data)
+	if atomic.LoadUint64(&s.check)^data != key {
+		return entry{}, false
+	}
+
+	return unpack(data), true
+}
+
+// store keeps the entry unless the slot holds a deeper search of the same position
+func (t *table) store(key uint64, e entry) {
+	s := &t.slots[key&t.mask]
+
+	if data := atomic.LoadUint64(&s.data); atomic.LoadUint64(&s.check)^data == key && unpack(data).depth > e.depth {
+		return
+	}
+
+	data := pack(e)
+	atomic.StoreUint64(&s.data, data)
+	atomic.StoreUint64(&s.check, key^data)
+}
+
+// pack lays the entry out as the score in the low 32 bits, then 8 bits of depth, 2 of bound, and a bit saying
+// whether there is a move followed by the files and ranks of its squares
+func pack(e entry) uint64 {
+	depth := e.depth
+	if depth > 0xff {
+		depth = 0xff
+	}
+
+	data := uint64(uint32(int32(e.score))) | uint64(depth)<<32 | uint64(e.bound)<<40
+
+	if e.hasMove {
+		data |= 1<<42 |
+			uint64(e.move.From.File)<<43 | uint64(e.move.From.Rank)<<46 |
+			uint64(e.move.To.File)<<49 | uint64(e.move.To.Rank)<<52
+	}
+
+	return data
+}
+
+func unpack(data uint64) entry {
+	e := entry{
+		score:   Score(int32(uint32(data))),
+		depth:   int(data >> 32 & 0xff),
+		bound:   bound(data >> 40 & 0x3),
+		hasMove: data>>42&1 == 1,
+	}
+
+	if e.hasMove {
+		e.move = move.Move{
+			From: move.Position{File: int(data >> 43 & 0x7), Rank: int(data >> 46 & 0x7)},
+			To:   move.Position{File: int(data >> 49 & 0x7), Rank: int(data >> 52 & 0x7)},
+		}
+	}
+
+	return e
+}
+
+// toTable makes a mate score relative to the position it is stored for rather than the root, as the position can be
+// reached at other plies
+func toTable(s Score, ply int) Score {
+	switch {
+	case s > Mate-maxPly:
+		return s + Score(ply)
+	case s < -Mate+maxPly:
+		return s - Score(ply)
+	default:
+		return s
+	}
+}
+
+// fromTable makes a mate score stored in the table relative to the root again
+func fromTable(s Score, ply int) Score {
+	switch {
+	case s > Mate-maxPly:
+		return s - Score(ply)
+	case s < -Mate+maxPly:
+		return s + Score(ply)
+	default:
+		return s
+	}
+}
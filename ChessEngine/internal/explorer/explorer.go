@@ -0,0 +1,256 @@
+// Package explorer builds a tree of the moves played from each position of a collection of games, with how they
+// scored and the strength of the players that chose them
+package explorer
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/pgn"
+	"github.com/tomwatson6/chessbot/internal/polyglot"
+)
+
+// DefaultDepth is the number of half moves of each game added to the tree by default
+const DefaultDepth = 30
+
+// Results are the results of a set of games
+type Results struct {
+	Games     int `json:"games"`
+	WhiteWins int `json:"white"`
+	Draws     int `json:"draws"`
+	BlackWins int `json:"black"`
+}
+
+func (r *Results) add(result string) {
+	r.Games++
+
+	switch result {
+	case pgn.WhiteWins:
+		r.WhiteWins++
+	case pgn.BlackWins:
+		r.BlackWins++
+	default:
+		r.Draws++
+	}
+}
+
+// Score gets the points scored by white for each game, between 0 and 1
+func (r Results) Score() float64 {
+	if r.Games == 0 {
+		return 0
+	}
+
+	return (float64(r.WhiteWins) + float64(r.Draws)/2) / float64(r.Games)
+}
+
+// Move is a move played from a position of the tree, along with the results of the games it was played in
+type Move struct {
+	UCI string `json:"uci"`
+	SAN string `json:"san"`
+	Results
+
+	// Score is the points scored by white for each game
+	Score float64 `json:"score"`
+	// AverageRating is the mean rating of the players of the games with ratings, 0 if none had one
+	AverageRating int `json:"averageRating"`
+
+	ratingSum int
+	rated     int
+}
+
+// Position is a position of the tree and the moves played from it, most played first
+type Position struct {
+	FEN string `json:"fen"`
+	Results
+	Moves []Move `json:"moves"`
+}
+
+type node struct {
+	results Results
+	moves   map[string]*Move
+}
+
+// Tree is the moves played from each position of a collection of games, found by the hash of the position so that
+// transpositions share their moves. It is safe for concurrent use, so it can be queried while games are added.
+type Tree struct {
+	depth int
+
+	mu    sync.RWMutex
+	nodes map[uint64]*node
+	games int
+}
+
+type Option func(t *Tree)
+
+// WithDepth sets the number of half moves of each game added to the tree
+func WithDepth(n int) Option {
+	return func(t *Tree) {
+		t.depth = n
+	}
+}
+
+func New(opts ...Option) *Tree {
+	t := &Tree{
+		depth: DefaultDepth,
+		nodes: make(map[uint64]*node),
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+// Add adds the moves of the game to the tree. Games without a result are skipped, and a game with a move that
+// cannot be read adds nothing.
+func (t *Tree) Add(g pgn.Game) error {
+	result := g.Result()
+	if result == pgn.Unfinished {
+		return nil
+	}
+
+	fen := g.Tag("FEN")
+	if fen == "" {
+		fen = chess.StartingFEN
+	}
+
+	c, err := chess.FromFEN(fen)
+	if err != nil {
+		return err
+	}
+
+	var ratings []int
+	for _, tag := range []string{"WhiteElo", "BlackElo"} {
+		if r, err := strconv.Atoi(g.Tag(tag)); err == nil && r > 0 {
+			ratings = append(ratings, r)
+		}
+	}
+
+	type played struct {
+		key      uint64
+		uci, san string
+	}
+
+	var moves []played
+
+	for i, pm := range g.Moves {
+		if i >= t.depth {
+			break
+		}
+
+		m, promotion, err := c.ParseSAN(pm.SAN)
+		if err != nil {
+			return fmt.Errorf("move %d: %w", i/2+1, err)
+		}
+
+		san, err := c.SAN(m, promotion)
+		if err != nil {
+			return fmt.Errorf("move %d: %w", i/2+1, err)
+		}
+
+		moves = append(moves, played{key: polyglot.Key(c), uci: c.UCI(m, promotion), san: san})
+
+		if _, err := c.MakeMoveWithPromotion(m, promotion); err != nil {
+			return fmt.Errorf("move %d: %w", i/2+1, err)
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, p := range moves {
+		n := t.nodes[p.key]
+		if n == nil {
+			n = &node{moves: make(map[string]*Move)}
+			t.nodes[p.key] = n
+		}
+
+		n.results.add(result)
+
+		mv := n.moves[p.uci]
+		if mv == nil {
+			mv = &Move{UCI: p.uci, SAN: p.san}
+			n.moves[p.uci] = mv
+		}
+
+		mv.Results.add(result)
+
+		for _, r := range ratings {
+			mv.ratingSum += r
+			mv.rated++
+		}
+	}
+
+	t.games++
+
+	return nil
+}
+
+// AddAll adds every game read from the collection, returning the number of games that could not be added
+func (t *Tree) AddAll(r *pgn.Reader) (int, error) {
+	var skipped int
+
+	for {
+		g, err := r.Read()
+		if err == io.EOF {
+			return skipped, nil
+		}
+
+		if err != nil {
+			return skipped, err
+		}
+
+		if err := t.Add(g); err != nil {
+			skipped++
+		}
+	}
+}
+
+// Games gets the number of games added to the tree
+func (t *Tree) Games() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.games
+}
+
+// Lookup gets the moves played from the position, which has no moves if it is not in the tree
+func (t *Tree) Lookup(c chess.Chess) Position {
+	p := Position{FEN: c.FEN(), Moves: []Move{}}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	n, ok := t.nodes[polyglot.Key(c)]
+	if !ok {
+		return p
+	}
+
+	p.Results = n.results
+
+	for _, m := range n.moves {
+		mv := *m
+		mv.Score = m.Results.Score()
+
+		if m.rated > 0 {
+			mv.AverageRating = (m.ratingSum + m.rated/2) / m.rated
+		}
+
+		p.Moves = append(p.Moves, mv)
+	}
+
+	sort.Slice(p.Moves, func(i, j int) bool {
+		if p.Moves[i].Games != p.Moves[j].Games {
+			return p.Moves[i].Games > p.Moves[j].Games
+		}
+
+		return p.Moves[i].UCI < p.Moves[j].UCI
+	})
+
+	return p
+}
@@ -0,0 +1,60 @@
+package explorer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/explorer"
+	"github.com/tomwatson6/chessbot/internal/pgn"
+)
+
+const archive = `[WhiteElo "2000"] [BlackElo "1800"] [Result "1-0"] 1. e4 e5 2. Nf3 1-0
+[WhiteElo "2200"] [BlackElo "2000"] [Result "1/2-1/2"] 1. e4 c5 1/2-1/2
+[Result "0-1"] 1. d4 d5 0-1
+[Result "*"] 1. c4 *
+[Result "1-0"] 1. e4 Ke7 1-0
+`
+
+func TestLookup(t *testing.T) {
+	tree := explorer.New(explorer.WithDepth(2))
+
+	skipped, err := tree.AddAll(pgn.NewReader(strings.NewReader(archive)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The unfinished game is ignored rather than skipped, the last game has an illegal move
+	if skipped != 1 || tree.Games() != 3 {
+		t.Fatalf("AddAll() added %d games and skipped %d, want 3 and 1", tree.Games(), skipped)
+	}
+
+	start, err := chess.FromFEN(chess.StartingFEN)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := tree.Lookup(start)
+	if p.Games != 3 || p.WhiteWins != 1 || p.Draws != 1 || p.BlackWins != 1 || len(p.Moves) != 2 {
+		t.Fatalf("Lookup(start) = %+v", p)
+	}
+
+	e4 := p.Moves[0]
+	if e4.SAN != "e4" || e4.UCI != "e2e4" || e4.Games != 2 || e4.Score != 0.75 || e4.AverageRating != 2000 {
+		t.Errorf("most played move = %+v, want e4 in 2 games scoring 0.75 with average rating 2000", e4)
+	}
+
+	if d4 := p.Moves[1]; d4.SAN != "d4" || d4.Score != 0 || d4.AverageRating != 0 {
+		t.Errorf("second move = %+v, want d4 scoring 0 without ratings", d4)
+	}
+
+	// The depth of the tree leaves out white's second move
+	c, err := chess.FromFEN("rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p := tree.Lookup(c); p.Games != 0 || len(p.Moves) != 0 {
+		t.Errorf("Lookup() past the depth of the tree = %+v", p)
+	}
+}
@@ -0,0 +1,83 @@
+package game
+
+import (
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/colour"
+)
+
+// Clock keeps the time remaining for each colour, it starts running once the first move has been made
+type Clock struct {
+	Initial   time.Duration
+	Increment time.Duration
+	remaining map[colour.Colour]time.Duration
+	running   bool
+	started   time.Time
+}
+
+func NewClock(initial, increment time.Duration) *Clock {
+	return &Clock{
+		Initial:   initial,
+		Increment: increment,
+		remaining: map[colour.Colour]time.Duration{
+			colour.White: initial,
+			colour.Black: initial,
+		},
+	}
+}
+
+// Remaining gets the time left for the colour provided at the time provided, when it is their turn
+func (c *Clock) Remaining(col, turn colour.Colour, now time.Time) time.Duration {
+	r := c.remaining[col]
+
+	if c.running && col == turn {
+		r -= now.Sub(c.started)
+	}
+
+	return r
+}
+
+// punch stops the clock of the colour that has moved, returning false if they had run out of time
+func (c *Clock) punch(col colour.Colour, now time.Time) bool {
+	if c.running {
+		c.remaining[col] -= now.Sub(c.started)
+
+		if c.remaining[col] <= 0 {
+			c.remaining[col] = 0
+			return false
+		}
+
+		c.remaining[col] += c.Increment
+	}
+
+	c.running = true
+	c.started = now
+
+	return true
+}
+
+func (c *Clock) stop(turn colour.Colour, now time.Time) {
+	if !c.running {
+		return
+	}
+
+	c.remaining[turn] = c.Remaining(turn, turn, now)
+	if c.remaining[turn] < 0 {
+		c.remaining[turn] = 0
+	}
+
+	c.running = false
+}
+
+func (c *Clock) event(turn colour.Colour, now time.Time) ClockEvent {
+	e := ClockEvent{
+		White: durationToMillis(c.Remaining(colour.White, turn, now)),
+		Black: durationToMillis(c.Remaining(colour.Black, turn, now)),
+	}
+
+	if c.running {
+		e.Running = turn.String()
+	}
+
+	return e
+}
@@ -0,0 +1,265 @@
+package game
+
+import (
+	"errors"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/storage"
+)
+
+var (
+	// ErrorPremoveOnTurn is thrown when setting premoves for the colour whose turn it is, who should move instead
+	ErrorPremoveOnTurn = errors.New("premoves can only be set while waiting for the opponent to move")
+	// ErrorInvalidPremove is thrown when a premove line is not made of pairs of an opponent move and a reply
+	ErrorInvalidPremove = errors.New("premove lines must be pairs of an opponent move followed by a reply")
+)
+
+// Correspondence is the time control of a game played over days, each move must be made within the time per move.
+// A player that goes over the time per move uses their vacation, and loses on time once it has run out.
+type Correspondence struct {
+	PerMove  time.Duration
+	Vacation time.Duration
+
+	vacationLeft map[colour.Colour]time.Duration
+	turnStarted  time.Time
+}
+
+func NewCorrespondence(perMove, vacation time.Duration) *Correspondence {
+	return &Correspondence{
+		PerMove:  perMove,
+		Vacation: vacation,
+		vacationLeft: map[colour.Colour]time.Duration{
+			colour.White: vacation,
+			colour.Black: vacation,
+		},
+	}
+}
+
+// Deadline gets the time the colour provided must move by, when it is their turn
+func (c *Correspondence) Deadline(col colour.Colour) time.Time {
+	return c.turnStarted.Add(c.PerMove + c.vacationLeft[col])
+}
+
+// VacationLeft gets the vacation the colour provided has not used, not including the move being thought about
+func (c *Correspondence) VacationLeft(col colour.Colour) time.Duration {
+	return c.vacationLeft[col]
+}
+
+// punch uses the vacation of the colour that has moved if they went over the time per move
+func (c *Correspondence) punch(col colour.Colour, now time.Time) {
+	if over := now.Sub(c.turnStarted) - c.PerMove; over > 0 {
+		c.vacationLeft[col] -= over
+
+		if c.vacationLeft[col] < 0 {
+			c.vacationLeft[col] = 0
+		}
+	}
+
+	c.turnStarted = now
+}
+
+type CorrespondenceEvent struct {
+	Deadline time.Time `json:"deadline"`
+	Turn     string    `json:"turn"`
+	Vacation struct {
+		White int64 `json:"white"`
+		Black int64 `json:"black"`
+	} `json:"vacation"`
+}
+
+func (c *Correspondence) event(turn colour.Colour) CorrespondenceEvent {
+	e := CorrespondenceEvent{
+		Deadline: c.Deadline(turn),
+		Turn:     turn.String(),
+	}
+
+	e.Vacation.White = durationToMillis(c.vacationLeft[colour.White])
+	e.Vacation.Black = durationToMillis(c.vacationLeft[colour.Black])
+
+	return e
+}
+
+// WithCorrespondence plays the game with the time per move provided, each player can go over it by the vacation provided
+func WithCorrespondence(perMove, vacation time.Duration) Option {
+	return func(g *Game) {
+		g.correspondence = NewCorrespondence(perMove, vacation)
+	}
+}
+
+// WithPlayers sets the names of the players of each colour
+func WithPlayers(white, black string) Option {
+	return func(g *Game) {
+		g.players = map[colour.Colour]string{
+			colour.White: white,
+			colour.Black: black,
+		}
+	}
+}
+
+// Player gets the name of the player of the colour provided
+func (g *Game) Player(c colour.Colour) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.players[c]
+}
+
+// IsPlayer reports whether the player provided holds either colour in the game
+func (g *Game) IsPlayer(player string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return player != "" && (g.players[colour.White] == player || g.players[colour.Black] == player)
+}
+
+// Authorise checks that the player provided holds the colour provided. Nobody may act for the colour of the bot, and
+// once either colour has a player only the player holding a colour may act for it. Anyone may act in a game without
+// players.
+func (g *Game) Authorise(player string, c colour.Colour) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.authorise(player, c)
+}
+
+func (g *Game) authorise(player string, c colour.Colour) error {
+	if g.bot != nil && g.bot.Colour == c {
+		return ErrorNotPlayer
+	}
+
+	named := g.players[colour.White] != "" || g.players[colour.Black] != ""
+	if named && (player == "" || g.players[c] != player) {
+		return ErrorNotPlayer
+	}
+
+	return nil
+}
+
+// MoveAs makes the move on behalf of the player provided, who must hold the colour whose turn it is
+func (g *Game) MoveAs(player string, m move.Move) ([]move.Move, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.authorise(player, g.chess.Turn); err != nil {
+		return []move.Move{}, err
+	}
+
+	return g.move(m)
+}
+
+// AwaitingMove reports whether the game is waiting for the player provided to move
+func (g *Game) AwaitingMove(player string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return player != "" && !g.status.IsOver() && g.players[g.chess.Turn] == player
+}
+
+// SetPremoves sets the conditional moves of the colour provided, replacing any already set. Each line is pairs
+// of a move the opponent might make followed by the reply to play automatically if they do.
+func (g *Game) SetPremoves(c colour.Colour, lines [][]move.Move) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.status.IsOver() {
+		return ErrorGameOver
+	}
+
+	if g.chess.Turn == c {
+		return ErrorPremoveOnTurn
+	}
+
+	for _, l := range lines {
+		if len(l) == 0 || len(l)%2 != 0 {
+			return ErrorInvalidPremove
+		}
+	}
+
+	return g.savePremoves(c, lines)
+}
+
+// Premoves gets the conditional moves of the colour provided
+func (g *Game) Premoves(c colour.Colour) [][]move.Move {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return copyLines(g.premoves[c])
+}
+
+// playPremove replies to the move just made by the opponent of the colour to move, if a premove line expects it.
+// Lines that do not start with the move made are dropped as the opponent has gone another way.
+func (g *Game) playPremove(made move.Move) {
+	c := g.chess.Turn
+
+	lines := g.premoves[c]
+	if len(lines) == 0 {
+		return
+	}
+
+	var reply *move.Move
+	var remaining [][]move.Move
+
+	for _, l := range lines {
+		if l[0] != made {
+			continue
+		}
+
+		if reply == nil {
+			r := l[1]
+			reply = &r
+		}
+
+		if l[1] == *reply && len(l) > 2 {
+			remaining = append(remaining, l[2:])
+		}
+	}
+
+	if err := g.savePremoves(c, remaining); err != nil {
+		return
+	}
+
+	if reply != nil {
+		// A premove that has become illegal is dropped, leaving the player to move themselves
+		if _, err := g.move(*reply); err != nil {
+			_ = g.savePremoves(c, nil)
+		}
+	}
+}
+
+func (g *Game) savePremoves(c colour.Colour, lines [][]move.Move) error {
+	lines = copyLines(lines)
+
+	if g.store != nil {
+		if err := g.store.SetPremoves(g.ID, c, lines); err != nil {
+			return err
+		}
+	}
+
+	if g.premoves == nil {
+		g.premoves = make(map[colour.Colour][][]move.Move)
+	}
+
+	g.premoves[c] = lines
+
+	return nil
+}
+
+func copyLines(lines [][]move.Move) [][]move.Move {
+	if lines == nil {
+		return nil
+	}
+
+	c := make([][]move.Move, len(lines))
+	for i, l := range lines {
+		c[i] = append([]move.Move{}, l...)
+	}
+
+	return c
+}
+
+// correspondenceRecord gets the stored settings of the correspondence time control
+func (c *Correspondence) record() *storage.Correspondence {
+	return &storage.Correspondence{PerMove: c.PerMove, Vacation: c.Vacation}
+}
@@ -0,0 +1,194 @@
+package game
+
+import (
+	"math"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+)
+
+type EventType string
+
+const (
+	EventMove      EventType = "move"
+	EventCheck     EventType = "check"
+	EventGameOver  EventType = "game-over"
+	EventClock     EventType = "clock"
+	EventDeadline  EventType = "deadline"
+	EventDrawOffer EventType = "draw-offer"
+)
+
+// Event is something that has happened in a game, the ID increases with each event published for the game
+type Event struct {
+	ID   int       `json:"id"`
+	Type EventType `json:"type"`
+	Data any       `json:"data"`
+}
+
+type MoveEvent struct {
+	Colour string      `json:"colour"`
+	Moves  []move.Move `json:"moves"`
+	Turn   string      `json:"turn"`
+}
+
+type CheckEvent struct {
+	Colour   string        `json:"colour"`
+	King     move.Position `json:"king"`
+	Attacker move.Position `json:"attacker"`
+}
+
+type StatusEvent struct {
+	Status string `json:"status"`
+	Winner string `json:"winner,omitempty"`
+}
+
+type ClockEvent struct {
+	White   int64  `json:"white"`
+	Black   int64  `json:"black"`
+	Running string `json:"running,omitempty"`
+}
+
+// The states of a draw offer
+const (
+	drawOffered  = "offered"
+	drawDeclined = "declined"
+	drawAccepted = "accepted"
+)
+
+type DrawOfferEvent struct {
+	Colour string `json:"colour"`
+	State  string `json:"state"`
+}
+
+const subscriberBuffer = 64
+
+// Subscribe registers for events published by the game, the returned func must be called to unsubscribe.
+// The channel is closed if the subscriber falls too far behind, or when unsubscribing.
+func (g *Game) Subscribe() (<-chan Event, func()) {
+	_, ch, unsubscribe := g.SubscribeFrom(math.MaxInt)
+	return ch, unsubscribe
+}
+
+// SubscribeFrom registers for events like Subscribe, also returning the events already published after the
+// event ID provided so a client can resume from the last event it saw without missing any in between
+func (g *Game) SubscribeFrom(lastID int) ([]Event, <-chan Event, func()) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	missed := g.eventsAfter(lastID)
+
+	id := g.nextSubscriber
+	g.nextSubscriber++
+
+	ch := make(chan Event, subscriberBuffer)
+	g.subscribers[id] = ch
+
+	return missed, ch, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		if ch, ok := g.subscribers[id]; ok {
+			close(ch)
+			delete(g.subscribers, id)
+		}
+	}
+}
+
+// History returns all of the events published by the game so far
+func (g *Game) History() []Event {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.eventsAfter(0)
+}
+
+func (g *Game) eventsAfter(lastID int) []Event {
+	if lastID < 0 {
+		lastID = 0
+	}
+
+	if lastID >= len(g.events) {
+		return []Event{}
+	}
+
+	return append([]Event{}, g.events[lastID:]...)
+}
+
+// publish sends an event to all subscribers, g.mu must be held by the caller
+func (g *Game) publish(t EventType, data any) {
+	e := Event{
+		ID:   len(g.events) + 1,
+		Type: t,
+		Data: data,
+	}
+
+	g.events = append(g.events, e)
+
+	for id, ch := range g.subscribers {
+		select {
+		case ch <- e:
+		default:
+			// Drop subscribers that are not keeping up rather than blocking the game
+			close(ch)
+			delete(g.subscribers, id)
+		}
+	}
+}
+
+func (g *Game) publishStatus() {
+	e := StatusEvent{Status: g.status.String()}
+
+	if g.winner != nil {
+		e.Winner = g.winner.String()
+	}
+
+	g.publish(EventGameOver, e)
+}
+
+// publishCheck publishes a check event if the colour provided is in check
+func (g *Game) publishCheck(c colour.Colour) {
+	attacker, check, err := g.chess.Board.IsCheck(c)
+	if err != nil || !check {
+		return
+	}
+
+	k, err := g.chess.Board.GetKing(c)
+	if err != nil {
+		return
+	}
+
+	g.publish(EventCheck, CheckEvent{
+		Colour:   c.String(),
+		King:     k.Position,
+		Attacker: attacker.Position,
+	})
+}
+
+func (g *Game) publishClock() {
+	if g.clock == nil {
+		return
+	}
+
+	g.publish(EventClock, g.clock.event(g.chess.Turn, g.now()))
+}
+
+func (g *Game) publishCorrespondence() {
+	if g.correspondence == nil {
+		return
+	}
+
+	g.publish(EventDeadline, g.correspondence.event(g.chess.Turn))
+}
+
+func durationToMillis(d time.Duration) int64 {
+	return int64(d / time.Millisecond)
+}
+
+func colourString(c *colour.Colour) string {
+	if c == nil {
+		return ""
+	}
+
+	return c.String()
+}
@@ -0,0 +1,580 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/storage"
+)
+
+var (
+	// ErrorGameOver is thrown when an action is attempted on a game that has finished
+	ErrorGameOver = errors.New("the game is over")
+	// ErrorNoDrawOffer is thrown when responding to a draw offer that has not been made by the opponent
+	ErrorNoDrawOffer = errors.New("there is no draw offer from the opponent to respond to")
+	// ErrorNotPlayer is thrown when acting for a colour that is held by another player
+	ErrorNotPlayer = errors.New("the colour is held by another player")
+)
+
+// Game is a game of chess being played on the server, safe for concurrent use
+type Game struct {
+	ID string
+
+	mu        sync.Mutex
+	chess     chess.Chess
+	start     chess.Chess
+	moves     []move.Move
+	status    Status
+	winner    *colour.Colour
+	drawOffer *colour.Colour
+	clock     *Clock
+	flag      *time.Timer
+	now       func() time.Time
+	store     storage.Store
+
+	correspondence *Correspondence
+	players        map[colour.Colour]string
+	bot            *Bot
+	premoves       map[colour.Colour][][]move.Move
+	replaying      bool
+	onFinish       func(Outcome)
+
+	events         []Event
+	subscribers    map[int]chan Event
+	nextSubscriber int
+}
+
+type Option func(g *Game)
+
+// Outcome is how a game finished, along with who played it and its time control. The bot of a game goes by its name.
+type Outcome struct {
+	ID             string
+	White          string
+	Black          string
+	Status         Status
+	Winner         *colour.Colour
+	Initial        time.Duration
+	Increment      time.Duration
+	Correspondence bool
+}
+
+// WithFinishHook calls the func provided once the game is over, while the game is locked so it must not call
+// back into the game. It is not called for games that had already finished when they were restored.
+func WithFinishHook(fn func(Outcome)) Option {
+	return func(g *Game) {
+		g.onFinish = fn
+	}
+}
+
+// WithClock plays the game with a clock of the initial time provided, adding the increment after each move
+func WithClock(initial, increment time.Duration) Option {
+	return func(g *Game) {
+		g.clock = NewClock(initial, increment)
+	}
+}
+
+// Bot is the engine playing a colour of the game, kept with the game so that the bot is started again when the game
+// is restored. Depth is how deep it searches, 0 for the depth of the engine, and Ponder whether it thinks on the time
+// of its opponent.
+type Bot struct {
+	Colour colour.Colour
+	Depth  int
+	Ponder bool
+}
+
+// Name gets the name the bot is rated under, bots searching to the same depth sharing a rating. It cannot be taken
+// by a player, as names of players have no colons.
+func (b Bot) Name() string {
+	if b.Depth == 0 {
+		return "bot:default"
+	}
+
+	return fmt.Sprintf("bot:depth-%d", b.Depth)
+}
+
+// WithBot records the engine playing a colour of the game, the bot itself is started by the caller
+func WithBot(b Bot) Option {
+	return func(g *Game) {
+		g.bot = &b
+	}
+}
+
+// Bot gets the engine playing a colour of the game, false if no bot plays in it
+func (g *Game) Bot() (Bot, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.bot == nil {
+		return Bot{}, false
+	}
+
+	return *g.bot, true
+}
+
+// WithTimeSource overrides the time used by the clock of the game
+func WithTimeSource(now func() time.Time) Option {
+	return func(g *Game) {
+		g.now = now
+	}
+}
+
+func New(id string, c chess.Chess, opts ...Option) *Game {
+	g := &Game{
+		ID:          id,
+		chess:       c,
+		now:         time.Now,
+		subscribers: make(map[int]chan Event),
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	g.start = c.Copy()
+
+	if g.correspondence != nil {
+		g.correspondence.turnStarted = g.now()
+		g.startFlagTimer()
+	}
+
+	return g
+}
+
+// Chess gets a copy of the current state of the game
+func (g *Game) Chess() chess.Chess {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.chess.Copy()
+}
+
+// Moves gets a copy of the position the game started from along with the moves made since
+func (g *Game) Moves() (chess.Chess, []move.Move) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.start.Copy(), append([]move.Move{}, g.moves...)
+}
+
+func (g *Game) Status() Status {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.status
+}
+
+// Winner gets the colour that won the game, nil if the game is ongoing or drawn
+func (g *Game) Winner() *colour.Colour {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.winner
+}
+
+// TimeLeft gets the time the colour provided has left on the clock and the increment they get for each move, false
+// if the game has no clock
+func (g *Game) TimeLeft(c colour.Colour) (time.Duration, time.Duration, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.clock == nil {
+		return 0, 0, false
+	}
+
+	return g.clock.Remaining(c, g.chess.Turn, g.now()), g.clock.Increment, true
+}
+
+// Move makes the move for the colour whose turn it is, publishing the events that result from it
+func (g *Game) Move(m move.Move) ([]move.Move, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.move(m)
+}
+
+// move makes the move, g.mu must be held by the caller
+func (g *Game) move(m move.Move) ([]move.Move, error) {
+	if g.status.IsOver() {
+		return []move.Move{}, ErrorGameOver
+	}
+
+	mover := g.chess.Turn
+
+	if left, timed := g.timeLeft(mover); timed && left <= 0 {
+		g.flagged(mover)
+		return []move.Move{}, ErrorGameOver
+	}
+
+	next := g.chess.Copy()
+
+	moves, err := next.MakeMove(m)
+	if err != nil {
+		return moves, err
+	}
+
+	if g.store != nil {
+		if err := g.store.AppendMove(g.ID, storage.Move{Move: m, At: g.now()}); err != nil {
+			return []move.Move{}, fmt.Errorf("failed to save move: %w", err)
+		}
+	}
+
+	g.chess = next
+	g.moves = append(g.moves, m)
+
+	g.publish(EventMove, MoveEvent{
+		Colour: mover.String(),
+		Moves:  moves,
+		Turn:   g.chess.Turn.String(),
+	})
+
+	g.publishCheck(g.chess.Turn)
+
+	// A draw offer stands until the opponent has moved
+	if g.drawOffer != nil && *g.drawOffer != mover {
+		g.drawOffer = nil
+	}
+
+	if g.clock != nil {
+		g.clock.punch(mover, g.now())
+		g.publishClock()
+	}
+
+	if g.correspondence != nil {
+		g.correspondence.punch(mover, g.now())
+		g.publishCorrespondence()
+	}
+
+	g.updateStatus(mover)
+
+	if g.status.IsOver() {
+		g.finish()
+	} else {
+		g.startFlagTimer()
+		g.playPremove(m)
+	}
+
+	return moves, nil
+}
+
+// Resign ends the game with the opponent of the colour provided as the winner
+func (g *Game) Resign(c colour.Colour) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.status.IsOver() {
+		return ErrorGameOver
+	}
+
+	winner := c.Opposite()
+	g.status = StatusResigned
+	g.winner = &winner
+	g.finish()
+	g.publishStatus()
+
+	return nil
+}
+
+// Abort ends the game without a result
+func (g *Game) Abort() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.status.IsOver() {
+		return ErrorGameOver
+	}
+
+	g.status = StatusAborted
+	g.finish()
+	g.publishStatus()
+
+	return nil
+}
+
+// OfferDraw offers a draw to the opponent of the colour provided, accepting any offer already made by the opponent
+func (g *Game) OfferDraw(c colour.Colour) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.status.IsOver() {
+		return ErrorGameOver
+	}
+
+	if g.drawOffer != nil && *g.drawOffer != c {
+		return g.acceptDraw(c)
+	}
+
+	if err := g.saveDrawOffer(c, drawOffered); err != nil {
+		return err
+	}
+
+	g.drawOffer = &c
+	g.publish(EventDrawOffer, DrawOfferEvent{Colour: c.String(), State: drawOffered})
+
+	return nil
+}
+
+// AcceptDraw accepts the draw offered by the opponent of the colour provided
+func (g *Game) AcceptDraw(c colour.Colour) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.status.IsOver() {
+		return ErrorGameOver
+	}
+
+	if g.drawOffer == nil || *g.drawOffer == c {
+		return ErrorNoDrawOffer
+	}
+
+	return g.acceptDraw(c)
+}
+
+// DeclineDraw declines the draw offered by the opponent of the colour provided
+func (g *Game) DeclineDraw(c colour.Colour) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.status.IsOver() {
+		return ErrorGameOver
+	}
+
+	if g.drawOffer == nil || *g.drawOffer == c {
+		return ErrorNoDrawOffer
+	}
+
+	if err := g.saveDrawOffer(c, drawDeclined); err != nil {
+		return err
+	}
+
+	g.drawOffer = nil
+	g.publish(EventDrawOffer, DrawOfferEvent{Colour: c.String(), State: drawDeclined})
+
+	return nil
+}
+
+func (g *Game) acceptDraw(c colour.Colour) error {
+	if err := g.saveDrawOffer(c, drawAccepted); err != nil {
+		return err
+	}
+
+	g.drawOffer = nil
+	g.status = StatusDrawAgreed
+	g.finish()
+	g.publish(EventDrawOffer, DrawOfferEvent{Colour: c.String(), State: drawAccepted})
+	g.publishStatus()
+
+	return nil
+}
+
+// saveDrawOffer saves the draw offer with the moves of the game, so that its event keeps its place in the events
+// published when the game is restored. g.mu must be held by the caller.
+func (g *Game) saveDrawOffer(c colour.Colour, state string) error {
+	if g.store == nil {
+		return nil
+	}
+
+	d := storage.DrawOffer{Colour: c, State: state, Ply: len(g.moves), At: g.now()}
+	if err := g.store.AppendDrawOffer(g.ID, d); err != nil {
+		return fmt.Errorf("failed to save draw offer: %w", err)
+	}
+
+	return nil
+}
+
+// updateStatus checks whether the move made by the colour provided has ended the game
+func (g *Game) updateStatus(mover colour.Colour) {
+	b := g.chess.Board
+	opp := mover.Opposite()
+
+	for _, c := range []colour.Colour{mover, opp} {
+		won, err := b.HasWon(c)
+		if err != nil || !won {
+			continue
+		}
+
+		winner := c
+		g.winner = &winner
+		g.status = StatusVariantWin
+
+		if b.Variant.HasRoyalKing() {
+			if mate, err := b.IsCheckMate(c.Opposite()); err == nil && mate {
+				g.status = StatusCheckmate
+			}
+		}
+
+		g.publishStatus()
+
+		return
+	}
+
+	if b.Variant.HasRoyalKing() && len(b.GetValidMovesForColour(opp)) == 0 {
+		g.status = StatusStalemate
+		g.publishStatus()
+	}
+}
+
+// timeLeft gets how long the colour to move has left, false if their time is not running
+func (g *Game) timeLeft(turn colour.Colour) (time.Duration, bool) {
+	var left time.Duration
+	timed := false
+
+	if g.clock != nil && g.clock.running {
+		left = g.clock.Remaining(turn, turn, g.now())
+		timed = true
+	}
+
+	if g.correspondence != nil {
+		if l := g.correspondence.Deadline(turn).Sub(g.now()); !timed || l < left {
+			left = l
+		}
+
+		timed = true
+	}
+
+	return left, timed
+}
+
+// startFlagTimer claims a win on time automatically once the colour to move runs out of time
+func (g *Game) startFlagTimer() {
+	if g.replaying {
+		return
+	}
+
+	turn := g.chess.Turn
+
+	left, timed := g.timeLeft(turn)
+	if !timed {
+		return
+	}
+
+	if g.flag != nil {
+		g.flag.Stop()
+	}
+
+	g.flag = time.AfterFunc(left, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		if g.status.IsOver() || g.chess.Turn != turn {
+			return
+		}
+
+		if left, _ := g.timeLeft(turn); left <= 0 {
+			g.flagged(turn)
+		}
+	})
+}
+
+// flagged ends the game as the colour provided has run out of time
+func (g *Game) flagged(c colour.Colour) {
+	winner := c.Opposite()
+	g.status = StatusTimeout
+	g.winner = &winner
+	g.finish()
+	g.publishClock()
+	g.publishStatus()
+}
+
+// finish stops the clock once the game is over
+func (g *Game) finish() {
+	if g.flag != nil {
+		g.flag.Stop()
+		g.flag = nil
+	}
+
+	if g.clock != nil {
+		g.clock.stop(g.chess.Turn, g.now())
+	}
+
+	g.premoves = nil
+
+	if g.onFinish != nil && !g.replaying {
+		g.onFinish(g.outcome())
+	}
+
+	if g.store != nil {
+		r := storage.Result{Status: g.status.String(), Winner: g.winner, At: g.now()}
+
+		if err := g.store.Finish(g.ID, r); err != nil {
+			log.Printf("Failed to save the result of game %s with error: %s\n", g.ID, err)
+		}
+	}
+}
+
+func (g *Game) outcome() Outcome {
+	o := Outcome{
+		ID:             g.ID,
+		White:          g.players[colour.White],
+		Black:          g.players[colour.Black],
+		Status:         g.status,
+		Winner:         g.winner,
+		Correspondence: g.correspondence != nil,
+	}
+
+	if g.bot != nil {
+		if g.bot.Colour == colour.White {
+			o.White = g.bot.Name()
+		} else {
+			o.Black = g.bot.Name()
+		}
+	}
+
+	if g.clock != nil {
+		o.Initial = g.clock.Initial
+		o.Increment = g.clock.Increment
+	}
+
+	return o
+}
+
+func (g *Game) MarshalJSON() ([]byte, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var clock *ClockEvent
+	if g.clock != nil {
+		e := g.clock.event(g.chess.Turn, g.now())
+		clock = &e
+	}
+
+	var correspondence *CorrespondenceEvent
+	if g.correspondence != nil {
+		e := g.correspondence.event(g.chess.Turn)
+		correspondence = &e
+	}
+
+	var players map[string]string
+	if g.players != nil {
+		players = map[string]string{
+			colour.White.String(): g.players[colour.White],
+			colour.Black.String(): g.players[colour.Black],
+		}
+	}
+
+	return json.Marshal(struct {
+		ID             string               `json:"id"`
+		Status         string               `json:"status"`
+		Winner         string               `json:"winner,omitempty"`
+		DrawOffer      string               `json:"drawOffer,omitempty"`
+		Players        map[string]string    `json:"players,omitempty"`
+		Clock          *ClockEvent          `json:"clock,omitempty"`
+		Correspondence *CorrespondenceEvent `json:"correspondence,omitempty"`
+		Chess          chess.Chess          `json:"chess"`
+	}{
+		ID:             g.ID,
+		Status:         g.status.String(),
+		Winner:         colourString(g.winner),
+		DrawOffer:      colourString(g.drawOffer),
+		Players:        players,
+		Clock:          clock,
+		Correspondence: correspondence,
+		Chess:          g.chess,
+	})
+}
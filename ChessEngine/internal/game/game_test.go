@@ -0,0 +1,652 @@
+package game_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/game"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/storage"
+)
+
+func mv(fromFile, fromRank, toFile, toRank int) move.Move {
+	return move.Move{
+		From: move.Position{File: fromFile, Rank: fromRank},
+		To:   move.Position{File: toFile, Rank: toRank},
+	}
+}
+
+func TestGame_MovePublishesEvents(t *testing.T) {
+	g := game.New("test", chess.New(colour.White))
+
+	events, unsubscribe := g.Subscribe()
+	defer unsubscribe()
+
+	if _, err := g.Move(mv(4, 1, 4, 3)); err != nil {
+		t.Fatalf("unexpected error making move: %v", err)
+	}
+
+	e := <-events
+	if e.ID != 1 || e.Type != game.EventMove {
+		t.Fatalf("want move event with id 1, got %+v", e)
+	}
+
+	me, ok := e.Data.(game.MoveEvent)
+	if !ok || me.Colour != colour.White.String() || me.Turn != colour.Black.String() {
+		t.Errorf("unexpected move event data: %+v", e.Data)
+	}
+}
+
+func TestGame_FoolsMate(t *testing.T) {
+	g := game.New("test", chess.New(colour.White))
+
+	// Visualisation of the board after the final move
+	// 8 bR bN bB ## bK bB bN bR
+	// 7 bP bP bP bP ## bP bP bP
+	// 6 ## ## ## ## ## ## ## ##
+	// 5 ## ## ## ## bP ## ## ##
+	// 4 ## ## ## ## ## ## wP bQ
+	// 3 ## ## ## ## ## wP ## ##
+	// 2 wP wP wP wP wP ## ## wP
+	// 1 wR wN wB wQ wK wB wN wR
+	//    A  B  C  D  E  F  G  H
+
+	moves := []move.Move{
+		mv(5, 1, 5, 2),
+		mv(4, 6, 4, 4),
+		mv(6, 1, 6, 3),
+		mv(3, 7, 7, 3),
+	}
+
+	for _, m := range moves {
+		if _, err := g.Move(m); err != nil {
+			t.Fatalf("unexpected error making move %v: %v", m, err)
+		}
+	}
+
+	if g.Status() != game.StatusCheckmate {
+		t.Fatalf("want status %s, got %s", game.StatusCheckmate, g.Status())
+	}
+
+	if w := g.Winner(); w == nil || *w != colour.Black {
+		t.Errorf("want black to have won, got %v", w)
+	}
+
+	if _, err := g.Move(mv(0, 1, 0, 2)); !errors.Is(err, game.ErrorGameOver) {
+		t.Errorf("want %v, got %v", game.ErrorGameOver, err)
+	}
+
+	start, made := g.Moves()
+	if !reflect.DeepEqual(made, moves) {
+		t.Errorf("want moves %v, got %v", moves, made)
+	}
+
+	if start.FEN() != chess.New(colour.White).FEN() {
+		t.Errorf("want the game to start from the starting position, got %s", start.FEN())
+	}
+}
+
+func TestGame_Draw(t *testing.T) {
+	tcs := []struct {
+		name       string
+		offer      colour.Colour
+		respond    func(g *game.Game) error
+		wantStatus game.Status
+		wantErr    error
+	}{
+		{
+			name:       "Accepted",
+			offer:      colour.White,
+			respond:    func(g *game.Game) error { return g.AcceptDraw(colour.Black) },
+			wantStatus: game.StatusDrawAgreed,
+		},
+		{
+			name:       "Declined",
+			offer:      colour.White,
+			respond:    func(g *game.Game) error { return g.DeclineDraw(colour.Black) },
+			wantStatus: game.StatusOngoing,
+		},
+		{
+			name:       "AcceptOwnOffer",
+			offer:      colour.White,
+			respond:    func(g *game.Game) error { return g.AcceptDraw(colour.White) },
+			wantStatus: game.StatusOngoing,
+			wantErr:    game.ErrorNoDrawOffer,
+		},
+		{
+			name:       "CounterOffer",
+			offer:      colour.White,
+			respond:    func(g *game.Game) error { return g.OfferDraw(colour.Black) },
+			wantStatus: game.StatusDrawAgreed,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			g := game.New("test", chess.New(colour.White))
+
+			if err := g.OfferDraw(tc.offer); err != nil {
+				t.Fatalf("unexpected error offering draw: %v", err)
+			}
+
+			if err := tc.respond(g); !errors.Is(err, tc.wantErr) {
+				t.Errorf("want error %v, got %v", tc.wantErr, err)
+			}
+
+			if g.Status() != tc.wantStatus {
+				t.Errorf("want status %s, got %s", tc.wantStatus, g.Status())
+			}
+		})
+	}
+}
+
+func TestGame_DrawAfterGameOver(t *testing.T) {
+	g := game.New("test", chess.New(colour.White))
+
+	events, unsubscribe := g.Subscribe()
+	defer unsubscribe()
+
+	if err := g.OfferDraw(colour.White); err != nil {
+		t.Fatalf("unexpected error offering draw: %v", err)
+	}
+
+	if err := g.Resign(colour.White); err != nil {
+		t.Fatalf("unexpected error resigning: %v", err)
+	}
+
+	for _, respond := range []func(colour.Colour) error{g.AcceptDraw, g.DeclineDraw, g.OfferDraw} {
+		if err := respond(colour.Black); !errors.Is(err, game.ErrorGameOver) {
+			t.Errorf("want %v, got %v", game.ErrorGameOver, err)
+		}
+	}
+
+	// Only the offer and the end of the game are published
+	if got := len(events); got != 2 {
+		t.Errorf("want 2 events, got %d", got)
+	}
+}
+
+func TestManager_LoadDrawOffers(t *testing.T) {
+	store := storage.NewMemory()
+	m := game.NewManager(game.WithStore(store))
+
+	g, err := m.Create(chess.New(colour.White))
+	if err != nil {
+		t.Fatalf("unexpected error creating game: %v", err)
+	}
+
+	steps := []func() error{
+		func() error { return g.OfferDraw(colour.White) },
+		func() error { return g.DeclineDraw(colour.Black) },
+		func() error { _, err := g.Move(mv(4, 1, 4, 3)); return err },
+		func() error { return g.OfferDraw(colour.White) },
+		func() error { _, err := g.Move(mv(4, 6, 4, 4)); return err },
+		func() error { return g.OfferDraw(colour.White) },
+		func() error { return g.AcceptDraw(colour.Black) },
+	}
+
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("unexpected error in step %d: %v", i, err)
+		}
+	}
+
+	restarted := game.NewManager(game.WithStore(store))
+	if err := restarted.Load(); err != nil {
+		t.Fatalf("unexpected error loading games: %v", err)
+	}
+
+	restored, err := restarted.Get(g.ID)
+	if err != nil {
+		t.Fatalf("unexpected error getting game: %v", err)
+	}
+
+	// The events are replayed with the ids they were published with, so that clients can resume from any of them
+	want, got := g.History(), restored.History()
+	if len(got) != len(want) {
+		t.Fatalf("want %d events to be replayed, got %d", len(want), len(got))
+	}
+
+	for i := range want {
+		if got[i].ID != want[i].ID || got[i].Type != want[i].Type || !reflect.DeepEqual(got[i].Data, want[i].Data) {
+			t.Errorf("want event %+v, got %+v", want[i], got[i])
+		}
+	}
+
+	if restored.Status() != game.StatusDrawAgreed {
+		t.Errorf("want status %s, got %s", game.StatusDrawAgreed, restored.Status())
+	}
+}
+
+func TestGame_Clock(t *testing.T) {
+	now := time.Unix(0, 0)
+	g := game.New("test", chess.New(colour.White),
+		game.WithClock(time.Minute, 2*time.Second),
+		game.WithTimeSource(func() time.Time { return now }),
+	)
+
+	if _, err := g.Move(mv(4, 1, 4, 3)); err != nil {
+		t.Fatalf("unexpected error making move: %v", err)
+	}
+
+	// The clock starts with the first move, and runs for the colour to move
+	now = now.Add(10 * time.Second)
+
+	if left, inc, ok := g.TimeLeft(colour.Black); !ok || left != 50*time.Second || inc != 2*time.Second {
+		t.Errorf("want black to have 50s left with a 2s increment, got %s and %s", left, inc)
+	}
+
+	if left, _, _ := g.TimeLeft(colour.White); left != time.Minute {
+		t.Errorf("want white to have 1m left, got %s", left)
+	}
+
+	// Black runs out of time before replying
+	now = now.Add(2 * time.Minute)
+
+	if _, err := g.Move(mv(4, 6, 4, 4)); !errors.Is(err, game.ErrorGameOver) {
+		t.Fatalf("want %v, got %v", game.ErrorGameOver, err)
+	}
+
+	if g.Status() != game.StatusTimeout {
+		t.Errorf("want status %s, got %s", game.StatusTimeout, g.Status())
+	}
+
+	if w := g.Winner(); w == nil || *w != colour.White {
+		t.Errorf("want white to have won, got %v", w)
+	}
+}
+
+func TestGame_CheckEvent(t *testing.T) {
+	g := game.New("test", chess.New(colour.White))
+
+	// Visualisation of the board after the final move
+	// 8 bR bN bB bQ bK bB bN bR
+	// 7 bP bP bP bP bP ## bP bP
+	// 6 ## ## ## ## ## bP ## ##
+	// 5 ## ## ## ## ## ## ## wQ
+	// 4 ## ## ## ## wP ## ## ##
+	// 3 ## ## ## ## ## ## ## ##
+	// 2 wP wP wP wP ## wP wP wP
+	// 1 wR wN wB ## wK wB wN wR
+	//    A  B  C  D  E  F  G  H
+
+	moves := []move.Move{
+		mv(4, 1, 4, 3),
+		mv(5, 6, 5, 5),
+		mv(3, 0, 7, 4),
+	}
+
+	for _, m := range moves {
+		if _, err := g.Move(m); err != nil {
+			t.Fatalf("unexpected error making move %v: %v", m, err)
+		}
+	}
+
+	history := g.History()
+	last := history[len(history)-1]
+
+	if last.Type != game.EventCheck {
+		t.Fatalf("want last event to be %s, got %+v", game.EventCheck, last)
+	}
+
+	want := game.CheckEvent{
+		Colour:   colour.Black.String(),
+		King:     move.Position{File: 4, Rank: 7},
+		Attacker: move.Position{File: 7, Rank: 4},
+	}
+
+	if last.Data != want {
+		t.Errorf("want %+v, got %+v", want, last.Data)
+	}
+
+	missed, _, unsubscribe := g.SubscribeFrom(2)
+	defer unsubscribe()
+
+	if len(missed) != len(history)-2 || missed[0].ID != 3 {
+		t.Errorf("want events after id 2, got %+v", missed)
+	}
+}
+
+func TestManager_Load(t *testing.T) {
+	now := time.Unix(0, 0)
+	clock := game.WithTimeSource(func() time.Time { return now })
+
+	store := storage.NewMemory()
+	m := game.NewManager(game.WithStore(store))
+
+	ongoing, err := m.Create(chess.New(colour.White), game.WithClock(time.Minute, 0), clock)
+	if err != nil {
+		t.Fatalf("unexpected error creating game: %v", err)
+	}
+
+	bot := game.Bot{Colour: colour.Black, Depth: 3, Ponder: true}
+
+	resigned, err := m.Create(chess.New(colour.White), clock, game.WithBot(bot))
+	if err != nil {
+		t.Fatalf("unexpected error creating game: %v", err)
+	}
+
+	for _, mv := range []move.Move{mv(4, 1, 4, 3), mv(4, 6, 4, 4)} {
+		now = now.Add(10 * time.Second)
+
+		if _, err := ongoing.Move(mv); err != nil {
+			t.Fatalf("unexpected error making move: %v", err)
+		}
+	}
+
+	if err := resigned.Resign(colour.White); err != nil {
+		t.Fatalf("unexpected error resigning: %v", err)
+	}
+
+	// The server restarts with the same store
+	restarted := game.NewManager(game.WithStore(store))
+	if err := restarted.Load(clock); err != nil {
+		t.Fatalf("unexpected error loading games: %v", err)
+	}
+
+	g, err := restarted.Get(ongoing.ID)
+	if err != nil {
+		t.Fatalf("unexpected error getting game: %v", err)
+	}
+
+	if got, want := g.Chess().Turn, ongoing.Chess().Turn; got != want {
+		t.Errorf("want turn %s, got %s", want, got)
+	}
+
+	if got := len(g.History()); got != len(ongoing.History()) {
+		t.Errorf("want %d events to be replayed, got %d", len(ongoing.History()), got)
+	}
+
+	// Black took 10 seconds over their move
+	if _, err := g.Move(mv(3, 1, 3, 3)); err != nil {
+		t.Fatalf("unexpected error making move after restoring: %v", err)
+	}
+
+	g, err = restarted.Get(resigned.ID)
+	if err != nil {
+		t.Fatalf("unexpected error getting game: %v", err)
+	}
+
+	if g.Status() != game.StatusResigned {
+		t.Errorf("want status %s, got %s", game.StatusResigned, g.Status())
+	}
+
+	if got, ok := g.Bot(); !ok || got != bot {
+		t.Errorf("want bot %+v to be restored, got %+v", bot, got)
+	}
+
+	stored, err := store.Load(ongoing.ID)
+	if err != nil {
+		t.Fatalf("unexpected error loading game: %v", err)
+	}
+
+	if len(stored.Moves) != 3 {
+		t.Errorf("want 3 stored moves, got %d", len(stored.Moves))
+	}
+
+	if err := restarted.Delete(ongoing.ID); err != nil {
+		t.Fatalf("unexpected error deleting game: %v", err)
+	}
+
+	if _, err := store.Load(ongoing.ID); !errors.Is(err, storage.ErrorGameNotFound) {
+		t.Errorf("want %v, got %v", storage.ErrorGameNotFound, err)
+	}
+}
+
+func TestManager_LoadSkipsGamesThatCannotBeReplayed(t *testing.T) {
+	store := storage.NewMemory()
+	m := game.NewManager(game.WithStore(store))
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		g, err := m.Create(chess.New(colour.White))
+		if err != nil {
+			t.Fatalf("unexpected error creating game: %v", err)
+		}
+
+		if _, err := g.Move(mv(4, 1, 4, 3)); err != nil {
+			t.Fatalf("unexpected error making move: %v", err)
+		}
+
+		ids = append(ids, g.ID)
+	}
+
+	// The middle game has a move stored that cannot be played after the moves before it
+	if err := store.AppendMove(ids[1], storage.Move{Move: mv(4, 3, 4, 5), At: time.Now()}); err != nil {
+		t.Fatalf("unexpected error storing move: %v", err)
+	}
+
+	restarted := game.NewManager(game.WithStore(store))
+	if err := restarted.Load(); err != nil {
+		t.Fatalf("unexpected error loading games: %v", err)
+	}
+
+	for i, id := range ids {
+		_, err := restarted.Get(id)
+		if i == 1 && !errors.Is(err, game.ErrorGameNotFound) {
+			t.Errorf("want the corrupt game to be skipped, got %v", err)
+		} else if i != 1 && err != nil {
+			t.Errorf("want game %d to be loaded, got %v", i, err)
+		}
+	}
+}
+
+func TestGame_Correspondence(t *testing.T) {
+	day := 24 * time.Hour
+	now := time.Unix(0, 0)
+
+	g := game.New("test", chess.New(colour.White),
+		game.WithCorrespondence(3*day, 2*day),
+		game.WithTimeSource(func() time.Time { return now }),
+	)
+
+	// White takes four days, using a day of vacation
+	now = now.Add(4 * day)
+
+	if _, err := g.Move(mv(4, 1, 4, 3)); err != nil {
+		t.Fatalf("unexpected error making move: %v", err)
+	}
+
+	// Black takes six days, which is over their time per move and all of their vacation
+	now = now.Add(6 * day)
+
+	if _, err := g.Move(mv(4, 6, 4, 4)); !errors.Is(err, game.ErrorGameOver) {
+		t.Fatalf("want %v, got %v", game.ErrorGameOver, err)
+	}
+
+	if g.Status() != game.StatusTimeout {
+		t.Errorf("want status %s, got %s", game.StatusTimeout, g.Status())
+	}
+
+	if w := g.Winner(); w == nil || *w != colour.White {
+		t.Errorf("want white to have won, got %v", w)
+	}
+}
+
+func TestGame_Premoves(t *testing.T) {
+	tcs := []struct {
+		name      string
+		lines     [][]move.Move
+		opponent  move.Move
+		wantTurn  colour.Colour
+		wantLines [][]move.Move
+		wantErr   error
+	}{
+		{
+			name: "Matched",
+			lines: [][]move.Move{
+				{mv(4, 6, 4, 4), mv(6, 0, 5, 2), mv(1, 7, 2, 5), mv(5, 0, 2, 3)},
+			},
+			opponent:  mv(4, 6, 4, 4),
+			wantTurn:  colour.Black,
+			wantLines: [][]move.Move{{mv(1, 7, 2, 5), mv(5, 0, 2, 3)}},
+		},
+		{
+			name: "NotMatched",
+			lines: [][]move.Move{
+				{mv(4, 6, 4, 4), mv(6, 0, 5, 2)},
+			},
+			opponent: mv(3, 6, 3, 4),
+			wantTurn: colour.White,
+		},
+		{
+			name: "IllegalReply",
+			lines: [][]move.Move{
+				{mv(4, 6, 4, 4), mv(4, 3, 4, 4)},
+			},
+			opponent: mv(4, 6, 4, 4),
+			wantTurn: colour.White,
+		},
+		{
+			name: "OddLine",
+			lines: [][]move.Move{
+				{mv(4, 6, 4, 4)},
+			},
+			opponent: mv(4, 6, 4, 4),
+			wantTurn: colour.White,
+			wantErr:  game.ErrorInvalidPremove,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			g := game.New("test", chess.New(colour.White))
+
+			if _, err := g.Move(mv(4, 1, 4, 3)); err != nil {
+				t.Fatalf("unexpected error making move: %v", err)
+			}
+
+			if err := g.SetPremoves(colour.White, tc.lines); !errors.Is(err, tc.wantErr) {
+				t.Fatalf("want error %v, got %v", tc.wantErr, err)
+			}
+
+			if _, err := g.Move(tc.opponent); err != nil {
+				t.Fatalf("unexpected error making move: %v", err)
+			}
+
+			if turn := g.Chess().Turn; turn != tc.wantTurn {
+				t.Errorf("want turn %s, got %s", tc.wantTurn, turn)
+			}
+
+			if got := g.Premoves(colour.White); len(got) != len(tc.wantLines) || (len(got) > 0 && !reflect.DeepEqual(got, tc.wantLines)) {
+				t.Errorf("want premoves %v, got %v", tc.wantLines, got)
+			}
+		})
+	}
+
+	g := game.New("test", chess.New(colour.White))
+	if err := g.SetPremoves(colour.White, nil); !errors.Is(err, game.ErrorPremoveOnTurn) {
+		t.Errorf("want %v, got %v", game.ErrorPremoveOnTurn, err)
+	}
+}
+
+func TestGame_AwaitingMove(t *testing.T) {
+	g := game.New("test", chess.New(colour.White), game.WithPlayers("alice", "bob"))
+
+	if !g.AwaitingMove("alice") || g.AwaitingMove("bob") {
+		t.Fatalf("want the game to be waiting for alice")
+	}
+
+	if _, err := g.Move(mv(4, 1, 4, 3)); err != nil {
+		t.Fatalf("unexpected error making move: %v", err)
+	}
+
+	if g.AwaitingMove("alice") || !g.AwaitingMove("bob") {
+		t.Errorf("want the game to be waiting for bob")
+	}
+}
+
+func TestGame_MoveAs(t *testing.T) {
+	bot := game.WithBot(game.Bot{Colour: colour.Black, Depth: 1})
+
+	tests := []struct {
+		name string
+		opts []game.Option
+		// white and black are the callers moving each colour, with whether the move is let through
+		white string
+		black string
+		wantW bool
+		wantB bool
+	}{
+		{
+			name:  "both players",
+			opts:  []game.Option{game.WithPlayers("alice", "bob")},
+			white: "alice",
+			black: "bob",
+			wantW: true,
+			wantB: true,
+		},
+		{
+			name:  "the other player",
+			opts:  []game.Option{game.WithPlayers("alice", "bob")},
+			white: "bob",
+			black: "alice",
+		},
+		{
+			name:  "one player moves the empty side",
+			opts:  []game.Option{game.WithPlayers("alice", "")},
+			white: "alice",
+			black: "alice",
+			wantW: true,
+		},
+		{
+			name:  "anyone moves the empty side",
+			opts:  []game.Option{game.WithPlayers("alice", "")},
+			white: "alice",
+			black: "",
+			wantW: true,
+		},
+		{
+			name:  "the player moves the bot",
+			opts:  []game.Option{game.WithPlayers("alice", ""), bot},
+			white: "alice",
+			black: "alice",
+			wantW: true,
+		},
+		{
+			name:  "anyone moves the bot",
+			opts:  []game.Option{bot},
+			white: "",
+			black: "",
+			wantW: true,
+		},
+		{
+			name:  "no players",
+			white: "",
+			black: "carol",
+			wantW: true,
+			wantB: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := game.New("test", chess.New(colour.White), tt.opts...)
+
+			_, err := g.MoveAs(tt.white, mv(4, 1, 4, 3))
+			if got := err == nil; got != tt.wantW || (err != nil && !errors.Is(err, game.ErrorNotPlayer)) {
+				t.Fatalf("%q moving white: got error %v", tt.white, err)
+			}
+
+			if err != nil {
+				// The rest of the test needs black to be to move
+				if _, err := g.Move(mv(4, 1, 4, 3)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err = g.MoveAs(tt.black, mv(4, 6, 4, 4))
+			if got := err == nil; got != tt.wantB || (err != nil && !errors.Is(err, game.ErrorNotPlayer)) {
+				t.Errorf("%q moving black: got error %v", tt.black, err)
+			}
+
+			if err := g.Authorise(tt.black, colour.Black); (err == nil) != tt.wantB {
+				t.Errorf("%q acting for black: got error %v", tt.black, err)
+			}
+		})
+	}
+}
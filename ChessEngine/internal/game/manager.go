@@ -0,0 +1,184 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/storage"
+)
+
+// ErrorGameNotFound is thrown when there is no game with the id provided
+var ErrorGameNotFound = errors.New("there is no game with the id provided")
+
+// Manager keeps track of all the games being played on the server
+type Manager struct {
+	mu    sync.RWMutex
+	games map[string]*Game
+	order []string
+	store storage.Store
+	opts  []Option
+}
+
+type ManagerOption func(m *Manager)
+
+// WithStore saves the games of the manager in the store provided as they are played
+func WithStore(s storage.Store) ManagerOption {
+	return func(m *Manager) {
+		m.store = s
+	}
+}
+
+// WithGameOptions applies the options provided to every game of the manager, before those given when creating it
+func WithGameOptions(opts ...Option) ManagerOption {
+	return func(m *Manager) {
+		m.opts = append(m.opts, opts...)
+	}
+}
+
+func NewManager(opts ...ManagerOption) *Manager {
+	m := &Manager{
+		games: make(map[string]*Game),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Create starts a new game from the state of chess provided
+func (m *Manager) Create(c chess.Chess, opts ...Option) (*Game, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id, err := storage.NewID(4)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ok := m.games[id]; ok; _, ok = m.games[id] {
+		if id, err = storage.NewID(4); err != nil {
+			return nil, err
+		}
+	}
+
+	g := New(id, c, append(append([]Option{}, m.opts...), opts...)...)
+
+	if m.store != nil {
+		if err := m.store.Create(g.record()); err != nil {
+			return nil, fmt.Errorf("failed to save game: %w", err)
+		}
+
+		g.store = m.store
+	}
+
+	m.games[id] = g
+	m.order = append(m.order, id)
+
+	return g, nil
+}
+
+// Load restores the games saved in the store of the manager, so that games carry on after a restart. Games that
+// cannot be replayed are logged and skipped.
+func (m *Manager) Load(opts ...Option) error {
+	if m.store == nil {
+		return nil
+	}
+
+	records, err := m.store.List()
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, r := range records {
+		if _, ok := m.games[r.ID]; ok {
+			continue
+		}
+
+		g, err := Restore(r, append(append([]Option{}, m.opts...), opts...)...)
+		if err != nil {
+			// A game that cannot be replayed is left in the store, so that it does not stop the other games from loading
+			log.Printf("Failed to restore game %s, skipping it with error: %s\n", r.ID, err)
+			continue
+		}
+
+		g.store = m.store
+
+		m.games[r.ID] = g
+		m.order = append(m.order, r.ID)
+	}
+
+	return nil
+}
+
+// Delete removes the game from the manager and its store
+func (m *Manager) Delete(id string) error {
+	g, err := m.remove(id)
+	if err != nil {
+		return err
+	}
+
+	// The game is closed once the manager is unlocked, as games call back into the manager when they finish
+	g.close()
+
+	return nil
+}
+
+func (m *Manager) remove(id string) (*Game, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	g, ok := m.games[id]
+	if !ok {
+		return nil, ErrorGameNotFound
+	}
+
+	if m.store != nil {
+		if err := m.store.Delete(id); err != nil && !errors.Is(err, storage.ErrorGameNotFound) {
+			return nil, err
+		}
+	}
+
+	delete(m.games, id)
+
+	for i, gid := range m.order {
+		if gid == id {
+			m.order = append(m.order[:i], m.order[i+1:]...)
+			break
+		}
+	}
+
+	return g, nil
+}
+
+func (m *Manager) Get(id string) (*Game, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	g, ok := m.games[id]
+	if !ok {
+		return nil, ErrorGameNotFound
+	}
+
+	return g, nil
+}
+
+// List gets all games in the order they were created
+func (m *Manager) List() []*Game {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	games := make([]*Game, len(m.order))
+	for i, id := range m.order {
+		games[i] = m.games[id]
+	}
+
+	return games
+}
@@ -0,0 +1,55 @@
+package game
+
+import "fmt"
+
+type Status byte
+
+const (
+	StatusOngoing Status = iota
+	StatusCheckmate
+	StatusVariantWin
+	StatusStalemate
+	StatusDrawAgreed
+	StatusResigned
+	StatusTimeout
+	StatusAborted
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusOngoing:
+		return "Ongoing"
+	case StatusCheckmate:
+		return "Checkmate"
+	case StatusVariantWin:
+		return "VariantWin"
+	case StatusStalemate:
+		return "Stalemate"
+	case StatusDrawAgreed:
+		return "DrawAgreed"
+	case StatusResigned:
+		return "Resigned"
+	case StatusTimeout:
+		return "Timeout"
+	case StatusAborted:
+		return "Aborted"
+	default:
+		return "Unknown"
+	}
+}
+
+// ParseStatus gets the status from its name
+func ParseStatus(s string) (Status, error) {
+	for st := StatusOngoing; st <= StatusAborted; st++ {
+		if st.String() == s {
+			return st, nil
+		}
+	}
+
+	return StatusOngoing, fmt.Errorf("unknown game status: %s", s)
+}
+
+// IsOver reports whether no more moves can be made in a game with the status
+func (s Status) IsOver() bool {
+	return s != StatusOngoing
+}
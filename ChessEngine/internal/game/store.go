@@ -0,0 +1,187 @@
+package game
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/board"
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/storage"
+	"github.com/tomwatson6/chessbot/internal/variant"
+)
+
+// record gets what is stored when the game is created, g.mu must be held by the caller
+func (g *Game) record() storage.Game {
+	r := storage.Game{
+		ID:        g.ID,
+		Variant:   g.chess.Board.Variant.String(),
+		Turn:      g.chess.Turn,
+		Width:     g.chess.Board.Width,
+		Height:    g.chess.Board.Height,
+		Pieces:    storage.NewPieces(g.chess.Board.Pieces),
+		CreatedAt: g.now(),
+	}
+
+	if g.clock != nil {
+		r.Clock = &storage.Clock{Initial: g.clock.Initial, Increment: g.clock.Increment}
+	}
+
+	if g.correspondence != nil {
+		r.Correspondence = g.correspondence.record()
+	}
+
+	if g.players != nil {
+		r.White = g.players[colour.White]
+		r.Black = g.players[colour.Black]
+	}
+
+	if g.bot != nil {
+		r.Bot = &storage.Bot{Colour: g.bot.Colour, Depth: g.bot.Depth, Ponder: g.bot.Ponder}
+	}
+
+	return r
+}
+
+// Restore rebuilds a stored game by replaying its moves at the times they were made
+func Restore(r storage.Game, opts ...Option) (*Game, error) {
+	v, err := variant.Parse(r.Variant)
+	if err != nil {
+		return nil, err
+	}
+
+	pieces, err := storage.ToPieces(r.Pieces)
+	if err != nil {
+		return nil, err
+	}
+
+	c := chess.Chess{
+		Board: board.New(r.Width, r.Height, board.WithVariant(v), board.WithPieces(pieces)),
+		Turn:  r.Turn,
+	}
+
+	if r.Clock != nil {
+		opts = append(opts, WithClock(r.Clock.Initial, r.Clock.Increment))
+	}
+
+	if r.Correspondence != nil {
+		opts = append(opts, WithCorrespondence(r.Correspondence.PerMove, r.Correspondence.Vacation))
+	}
+
+	if r.White != "" || r.Black != "" {
+		opts = append(opts, WithPlayers(r.White, r.Black))
+	}
+
+	if r.Bot != nil {
+		opts = append(opts, WithBot(Bot{Colour: r.Bot.Colour, Depth: r.Bot.Depth, Ponder: r.Bot.Ponder}))
+	}
+
+	g := New(r.ID, c, opts...)
+	now := g.now
+
+	// The game is not timed until it has been replayed, as it is replayed at the times moves were made
+	g.replaying = true
+	if g.flag != nil {
+		g.flag.Stop()
+		g.flag = nil
+	}
+
+	// Replay the game at the time each move was made so that the clocks are restored as they were
+	at := r.CreatedAt
+	g.now = func() time.Time { return at }
+
+	if g.correspondence != nil {
+		g.correspondence.turnStarted = at
+	}
+
+	offers := r.DrawOffers
+
+	// replayDrawOffers replays the draw offers made before the move at the ply provided
+	replayDrawOffers := func(ply int) error {
+		for len(offers) > 0 && offers[0].Ply <= ply {
+			d := offers[0]
+			offers = offers[1:]
+			at = d.At
+
+			var err error
+
+			switch d.State {
+			case drawOffered:
+				err = g.OfferDraw(d.Colour)
+			case drawDeclined:
+				err = g.DeclineDraw(d.Colour)
+			case drawAccepted:
+				err = g.AcceptDraw(d.Colour)
+			default:
+				err = fmt.Errorf("invalid draw offer state %s", d.State)
+			}
+
+			if err != nil {
+				return fmt.Errorf("failed to replay draw offer at ply %d of game %s: %w", d.Ply, r.ID, err)
+			}
+		}
+
+		return nil
+	}
+
+	for i, m := range r.Moves {
+		if err := replayDrawOffers(i); err != nil {
+			g.finish()
+			return nil, err
+		}
+
+		at = m.At
+
+		if _, err := g.Move(m.Move); err != nil {
+			g.finish()
+			return nil, fmt.Errorf("failed to replay move %d of game %s: %w", i+1, r.ID, err)
+		}
+	}
+
+	if err := replayDrawOffers(len(r.Moves)); err != nil {
+		g.finish()
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if r.Result != nil && !g.status.IsOver() {
+		status, err := ParseStatus(r.Result.Status)
+		if err != nil {
+			return nil, err
+		}
+
+		at = r.Result.At
+		g.status = status
+		g.winner = r.Result.Winner
+		g.finish()
+		g.publishStatus()
+	}
+
+	g.now = now
+	g.replaying = false
+
+	if !g.status.IsOver() {
+		g.premoves = r.Premoves
+		g.startFlagTimer()
+	}
+
+	return g, nil
+}
+
+// close stops the game from running once it has been deleted, without saving a result for it
+func (g *Game) close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.flag != nil {
+		g.flag.Stop()
+		g.flag = nil
+	}
+
+	for id, ch := range g.subscribers {
+		close(ch)
+		delete(g.subscribers, id)
+	}
+}
@@ -0,0 +1,397 @@
+package match
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/engine"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/pgn"
+	"github.com/tomwatson6/chessbot/internal/piece"
+)
+
+// TimeControl is how long players have for their moves. Games are played on a clock when there is an initial time,
+// otherwise each move is limited by the move time, depth or nodes.
+type TimeControl struct {
+	Initial   time.Duration
+	Increment time.Duration
+	MoveTime  time.Duration
+	Depth     int
+	Nodes     int64
+}
+
+// String gets the time control in the format of the PGN TimeControl tag
+func (tc TimeControl) String() string {
+	switch {
+	case tc.Initial > 0:
+		return fmt.Sprintf("%g+%g", tc.Initial.Seconds(), tc.Increment.Seconds())
+	case tc.MoveTime > 0:
+		return fmt.Sprintf("%g/move", tc.MoveTime.Seconds())
+	default:
+		return "-"
+	}
+}
+
+// Adjudication ends games early. A player resigns once their own score and their opponent's have agreed they are
+// lost by at least ResignScore for ResignMoves moves in a row. A game is drawn once both scores have been within
+// DrawScore of 0 for DrawMoves moves in a row, from move DrawAfter. Games that reach MaxMoves are drawn.
+// Each rule is off while its number of moves is 0.
+type Adjudication struct {
+	ResignScore engine.Score
+	ResignMoves int
+	DrawScore   engine.Score
+	DrawMoves   int
+	DrawAfter   int
+	MaxMoves    int
+}
+
+// The reasons a game can end, used for the PGN Termination tag
+const (
+	TerminationNormal       = "normal"
+	TerminationTimeForfeit  = "time forfeit"
+	TerminationIllegalMove  = "illegal move"
+	TerminationAdjudication = "adjudication"
+	TerminationEngineError  = "rules infraction"
+)
+
+// GameResult is the outcome of a game of a match
+type GameResult struct {
+	Round   int
+	Opening int
+	White   string
+	Black   string
+	// Score is from white's point of view
+	Score       float64
+	Termination string
+	Reason      string
+	PGN         pgn.Game
+}
+
+// playGame plays a game between the players from the opening provided
+func playGame(ctx context.Context, white, black Player, opening Opening, tc TimeControl, adj Adjudication) (GameResult, error) {
+	start, err := opening.Position()
+	if err != nil {
+		return GameResult{}, err
+	}
+
+	for _, p := range []Player{white, black} {
+		if err := p.NewGame(); err != nil {
+			return GameResult{}, fmt.Errorf("%s failed to start a new game: %w", p.Name(), err)
+		}
+	}
+
+	g := &gameState{
+		pos:     start,
+		players: map[colour.Colour]Player{colour.White: white, colour.Black: black},
+		clocks:  map[colour.Colour]time.Duration{colour.White: tc.Initial, colour.Black: tc.Initial},
+		seen:    make(map[string]int),
+		tc:      tc,
+		adj:     adj,
+	}
+
+	// The opening is replayed from the starting position so that its moves appear in the PGN
+	opened, err := Opening{FEN: opening.FEN}.Position()
+	if err != nil {
+		return GameResult{}, err
+	}
+
+	for _, s := range start.Moves {
+		m, promotion, err := move.ParseUCI(s)
+		if err != nil {
+			return GameResult{}, err
+		}
+
+		g.record(opened.Chess, m, promotionType(promotion), "book")
+
+		if err := opened.play(s); err != nil {
+			return GameResult{}, err
+		}
+	}
+
+	g.seen[repetitionKey(g.pos.Chess)]++
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return GameResult{}, err
+		}
+
+		if g.over() {
+			break
+		}
+
+		g.step(ctx)
+	}
+
+	res := GameResult{
+		White:       white.Name(),
+		Black:       black.Name(),
+		Score:       g.score,
+		Termination: g.termination,
+		Reason:      g.reason,
+	}
+
+	res.PGN = pgn.Game{Moves: g.moves}
+	res.PGN.SetTag("White", res.White)
+	res.PGN.SetTag("Black", res.Black)
+	res.PGN.SetTag("Result", resultTag(res.Score))
+
+	if opening.FEN != "" {
+		res.PGN.SetTag("SetUp", "1")
+		res.PGN.SetTag("FEN", opening.FEN)
+	}
+
+	res.PGN.SetTag("TimeControl", tc.String())
+	res.PGN.SetTag("Termination", res.Termination)
+	res.PGN.SetTag("PlyCount", fmt.Sprint(len(g.moves)))
+
+	if len(g.moves) > 0 {
+		last := &res.PGN.Moves[len(res.PGN.Moves)-1]
+		last.Comment = strings.TrimSpace(last.Comment + " " + res.Reason)
+	}
+
+	return res, nil
+}
+
+type gameState struct {
+	pos     Position
+	players map[colour.Colour]Player
+	clocks  map[colour.Colour]time.Duration
+	seen    map[string]int
+	tc      TimeControl
+	adj     Adjudication
+	moves   []pgn.Move
+
+	// scores are the scores reported by each player for their moves, from white's point of view
+	scores map[colour.Colour][]engine.Score
+
+	finished    bool
+	score       float64
+	termination string
+	reason      string
+}
+
+// over checks whether the game has ended in the current position
+func (g *gameState) over() bool {
+	if g.finished {
+		return true
+	}
+
+	c := g.pos.Chess
+
+	if len(c.LegalMoves()) == 0 {
+		switch {
+		case !c.Board.Variant.HasRoyalKing():
+			g.end(winFor(c.Turn), TerminationNormal, c.Turn.String()+" has no moves")
+		case c.Board.InCheck(c.Turn):
+			g.end(winFor(c.Turn.Opposite()), TerminationNormal, c.Turn.Opposite().String()+" mates")
+		default:
+			g.end(0.5, TerminationNormal, "stalemate")
+		}
+
+		return true
+	}
+
+	switch {
+	case insufficientMaterial(c):
+		g.end(0.5, TerminationNormal, "insufficient material")
+	case g.seen[repetitionKey(c)] >= 3:
+		g.end(0.5, TerminationNormal, "threefold repetition")
+	case g.adj.MaxMoves > 0 && len(g.moves) >= 2*g.adj.MaxMoves:
+		g.end(0.5, TerminationAdjudication, "move limit reached")
+	}
+
+	return g.finished
+}
+
+// step asks the player to move for their move and makes it
+func (g *gameState) step(ctx context.Context) {
+	c := g.pos.Chess
+	turn := c.Turn
+	p := g.players[turn]
+
+	l := engine.Limits{Depth: g.tc.Depth, Nodes: g.tc.Nodes, MoveTime: g.tc.MoveTime}
+	if g.tc.Initial > 0 {
+		l.WhiteTime, l.BlackTime = g.clocks[colour.White], g.clocks[colour.Black]
+		l.WhiteIncrement, l.BlackIncrement = g.tc.Increment, g.tc.Increment
+	}
+
+	moveCtx := ctx
+	if g.tc.Initial > 0 {
+		// A player that does not answer long after their flag has fallen loses on time
+		var cancel context.CancelFunc
+		moveCtx, cancel = context.WithTimeout(ctx, g.clocks[turn]+time.Second)
+		defer cancel()
+	}
+
+	started := time.Now()
+	reply, err := p.Play(moveCtx, g.pos, l)
+	elapsed := time.Since(started)
+
+	if err != nil {
+		g.end(winFor(turn.Opposite()), TerminationEngineError, fmt.Sprintf("%s failed to move: %s", p.Name(), err))
+		return
+	}
+
+	if g.tc.Initial > 0 {
+		g.clocks[turn] -= elapsed
+		if g.clocks[turn] < 0 {
+			g.end(winFor(turn.Opposite()), TerminationTimeForfeit, p.Name()+" loses on time")
+			return
+		}
+
+		g.clocks[turn] += g.tc.Increment
+	}
+
+	if !isLegal(c, reply.Move) {
+		g.end(winFor(turn.Opposite()), TerminationIllegalMove, fmt.Sprintf("%s played illegal move %s", p.Name(), reply.Move.UCI()))
+		return
+	}
+
+	comment := ""
+	if reply.Score != nil {
+		comment = reply.Score.String()
+		g.addScore(turn, *reply.Score)
+	}
+
+	if reply.Promotion == piece.PieceTypePawn {
+		reply.Promotion = piece.PieceTypeQueen
+	}
+
+	g.record(c, reply.Move, reply.Promotion, comment)
+
+	if err := g.pos.play(uciMove(reply.Move, reply.Promotion, c.IsPromotion(reply.Move))); err != nil {
+		g.end(winFor(turn.Opposite()), TerminationIllegalMove, err.Error())
+		return
+	}
+
+	g.seen[repetitionKey(g.pos.Chess)]++
+	g.adjudicate(turn)
+}
+
+// record adds the move to the moves of the game in SAN, falling back to UCI notation if it cannot be written
+func (g *gameState) record(c chess.Chess, m move.Move, promotion piece.PieceType, comment string) {
+	san, err := c.SAN(m, promotion)
+	if err != nil {
+		san = m.UCI()
+	}
+
+	g.moves = append(g.moves, pgn.Move{SAN: san, Comment: comment})
+}
+
+func (g *gameState) addScore(c colour.Colour, s engine.Score) {
+	if g.scores == nil {
+		g.scores = make(map[colour.Colour][]engine.Score)
+	}
+
+	if c == colour.Black {
+		s = -s
+	}
+
+	g.scores[c] = append(g.scores[c], s)
+}
+
+// adjudicate ends the game once the scores of both players agree on the result, after the colour provided moved
+func (g *gameState) adjudicate(mover colour.Colour) {
+	last := func(c colour.Colour, n int) []engine.Score {
+		s := g.scores[c]
+		if n == 0 || len(s) < n {
+			return nil
+		}
+
+		return s[len(s)-n:]
+	}
+
+	if w, b := last(colour.White, g.adj.ResignMoves), last(colour.Black, g.adj.ResignMoves); w != nil && b != nil {
+		for _, winner := range []colour.Colour{colour.White, colour.Black} {
+			sign := engine.Score(1)
+			if winner == colour.Black {
+				sign = -1
+			}
+
+			agreed := true
+			for _, s := range append(append([]engine.Score{}, w...), b...) {
+				if s*sign < g.adj.ResignScore {
+					agreed = false
+					break
+				}
+			}
+
+			if agreed {
+				g.end(winFor(winner), TerminationAdjudication, fmt.Sprintf("%s resigns", g.players[winner.Opposite()].Name()))
+				return
+			}
+		}
+	}
+
+	if len(g.moves)/2 < g.adj.DrawAfter {
+		return
+	}
+
+	if w, b := last(colour.White, g.adj.DrawMoves), last(colour.Black, g.adj.DrawMoves); w != nil && b != nil {
+		for _, s := range append(append([]engine.Score{}, w...), b...) {
+			if s > g.adj.DrawScore || s < -g.adj.DrawScore {
+				return
+			}
+		}
+
+		g.end(0.5, TerminationAdjudication, "draw by adjudication")
+	}
+}
+
+func (g *gameState) end(score float64, termination, reason string) {
+	g.finished = true
+	g.score = score
+	g.termination = termination
+	g.reason = reason
+}
+
+func winFor(c colour.Colour) float64 {
+	if c == colour.White {
+		return 1
+	}
+
+	return 0
+}
+
+func resultTag(score float64) string {
+	switch score {
+	case 1:
+		return pgn.WhiteWins
+	case 0:
+		return pgn.BlackWins
+	default:
+		return pgn.Draw
+	}
+}
+
+// repetitionKey identifies a position for repetitions: the pieces, colour to move, castling and en passant
+func repetitionKey(c chess.Chess) string {
+	fields := strings.Fields(c.FEN())
+
+	return strings.Join(fields[:4], " ")
+}
+
+// insufficientMaterial reports whether neither side can mate, with only kings and at most one minor piece left
+func insufficientMaterial(c chess.Chess) bool {
+	if !c.Board.Variant.HasRoyalKing() {
+		return false
+	}
+
+	minors := 0
+
+	for _, p := range c.Board.Pieces {
+		switch p.GetPieceType() {
+		case piece.PieceTypeKing:
+		case piece.PieceTypeKnight, piece.PieceTypeBishop:
+			minors++
+		default:
+			return false
+		}
+	}
+
+	return minors <= 1
+}
@@ -0,0 +1,183 @@
+// Package match plays games between two players to measure the difference in their strength
+package match
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// ErrorNoOpenings is thrown when running a match with an empty list of openings
+var ErrorNoOpenings = errors.New("a match needs at least one opening")
+
+// Config is how a match is played. Each opening is played twice, once with each player as white.
+type Config struct {
+	Games        int
+	Openings     []Opening
+	Concurrency  int
+	TimeControl  TimeControl
+	Adjudication Adjudication
+	// SPRT stops the match as soon as the test has accepted either hypothesis
+	SPRT *SPRT
+	// OnGame is called with each game as it finishes along with the results so far
+	OnGame func(r GameResult, s Stats)
+}
+
+// Report is the outcome of a match
+type Report struct {
+	First    string
+	Second   string
+	Stats    Stats
+	Decision Decision
+	LLR      float64
+	Duration time.Duration
+}
+
+// Run plays the games of the match between the players created by the factories provided, the stats are from the
+// first player's point of view. Games are played concurrently, each worker having its own pair of players.
+func Run(ctx context.Context, first, second Factory, cfg Config) (Report, error) {
+	openings := cfg.Openings
+	if openings == nil {
+		openings = DefaultOpenings
+	}
+
+	if len(openings) == 0 {
+		return Report{}, ErrorNoOpenings
+	}
+
+	workers := cfg.Concurrency
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	games := cfg.Games
+	if games <= 0 {
+		games = 2 * len(openings)
+	}
+
+	if workers > games {
+		workers = games
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+
+		for i := 0; i < games; i++ {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var (
+		mu      sync.Mutex
+		report  Report
+		firstEr error
+		wg      sync.WaitGroup
+	)
+
+	started := time.Now()
+
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if firstEr == nil {
+			firstEr = err
+		}
+
+		cancel()
+	}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			a, err := first()
+			if err != nil {
+				fail(fmt.Errorf("failed to create the first player: %w", err))
+				return
+			}
+			defer a.Close()
+
+			b, err := second()
+			if err != nil {
+				fail(fmt.Errorf("failed to create the second player: %w", err))
+				return
+			}
+			defer b.Close()
+
+			for i := range jobs {
+				opening := (i / 2) % len(openings)
+
+				// The first player is white in even games and black in odd games, so that each opening is played
+				// with both colours
+				white, black := a, b
+				if i%2 != 0 {
+					white, black = b, a
+				}
+
+				res, err := playGame(ctx, white, black, openings[opening], cfg.TimeControl, cfg.Adjudication)
+				if errors.Is(err, context.Canceled) {
+					return
+				}
+
+				if err != nil {
+					fail(fmt.Errorf("game %d: %w", i+1, err))
+					return
+				}
+
+				res.Round = i + 1
+				res.Opening = opening + 1
+				res.PGN.SetTag("Round", fmt.Sprint(res.Round))
+
+				score := res.Score
+				if white != a {
+					score = 1 - score
+				}
+
+				mu.Lock()
+				report.First, report.Second = a.Name(), b.Name()
+				report.Stats.Add(score)
+
+				if cfg.OnGame != nil {
+					cfg.OnGame(res, report.Stats)
+				}
+
+				if cfg.SPRT != nil {
+					report.LLR = cfg.SPRT.LLR(report.Stats)
+					if report.Decision = cfg.SPRT.Decide(report.Stats); report.Decision != Continue {
+						cancel()
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	report.Duration = time.Since(started)
+
+	if firstEr != nil {
+		return report, firstEr
+	}
+
+	// The match was stopped from outside rather than by the SPRT
+	if err := ctx.Err(); err != nil && report.Decision == Continue && report.Stats.Games() < games {
+		return report, err
+	}
+
+	return report, nil
+}
@@ -0,0 +1,186 @@
+package match_test
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/engine"
+	"github.com/tomwatson6/chessbot/internal/match"
+	"github.com/tomwatson6/chessbot/internal/pgn"
+	"github.com/tomwatson6/chessbot/internal/polyglot"
+)
+
+func enginePlayer(name string, eval engine.Evaluator) match.Factory {
+	return func() (match.Player, error) {
+		return match.NewEnginePlayer(name, engine.New(engine.WithEvaluator(eval))), nil
+	}
+}
+
+func TestRun(t *testing.T) {
+	openings, err := match.ReadOpenings(strings.NewReader(`
+# Two openings, each played with both colours
+e2e4 e7e5
+rnbqkbnr/pppppppp/8/8/3P4/8/PPP1PPPP/RNBQKBNR b KQkq - 0 1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var games []match.GameResult
+
+	report, err := match.Run(context.Background(), enginePlayer("standard", engine.Evaluate), enginePlayer("material", engine.Material), match.Config{
+		Openings:     openings,
+		Concurrency:  2,
+		TimeControl:  match.TimeControl{Depth: 1},
+		Adjudication: match.Adjudication{MaxMoves: 6},
+		OnGame: func(r match.GameResult, s match.Stats) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			games = append(games, r)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Stats.Games() != 4 || len(games) != 4 {
+		t.Fatalf("got %d games reported and %d played, want 4", report.Stats.Games(), len(games))
+	}
+
+	whites := make(map[int][]string)
+	for _, g := range games {
+		whites[g.Opening] = append(whites[g.Opening], g.White)
+
+		if g.PGN.Tag("Round") == "" || g.PGN.Result() == pgn.Unfinished {
+			t.Errorf("game %d has tags %+v", g.Round, g.PGN.Tags)
+		}
+
+		if g.Opening == 2 && g.PGN.Tag("FEN") == "" {
+			t.Errorf("game %d from a FEN opening has no FEN tag", g.Round)
+		}
+	}
+
+	for opening, w := range whites {
+		if len(w) != 2 || w[0] == w[1] {
+			t.Errorf("opening %d was played with white by %v, want each player once", opening, w)
+		}
+	}
+}
+
+func TestAdjudication(t *testing.T) {
+	// White is a queen up, so both players agree black is lost straight away
+	openings := []match.Opening{{FEN: "4k3/8/8/8/8/8/8/3QK3 w - - 0 1"}}
+
+	report, err := match.Run(context.Background(), enginePlayer("a", engine.Material), enginePlayer("b", engine.Material), match.Config{
+		Games:        1,
+		Openings:     openings,
+		TimeControl:  match.TimeControl{Depth: 1},
+		Adjudication: match.Adjudication{ResignScore: 500, ResignMoves: 1},
+		OnGame: func(r match.GameResult, s match.Stats) {
+			if r.Termination != match.TerminationAdjudication || r.Score != 1 {
+				t.Errorf("got %s (%s) scoring %v, want white to win by adjudication", r.Termination, r.Reason, r.Score)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Stats.Wins != 1 {
+		t.Errorf("got stats %s, want a win", report.Stats)
+	}
+}
+
+func TestEnginePlayerBook(t *testing.T) {
+	g, err := pgn.Parse(`[Result "1-0"] 1. a3 a6 1-0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := polyglot.NewBuilder()
+	if err := b.Add(g); err != nil {
+		t.Fatal(err)
+	}
+
+	book := polyglot.New(b.Entries(), polyglot.WithSelection(polyglot.Best))
+	p := match.NewEnginePlayer("booked", engine.New(engine.WithEvaluator(engine.Material)), match.WithBook(book))
+
+	start := chess.New(colour.White)
+
+	r, err := p.Play(context.Background(), match.Position{StartFEN: chess.StartingFEN, Chess: start}, engine.Limits{Depth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Move.UCI() != "a2a3" || r.Score != nil {
+		t.Errorf("Play() = %s with score %v, want the book move a2a3 without a score", r.Move.UCI(), r.Score)
+	}
+
+	// Black's reply lost, so it is left out of the book and the engine searches as usual
+	if _, err := start.MakeMove(r.Move); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err = p.Play(context.Background(), match.Position{StartFEN: chess.StartingFEN, Moves: []string{"a2a3"}, Chess: start}, engine.Limits{Depth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Score == nil {
+		t.Errorf("Play() out of the book = %s without a score, want a searched move", r.Move.UCI())
+	}
+}
+
+func TestElo(t *testing.T) {
+	tests := []struct {
+		name         string
+		stats        match.Stats
+		diff         float64
+		lower, upper float64
+	}{
+		{name: "even", stats: match.Stats{Wins: 10, Losses: 10, Draws: 20}, diff: 0, lower: -77.3, upper: 77.3},
+		{name: "two thirds", stats: match.Stats{Wins: 50, Losses: 0, Draws: 100}, diff: 120.4, lower: 91.7, upper: 150.8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, lower, upper := tt.stats.Elo()
+
+			for _, c := range []struct{ got, want float64 }{{diff, tt.diff}, {lower, tt.lower}, {upper, tt.upper}} {
+				if math.Abs(c.got-c.want) > 0.1 {
+					t.Errorf("Elo() = %.1f [%.1f, %.1f], want %.1f [%.1f, %.1f]", diff, lower, upper, tt.diff, tt.lower, tt.upper)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestSPRT(t *testing.T) {
+	sprt := match.SPRT{Elo0: 0, Elo1: 10, Alpha: 0.05, Beta: 0.05}
+
+	tests := []struct {
+		name  string
+		stats match.Stats
+		want  match.Decision
+	}{
+		{name: "too few games", stats: match.Stats{Wins: 3, Losses: 2, Draws: 5}, want: match.Continue},
+		{name: "clearly stronger", stats: match.Stats{Wins: 600, Losses: 400, Draws: 1000}, want: match.AcceptH1},
+		{name: "clearly weaker", stats: match.Stats{Wins: 400, Losses: 600, Draws: 1000}, want: match.AcceptH0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sprt.Decide(tt.stats); got != tt.want {
+				t.Errorf("Decide(%s) = %s with LLR %.2f, want %s", tt.stats, got, sprt.LLR(tt.stats), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package match
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/piece"
+)
+
+// Opening is a position games are started from, a FEN followed by moves in UCI notation
+type Opening struct {
+	FEN   string
+	Moves []string
+}
+
+// DefaultOpenings are a handful of common openings, used when no openings are provided
+var DefaultOpenings = []Opening{
+	{Moves: strings.Fields("e2e4 e7e5 g1f3 b8c6")},
+	{Moves: strings.Fields("e2e4 c7c5 g1f3 d7d6")},
+	{Moves: strings.Fields("e2e4 e7e6 d2d4 d7d5")},
+	{Moves: strings.Fields("e2e4 c7c6 d2d4 d7d5")},
+	{Moves: strings.Fields("d2d4 d7d5 c2c4 e7e6")},
+	{Moves: strings.Fields("d2d4 g8f6 c2c4 e7e6")},
+	{Moves: strings.Fields("d2d4 g8f6 c2c4 g7g6")},
+	{Moves: strings.Fields("c2c4 e7e5 b1c3 g8f6")},
+}
+
+// ReadOpenings reads one opening per line, either a FEN or moves in UCI notation from the starting position.
+// Blank lines and lines starting with # are skipped.
+func ReadOpenings(r io.Reader) ([]Opening, error) {
+	var openings []Opening
+
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		o := Opening{Moves: strings.Fields(line)}
+		if strings.Contains(line, "/") {
+			o = Opening{FEN: line}
+		}
+
+		if _, err := o.Position(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+
+		openings = append(openings, o)
+	}
+
+	return openings, s.Err()
+}
+
+// Position plays the moves of the opening from its FEN, or the starting position if it does not have one
+func (o Opening) Position() (Position, error) {
+	fen := o.FEN
+	if fen == "" {
+		fen = chess.StartingFEN
+	}
+
+	c, err := chess.FromFEN(fen)
+	if err != nil {
+		return Position{}, err
+	}
+
+	if o.FEN == "" {
+		c = chess.New(colour.White)
+	}
+
+	p := Position{StartFEN: fen, Chess: c}
+
+	for _, s := range o.Moves {
+		if err := p.play(s); err != nil {
+			return Position{}, err
+		}
+	}
+
+	return p, nil
+}
+
+// play makes the move in UCI notation
+func (p *Position) play(s string) error {
+	m, promotion, err := move.ParseUCI(s)
+	if err != nil {
+		return err
+	}
+
+	if !isLegal(p.Chess, m) {
+		return fmt.Errorf("illegal move %s in %s", s, p.Chess.FEN())
+	}
+
+	t := promotionType(promotion)
+	promotes := p.Chess.IsPromotion(m)
+
+	if _, err := p.Chess.MakeMoveWithPromotion(m, t); err != nil {
+		return err
+	}
+
+	p.Moves = append(p.Moves, uciMove(m, t, promotes))
+
+	return nil
+}
+
+func isLegal(c chess.Chess, m move.Move) bool {
+	for _, l := range c.LegalMoves() {
+		if l == m {
+			return true
+		}
+	}
+
+	return false
+}
+
+// uciMove gets the move in UCI notation, adding the piece promoted to when the move is a promotion
+func uciMove(m move.Move, t piece.PieceType, promotion bool) string {
+	if !promotion {
+		return m.UCI()
+	}
+
+	return m.UCI() + promotionLetter(t)
+}
@@ -0,0 +1,177 @@
+package match
+
+import (
+	"context"
+	"strings"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/engine"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/piece"
+	"github.com/tomwatson6/chessbot/internal/polyglot"
+	"github.com/tomwatson6/chessbot/internal/uci"
+)
+
+// Position is the position a player is asked to move in, along with how it was reached
+type Position struct {
+	StartFEN string
+	Moves    []string
+	Chess    chess.Chess
+}
+
+// Reply is the move chosen by a player, with its score from the player's point of view if it reported one
+type Reply struct {
+	Move      move.Move
+	Promotion piece.PieceType
+	Score     *engine.Score
+}
+
+// Player plays the moves of one side of a game
+type Player interface {
+	Name() string
+	NewGame() error
+	Play(ctx context.Context, p Position, l engine.Limits) (Reply, error)
+	Close() error
+}
+
+// Factory creates a player, each game runs with its own players so that games can be played at the same time
+type Factory func() (Player, error)
+
+// EnginePlayer plays with the engine of this repository
+type EnginePlayer struct {
+	name   string
+	engine *engine.Engine
+	book   *polyglot.Book
+}
+
+type EnginePlayerOption func(p *EnginePlayer)
+
+// WithBook plays moves from the opening book while it has them, before searching
+func WithBook(b *polyglot.Book) EnginePlayerOption {
+	return func(p *EnginePlayer) {
+		p.book = b
+	}
+}
+
+func NewEnginePlayer(name string, e *engine.Engine, opts ...EnginePlayerOption) *EnginePlayer {
+	p := &EnginePlayer{name: name, engine: e}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+func (p *EnginePlayer) Name() string {
+	return p.name
+}
+
+func (p *EnginePlayer) NewGame() error {
+	return nil
+}
+
+func (p *EnginePlayer) Play(ctx context.Context, pos Position, l engine.Limits) (Reply, error) {
+	if p.book != nil {
+		if m, ok := p.book.Probe(pos.Chess); ok {
+			return Reply{Move: m.Move, Promotion: m.Promotion}, nil
+		}
+	}
+
+	res, err := p.engine.Search(ctx, pos.Chess, l, nil)
+	if err != nil {
+		return Reply{}, err
+	}
+
+	return Reply{Move: res.Move, Promotion: piece.PieceTypeQueen, Score: &res.Score}, nil
+}
+
+func (p *EnginePlayer) Close() error {
+	return nil
+}
+
+// UCIPlayer plays with an engine run as a local process
+type UCIPlayer struct {
+	name   string
+	client *uci.Client
+}
+
+// NewUCIPlayer starts the engine at the path provided, setting the options given. The name of the player is the
+// name the engine reports unless one is provided.
+func NewUCIPlayer(name, path string, options map[string]string) (*UCIPlayer, error) {
+	c, err := uci.Start(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range options {
+		if err := c.SetOption(k, v); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+
+	if name == "" {
+		name = c.Name
+	}
+
+	return &UCIPlayer{name: name, client: c}, nil
+}
+
+func (p *UCIPlayer) Name() string {
+	return p.name
+}
+
+func (p *UCIPlayer) NewGame() error {
+	return p.client.NewGame()
+}
+
+func (p *UCIPlayer) Play(ctx context.Context, pos Position, l engine.Limits) (Reply, error) {
+	res, err := p.client.Go(ctx, pos.StartFEN, pos.Moves, l, nil)
+	if err != nil {
+		return Reply{}, err
+	}
+
+	r := Reply{Move: res.Move, Promotion: promotionType(res.Promotion)}
+	if res.Depth > 0 {
+		r.Score = &res.Score
+	}
+
+	return r, nil
+}
+
+func (p *UCIPlayer) Close() error {
+	return p.client.Close()
+}
+
+// promotionType gets the type of piece from the letter used in UCI moves, queens when there is no letter
+func promotionType(r rune) piece.PieceType {
+	switch strings.ToLower(string(r)) {
+	case "n":
+		return piece.PieceTypeKnight
+	case "b":
+		return piece.PieceTypeBishop
+	case "r":
+		return piece.PieceTypeRook
+	case "k":
+		return piece.PieceTypeKing
+	default:
+		return piece.PieceTypeQueen
+	}
+}
+
+// promotionLetter gets the letter used in UCI moves for the type of piece promoted to
+func promotionLetter(t piece.PieceType) string {
+	switch t {
+	case piece.PieceTypeKnight:
+		return "n"
+	case piece.PieceTypeBishop:
+		return "b"
+	case piece.PieceTypeRook:
+		return "r"
+	case piece.PieceTypeKing:
+		return "k"
+	default:
+		return "q"
+	}
+}
@@ -0,0 +1,160 @@
+package match
+
+import (
+	"fmt"
+	"math"
+)
+
+// Stats are the results of the first player of a match against the second
+type Stats struct {
+	Wins   int `json:"wins"`
+	Losses int `json:"losses"`
+	Draws  int `json:"draws"`
+}
+
+// Add counts a game with the score provided, from the first player's point of view
+func (s *Stats) Add(score float64) {
+	switch score {
+	case 1:
+		s.Wins++
+	case 0:
+		s.Losses++
+	default:
+		s.Draws++
+	}
+}
+
+func (s Stats) Games() int {
+	return s.Wins + s.Losses + s.Draws
+}
+
+// Score gets the average points per game of the first player
+func (s Stats) Score() float64 {
+	if s.Games() == 0 {
+		return 0.5
+	}
+
+	return (float64(s.Wins) + float64(s.Draws)/2) / float64(s.Games())
+}
+
+// variance gets the variance of the score of a single game
+func (s Stats) variance() float64 {
+	n := float64(s.Games())
+	if n == 0 {
+		return 0
+	}
+
+	mu := s.Score()
+
+	return (float64(s.Wins)*(1-mu)*(1-mu) + float64(s.Draws)*(0.5-mu)*(0.5-mu) + float64(s.Losses)*mu*mu) / n
+}
+
+// Elo gets the Elo difference between the players along with the bounds of its 95% confidence interval. The
+// difference is infinite when one player has won every game.
+func (s Stats) Elo() (diff, lower, upper float64) {
+	n := float64(s.Games())
+	if n == 0 {
+		return 0, math.Inf(-1), math.Inf(1)
+	}
+
+	mu := s.Score()
+	margin := 1.959964 * math.Sqrt(s.variance()/n)
+
+	return elo(mu), elo(mu - margin), elo(mu + margin)
+}
+
+// LOS gets the likelihood of superiority, the chance that the first player is the stronger
+func (s Stats) LOS() float64 {
+	if s.Wins+s.Losses == 0 {
+		return 0.5
+	}
+
+	return 0.5 * (1 + math.Erf(float64(s.Wins-s.Losses)/math.Sqrt(2*float64(s.Wins+s.Losses))))
+}
+
+// String gets the results with the Elo difference and likelihood of superiority
+func (s Stats) String() string {
+	diff, lower, upper := s.Elo()
+
+	return fmt.Sprintf("+%d -%d =%d, score %.1f%%, elo %.1f [%.1f, %.1f], LOS %.1f%%",
+		s.Wins, s.Losses, s.Draws, 100*s.Score(), diff, lower, upper, 100*s.LOS())
+}
+
+// elo converts an average score into an Elo difference
+func elo(score float64) float64 {
+	switch {
+	case score <= 0:
+		return math.Inf(-1)
+	case score >= 1:
+		return math.Inf(1)
+	default:
+		return 400 * math.Log10(score/(1-score))
+	}
+}
+
+// expected gets the average score of a player rated the Elo difference provided above their opponent
+func expected(diff float64) float64 {
+	return 1 / (1 + math.Pow(10, -diff/400))
+}
+
+// SPRT is a sequential probability ratio test of whether the first player is Elo1 stronger than the second,
+// against the hypothesis that they are only Elo0 stronger. Alpha and Beta are the rates of false positives and
+// false negatives accepted.
+type SPRT struct {
+	Elo0  float64
+	Elo1  float64
+	Alpha float64
+	Beta  float64
+}
+
+// Decision is the outcome of an SPRT
+type Decision int
+
+const (
+	Continue Decision = iota
+	AcceptH0
+	AcceptH1
+)
+
+func (d Decision) String() string {
+	switch d {
+	case AcceptH0:
+		return "H0 accepted"
+	case AcceptH1:
+		return "H1 accepted"
+	default:
+		return "continue"
+	}
+}
+
+// Bounds gets the log-likelihood ratios at which H0 and H1 are accepted
+func (t SPRT) Bounds() (lower, upper float64) {
+	return math.Log(t.Beta / (1 - t.Alpha)), math.Log((1 - t.Beta) / t.Alpha)
+}
+
+// LLR gets the log-likelihood ratio of the results, using the normal approximation of the generalised SPRT
+func (t SPRT) LLR(s Stats) float64 {
+	v := s.variance()
+	if s.Games() == 0 || v == 0 {
+		return 0
+	}
+
+	s0, s1 := expected(t.Elo0), expected(t.Elo1)
+
+	return float64(s.Games()) * (s1 - s0) * (2*s.Score() - s0 - s1) / (2 * v)
+}
+
+// Decide gets whether the results are enough to accept either hypothesis
+func (t SPRT) Decide(s Stats) Decision {
+	llr := t.LLR(s)
+	lower, upper := t.Bounds()
+
+	switch {
+	case llr >= upper:
+		return AcceptH1
+	case llr <= lower:
+		return AcceptH0
+	default:
+		return Continue
+	}
+}
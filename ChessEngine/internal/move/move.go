@@ -65,3 +65,33 @@ func (m Move) Distance() int {
 func (m Move) String() string {
 	return fmt.Sprintf("%s->%s", m.From.String(), m.To.String())
 }
+
+// UCI gets the move in the long algebraic notation used by the Universal Chess Interface e.g. e2e4
+func (m Move) UCI() string {
+	return m.From.Notation() + m.To.Notation()
+}
+
+// ParseUCI reads a move in the long algebraic notation used by the Universal Chess Interface e.g. e2e4 or e7e8q,
+// returning the letter of the piece promoted to, or 0 if the move is not a promotion
+func ParseUCI(s string) (Move, rune, error) {
+	if len(s) != 4 && len(s) != 5 {
+		return Move{}, 0, fmt.Errorf("invalid UCI move: %q", s)
+	}
+
+	from, err := ParsePosition(s[0:2])
+	if err != nil {
+		return Move{}, 0, err
+	}
+
+	to, err := ParsePosition(s[2:4])
+	if err != nil {
+		return Move{}, 0, err
+	}
+
+	var promotion rune
+	if len(s) == 5 {
+		promotion = rune(s[4])
+	}
+
+	return Move{From: from, To: to}, promotion, nil
+}
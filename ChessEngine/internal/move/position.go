@@ -10,3 +10,17 @@ type Position struct {
 func (p Position) String() string {
 	return fmt.Sprintf("(%d,%d)", p.File, p.Rank)
 }
+
+// Notation gets the position in algebraic notation e.g. e4
+func (p Position) Notation() string {
+	return fmt.Sprintf("%c%d", rune('a'+p.File), p.Rank+1)
+}
+
+// ParsePosition reads a position in algebraic notation e.g. e4
+func ParsePosition(s string) (Position, error) {
+	if len(s) != 2 || s[0] < 'a' || s[0] > 'z' || s[1] < '1' || s[1] > '9' {
+		return Position{}, fmt.Errorf("invalid square: %q", s)
+	}
+
+	return Position{File: int(s[0] - 'a'), Rank: int(s[1] - '1')}, nil
+}
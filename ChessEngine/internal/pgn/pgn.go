@@ -0,0 +1,191 @@
+// Package pgn reads and writes games in Portable Game Notation
+package pgn
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The results a game can be tagged with
+const (
+	WhiteWins  = "1-0"
+	BlackWins  = "0-1"
+	Draw       = "1/2-1/2"
+	Unfinished = "*"
+)
+
+// roster is the order of the Seven Tag Roster, which come before any other tags
+var roster = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+type Tag struct {
+	Name  string
+	Value string
+}
+
+// Move is a move of a game in Standard Algebraic Notation, along with any annotation of it
+type Move struct {
+	SAN     string
+	NAGs    []int
+	Comment string
+}
+
+type Game struct {
+	Tags  []Tag
+	Moves []Move
+}
+
+// Tag gets the value of the tag with the name provided, empty if the game does not have it
+func (g Game) Tag(name string) string {
+	for _, t := range g.Tags {
+		if t.Name == name {
+			return t.Value
+		}
+	}
+
+	return ""
+}
+
+// SetTag sets the value of the tag with the name provided, adding it if the game does not have it
+func (g *Game) SetTag(name, value string) {
+	for i, t := range g.Tags {
+		if t.Name == name {
+			g.Tags[i].Value = value
+			return
+		}
+	}
+
+	g.Tags = append(g.Tags, Tag{Name: name, Value: value})
+}
+
+// Result gets the result of the game, * if it has not been tagged
+func (g Game) Result() string {
+	if r := g.Tag("Result"); r != "" {
+		return r
+	}
+
+	return Unfinished
+}
+
+// String gets the game in Portable Game Notation
+func (g Game) String() string {
+	var sb strings.Builder
+	_ = g.Write(&sb)
+
+	return sb.String()
+}
+
+// Write writes the game in Portable Game Notation, with the Seven Tag Roster first and lines wrapped at 80
+// characters
+func (g Game) Write(w io.Writer) error {
+	var sb strings.Builder
+
+	for _, name := range roster {
+		v := g.Tag(name)
+		if v == "" {
+			v = "?"
+			if name == "Result" {
+				v = g.Result()
+			}
+		}
+
+		writeTag(&sb, name, v)
+	}
+
+	for _, t := range g.Tags {
+		if !isRoster(t.Name) {
+			writeTag(&sb, t.Name, t.Value)
+		}
+	}
+
+	sb.WriteByte('\n')
+
+	number, black := g.firstMove()
+
+	var tokens []string
+	for i, m := range g.Moves {
+		switch {
+		case !black:
+			tokens = append(tokens, strconv.Itoa(number)+".")
+		case i == 0 || g.Moves[i-1].Comment != "":
+			// Black's move needs its number when it does not follow white's move directly
+			tokens = append(tokens, strconv.Itoa(number)+"...")
+		}
+
+		tokens = append(tokens, m.SAN)
+
+		for _, nag := range m.NAGs {
+			tokens = append(tokens, "$"+strconv.Itoa(nag))
+		}
+
+		if m.Comment != "" {
+			tokens = append(tokens, strings.Fields("{"+strings.ReplaceAll(m.Comment, "}", ")")+"}")...)
+		}
+
+		if black {
+			number++
+		}
+
+		black = !black
+	}
+
+	tokens = append(tokens, g.Result())
+
+	line := 0
+	for i, t := range tokens {
+		if i > 0 {
+			if line+1+len(t) > 80 {
+				sb.WriteByte('\n')
+				line = 0
+			} else {
+				sb.WriteByte(' ')
+				line++
+			}
+		}
+
+		sb.WriteString(t)
+		line += len(t)
+	}
+
+	sb.WriteString("\n\n")
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+// firstMove gets the number of the first move and whether black makes it, from the FEN tag of games that do not
+// start from the starting position
+func (g Game) firstMove() (int, bool) {
+	fields := strings.Fields(g.Tag("FEN"))
+	if len(fields) < 2 {
+		return 1, false
+	}
+
+	number := 1
+	if len(fields) >= 6 {
+		if n, err := strconv.Atoi(fields[5]); err == nil && n > 0 {
+			number = n
+		}
+	}
+
+	return number, fields[1] == "b"
+}
+
+func writeTag(sb *strings.Builder, name, value string) {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+
+	fmt.Fprintf(sb, "[%s \"%s\"]\n", name, value)
+}
+
+func isRoster(name string) bool {
+	for _, r := range roster {
+		if r == name {
+			return true
+		}
+	}
+
+	return false
+}
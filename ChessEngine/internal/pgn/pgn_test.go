@@ -0,0 +1,65 @@
+package pgn_test
+
+import (
+	"testing"
+
+	"github.com/tomwatson6/chessbot/internal/pgn"
+)
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name string
+		game pgn.Game
+		want string
+	}{
+		{
+			name: "seven tag roster is filled in",
+			game: pgn.Game{
+				Tags:  []pgn.Tag{{Name: "Termination", Value: "normal"}, {Name: "White", Value: "A"}, {Name: "Result", Value: pgn.WhiteWins}},
+				Moves: []pgn.Move{{SAN: "e4"}, {SAN: "e5"}, {SAN: "Qh5"}},
+			},
+			want: `[Event "?"]
+[Site "?"]
+[Date "?"]
+[Round "?"]
+[White "A"]
+[Black "?"]
+[Result "1-0"]
+[Termination "normal"]
+
+1. e4 e5 2. Qh5 1-0
+
+`,
+		},
+		{
+			name: "black to move from a FEN with comments and NAGs",
+			game: pgn.Game{
+				Tags: []pgn.Tag{{Name: "FEN", Value: "4k3/8/8/8/8/8/8/R3K3 b - - 0 12"}},
+				Moves: []pgn.Move{
+					{SAN: "Kd7", NAGs: []int{2}, Comment: "loses"},
+					{SAN: "Ra7+"},
+				},
+			},
+			want: `[Event "?"]
+[Site "?"]
+[Date "?"]
+[Round "?"]
+[White "?"]
+[Black "?"]
+[Result "*"]
+[FEN "4k3/8/8/8/8/8/8/R3K3 b - - 0 12"]
+
+12... Kd7 $2 {loses} 13. Ra7+ *
+
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.game.String(); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,339 @@
+package pgn
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrorInvalidPGN is thrown when a game cannot be read from the Portable Game Notation provided
+var ErrorInvalidPGN = errors.New("invalid PGN")
+
+// suffixes are the move suffix annotations, and the NAGs they are read as
+var suffixes = map[string]int{
+	"!":  1,
+	"?":  2,
+	"!!": 3,
+	"??": 4,
+	"!?": 5,
+	"?!": 6,
+}
+
+// Reader reads games one at a time from a collection in Portable Game Notation, so that large collections do not
+// have to be held in memory. Variations are skipped, and comments are kept on the move they follow.
+type Reader struct {
+	r    *bufio.Reader
+	line int
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r), line: 1}
+}
+
+// Read reads the next game, returning io.EOF when there are no games left
+func (r *Reader) Read() (Game, error) {
+	var g Game
+	started := false
+
+	for {
+		tok, err := r.token()
+		if err == io.EOF {
+			if started {
+				return g, nil
+			}
+
+			return Game{}, io.EOF
+		}
+
+		if err != nil {
+			return Game{}, err
+		}
+
+		switch {
+		case tok == "[":
+			if len(g.Moves) > 0 {
+				// The tags of the next game, when the last game was missing its result
+				return Game{}, r.errorf("tags after the moves of a game")
+			}
+
+			t, err := r.tag()
+			if err != nil {
+				return Game{}, err
+			}
+
+			g.Tags = append(g.Tags, t)
+		case tok == "(":
+			if err := r.skipVariation(); err != nil {
+				return Game{}, err
+			}
+		case strings.HasPrefix(tok, "{"):
+			if len(g.Moves) > 0 {
+				last := &g.Moves[len(g.Moves)-1]
+				last.Comment = strings.TrimSpace(last.Comment + " " + tok[1:])
+			}
+		case strings.HasPrefix(tok, "$"):
+			nag, err := strconv.Atoi(tok[1:])
+			if err != nil || len(g.Moves) == 0 {
+				return Game{}, r.errorf("unexpected NAG %q", tok)
+			}
+
+			g.Moves[len(g.Moves)-1].NAGs = append(g.Moves[len(g.Moves)-1].NAGs, nag)
+		case isResult(tok):
+			if g.Tag("Result") == "" {
+				g.SetTag("Result", tok)
+			}
+
+			return g, nil
+		case isMoveNumber(tok):
+		default:
+			san, nag := splitSuffix(tok)
+			if san == "" {
+				return Game{}, r.errorf("unexpected %q", tok)
+			}
+
+			m := Move{SAN: san}
+			if nag > 0 {
+				m.NAGs = []int{nag}
+			}
+
+			g.Moves = append(g.Moves, m)
+		}
+
+		started = true
+	}
+}
+
+// ReadAll reads every game left in the collection
+func (r *Reader) ReadAll() ([]Game, error) {
+	var games []Game
+
+	for {
+		g, err := r.Read()
+		if err == io.EOF {
+			return games, nil
+		}
+
+		if err != nil {
+			return games, err
+		}
+
+		games = append(games, g)
+	}
+}
+
+// Parse reads a single game in Portable Game Notation
+func Parse(s string) (Game, error) {
+	g, err := NewReader(strings.NewReader(s)).Read()
+	if err == io.EOF {
+		return Game{}, fmt.Errorf("%w: no game found", ErrorInvalidPGN)
+	}
+
+	return g, err
+}
+
+// token reads the next token, skipping whitespace, comments to the end of the line and escaped lines. Brace
+// comments are returned whole with their opening brace.
+func (r *Reader) token() (string, error) {
+	for {
+		c, err := r.next()
+		if err != nil {
+			return "", err
+		}
+
+		switch {
+		case unicode.IsSpace(c):
+		case c == ';':
+			if err := r.skipLine(); err != nil {
+				return "", err
+			}
+		case c == '%':
+			if err := r.skipLine(); err != nil {
+				return "", err
+			}
+		case c == '[' || c == ']' || c == '(' || c == ')':
+			return string(c), nil
+		case c == '{':
+			var sb strings.Builder
+			sb.WriteRune('{')
+
+			for {
+				c, err := r.next()
+				if err == io.EOF {
+					return "", r.errorf("unterminated comment")
+				}
+
+				if err != nil {
+					return "", err
+				}
+
+				if c == '}' {
+					return sb.String(), nil
+				}
+
+				if unicode.IsSpace(c) {
+					c = ' '
+				}
+
+				sb.WriteRune(c)
+			}
+		case c == '"':
+			return r.quoted()
+		default:
+			var sb strings.Builder
+			sb.WriteRune(c)
+
+			for {
+				c, err := r.next()
+				if err == io.EOF {
+					return sb.String(), nil
+				}
+
+				if err != nil {
+					return "", err
+				}
+
+				if unicode.IsSpace(c) || strings.ContainsRune("[](){};\"", c) {
+					r.unread(c)
+					return sb.String(), nil
+				}
+
+				sb.WriteRune(c)
+
+				// A move number can be written without a space before the move e.g. 1.e4
+				if c == '.' && isMoveNumber(sb.String()) {
+					if n, err := r.r.Peek(1); err == nil && n[0] != '.' {
+						return sb.String(), nil
+					}
+				}
+			}
+		}
+	}
+}
+
+// tag reads the rest of a tag pair, after its opening bracket
+func (r *Reader) tag() (Tag, error) {
+	name, err := r.token()
+	if err != nil {
+		return Tag{}, r.errorf("unterminated tag")
+	}
+
+	value, err := r.token()
+	if err != nil || !strings.HasPrefix(value, `"`) {
+		return Tag{}, r.errorf("tag %s has no value", name)
+	}
+
+	if end, err := r.token(); err != nil || end != "]" {
+		return Tag{}, r.errorf("unterminated tag %s", name)
+	}
+
+	return Tag{Name: name, Value: value[1:]}, nil
+}
+
+// quoted reads a string after its opening quote, returned with the opening quote so that it can be told apart
+// from other tokens
+func (r *Reader) quoted() (string, error) {
+	var sb strings.Builder
+	sb.WriteRune('"')
+
+	for {
+		c, err := r.next()
+		if err != nil {
+			return "", r.errorf("unterminated string")
+		}
+
+		switch c {
+		case '"':
+			return sb.String(), nil
+		case '\\':
+			if c, err = r.next(); err != nil {
+				return "", r.errorf("unterminated string")
+			}
+		}
+
+		sb.WriteRune(c)
+	}
+}
+
+// skipVariation skips a variation after its opening bracket, along with any variations inside it
+func (r *Reader) skipVariation() error {
+	for depth := 1; depth > 0; {
+		tok, err := r.token()
+		if err == io.EOF {
+			return r.errorf("unterminated variation")
+		}
+
+		if err != nil {
+			return err
+		}
+
+		switch tok {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		}
+	}
+
+	return nil
+}
+
+func (r *Reader) skipLine() error {
+	for {
+		c, err := r.next()
+		if err == io.EOF || c == '\n' {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (r *Reader) next() (rune, error) {
+	c, _, err := r.r.ReadRune()
+	if c == '\n' {
+		r.line++
+	}
+
+	return c, err
+}
+
+func (r *Reader) unread(c rune) {
+	if c == '\n' {
+		r.line--
+	}
+
+	_ = r.r.UnreadRune()
+}
+
+func (r *Reader) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: line %d: %s", ErrorInvalidPGN, r.line, fmt.Sprintf(format, args...))
+}
+
+func isResult(tok string) bool {
+	return tok == WhiteWins || tok == BlackWins || tok == Draw || tok == Unfinished
+}
+
+// isMoveNumber reports whether the token is a move number e.g. 12. or 12...
+func isMoveNumber(tok string) bool {
+	digits := strings.TrimRight(tok, ".")
+	if digits == "" || digits == tok {
+		return false
+	}
+
+	_, err := strconv.Atoi(digits)
+
+	return err == nil
+}
+
+// splitSuffix splits a move from its suffix annotation e.g. Nf3!? is Nf3 and 5
+func splitSuffix(tok string) (string, int) {
+	san := strings.TrimRight(tok, "!?")
+
+	return san, suffixes[tok[len(san):]]
+}
@@ -0,0 +1,79 @@
+package pgn_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/internal/pgn"
+)
+
+func TestRead(t *testing.T) {
+	const collection = `[Event "First"]
+[White "A \"the\" player"]
+[Result "1-0"]
+
+1. e4 {best by test} e5 2.Nf3 $1 (2. Qh5?! Nc6 (2... g6) 3. Bc4) 2... Nc6?
+; a comment to the end of the line
+3. Bb5 1-0
+
+[Event "Second"]
+
+1. d4 d5 *
+`
+
+	games, err := pgn.NewReader(strings.NewReader(collection)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(games) != 2 {
+		t.Fatalf("ReadAll() read %d games, want 2", len(games))
+	}
+
+	first := games[0]
+	if got := first.Tag("White"); got != `A "the" player` {
+		t.Errorf("Tag(White) = %q", got)
+	}
+
+	want := []pgn.Move{
+		{SAN: "e4", Comment: "best by test"},
+		{SAN: "e5"},
+		{SAN: "Nf3", NAGs: []int{1}},
+		{SAN: "Nc6", NAGs: []int{2}},
+		{SAN: "Bb5"},
+	}
+
+	if !reflect.DeepEqual(first.Moves, want) {
+		t.Errorf("Moves = %+v, want %+v", first.Moves, want)
+	}
+
+	if games[1].Result() != pgn.Unfinished || len(games[1].Moves) != 2 {
+		t.Errorf("second game = %+v", games[1])
+	}
+
+	// Writing the game and reading it back gets the same game
+	again, err := pgn.Parse(first.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(again.Moves, first.Moves) || again.Result() != first.Result() {
+		t.Errorf("Parse(String()) = %+v, want %+v", again, first)
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	for _, s := range []string{
+		`[Event "unterminated`,
+		`1. e4 {no end`,
+		`1. e4 (1. d4`,
+		`$1 e4`,
+		``,
+	} {
+		if _, err := pgn.Parse(s); !errors.Is(err, pgn.ErrorInvalidPGN) {
+			t.Errorf("Parse(%q) returned %v, want ErrorInvalidPGN", s, err)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package piece
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tomwatson6/chessbot/internal/colour"
+)
+
+// ErrorUnknownPieceType is thrown when creating a piece from a type that does not exist
+var ErrorUnknownPieceType = errors.New("unknown piece type")
+
+// NewPieceDetails creates the details of a piece of the type provided, for restoring pieces that have been saved
+func NewPieceDetails(t PieceType, c colour.Colour, hasMoved bool) (PieceDetails, error) {
+	switch t {
+	case PieceTypePawn:
+		return NewPawn(PawnWithColour(c), PawnWithHasMoved(hasMoved)), nil
+	case PieceTypeKnight:
+		return NewKnight(), nil
+	case PieceTypeBishop:
+		return NewBishop(), nil
+	case PieceTypeRook:
+		return NewRook(RookWithHasMoved(hasMoved)), nil
+	case PieceTypeQueen:
+		return NewQueen(), nil
+	case PieceTypeKing:
+		return NewKing(KingWithHasMoved(hasMoved)), nil
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrorUnknownPieceType, t)
+	}
+}
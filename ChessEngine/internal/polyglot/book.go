@@ -0,0 +1,250 @@
+package polyglot
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/piece"
+)
+
+// entrySize is the number of bytes of each entry of a book
+const entrySize = 16
+
+// ErrorInvalidBook is thrown when a book is not a whole number of entries
+var ErrorInvalidBook = errors.New("invalid Polyglot book")
+
+// Selection is how a move is chosen from the moves a book has for a position
+type Selection string
+
+const (
+	// Weighted chooses at random, in proportion to the weight of each move
+	Weighted Selection = "weighted"
+	// Best chooses the move with the highest weight
+	Best Selection = "best"
+)
+
+// ParseSelection gets the selection with the name provided
+func ParseSelection(s string) (Selection, error) {
+	switch sel := Selection(s); sel {
+	case Weighted, Best:
+		return sel, nil
+	default:
+		return "", fmt.Errorf("unknown book selection %q", s)
+	}
+}
+
+// BookMove is a move of a book along with its weight
+type BookMove struct {
+	Move      move.Move
+	Promotion piece.PieceType
+	Weight    uint16
+}
+
+// Book is a Polyglot opening book held in memory, sorted by key so that positions can be found with a binary
+// search. It is safe for concurrent use.
+type Book struct {
+	entries   []Entry
+	selection Selection
+	maxPly    int
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+type Option func(b *Book)
+
+// WithSelection sets how moves are chosen from the book, weighted random by default
+func WithSelection(s Selection) Option {
+	return func(b *Book) {
+		b.selection = s
+	}
+}
+
+// WithMaxPly stops the book being used once the number of half moves provided have been played, 0 for no limit
+func WithMaxPly(n int) Option {
+	return func(b *Book) {
+		b.maxPly = n
+	}
+}
+
+// WithRand sets the source of randomness of weighted selection, so that the moves chosen can be repeated
+func WithRand(r *rand.Rand) Option {
+	return func(b *Book) {
+		b.rand = r
+	}
+}
+
+func New(entries []Entry, opts ...Option) *Book {
+	b := &Book{
+		entries:   append([]Entry(nil), entries...),
+		selection: Weighted,
+		rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	sort.SliceStable(b.entries, func(i, j int) bool {
+		return b.entries[i].Key < b.entries[j].Key
+	})
+
+	return b
+}
+
+// Read reads a book in the Polyglot .bin format
+func Read(r io.Reader, opts ...Option) (*Book, error) {
+	var entries []Entry
+
+	br := bufio.NewReader(r)
+	buf := make([]byte, entrySize)
+
+	for {
+		n, err := io.ReadFull(br, buf)
+		if err == io.EOF {
+			break
+		}
+
+		if err == io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("%w: %d trailing bytes", ErrorInvalidBook, n)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, Entry{
+			Key:    binary.BigEndian.Uint64(buf[0:8]),
+			Move:   binary.BigEndian.Uint16(buf[8:10]),
+			Weight: binary.BigEndian.Uint16(buf[10:12]),
+			Learn:  binary.BigEndian.Uint32(buf[12:16]),
+		})
+	}
+
+	return New(entries, opts...), nil
+}
+
+// Open reads the book in the file at the path provided
+func Open(path string, opts ...Option) (*Book, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read(f, opts...)
+}
+
+// Write writes the entries in the Polyglot .bin format, in the order provided
+func Write(w io.Writer, entries []Entry) error {
+	bw := bufio.NewWriter(w)
+	buf := make([]byte, entrySize)
+
+	for _, e := range entries {
+		binary.BigEndian.PutUint64(buf[0:8], e.Key)
+		binary.BigEndian.PutUint16(buf[8:10], e.Move)
+		binary.BigEndian.PutUint16(buf[10:12], e.Weight)
+		binary.BigEndian.PutUint32(buf[12:16], e.Learn)
+
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// Len gets the number of entries in the book
+func (b *Book) Len() int {
+	return len(b.entries)
+}
+
+// Moves gets the legal moves the book has for the position, highest weight first. Moves that are not legal, which
+// can happen when two positions share a key, are left out.
+func (b *Book) Moves(c chess.Chess) []BookMove {
+	key := Key(c)
+
+	i := sort.Search(len(b.entries), func(i int) bool {
+		return b.entries[i].Key >= key
+	})
+
+	var moves []BookMove
+	var legal []move.Move
+
+	for ; i < len(b.entries) && b.entries[i].Key == key; i++ {
+		if legal == nil {
+			legal = c.LegalMoves()
+		}
+
+		m, promotion := DecodeMove(c, b.entries[i].Move)
+		if !contains(legal, m) {
+			continue
+		}
+
+		if c.IsPromotion(m) && promotion == piece.PieceTypePawn {
+			promotion = piece.PieceTypeQueen
+		}
+
+		moves = append(moves, BookMove{Move: m, Promotion: promotion, Weight: b.entries[i].Weight})
+	}
+
+	sort.SliceStable(moves, func(i, j int) bool {
+		return moves[i].Weight > moves[j].Weight
+	})
+
+	return moves
+}
+
+// Probe chooses a move from the book for the position, false if the book has no moves for it or the position is
+// past the depth limit of the book
+func (b *Book) Probe(c chess.Chess) (BookMove, bool) {
+	if b.maxPly > 0 && Ply(c) >= b.maxPly {
+		return BookMove{}, false
+	}
+
+	moves := b.Moves(c)
+	if len(moves) == 0 {
+		return BookMove{}, false
+	}
+
+	var total int
+	for _, m := range moves {
+		total += int(m.Weight)
+	}
+
+	if b.selection == Best || total == 0 {
+		return moves[0], true
+	}
+
+	b.mu.Lock()
+	n := b.rand.Intn(total)
+	b.mu.Unlock()
+
+	for _, m := range moves {
+		if n -= int(m.Weight); n < 0 {
+			return m, true
+		}
+	}
+
+	return moves[0], true
+}
+
+func contains(moves []move.Move, m move.Move) bool {
+	for _, l := range moves {
+		if l == m {
+			return true
+		}
+	}
+
+	return false
+}
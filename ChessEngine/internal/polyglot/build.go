@@ -0,0 +1,223 @@
+package polyglot
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/pgn"
+)
+
+// DefaultBuildDepth is the number of half moves of each game added to a book by default
+const DefaultBuildDepth = 20
+
+// tally is the results of the games a move was played in, from the point of view of the side that played it
+type tally struct {
+	wins, draws, losses int
+}
+
+// points gets the weight of a move, 2 for each win and 1 for each draw, so that moves that are played often and
+// score well are chosen most
+func (t tally) points() int {
+	return 2*t.wins + t.draws
+}
+
+func (t tally) games() int {
+	return t.wins + t.draws + t.losses
+}
+
+// Builder builds a book from a collection of games
+type Builder struct {
+	depth    int
+	minGames int
+	moves    map[uint64]map[uint16]*tally
+	games    int
+}
+
+type BuildOption func(b *Builder)
+
+// WithDepth sets the number of half moves of each game added to the book
+func WithDepth(n int) BuildOption {
+	return func(b *Builder) {
+		b.depth = n
+	}
+}
+
+// WithMinGames leaves out moves played in fewer games than the number provided
+func WithMinGames(n int) BuildOption {
+	return func(b *Builder) {
+		b.minGames = n
+	}
+}
+
+func NewBuilder(opts ...BuildOption) *Builder {
+	b := &Builder{
+		depth:    DefaultBuildDepth,
+		minGames: 1,
+		moves:    make(map[uint64]map[uint16]*tally),
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
+}
+
+// Add adds the moves of the game to the book. Games without a result are skipped, as their moves cannot be
+// weighted.
+func (b *Builder) Add(g pgn.Game) error {
+	var winner *colour.Colour
+
+	switch g.Result() {
+	case pgn.WhiteWins:
+		w := colour.White
+		winner = &w
+	case pgn.BlackWins:
+		w := colour.Black
+		winner = &w
+	case pgn.Draw:
+	default:
+		return nil
+	}
+
+	fen := g.Tag("FEN")
+	if fen == "" {
+		fen = chess.StartingFEN
+	}
+
+	c, err := chess.FromFEN(fen)
+	if err != nil {
+		return err
+	}
+
+	type played struct {
+		key  uint64
+		move uint16
+		turn colour.Colour
+	}
+
+	// The moves are only added once the whole game has been read, so that a game with an illegal move adds nothing
+	var moves []played
+
+	for i, pm := range g.Moves {
+		if i >= b.depth {
+			break
+		}
+
+		m, promotion, err := c.ParseSAN(pm.SAN)
+		if err != nil {
+			return fmt.Errorf("move %d: %w", i/2+1, err)
+		}
+
+		moves = append(moves, played{key: Key(c), move: EncodeMove(c, m, promotion), turn: c.Turn})
+
+		if _, err := c.MakeMoveWithPromotion(m, promotion); err != nil {
+			return fmt.Errorf("move %d: %w", i/2+1, err)
+		}
+	}
+
+	for _, p := range moves {
+		if b.moves[p.key] == nil {
+			b.moves[p.key] = make(map[uint16]*tally)
+		}
+
+		t := b.moves[p.key][p.move]
+		if t == nil {
+			t = &tally{}
+			b.moves[p.key][p.move] = t
+		}
+
+		switch {
+		case winner == nil:
+			t.draws++
+		case *winner == p.turn:
+			t.wins++
+		default:
+			t.losses++
+		}
+	}
+
+	b.games++
+
+	return nil
+}
+
+// AddAll adds every game read from the collection, returning the number of games that could not be read
+func (b *Builder) AddAll(r *pgn.Reader) (int, error) {
+	var skipped int
+
+	for {
+		g, err := r.Read()
+		if err == io.EOF {
+			return skipped, nil
+		}
+
+		if err != nil {
+			return skipped, err
+		}
+
+		if err := b.Add(g); err != nil {
+			skipped++
+		}
+	}
+}
+
+// Games gets the number of games added to the book
+func (b *Builder) Games() int {
+	return b.games
+}
+
+// Entries gets the entries of the book, sorted by key then weight. Moves that never scored a point are left out,
+// and weights are scaled down to fit when the most points scored by a move does not.
+func (b *Builder) Entries() []Entry {
+	var entries []Entry
+	var points []int
+
+	highest := 0
+
+	for key, moves := range b.moves {
+		for v, t := range moves {
+			if t.games() < b.minGames || t.points() == 0 {
+				continue
+			}
+
+			entries = append(entries, Entry{Key: key, Move: v})
+			points = append(points, t.points())
+
+			if t.points() > highest {
+				highest = t.points()
+			}
+		}
+	}
+
+	for i := range entries {
+		w := points[i]
+		if highest > 0xffff {
+			w = (w*0xffff + highest - 1) / highest
+		}
+
+		entries[i].Weight = uint16(w)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Key != entries[j].Key {
+			return entries[i].Key < entries[j].Key
+		}
+
+		if entries[i].Weight != entries[j].Weight {
+			return entries[i].Weight > entries[j].Weight
+		}
+
+		return entries[i].Move < entries[j].Move
+	})
+
+	return entries
+}
+
+// Write writes the book in the Polyglot .bin format
+func (b *Builder) Write(w io.Writer) error {
+	return Write(w, b.Entries())
+}
@@ -0,0 +1,167 @@
+// Package polyglot reads and builds opening books in the Polyglot .bin format, which most chess programs use
+package polyglot
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/piece"
+)
+
+// The offsets of the castling rights, en passant file and turn in the Random64 table
+const (
+	castleOffset    = 768
+	enPassantOffset = 772
+	turnOffset      = 780
+)
+
+// Entry is a move of a position in a book, stored big-endian in 16 bytes
+type Entry struct {
+	Key    uint64
+	Move   uint16
+	Weight uint16
+	Learn  uint32
+}
+
+// kinds are the order of the types of pieces in the Random64 table, each type has a black then a white piece
+var kinds = map[piece.PieceType]int{
+	piece.PieceTypePawn:   0,
+	piece.PieceTypeKnight: 1,
+	piece.PieceTypeBishop: 2,
+	piece.PieceTypeRook:   3,
+	piece.PieceTypeQueen:  4,
+	piece.PieceTypeKing:   5,
+}
+
+// promotions are the types of piece promoted to, in the order they are numbered in the moves of a book
+var promotions = []piece.PieceType{
+	piece.PieceTypePawn,
+	piece.PieceTypeKnight,
+	piece.PieceTypeBishop,
+	piece.PieceTypeRook,
+	piece.PieceTypeQueen,
+}
+
+// Key gets the Polyglot hash of the position. En passant is only hashed when a pawn can capture the pawn that
+// has just moved two squares, as Polyglot requires.
+func Key(c chess.Chess) uint64 {
+	var key uint64
+
+	for pos, p := range c.Board.Pieces {
+		kind := 2 * kinds[p.GetPieceType()]
+		if p.Colour == colour.White {
+			kind++
+		}
+
+		key ^= random[64*kind+8*pos.Rank+pos.File]
+	}
+
+	fields := strings.Fields(c.FEN())
+
+	for i, r := range "KQkq" {
+		if strings.ContainsRune(fields[2], r) {
+			key ^= random[castleOffset+i]
+		}
+	}
+
+	if ep, err := move.ParsePosition(fields[3]); err == nil && canCaptureEnPassant(c, ep) {
+		key ^= random[enPassantOffset+ep.File]
+	}
+
+	if c.Turn == colour.White {
+		key ^= random[turnOffset]
+	}
+
+	return key
+}
+
+// canCaptureEnPassant reports whether a pawn of the colour to move stands next to the pawn that has just moved
+// past the square provided
+func canCaptureEnPassant(c chess.Chess, ep move.Position) bool {
+	rank := ep.Rank - 1
+	if c.Turn == colour.Black {
+		rank = ep.Rank + 1
+	}
+
+	for _, file := range []int{ep.File - 1, ep.File + 1} {
+		p, ok := c.Board.Pieces[move.Position{File: file, Rank: rank}]
+		if ok && p.Colour == c.Turn && p.GetPieceType() == piece.PieceTypePawn {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Ply gets the number of half moves played before the position, from its fullmove number
+func Ply(c chess.Chess) int {
+	fields := strings.Fields(c.FEN())
+
+	fullmove, err := strconv.Atoi(fields[5])
+	if err != nil || fullmove < 1 {
+		fullmove = 1
+	}
+
+	ply := 2 * (fullmove - 1)
+	if c.Turn == colour.Black {
+		ply++
+	}
+
+	return ply
+}
+
+// EncodeMove gets the move as it is stored in a book, where castling is written as the king taking its own rook
+func EncodeMove(c chess.Chess, m move.Move, promotion piece.PieceType) uint16 {
+	to := m.To
+
+	if p, ok := c.Board.Pieces[m.From]; ok && p.GetPieceType() == piece.PieceTypeKing {
+		switch m.To.File - m.From.File {
+		case 2:
+			to.File = c.Board.Width - 1
+		case -2:
+			to.File = 0
+		}
+	}
+
+	var promo int
+	if c.IsPromotion(m) {
+		for i, t := range promotions {
+			if t == promotion {
+				promo = i
+			}
+		}
+	}
+
+	return uint16(to.File | to.Rank<<3 | m.From.File<<6 | m.From.Rank<<9 | promo<<12)
+}
+
+// DecodeMove gets the move stored in a book in the position provided, turning the king taking its own rook back
+// into castling. The type of piece promoted to is a pawn when the move is not a promotion.
+func DecodeMove(c chess.Chess, v uint16) (move.Move, piece.PieceType) {
+	m := move.Move{
+		From: move.Position{File: int(v >> 6 & 7), Rank: int(v >> 9 & 7)},
+		To:   move.Position{File: int(v & 7), Rank: int(v >> 3 & 7)},
+	}
+
+	promotion := piece.PieceTypePawn
+	if i := int(v >> 12 & 7); i < len(promotions) {
+		promotion = promotions[i]
+	}
+
+	king, ok := c.Board.Pieces[m.From]
+	rook, ok2 := c.Board.Pieces[m.To]
+
+	if ok && ok2 && king.GetPieceType() == piece.PieceTypeKing && rook.GetPieceType() == piece.PieceTypeRook &&
+		king.Colour == rook.Colour {
+		if m.To.File > m.From.File {
+			m.To.File = m.From.File + 2
+		} else {
+			m.To.File = m.From.File - 2
+		}
+	}
+
+	return m, promotion
+}
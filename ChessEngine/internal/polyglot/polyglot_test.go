@@ -0,0 +1,180 @@
+package polyglot_test
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/pgn"
+	"github.com/tomwatson6/chessbot/internal/polyglot"
+)
+
+func play(t *testing.T, moves ...string) chess.Chess {
+	t.Helper()
+
+	c := chess.New(colour.White)
+
+	for _, s := range moves {
+		m, _, err := move.ParseUCI(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := c.MakeMove(m); err != nil {
+			t.Fatalf("MakeMove(%s) returned error: %s", s, err)
+		}
+	}
+
+	return c
+}
+
+// The keys are the examples given with the Polyglot book format
+func TestKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		moves []string
+		want  uint64
+	}{
+		{name: "starting position", want: 0x463b96181691fc9c},
+		{name: "e4", moves: []string{"e2e4"}, want: 0x823c9b50fd114196},
+		{name: "e4 d5", moves: []string{"e2e4", "d7d5"}, want: 0x0756b94461c50fb0},
+		{name: "e4 d5 e5", moves: []string{"e2e4", "d7d5", "e4e5"}, want: 0x662fafb965db29d4},
+		{name: "en passant can be captured", moves: []string{"e2e4", "d7d5", "e4e5", "f7f5"}, want: 0x22a48b5a8e47ff78},
+		{name: "white loses castling rights", moves: []string{"e2e4", "d7d5", "e4e5", "f7f5", "e1e2"}, want: 0x652a607ca3f242c1},
+		{name: "black loses castling rights", moves: []string{"e2e4", "d7d5", "e4e5", "f7f5", "e1e2", "e8f7"}, want: 0x00fdd303c946bdd9},
+		{name: "en passant by black", moves: []string{"a2a4", "b7b5", "h2h4", "b5b4", "c2c4"}, want: 0x3c8123ea7b067637},
+		{name: "rook loses castling rights", moves: []string{"a2a4", "b7b5", "h2h4", "b5b4", "c2c4", "b4c3", "a1a3"}, want: 0x5c3f9b829b279560},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := polyglot.Key(play(t, tt.moves...)); got != tt.want {
+				t.Errorf("Key() = %016x, want %016x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeMove(t *testing.T) {
+	c, err := chess.FromFEN("r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	castle := move.Move{From: move.Position{File: 4, Rank: 0}, To: move.Position{File: 6, Rank: 0}}
+
+	v := polyglot.EncodeMove(c, castle, 0)
+	if want := uint16(4<<6 | 7); v != want {
+		t.Fatalf("EncodeMove(O-O) = %d, want %d as the king takes the rook", v, want)
+	}
+
+	if m, _ := polyglot.DecodeMove(c, v); m != castle {
+		t.Errorf("DecodeMove(%d) = %s, want %s", v, m.UCI(), castle.UCI())
+	}
+}
+
+const games = `[Event "a"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 Nc6 1-0
+
+[Event "b"]
+[Result "1-0"]
+
+1. e4 c5 2. Nf3 1-0
+
+[Event "c"]
+[Result "0-1"]
+
+1. d4 d5 0-1
+
+[Event "d"]
+[Result "1/2-1/2"]
+
+1. e4 e5 1/2-1/2
+`
+
+func TestBuild(t *testing.T) {
+	b := polyglot.NewBuilder(polyglot.WithDepth(2))
+
+	skipped, err := b.AddAll(pgn.NewReader(strings.NewReader(games)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if skipped != 0 || b.Games() != 4 {
+		t.Fatalf("AddAll() added %d games, skipped %d", b.Games(), skipped)
+	}
+
+	var buf bytes.Buffer
+	if err := b.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	book, err := polyglot.Read(&buf, polyglot.WithSelection(polyglot.Best))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// e4 won twice and drew once, d4 lost its only game so is left out
+	start := play(t)
+	moves := book.Moves(start)
+	if len(moves) != 1 || moves[0].Move.UCI() != "e2e4" || moves[0].Weight != 5 {
+		t.Fatalf("Moves(start) = %+v, want only e2e4 with weight 5", moves)
+	}
+
+	// Black's replies are weighted from black's point of view, the draw with e5 beats the loss with c5
+	moves = book.Moves(play(t, "e2e4"))
+	if len(moves) != 1 || moves[0].Move.UCI() != "e7e5" || moves[0].Weight != 1 {
+		t.Fatalf("Moves(e4) = %+v, want only e7e5 with weight 1", moves)
+	}
+
+	// The depth limit of the build leaves out white's second move
+	if _, ok := book.Probe(play(t, "e2e4", "e7e5")); ok {
+		t.Error("Probe() found a move past the depth of the book")
+	}
+}
+
+func TestProbe(t *testing.T) {
+	start := play(t)
+	key := polyglot.Key(start)
+
+	e4 := polyglot.EncodeMove(start, move.Move{From: move.Position{File: 4, Rank: 1}, To: move.Position{File: 4, Rank: 3}}, 0)
+	d4 := polyglot.EncodeMove(start, move.Move{From: move.Position{File: 3, Rank: 1}, To: move.Position{File: 3, Rank: 3}}, 0)
+	illegal := polyglot.EncodeMove(start, move.Move{From: move.Position{File: 4, Rank: 1}, To: move.Position{File: 4, Rank: 4}}, 0)
+
+	entries := []polyglot.Entry{
+		{Key: key, Move: d4, Weight: 1},
+		{Key: key, Move: e4, Weight: 3},
+		{Key: key, Move: illegal, Weight: 100},
+	}
+
+	best, ok := polyglot.New(entries, polyglot.WithSelection(polyglot.Best)).Probe(start)
+	if !ok || best.Move.UCI() != "e2e4" {
+		t.Errorf("Probe() with best selection = %s, want e2e4", best.Move.UCI())
+	}
+
+	counts := make(map[string]int)
+	book := polyglot.New(entries, polyglot.WithRand(rand.New(rand.NewSource(1))))
+	for i := 0; i < 200; i++ {
+		m, ok := book.Probe(start)
+		if !ok {
+			t.Fatal("Probe() found no move")
+		}
+
+		counts[m.Move.UCI()]++
+	}
+
+	if counts["e2e4"] <= counts["d2d4"] || counts["d2d4"] == 0 || len(counts) != 2 {
+		t.Errorf("Probe() with weighted selection chose %v, want e2e4 about three times as often as d2d4", counts)
+	}
+
+	limited := polyglot.New(entries, polyglot.WithMaxPly(1))
+	if _, ok := limited.Probe(play(t, "g1f3", "g8f6")); ok {
+		t.Error("Probe() found a move past the depth limit")
+	}
+}
@@ -0,0 +1,93 @@
+// Package problem solves chess problems: direct mates, self-mates and help-mates in a number of moves
+package problem
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrorInvalidProblem is thrown when a problem cannot be read from its notation e.g. #2, s#3 or h#2
+	ErrorInvalidProblem = errors.New("invalid problem")
+)
+
+// Stipulation is what the solution of a problem has to achieve
+type Stipulation int
+
+const (
+	// Mate is a direct mate, the side to move forces mate against any defence
+	Mate Stipulation = iota
+	// SelfMate is a self-mate, the side to move forces the other side to mate it, however it defends
+	SelfMate
+	// HelpMate is a help-mate, the side to move and the other side cooperate so that the side to move is mated
+	HelpMate
+)
+
+var stipulations = []struct {
+	name   string
+	prefix string
+}{
+	Mate:     {name: "mate", prefix: "#"},
+	SelfMate: {name: "selfmate", prefix: "s#"},
+	HelpMate: {name: "helpmate", prefix: "h#"},
+}
+
+func (s Stipulation) String() string {
+	if s < 0 || int(s) >= len(stipulations) {
+		return "unknown"
+	}
+
+	return stipulations[s].name
+}
+
+func (s Stipulation) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Stipulation) UnmarshalText(text []byte) error {
+	for i, st := range stipulations {
+		if st.name == string(text) {
+			*s = Stipulation(i)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: unknown stipulation %q", ErrorInvalidProblem, text)
+}
+
+// Problem is a stipulation to be achieved in a number of moves of the side to move
+type Problem struct {
+	Stipulation Stipulation `json:"stipulation"`
+	Moves       int         `json:"moves"`
+}
+
+// Parse reads a problem from its usual notation, #2 for a mate in two, s#3 for a self-mate in three and h#2 for a
+// help-mate in two
+func Parse(s string) (Problem, error) {
+	// The longest prefixes are checked first, so that s#2 is not read as a mate
+	for _, st := range []Stipulation{SelfMate, HelpMate, Mate} {
+		rest := strings.TrimPrefix(strings.ToLower(s), stipulations[st].prefix)
+		if len(rest) == len(s) {
+			continue
+		}
+
+		n, err := strconv.Atoi(rest)
+		if err != nil || n <= 0 {
+			return Problem{}, fmt.Errorf("%w: %q", ErrorInvalidProblem, s)
+		}
+
+		return Problem{Stipulation: st, Moves: n}, nil
+	}
+
+	return Problem{}, fmt.Errorf("%w: %q", ErrorInvalidProblem, s)
+}
+
+func (p Problem) String() string {
+	if p.Stipulation < 0 || int(p.Stipulation) >= len(stipulations) {
+		return fmt.Sprintf("?%d", p.Moves)
+	}
+
+	return stipulations[p.Stipulation].prefix + strconv.Itoa(p.Moves)
+}
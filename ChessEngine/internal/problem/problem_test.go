@@ -0,0 +1,156 @@
+package problem_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/problem"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want problem.Problem
+		err  error
+	}{
+		{in: "#2", want: problem.Problem{Stipulation: problem.Mate, Moves: 2}},
+		{in: "s#3", want: problem.Problem{Stipulation: problem.SelfMate, Moves: 3}},
+		{in: "H#1", want: problem.Problem{Stipulation: problem.HelpMate, Moves: 1}},
+		{in: "#0", err: problem.ErrorInvalidProblem},
+		{in: "x#2", err: problem.ErrorInvalidProblem},
+		{in: "2", err: problem.ErrorInvalidProblem},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := problem.Parse(tt.in)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSolve(t *testing.T) {
+	tests := []struct {
+		name    string
+		fen     string
+		problem string
+		keys    []string
+		// line is the first line of the solution, the key followed by the first child of each move
+		line []string
+	}{
+		{
+			name:    "mate in one",
+			fen:     "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1",
+			problem: "#1",
+			keys:    []string{"Ra8#"},
+			line:    []string{"Ra8#"},
+		},
+		{
+			name:    "no mate in one",
+			fen:     "7k/8/5K2/8/8/8/8/R7 w - - 0 1",
+			problem: "#1",
+		},
+		{
+			name:    "cooked mate in two",
+			fen:     "7k/8/5K2/8/8/8/8/R7 w - - 0 1",
+			problem: "#2",
+			keys:    []string{"Kg6", "Kf7"},
+			line:    []string{"Kg6", "Kg8", "Ra8#"},
+		},
+		{
+			name:    "self-mate in one",
+			fen:     "8/Q7/8/8/8/2p5/6PP/3rk2K w - - 0 1",
+			problem: "s#1",
+			keys:    []string{"Qf2+"},
+			line:    []string{"Qf2+", "Kxf2#"},
+		},
+		{
+			name:    "help-mate in one",
+			fen:     "k7/8/1K6/8/8/8/8/7R b - - 0 1",
+			problem: "h#1",
+			keys:    []string{"Kb8"},
+			line:    []string{"Kb8", "Rh8#"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := chess.FromFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			p, err := problem.Parse(tt.problem)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sol, err := problem.Solve(context.Background(), c, p)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var keys []string
+			for _, k := range sol.Keys {
+				keys = append(keys, k.SAN)
+			}
+
+			if len(keys) != len(tt.keys) {
+				t.Fatalf("got keys %v, want %v", keys, tt.keys)
+			}
+
+			for i := range keys {
+				if keys[i] != tt.keys[i] {
+					t.Errorf("got keys %v, want %v", keys, tt.keys)
+				}
+			}
+
+			if got := len(sol.Cooks()); got != len(tt.keys)-1 && len(tt.keys) > 0 {
+				t.Errorf("got %d cooks, want %d", got, len(tt.keys)-1)
+			}
+
+			if !sol.Solved() {
+				return
+			}
+
+			var line []string
+			for n := sol.Keys[0]; n != nil; {
+				line = append(line, n.SAN)
+
+				if len(n.Children) == 0 {
+					break
+				}
+
+				n = n.Children[0]
+			}
+
+			if len(line) != len(tt.line) {
+				t.Fatalf("got line %v, want %v", line, tt.line)
+			}
+
+			for i := range line {
+				if line[i] != tt.line[i] {
+					t.Errorf("got line %v, want %v", line, tt.line)
+				}
+			}
+		})
+	}
+}
+
+func TestSolveCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := problem.Solve(ctx, chess.New(0), problem.Problem{Stipulation: problem.Mate, Moves: 3})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,398 @@
+package problem
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/piece"
+	"github.com/tomwatson6/chessbot/internal/polyglot"
+)
+
+// Node is a move of a solution, followed by every defence to it, or by the move that answers a defence. The moves
+// of a help-mate are followed by every move that goes on to a solution.
+type Node struct {
+	SAN      string  `json:"san"`
+	UCI      string  `json:"uci"`
+	Children []*Node `json:"children,omitempty"`
+}
+
+// Solution is the result of solving a problem. Keys are the first moves that solve it, a sound problem has exactly
+// one and any others are cooks.
+type Solution struct {
+	Problem
+	Keys  []*Node `json:"keys"`
+	Nodes int64   `json:"nodes"`
+}
+
+// Solved reports whether the problem has a solution
+func (s Solution) Solved() bool {
+	return len(s.Keys) > 0
+}
+
+// Cooks gets the keys beyond the first, which make the problem unsound
+func (s Solution) Cooks() []*Node {
+	if len(s.Keys) < 2 {
+		return nil
+	}
+
+	return s.Keys[1:]
+}
+
+// play is a move along with the piece promoted to, pawn when the move is not a promotion
+type play struct {
+	move      move.Move
+	promotion piece.PieceType
+}
+
+type memoKey struct {
+	key   uint64
+	moves int
+	// defending is whether the key is of the position after a move of the side that started
+	defending bool
+}
+
+type solver struct {
+	ctx   context.Context
+	stip  Stipulation
+	memo  map[memoKey]bool
+	nodes int64
+}
+
+// Solve proves or refutes the problem in the position, finding every key along with the full tree of defences to
+// each. Mate is checkmate, stalemate never solves a problem.
+func Solve(ctx context.Context, c chess.Chess, p Problem) (Solution, error) {
+	if p.Moves <= 0 || p.Stipulation < Mate || p.Stipulation > HelpMate {
+		return Solution{}, fmt.Errorf("%w: %s", ErrorInvalidProblem, p)
+	}
+
+	s := &solver{ctx: ctx, stip: p.Stipulation, memo: make(map[memoKey]bool)}
+	sol := Solution{Problem: p}
+
+	for _, pl := range s.plays(c) {
+		next := after(c, pl)
+
+		ok, err := s.follows(next, p.Moves)
+		if err != nil {
+			return Solution{}, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		n, err := s.node(c, pl)
+		if err != nil {
+			return Solution{}, err
+		}
+
+		if n.Children, err = s.tree(next, p.Moves); err != nil {
+			return Solution{}, err
+		}
+
+		sol.Keys = append(sol.Keys, n)
+	}
+
+	sol.Nodes = s.nodes
+
+	return sol, nil
+}
+
+// follows reports whether the position after a move of the side that started, with the moves provided left to it
+// including that move, still solves the problem
+func (s *solver) follows(c chess.Chess, moves int) (bool, error) {
+	switch s.stip {
+	case SelfMate:
+		if s.mated(c) {
+			// Mating the other side is the one way a self-mate cannot be solved
+			return false, nil
+		}
+
+		return s.forcedToMate(c, moves-1)
+	case HelpMate:
+		return s.helped(c, moves)
+	default:
+		if moves == 1 && !c.Board.InCheck(c.Turn) {
+			// The last move has to mate, so it has to check
+			return false, nil
+		}
+
+		return s.defeated(c, moves)
+	}
+}
+
+// attacks reports whether the side to move solves the problem in the moves provided
+func (s *solver) attacks(c chess.Chess, moves int) (bool, error) {
+	k := memoKey{key: polyglot.Key(c), moves: moves}
+	if v, ok := s.memo[k]; ok {
+		return v, nil
+	}
+
+	if err := s.ctx.Err(); err != nil {
+		return false, err
+	}
+
+	found := false
+
+	for _, pl := range s.plays(c) {
+		ok, err := s.follows(after(c, pl), moves)
+		if err != nil {
+			return false, err
+		}
+
+		if ok {
+			found = true
+			break
+		}
+	}
+
+	s.memo[k] = found
+
+	return found, nil
+}
+
+// defeated reports whether every defence of the side to move is mated within the moves the attacker has left,
+// including the move after the defence
+func (s *solver) defeated(c chess.Chess, moves int) (bool, error) {
+	k := memoKey{key: polyglot.Key(c), moves: moves, defending: true}
+	if v, ok := s.memo[k]; ok {
+		return v, nil
+	}
+
+	defences := s.plays(c)
+
+	result := true
+	switch {
+	case len(defences) == 0:
+		result = c.Board.InCheck(c.Turn)
+	case moves == 1:
+		result = false
+	default:
+		for _, pl := range defences {
+			ok, err := s.attacks(after(c, pl), moves-1)
+			if err != nil {
+				return false, err
+			}
+
+			if !ok {
+				result = false
+				break
+			}
+		}
+	}
+
+	s.memo[k] = result
+
+	return result, nil
+}
+
+// forcedToMate reports whether the side to move has to mate the side that started, either now or after one of
+// the moves left to it
+func (s *solver) forcedToMate(c chess.Chess, moves int) (bool, error) {
+	k := memoKey{key: polyglot.Key(c), moves: moves, defending: true}
+	if v, ok := s.memo[k]; ok {
+		return v, nil
+	}
+
+	defences := s.plays(c)
+
+	// With no moves at all the side to move has been mated or stalemated, neither of which solves a self-mate
+	result := len(defences) > 0
+
+	for _, pl := range defences {
+		next := after(c, pl)
+		if s.mated(next) {
+			continue
+		}
+
+		if moves == 0 {
+			result = false
+			break
+		}
+
+		ok, err := s.attacks(next, moves)
+		if err != nil {
+			return false, err
+		}
+
+		if !ok {
+			result = false
+			break
+		}
+	}
+
+	s.memo[k] = result
+
+	return result, nil
+}
+
+// helped reports whether the side that did not start can reply to the move just made so that the help-mate is
+// solved within the moves left, including that reply
+func (s *solver) helped(c chess.Chess, moves int) (bool, error) {
+	k := memoKey{key: polyglot.Key(c), moves: moves, defending: true}
+	if v, ok := s.memo[k]; ok {
+		return v, nil
+	}
+
+	found := false
+
+	for _, pl := range s.plays(c) {
+		ok, err := s.helpedBy(after(c, pl), moves)
+		if err != nil {
+			return false, err
+		}
+
+		if ok {
+			found = true
+			break
+		}
+	}
+
+	s.memo[k] = found
+
+	return found, nil
+}
+
+// helpedBy reports whether the position after a reply of the side that did not start solves the help-mate
+func (s *solver) helpedBy(c chess.Chess, moves int) (bool, error) {
+	if moves == 1 {
+		return s.mated(c), nil
+	}
+
+	if s.mated(c) {
+		// Mated too soon, the help-mate has to take exactly the moves stated
+		return false, nil
+	}
+
+	return s.attacks(c, moves-1)
+}
+
+// tree gets the moves that follow a move of the side that started in the solution
+func (s *solver) tree(c chess.Chess, moves int) ([]*Node, error) {
+	var nodes []*Node
+
+	for _, pl := range s.plays(c) {
+		next := after(c, pl)
+
+		var children []*Node
+		var err error
+
+		switch s.stip {
+		case HelpMate:
+			ok, err := s.helpedBy(next, moves)
+			if err != nil {
+				return nil, err
+			}
+
+			if !ok {
+				continue
+			}
+
+			if moves > 1 {
+				children, err = s.keys(next, moves-1, true)
+			}
+		case SelfMate:
+			if !s.mated(next) && moves > 1 {
+				children, err = s.keys(next, moves-1, false)
+			}
+		default:
+			if moves > 1 {
+				children, err = s.keys(next, moves-1, false)
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		n, err := s.node(c, pl)
+		if err != nil {
+			return nil, err
+		}
+
+		n.Children = children
+		nodes = append(nodes, n)
+	}
+
+	return nodes, nil
+}
+
+// keys gets the moves of the side that started which solve the problem from the position, every one of them when
+// all is set or else the first
+func (s *solver) keys(c chess.Chess, moves int, all bool) ([]*Node, error) {
+	var nodes []*Node
+
+	for _, pl := range s.plays(c) {
+		next := after(c, pl)
+
+		ok, err := s.follows(next, moves)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		n, err := s.node(c, pl)
+		if err != nil {
+			return nil, err
+		}
+
+		if n.Children, err = s.tree(next, moves); err != nil {
+			return nil, err
+		}
+
+		nodes = append(nodes, n)
+
+		if !all {
+			break
+		}
+	}
+
+	return nodes, nil
+}
+
+func (s *solver) node(c chess.Chess, pl play) (*Node, error) {
+	san, err := c.SAN(pl.move, pl.promotion)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Node{SAN: san, UCI: c.UCI(pl.move, pl.promotion)}, nil
+}
+
+// plays gets the legal moves of the position, with a move for each piece a pawn can be promoted to
+func (s *solver) plays(c chess.Chess) []play {
+	s.nodes++
+
+	var plays []play
+
+	for _, m := range c.LegalMoves() {
+		if !c.IsPromotion(m) {
+			plays = append(plays, play{move: m, promotion: piece.PieceTypePawn})
+			continue
+		}
+
+		for _, t := range []piece.PieceType{piece.PieceTypeQueen, piece.PieceTypeRook, piece.PieceTypeBishop, piece.PieceTypeKnight} {
+			plays = append(plays, play{move: m, promotion: t})
+		}
+	}
+
+	return plays
+}
+
+// mated reports whether the side to move has been checkmated
+func (s *solver) mated(c chess.Chess) bool {
+	return c.Board.InCheck(c.Turn) && len(c.LegalMoves()) == 0
+}
+
+func after(c chess.Chess, pl play) chess.Chess {
+	next := c.Copy()
+
+	// The move is one of the legal moves of the position, so it can always be made
+	_, _ = next.MakeMoveWithPromotion(pl.move, pl.promotion)
+
+	return next
+}
@@ -0,0 +1,142 @@
+package puzzle
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/tomwatson6/chessbot/internal/storage"
+)
+
+// Collection holds puzzles in the order they were added, saving them to a file when it has one
+type Collection struct {
+	mu      sync.RWMutex
+	path    string
+	puzzles []Puzzle
+	index   map[string]int
+}
+
+// NewCollection loads the puzzles saved in the file at the path provided, keeping the puzzles in memory when the
+// path is empty
+func NewCollection(path string) (*Collection, error) {
+	c := &Collection{
+		path:  path,
+		index: make(map[string]int),
+	}
+
+	if path == "" {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var puzzles []Puzzle
+	if err := json.Unmarshal(data, &puzzles); err != nil {
+		return nil, err
+	}
+
+	for _, p := range puzzles {
+		c.add(p)
+	}
+
+	return c, nil
+}
+
+// Add adds the puzzles that are not already in the collection, getting how many were added
+func (c *Collection) Add(puzzles ...Puzzle) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	before := len(c.puzzles)
+	for _, p := range puzzles {
+		c.add(p)
+	}
+
+	n := len(c.puzzles) - before
+	if n == 0 {
+		return 0, nil
+	}
+
+	if err := c.save(); err != nil {
+		for _, p := range c.puzzles[before:] {
+			delete(c.index, p.ID)
+		}
+
+		c.puzzles = c.puzzles[:before]
+
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// add adds the puzzle unless there is already one with its id, c.mu must be held by the caller
+func (c *Collection) add(p Puzzle) {
+	if _, ok := c.index[p.ID]; ok {
+		return
+	}
+
+	c.index[p.ID] = len(c.puzzles)
+	c.puzzles = append(c.puzzles, p)
+}
+
+func (c *Collection) Get(id string) (Puzzle, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	i, ok := c.index[id]
+	if !ok {
+		return Puzzle{}, ErrorPuzzleNotFound
+	}
+
+	return c.puzzles[i], nil
+}
+
+// Next gets the puzzle added after the one with the id provided, going back to the first puzzle after the last one
+// or when the id is not of a puzzle in the collection
+func (c *Collection) Next(after string) (Puzzle, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if len(c.puzzles) == 0 {
+		return Puzzle{}, ErrorNoPuzzles
+	}
+
+	i, ok := c.index[after]
+	if !ok {
+		return c.puzzles[0], nil
+	}
+
+	return c.puzzles[(i+1)%len(c.puzzles)], nil
+}
+
+// List gets the puzzles in the order they were added
+func (c *Collection) List() []Puzzle {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return append([]Puzzle{}, c.puzzles...)
+}
+
+// save writes the puzzles to the file of the collection. c.mu must be held by the caller.
+func (c *Collection) save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(c.puzzles, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return storage.WriteFile(c.path, data, 0o644)
+}
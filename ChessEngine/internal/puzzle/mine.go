@@ -0,0 +1,292 @@
+package puzzle
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/tomwatson6/chessbot/internal/annotate"
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/engine"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/piece"
+	"github.com/tomwatson6/chessbot/internal/tactics"
+)
+
+const (
+	// Winning is how many centipawns ahead the key move must leave the solver, with every other move leaving them
+	// short of it, and how much material the solution must win for the advantage to be decisive
+	Winning = 300
+	// MaxMoves is the most moves of the solver a puzzle can take
+	MaxMoves = 4
+)
+
+// The themes puzzles are tagged with, along with the names of the tactical motifs of their key move
+const (
+	ThemeMate    = "mate"
+	ThemeEndgame = "endgame"
+	ThemeQuiet   = "quiet-move"
+)
+
+// endgamePieces is the most pieces other than kings and pawns a position can have to be an endgame
+const endgamePieces = 4
+
+type Option func(o *options)
+
+type options struct {
+	engine *engine.Engine
+	depth  int
+}
+
+// WithEngine searches for puzzles with the engine provided, rather than one with the default evaluation
+func WithEngine(e *engine.Engine) Option {
+	return func(o *options) {
+		o.engine = e
+	}
+}
+
+// WithDepth sets how deep positions are searched, the maximum depth of the engine by default
+func WithDepth(depth int) Option {
+	return func(o *options) {
+		o.depth = depth
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.engine == nil {
+		o.engine = engine.New()
+	}
+
+	return o
+}
+
+// Mine finds puzzles in the positions of a game that follow a mistake, where the player who did not make it was
+// left winning. The analysis must be of the moves provided, as made by annotate.
+func Mine(ctx context.Context, start chess.Chess, moves []move.Move, a annotate.Analysis, opts ...Option) ([]Puzzle, error) {
+	if len(a.Moves) != len(moves) {
+		return nil, fmt.Errorf("the analysis has %d moves but the game has %d", len(a.Moves), len(moves))
+	}
+
+	var puzzles []Puzzle
+
+	c := start.Copy()
+
+	for i, m := range moves {
+		if _, err := c.MakeMoveWithPromotion(m, piece.PieceTypeQueen); err != nil {
+			return nil, fmt.Errorf("move %d %s: %w", i+1, m, err)
+		}
+
+		mv := a.Moves[i]
+
+		// The evaluation of the analysis is from the point of view of white
+		score := mv.After
+		if c.Turn == colour.Black {
+			score = -score
+		}
+
+		if mv.Loss < annotate.MistakeLoss || score < Winning {
+			continue
+		}
+
+		p, ok, err := Find(ctx, c, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			puzzles = append(puzzles, p)
+		}
+	}
+
+	return puzzles, nil
+}
+
+// Find makes a puzzle of the position when the colour to move has a single winning move, following the line the
+// engine expects for as long as each move of the solver is the only one that keeps the win, until it mates or has
+// won enough material. It reports false when the position is not a puzzle.
+func Find(ctx context.Context, c chess.Chess, opts ...Option) (Puzzle, bool, error) {
+	o := newOptions(opts)
+
+	if len(c.LegalMoves()) < 2 {
+		return Puzzle{}, false, nil
+	}
+
+	solver := c.Turn
+	base := material(c, solver)
+
+	p := Puzzle{FEN: c.FEN()}
+	pos := c.Copy()
+	quiet := false
+
+	for n := 1; n <= MaxMoves; n++ {
+		res, ok, err := o.unique(ctx, pos)
+		if err != nil || !ok {
+			return Puzzle{}, false, err
+		}
+
+		if n == 1 {
+			quiet = isQuiet(pos, res.Move)
+			p.Themes = themes(pos, res.Move)
+		}
+
+		p.Moves = append(p.Moves, pos.UCI(res.Move, piece.PieceTypeQueen))
+
+		if _, err := pos.MakeMoveWithPromotion(res.Move, piece.PieceTypeQueen); err != nil {
+			return Puzzle{}, false, err
+		}
+
+		if mated(pos) {
+			p.Themes = append(p.Themes, ThemeMate, fmt.Sprintf("%s-in-%d", ThemeMate, n))
+			return p.finish(c, n, quiet), true, nil
+		}
+
+		reply, err := o.engine.Search(ctx, pos, engine.Limits{Depth: o.depth}, nil)
+		if errors.Is(err, engine.ErrorNoMoves) {
+			// Stalemate throws the win away
+			return Puzzle{}, false, nil
+		}
+
+		if err != nil {
+			return Puzzle{}, false, err
+		}
+
+		after := pos.Copy()
+		if _, err := after.MakeMoveWithPromotion(reply.Move, piece.PieceTypeQueen); err != nil {
+			return Puzzle{}, false, err
+		}
+
+		// The material is counted after the reply, so that a capture that is taken back does not end the puzzle
+		if !res.Score.IsMate() && material(after, solver)-base >= Winning {
+			return p.finish(c, n, quiet), true, nil
+		}
+
+		p.Moves = append(p.Moves, pos.UCI(reply.Move, piece.PieceTypeQueen))
+		pos = after
+	}
+
+	return Puzzle{}, false, nil
+}
+
+// unique searches the position, reporting whether the best move is the only one that wins. Every mate in one is
+// allowed, as attempts that mate with another move are counted as correct.
+func (o options) unique(ctx context.Context, c chess.Chess) (engine.Result, bool, error) {
+	best, err := o.engine.Search(ctx, c, engine.Limits{Depth: o.depth}, nil)
+	if err != nil {
+		return engine.Result{}, false, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return engine.Result{}, false, err
+	}
+
+	if best.Score < Winning {
+		return best, false, nil
+	}
+
+	var others []move.Move
+	for _, m := range c.LegalMoves() {
+		if m != best.Move {
+			others = append(others, m)
+		}
+	}
+
+	if len(others) == 0 || best.Score.MateIn() == 1 {
+		return best, true, nil
+	}
+
+	second, err := o.engine.Search(ctx, c, engine.Limits{Depth: o.depth, SearchMoves: others}, nil)
+	if err != nil {
+		return engine.Result{}, false, err
+	}
+
+	if best.Score.IsMate() {
+		return best, !second.Score.IsMate() || second.Score < 0, nil
+	}
+
+	return best, second.Score < Winning, nil
+}
+
+// finish sets the id and rating of the puzzle once its solution is known, the solver making n moves from the
+// position provided. Longer solutions are rated harder, as are those that start with a quiet move.
+func (p Puzzle) finish(c chess.Chess, n int, quiet bool) Puzzle {
+	h := sha256.Sum256([]byte(p.FEN + " " + p.Moves[0]))
+	p.ID = hex.EncodeToString(h[:8])
+
+	p.Rating = 1000 + 300*(n-1)
+	if quiet {
+		p.Rating += 200
+		p.Themes = append(p.Themes, ThemeQuiet)
+	}
+
+	if isEndgame(c) {
+		p.Themes = append(p.Themes, ThemeEndgame)
+	}
+
+	sort.Strings(p.Themes)
+
+	return p
+}
+
+// themes gets the names of the tactical motifs the key move makes for the solver
+func themes(c chess.Chess, m move.Move) []string {
+	seen := make(map[string]bool)
+	themes := []string{}
+
+	motifs, err := tactics.Move(c, m, piece.PieceTypeQueen)
+	if err != nil {
+		return themes
+	}
+
+	for _, motif := range motifs {
+		if name := motif.Kind.String(); motif.Colour == c.Turn && !seen[name] {
+			seen[name] = true
+			themes = append(themes, name)
+		}
+	}
+
+	return themes
+}
+
+// isQuiet reports whether the move neither captures, checks nor promotes
+func isQuiet(c chess.Chess, m move.Move) bool {
+	if _, capture := c.Board.Pieces[m.To]; capture || c.IsPromotion(m) {
+		return false
+	}
+
+	next := c.Copy()
+	if _, err := next.MakeMoveWithPromotion(m, piece.PieceTypeQueen); err != nil {
+		return false
+	}
+
+	return !next.Board.InCheck(next.Turn)
+}
+
+func isEndgame(c chess.Chess) bool {
+	n := 0
+	for _, p := range c.Board.Pieces {
+		if t := p.GetPieceType(); t != piece.PieceTypeKing && t != piece.PieceTypePawn {
+			n++
+		}
+	}
+
+	return n <= endgamePieces
+}
+
+// material gets the worth of the pieces of the colour provided less the worth of those of the other colour
+func material(c chess.Chess, col colour.Colour) engine.Score {
+	s := engine.Material(c)
+	if c.Turn != col {
+		s = -s
+	}
+
+	return s
+}
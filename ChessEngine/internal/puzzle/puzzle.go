@@ -0,0 +1,177 @@
+// Package puzzle finds puzzles in played games, positions where one side has a single winning move, and checks
+// attempts at solving them
+package puzzle
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tomwatson6/chessbot/internal/board"
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/piece"
+)
+
+var (
+	// ErrorPuzzleNotFound is thrown when there is no puzzle with the id provided
+	ErrorPuzzleNotFound = errors.New("there is no puzzle with the id provided")
+	// ErrorNoPuzzles is thrown when getting a puzzle from a collection that does not have any
+	ErrorNoPuzzles = errors.New("there are no puzzles")
+	// ErrorAttemptOver is thrown when moving in an attempt at a puzzle that has been solved or failed
+	ErrorAttemptOver = errors.New("the attempt at the puzzle is over")
+)
+
+// Puzzle is a position to find the winning line in
+type Puzzle struct {
+	ID string `json:"id"`
+	// FEN is the position the solver moves first in
+	FEN string `json:"fen"`
+	// Moves is the solution in the notation of the Universal Chess Interface, the moves of the solver alternating
+	// with the replies of the opponent and ending with a move of the solver
+	Moves  []string `json:"moves"`
+	Themes []string `json:"themes"`
+	// Rating is how hard the puzzle is, on the same scale as the ratings of players
+	Rating int `json:"rating"`
+	// Game is the id of the game the puzzle was found in, if it was found in a game of the server
+	Game string `json:"game,omitempty"`
+}
+
+// HasTheme reports whether the puzzle is tagged with the theme provided
+func (p Puzzle) HasTheme(theme string) bool {
+	for _, t := range p.Themes {
+		if t == theme {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Result is how far an attempt at a puzzle got
+type Result struct {
+	Solved bool `json:"solved"`
+	// Correct is how many of the moves of the solver were correct
+	Correct  int      `json:"correct"`
+	Solution []string `json:"solution"`
+}
+
+// IllegalMoveError is thrown when a move of an attempt is not allowed by the board, holding the board before the
+// move was made
+type IllegalMoveError struct {
+	Board board.Board
+	Move  move.Move
+	Err   error
+}
+
+func (e *IllegalMoveError) Error() string {
+	return fmt.Sprintf("move %s: %s", e.Move, e.Err)
+}
+
+func (e *IllegalMoveError) Unwrap() error {
+	return e.Err
+}
+
+// Attempt is an attempt at solving a puzzle a move at a time, the opponent replying with the moves of the solution
+// that the engine chose when the puzzle was found
+type Attempt struct {
+	puzzle  Puzzle
+	chess   chess.Chess
+	correct int
+	solved  bool
+	over    bool
+}
+
+// Start starts an attempt at the puzzle from its position
+func (p Puzzle) Start() (*Attempt, error) {
+	c, err := chess.FromFEN(p.FEN)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Attempt{puzzle: p, chess: c}, nil
+}
+
+// Chess gets a copy of the position the solver is to move in
+func (a *Attempt) Chess() chess.Chess {
+	return a.chess.Copy()
+}
+
+func (a *Attempt) Puzzle() Puzzle {
+	return a.puzzle
+}
+
+// Over reports whether the puzzle has been solved or a wrong move has been made
+func (a *Attempt) Over() bool {
+	return a.over
+}
+
+func (a *Attempt) Result() Result {
+	return Result{Solved: a.solved, Correct: a.correct, Solution: a.puzzle.Moves}
+}
+
+// Move plays the move of the solver, made as it is in games so that a move the board does not allow gets an
+// IllegalMoveError. A correct move is followed by the reply of the opponent, which is returned in the notation of
+// the Universal Chess Interface, empty once the puzzle has been solved. A move that mates is correct even when the
+// solution mates with another. A wrong move ends the attempt.
+func (a *Attempt) Move(m move.Move) (string, error) {
+	if a.over {
+		return "", ErrorAttemptOver
+	}
+
+	before := a.chess.Copy()
+	if _, err := a.chess.MakeMoveWithPromotion(m, piece.PieceTypeQueen); err != nil {
+		a.chess = before
+		return "", &IllegalMoveError{Board: before.Board, Move: m, Err: err}
+	}
+
+	if before.UCI(m, piece.PieceTypeQueen) != a.puzzle.Moves[2*a.correct] && !mated(a.chess) {
+		a.over = true
+		return "", nil
+	}
+
+	a.correct++
+
+	if mated(a.chess) || 2*a.correct >= len(a.puzzle.Moves) {
+		a.solved, a.over = true, true
+		return "", nil
+	}
+
+	reply := a.puzzle.Moves[2*a.correct-1]
+
+	r, promotion, err := a.chess.ParseUCI(reply)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := a.chess.MakeMoveWithPromotion(r, promotion); err != nil {
+		return "", err
+	}
+
+	return reply, nil
+}
+
+// Check plays the moves of the solver from the position of the puzzle, with the replies of the solution between
+// them, stopping at the first wrong move
+func (p Puzzle) Check(moves []move.Move) (Result, error) {
+	a, err := p.Start()
+	if err != nil {
+		return Result{Solution: p.Moves}, err
+	}
+
+	for _, m := range moves {
+		if a.Over() {
+			break
+		}
+
+		if _, err := a.Move(m); err != nil {
+			return a.Result(), err
+		}
+	}
+
+	return a.Result(), nil
+}
+
+// mated reports whether the colour to move has been checkmated
+func mated(c chess.Chess) bool {
+	return c.Board.InCheck(c.Turn) && len(c.LegalMoves()) == 0
+}
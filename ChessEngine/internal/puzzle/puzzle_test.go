@@ -0,0 +1,217 @@
+package puzzle_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/internal/annotate"
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/puzzle"
+)
+
+func uci(t *testing.T, s string) move.Move {
+	t.Helper()
+
+	m, _, err := move.ParseUCI(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name   string
+		fen    string
+		moves  []string
+		themes []string
+		rating int
+	}{
+		{
+			name:   "back rank mate",
+			fen:    "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1",
+			moves:  []string{"a1a8"},
+			themes: []string{"endgame", "mate", "mate-in-1"},
+			rating: 1000,
+		},
+		{
+			name:   "hanging queen",
+			fen:    "4k3/8/8/3q4/8/8/3R4/4K3 w - - 0 1",
+			moves:  []string{"d2d5"},
+			themes: []string{"endgame"},
+			rating: 1000,
+		},
+		{
+			name:   "knight fork",
+			fen:    "2q1k3/8/8/8/2N5/8/PP4PP/4K3 w - - 0 1",
+			moves:  []string{"c4d6", "e8d8", "d6c8"},
+			themes: []string{"endgame", "fork", "hanging-piece"},
+			rating: 1300,
+		},
+		{
+			name: "starting position",
+			fen:  "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := chess.FromFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			p, ok, err := puzzle.Find(context.Background(), c, puzzle.WithDepth(3))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if ok != (tt.moves != nil) {
+				t.Fatalf("got puzzle %v, want %v: %+v", ok, tt.moves != nil, p)
+			}
+
+			if !ok {
+				return
+			}
+
+			if p.FEN != c.FEN() || p.ID == "" {
+				t.Errorf("want the puzzle to have an id and the position it was found in, got %+v", p)
+			}
+
+			// The reply of the opponent depends on the evaluation when it has more than one, so only the moves of
+			// the solver are compared
+			for i := 0; i < len(tt.moves); i += 2 {
+				if i >= len(p.Moves) || p.Moves[i] != tt.moves[i] {
+					t.Fatalf("got solution %v, want %v", p.Moves, tt.moves)
+				}
+			}
+
+			if len(p.Moves) != len(tt.moves) {
+				t.Errorf("got solution %v, want %v", p.Moves, tt.moves)
+			}
+
+			if !reflect.DeepEqual(p.Themes, tt.themes) {
+				t.Errorf("got themes %v, want %v", p.Themes, tt.themes)
+			}
+
+			if p.Rating != tt.rating {
+				t.Errorf("got rating %d, want %d", p.Rating, tt.rating)
+			}
+		})
+	}
+}
+
+func TestMine(t *testing.T) {
+	c := chess.New(colour.White)
+
+	var moves []move.Move
+	for _, s := range []string{"f2f3", "e7e5", "g2g4", "d8h4"} {
+		moves = append(moves, uci(t, s))
+	}
+
+	a, err := annotate.Annotate(context.Background(), c, moves, annotate.WithDepth(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	puzzles, err := puzzle.Mine(context.Background(), c, moves, a, puzzle.WithDepth(2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Only g4 leaves the opponent winning
+	if len(puzzles) != 1 || !reflect.DeepEqual(puzzles[0].Moves, []string{"d8h4"}) || !puzzles[0].HasTheme(puzzle.ThemeMate) {
+		t.Fatalf("want the mate after g4, got %+v", puzzles)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	p := puzzle.Puzzle{
+		ID:    "fork",
+		FEN:   "2q1k3/8/8/8/2N5/8/PP4PP/4K3 w - - 0 1",
+		Moves: []string{"c4d6", "e8d8", "d6c8"},
+	}
+
+	tests := []struct {
+		name  string
+		moves []string
+		want  puzzle.Result
+		err   bool
+	}{
+		{name: "solved", moves: []string{"c4d6", "d6c8"}, want: puzzle.Result{Solved: true, Correct: 2}},
+		{name: "part of the way", moves: []string{"c4d6"}, want: puzzle.Result{Correct: 1}},
+		{name: "wrong move", moves: []string{"c4b6", "b6c8"}, want: puzzle.Result{}},
+		{name: "illegal move", moves: []string{"c4c6"}, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var moves []move.Move
+			for _, s := range tt.moves {
+				moves = append(moves, uci(t, s))
+			}
+
+			got, err := p.Check(moves)
+
+			var illegal *puzzle.IllegalMoveError
+			if errors.As(err, &illegal) != tt.err {
+				t.Fatalf("got error %v, want an illegal move %v", err, tt.err)
+			}
+
+			tt.want.Solution = p.Moves
+			if !tt.err && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck_OtherMate(t *testing.T) {
+	// Either rook mates
+	p := puzzle.Puzzle{FEN: "6k1/5ppp/8/8/8/8/8/RR4K1 w - - 0 1", Moves: []string{"a1a8"}}
+
+	got, err := p.Check([]move.Move{uci(t, "b1b8")})
+	if err != nil || !got.Solved {
+		t.Errorf("want another mate to solve the puzzle, got %+v with error %v", got, err)
+	}
+}
+
+func TestCollection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "puzzles.json")
+
+	c, err := puzzle.NewCollection(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Next(""); !errors.Is(err, puzzle.ErrorNoPuzzles) {
+		t.Errorf("got error %v, want %v", err, puzzle.ErrorNoPuzzles)
+	}
+
+	a, b := puzzle.Puzzle{ID: "a", Moves: []string{"a1a8"}}, puzzle.Puzzle{ID: "b", Moves: []string{"d2d5"}}
+
+	if n, err := c.Add(a, b, a); err != nil || n != 2 {
+		t.Fatalf("want 2 puzzles added, got %d with error %v", n, err)
+	}
+
+	loaded, err := puzzle.NewCollection(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for after, want := range map[string]string{"": "a", "a": "b", "b": "a", "unknown": "a"} {
+		if p, err := loaded.Next(after); err != nil || p.ID != want {
+			t.Errorf("want puzzle %s after %q, got %+v with error %v", want, after, p, err)
+		}
+	}
+
+	if _, err := loaded.Get("c"); !errors.Is(err, puzzle.ErrorPuzzleNotFound) {
+		t.Errorf("got error %v, want %v", err, puzzle.ErrorPuzzleNotFound)
+	}
+}
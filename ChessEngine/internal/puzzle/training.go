@@ -0,0 +1,420 @@
+package puzzle
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/rating"
+	"github.com/tomwatson6/chessbot/internal/storage"
+	"github.com/tomwatson6/chessbot/internal/tactics"
+)
+
+var (
+	// ErrorNoAttempt is thrown when moving for a player who is not solving a puzzle
+	ErrorNoAttempt = errors.New("there is no puzzle being solved")
+	// ErrorRushNotFound is thrown when there is no rush with the id provided for the player
+	ErrorRushNotFound = errors.New("there is no rush with the id provided")
+	// ErrorRushOver is thrown when moving in a rush that has run out of time or strikes
+	ErrorRushOver = errors.New("the rush is over")
+)
+
+const (
+	// MaxStrikes is how many puzzles can be failed before a rush is over
+	MaxStrikes = 3
+	// DefaultRushDuration is how long a rush lasts when no duration is given
+	DefaultRushDuration = 3 * time.Minute
+
+	// puzzleDeviation is how certain the rating of a puzzle is taken to be when a player is rated against it
+	puzzleDeviation = 80.0
+	// The rating a rush starts from, which rises with each puzzle solved
+	rushStart = 1000
+	rushStep  = 100
+)
+
+// Sets are the themes of the sets of puzzles picked out for training, though any theme can be trained on
+var Sets = []string{ThemeMate + "-in-2", ThemeEndgame, tactics.Fork.String()}
+
+// Player is the puzzle rating of a player, along with the puzzles they have tried
+type Player struct {
+	rating.Rating
+	Attempts int `json:"attempts"`
+	Solved   int `json:"solved"`
+	// Seen is the ids of the puzzles the player has tried, which they are not given again while there are others
+	Seen []string `json:"seen"`
+}
+
+// Step is what happened after a move of an attempt at a puzzle, the solution is given once the attempt is over
+type Step struct {
+	Correct  bool     `json:"correct"`
+	Reply    string   `json:"reply,omitempty"`
+	Solved   bool     `json:"solved"`
+	Over     bool     `json:"over"`
+	Solution []string `json:"solution,omitempty"`
+}
+
+// Rush is a timed run of puzzles getting harder with each one solved, until time runs out or too many are failed.
+// Puzzle is the puzzle being solved, nil once the rush is over.
+type Rush struct {
+	ID      string    `json:"id"`
+	Player  string    `json:"player"`
+	Theme   string    `json:"theme,omitempty"`
+	Ends    time.Time `json:"ends"`
+	Score   int       `json:"score"`
+	Strikes int       `json:"strikes"`
+	Over    bool      `json:"over"`
+	Puzzle  *Puzzle   `json:"puzzle,omitempty"`
+
+	attempt *Attempt
+	seen    map[string]bool
+}
+
+// Trainer gives players puzzles to solve, rating each player by the puzzles they solve and fail and giving them
+// puzzles near their rating. Ratings are saved to a file when the trainer has one, attempts and rushes are not.
+type Trainer struct {
+	mu       sync.Mutex
+	path     string
+	puzzles  *Collection
+	now      func() time.Time
+	players  map[string]*Player
+	attempts map[string]*Attempt
+	rushes   map[string]*Rush
+}
+
+type TrainerOption func(t *Trainer)
+
+// WithFile saves the ratings of players to the file at the path provided, loading it if it already exists
+func WithFile(path string) TrainerOption {
+	return func(t *Trainer) {
+		t.path = path
+	}
+}
+
+// WithTimeSource overrides the time used to end rushes
+func WithTimeSource(now func() time.Time) TrainerOption {
+	return func(t *Trainer) {
+		t.now = now
+	}
+}
+
+func NewTrainer(puzzles *Collection, opts ...TrainerOption) (*Trainer, error) {
+	t := &Trainer{
+		puzzles:  puzzles,
+		now:      time.Now,
+		players:  make(map[string]*Player),
+		attempts: make(map[string]*Attempt),
+		rushes:   make(map[string]*Rush),
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	if t.path == "" {
+		return t, nil
+	}
+
+	data, err := os.ReadFile(t.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return t, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &t.players); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Player gets the puzzle rating of the player, the default rating when they have not tried a puzzle
+func (t *Trainer) Player(name string) Player {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p := *t.player(name)
+	p.Seen = append([]string{}, p.Seen...)
+
+	return p
+}
+
+// player gets the player, adding them if they have not tried a puzzle, t.mu must be held by the caller
+func (t *Trainer) player(name string) *Player {
+	p, ok := t.players[name]
+	if !ok {
+		p = &Player{Rating: rating.NewRating(), Seen: []string{}}
+		t.players[name] = p
+	}
+
+	return p
+}
+
+// Next gives the player the puzzle with the theme, or any theme when it is empty, nearest to their rating that they
+// have not seen, or the one nearest their rating once they have seen them all. A puzzle the player was part way
+// through is given up on without counting against them.
+func (t *Trainer) Next(name, theme string) (Puzzle, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p := t.player(name)
+
+	seen := make(map[string]bool, len(p.Seen))
+	for _, id := range p.Seen {
+		seen[id] = true
+	}
+
+	pz, err := t.pick(theme, p.Rating.Rating, seen)
+	if errors.Is(err, ErrorNoPuzzles) && len(seen) > 0 {
+		pz, err = t.pick(theme, p.Rating.Rating, nil)
+	}
+
+	if err != nil {
+		return Puzzle{}, err
+	}
+
+	a, err := pz.Start()
+	if err != nil {
+		return Puzzle{}, err
+	}
+
+	t.attempts[name] = a
+
+	return pz, nil
+}
+
+// Move plays the move of the player in the puzzle they are solving. Once the puzzle is solved or failed the player
+// is rated against it.
+func (t *Trainer) Move(name string, m move.Move) (Step, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	a, ok := t.attempts[name]
+	if !ok {
+		return Step{}, ErrorNoAttempt
+	}
+
+	step, err := play(a, m)
+	if err != nil || !step.Over {
+		return step, err
+	}
+
+	delete(t.attempts, name)
+
+	p := t.player(name)
+	p.Attempts++
+	if step.Solved {
+		p.Solved++
+	}
+
+	p.Seen = append(p.Seen, a.Puzzle().ID)
+	p.Rating = rate(p.Rating, a.Puzzle(), step.Solved)
+
+	return step, t.save()
+}
+
+// play makes the move of an attempt, getting the step that follows from it
+func play(a *Attempt, m move.Move) (Step, error) {
+	reply, err := a.Move(m)
+	if err != nil {
+		return Step{}, err
+	}
+
+	res := a.Result()
+	step := Step{Correct: res.Solved || reply != "", Reply: reply, Solved: res.Solved, Over: a.Over()}
+
+	if step.Over {
+		step.Solution = res.Solution
+	}
+
+	return step, nil
+}
+
+// rate rates the player as if they had played the puzzle, winning when they solved it
+func rate(r rating.Rating, p Puzzle, solved bool) rating.Rating {
+	opponent := rating.Rating{Rating: float64(p.Rating), Deviation: puzzleDeviation, Volatility: rating.DefaultVolatility}
+
+	score := 0.0
+	if solved {
+		score = 1
+	}
+
+	return rating.UpdateGlicko2(r, []rating.Result{{Opponent: opponent, Score: score}}, rating.DefaultTau)
+}
+
+// StartRush starts a rush of puzzles with the theme, or any theme when it is empty, lasting the duration provided
+func (t *Trainer) StartRush(name, theme string, d time.Duration) (Rush, error) {
+	if d <= 0 {
+		d = DefaultRushDuration
+	}
+
+	id, err := storage.NewID(4)
+	if err != nil {
+		return Rush{}, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	r := &Rush{
+		ID:     id,
+		Player: name,
+		Theme:  theme,
+		Ends:   t.now().Add(d),
+		seen:   make(map[string]bool),
+	}
+
+	if err := t.nextInRush(r); err != nil {
+		return Rush{}, err
+	}
+
+	t.rushes[id] = r
+
+	return r.view(), nil
+}
+
+// Rush gets the rush of the player with the id provided
+func (t *Trainer) Rush(id, name string) (Rush, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	r, err := t.rush(id, name)
+	if err != nil {
+		return Rush{}, err
+	}
+
+	return r.view(), nil
+}
+
+// RushMove plays the move of the player in the puzzle of the rush. Solving a puzzle scores a point and failing one
+// is a strike, either way the next puzzle is given. Rushes do not change the ratings of players.
+func (t *Trainer) RushMove(id, name string, m move.Move) (Rush, Step, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	r, err := t.rush(id, name)
+	if err != nil {
+		return Rush{}, Step{}, err
+	}
+
+	if r.Over {
+		return r.view(), Step{}, ErrorRushOver
+	}
+
+	step, err := play(r.attempt, m)
+	if err != nil || !step.Over {
+		return r.view(), step, err
+	}
+
+	if step.Solved {
+		r.Score++
+	} else {
+		r.Strikes++
+	}
+
+	if r.Strikes >= MaxStrikes {
+		r.end()
+	} else if err := t.nextInRush(r); err != nil {
+		// Running out of puzzles ends the rush
+		r.end()
+	}
+
+	return r.view(), step, nil
+}
+
+// rush gets the rush, ending it if it has run out of time, t.mu must be held by the caller
+func (t *Trainer) rush(id, name string) (*Rush, error) {
+	r, ok := t.rushes[id]
+	if !ok || r.Player != name {
+		return nil, ErrorRushNotFound
+	}
+
+	if !r.Over && !t.now().Before(r.Ends) {
+		r.end()
+	}
+
+	return r, nil
+}
+
+// nextInRush gives the rush the next puzzle, the one nearest the rating the rush has reached that has not been
+// given in it yet, t.mu must be held by the caller
+func (t *Trainer) nextInRush(r *Rush) error {
+	pz, err := t.pick(r.Theme, float64(rushStart+rushStep*r.Score), r.seen)
+	if err != nil {
+		return err
+	}
+
+	a, err := pz.Start()
+	if err != nil {
+		return err
+	}
+
+	r.seen[pz.ID] = true
+	r.attempt = a
+	r.Puzzle = &pz
+
+	return nil
+}
+
+func (r *Rush) end() {
+	r.Over = true
+	r.attempt = nil
+	r.Puzzle = nil
+}
+
+// view gets a copy of the rush to give out of the trainer
+func (r *Rush) view() Rush {
+	v := *r
+	v.attempt, v.seen = nil, nil
+
+	if r.Puzzle != nil {
+		p := *r.Puzzle
+		v.Puzzle = &p
+	}
+
+	return v
+}
+
+// pick gets the puzzle with the theme, or any theme when it is empty, that is nearest to the rating provided and
+// not in seen
+func (t *Trainer) pick(theme string, target float64, seen map[string]bool) (Puzzle, error) {
+	var best Puzzle
+	found := false
+
+	for _, p := range t.puzzles.List() {
+		if seen[p.ID] || (theme != "" && !p.HasTheme(theme)) {
+			continue
+		}
+
+		if !found || math.Abs(float64(p.Rating)-target) < math.Abs(float64(best.Rating)-target) {
+			best, found = p, true
+		}
+	}
+
+	if !found {
+		return Puzzle{}, ErrorNoPuzzles
+	}
+
+	return best, nil
+}
+
+// save writes the ratings of the players to the file of the trainer. t.mu must be held by the caller.
+func (t *Trainer) save() error {
+	if t.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(t.players, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return storage.WriteFile(t.path, data, 0o644)
+}
@@ -0,0 +1,170 @@
+package puzzle_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/puzzle"
+	"github.com/tomwatson6/chessbot/internal/rating"
+)
+
+func newCollection(t *testing.T) *puzzle.Collection {
+	t.Helper()
+
+	c, err := puzzle.NewCollection("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.Add(
+		puzzle.Puzzle{
+			ID:     "mate",
+			FEN:    "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1",
+			Moves:  []string{"a1a8"},
+			Themes: []string{"endgame", "mate", "mate-in-1"},
+			Rating: 1000,
+		},
+		puzzle.Puzzle{
+			ID:     "fork",
+			FEN:    "2q1k3/8/8/8/2N5/8/PP4PP/4K3 w - - 0 1",
+			Moves:  []string{"c4d6", "e8d8", "d6c8"},
+			Themes: []string{"endgame", "fork", "hanging-piece"},
+			Rating: 1300,
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func TestTrainer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ratings.json")
+
+	tr, err := puzzle.NewTrainer(newCollection(t), puzzle.WithFile(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tr.Move("alice", uci(t, "a1a8")); !errors.Is(err, puzzle.ErrorNoAttempt) {
+		t.Errorf("got error %v, want %v", err, puzzle.ErrorNoAttempt)
+	}
+
+	// The fork is nearest the default rating
+	p, err := tr.Next("alice", "")
+	if err != nil || p.ID != "fork" {
+		t.Fatalf("want the fork, got %+v with error %v", p, err)
+	}
+
+	var illegal *puzzle.IllegalMoveError
+	if _, err := tr.Move("alice", uci(t, "c4c6")); !errors.As(err, &illegal) {
+		t.Errorf("got error %v, want an illegal move", err)
+	}
+
+	step, err := tr.Move("alice", uci(t, "c4d6"))
+	if err != nil || !step.Correct || step.Reply != "e8d8" || step.Over || step.Solution != nil {
+		t.Fatalf("want the reply of the opponent, got %+v with error %v", step, err)
+	}
+
+	step, err = tr.Move("alice", uci(t, "d6c8"))
+	if err != nil || !step.Solved || !step.Over || len(step.Solution) != 3 {
+		t.Fatalf("want the puzzle solved, got %+v with error %v", step, err)
+	}
+
+	solved := tr.Player("alice")
+	if solved.Rating.Rating <= rating.DefaultRating || solved.Attempts != 1 || solved.Solved != 1 {
+		t.Errorf("want the rating to rise after solving the puzzle, got %+v", solved)
+	}
+
+	// The fork has been seen, and the theme filters out nothing else
+	if p, err := tr.Next("alice", "mate"); err != nil || p.ID != "mate" {
+		t.Fatalf("want the mate, got %+v with error %v", p, err)
+	}
+
+	step, err = tr.Move("alice", uci(t, "a1a7"))
+	if err != nil || step.Correct || step.Solved || !step.Over {
+		t.Fatalf("want the attempt failed, got %+v with error %v", step, err)
+	}
+
+	failed := tr.Player("alice")
+	if failed.Rating.Rating >= solved.Rating.Rating || failed.Attempts != 2 || failed.Solved != 1 {
+		t.Errorf("want the rating to fall after failing the puzzle, got %+v", failed)
+	}
+
+	if _, err := tr.Next("alice", "opposition"); !errors.Is(err, puzzle.ErrorNoPuzzles) {
+		t.Errorf("got error %v, want %v", err, puzzle.ErrorNoPuzzles)
+	}
+
+	loaded, err := puzzle.NewTrainer(newCollection(t), puzzle.WithFile(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := loaded.Player("alice"); got.Rating != failed.Rating || len(got.Seen) != 2 {
+		t.Errorf("want the player to be loaded, got %+v", got)
+	}
+}
+
+func TestRush(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tr, err := puzzle.NewTrainer(newCollection(t), puzzle.WithTimeSource(func() time.Time { return now }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("out of time", func(t *testing.T) {
+		r, err := tr.StartRush("alice", "", time.Minute)
+		if err != nil || r.Puzzle == nil || r.Puzzle.ID != "mate" {
+			t.Fatalf("want the rush to start with the easiest puzzle, got %+v with error %v", r, err)
+		}
+
+		r, step, err := tr.RushMove(r.ID, "alice", uci(t, "a1a8"))
+		if err != nil || !step.Solved || r.Score != 1 || r.Puzzle == nil || r.Puzzle.ID != "fork" {
+			t.Fatalf("want the next puzzle after solving one, got %+v %+v with error %v", r, step, err)
+		}
+
+		if _, err := tr.Rush(r.ID, "bob"); !errors.Is(err, puzzle.ErrorRushNotFound) {
+			t.Errorf("got error %v, want %v", err, puzzle.ErrorRushNotFound)
+		}
+
+		now = now.Add(time.Minute)
+
+		if r, err := tr.Rush(r.ID, "alice"); err != nil || !r.Over || r.Puzzle != nil || r.Score != 1 {
+			t.Errorf("want the rush over, got %+v with error %v", r, err)
+		}
+
+		if _, _, err := tr.RushMove(r.ID, "alice", uci(t, "c4d6")); !errors.Is(err, puzzle.ErrorRushOver) {
+			t.Errorf("got error %v, want %v", err, puzzle.ErrorRushOver)
+		}
+	})
+
+	t.Run("out of puzzles", func(t *testing.T) {
+		r, err := tr.StartRush("alice", "", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !r.Ends.Equal(now.Add(puzzle.DefaultRushDuration)) {
+			t.Errorf("want the rush to end at %v, got %v", now.Add(puzzle.DefaultRushDuration), r.Ends)
+		}
+
+		for _, s := range []string{"a1a7", "c4e5"} {
+			if r, _, err = tr.RushMove(r.ID, "alice", uci(t, s)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if !r.Over || r.Strikes != 2 || r.Score != 0 {
+			t.Errorf("want the rush over with two strikes, got %+v", r)
+		}
+	})
+
+	// Rushes do not change ratings
+	if got := tr.Player("alice"); got.Attempts != 0 || got.Rating.Rating != rating.DefaultRating {
+		t.Errorf("want the rating unchanged by rushes, got %+v", got)
+	}
+}
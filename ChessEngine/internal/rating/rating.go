@@ -0,0 +1,217 @@
+// Package rating rates players from the results of their games, using Glicko-2 or Elo
+package rating
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// DefaultRating is the rating of a player who has not played any rated games
+	DefaultRating = 1500.0
+	// DefaultDeviation is the deviation of a player who has not played any rated games
+	DefaultDeviation = 350.0
+	// DefaultVolatility is the volatility of a player who has not played any rated games
+	DefaultVolatility = 0.06
+	// ProvisionalDeviation is the deviation above which a rating is not yet reliable
+	ProvisionalDeviation = 110.0
+
+	// glicko2Scale converts between the Glicko and Glicko-2 scales
+	glicko2Scale = 173.7178
+	// convergence is the tolerance of the iteration for the new volatility
+	convergence = 0.000001
+)
+
+type System byte
+
+const (
+	Glicko2 System = iota
+	Elo
+)
+
+func (s System) String() string {
+	switch s {
+	case Glicko2:
+		return "glicko2"
+	case Elo:
+		return "elo"
+	default:
+		return "unknown"
+	}
+}
+
+func ParseSystem(s string) (System, error) {
+	for _, sys := range []System{Glicko2, Elo} {
+		if sys.String() == s {
+			return sys, nil
+		}
+	}
+
+	return Glicko2, fmt.Errorf("unknown rating system: %s", s)
+}
+
+// Pool is a group of games played at similar time controls, a player has a separate rating in each pool
+type Pool string
+
+const (
+	PoolBullet         Pool = "bullet"
+	PoolBlitz          Pool = "blitz"
+	PoolRapid          Pool = "rapid"
+	PoolClassical      Pool = "classical"
+	PoolCorrespondence Pool = "correspondence"
+)
+
+// Pools are all of the pools that games are rated in
+var Pools = []Pool{PoolBullet, PoolBlitz, PoolRapid, PoolClassical, PoolCorrespondence}
+
+func ParsePool(s string) (Pool, error) {
+	for _, p := range Pools {
+		if string(p) == s {
+			return p, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown rating pool: %s", s)
+}
+
+// PoolFor gets the pool of a game from its clock, estimating its length as the initial time plus 40 increments.
+// Correspondence games and games without a clock are rated in the correspondence pool.
+func PoolFor(initial, increment time.Duration, correspondence bool) Pool {
+	if correspondence || initial <= 0 {
+		return PoolCorrespondence
+	}
+
+	switch estimated := initial + 40*increment; {
+	case estimated < 3*time.Minute:
+		return PoolBullet
+	case estimated < 8*time.Minute:
+		return PoolBlitz
+	case estimated < 25*time.Minute:
+		return PoolRapid
+	default:
+		return PoolClassical
+	}
+}
+
+type Rating struct {
+	Rating     float64 `json:"rating"`
+	Deviation  float64 `json:"deviation"`
+	Volatility float64 `json:"volatility"`
+}
+
+func NewRating() Rating {
+	return Rating{
+		Rating:     DefaultRating,
+		Deviation:  DefaultDeviation,
+		Volatility: DefaultVolatility,
+	}
+}
+
+// IsProvisional reports whether too few games have been played for the rating to be reliable
+func (r Rating) IsProvisional() bool {
+	return r.Deviation > ProvisionalDeviation
+}
+
+// Result is the score of a game against an opponent, 1 for a win, 0.5 for a draw and 0 for a loss
+type Result struct {
+	Opponent Rating
+	Score    float64
+}
+
+// UpdateGlicko2 rates the player from the results of the rating period, following Glickman's description of
+// Glicko-2. A player without results in the period only has their deviation increased.
+func UpdateGlicko2(r Rating, results []Result, tau float64) Rating {
+	mu := (r.Rating - DefaultRating) / glicko2Scale
+	phi := r.Deviation / glicko2Scale
+	sigma := r.Volatility
+
+	if len(results) == 0 {
+		phi = math.Min(math.Sqrt(phi*phi+sigma*sigma), DefaultDeviation/glicko2Scale)
+		return Rating{Rating: r.Rating, Deviation: phi * glicko2Scale, Volatility: sigma}
+	}
+
+	var vInv, sum float64
+
+	for _, res := range results {
+		muJ := (res.Opponent.Rating - DefaultRating) / glicko2Scale
+		phiJ := res.Opponent.Deviation / glicko2Scale
+
+		g := glickoG(phiJ)
+		e := 1 / (1 + math.Exp(-g*(mu-muJ)))
+
+		vInv += g * g * e * (1 - e)
+		sum += g * (res.Score - e)
+	}
+
+	v := 1 / vInv
+	delta := v * sum
+
+	sigma = newVolatility(phi, sigma, v, delta, tau)
+
+	phiStar := math.Sqrt(phi*phi + sigma*sigma)
+	phi = 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	mu += phi * phi * sum
+
+	return Rating{
+		Rating:     mu*glicko2Scale + DefaultRating,
+		Deviation:  phi * glicko2Scale,
+		Volatility: sigma,
+	}
+}
+
+func glickoG(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+// newVolatility finds the new volatility with the Illinois algorithm
+func newVolatility(phi, sigma, v, delta, tau float64) float64 {
+	a := math.Log(sigma * sigma)
+
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + v + ex
+
+		return ex*(delta*delta-phi*phi-v-ex)/(2*d*d) - (x-a)/(tau*tau)
+	}
+
+	lower := a
+	var upper float64
+
+	if delta*delta > phi*phi+v {
+		upper = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*tau) < 0 {
+			k++
+		}
+
+		upper = a - k*tau
+	}
+
+	fLower, fUpper := f(lower), f(upper)
+
+	for math.Abs(upper-lower) > convergence {
+		c := lower + (lower-upper)*fLower/(fUpper-fLower)
+		fC := f(c)
+
+		if fC*fUpper <= 0 {
+			lower, fLower = upper, fUpper
+		} else {
+			fLower /= 2
+		}
+
+		upper, fUpper = c, fC
+	}
+
+	return math.Exp(lower / 2)
+}
+
+// UpdateElo rates the player from a single game against the opponent, moving the rating by at most k
+func UpdateElo(r Rating, opponent Rating, score, k float64) Rating {
+	expected := 1 / (1 + math.Pow(10, (opponent.Rating-r.Rating)/400))
+
+	r.Rating += k * (score - expected)
+
+	return r
+}
@@ -0,0 +1,136 @@
+package rating_test
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/rating"
+)
+
+func near(a, b, tolerance float64) bool {
+	return math.Abs(a-b) <= tolerance
+}
+
+func TestUpdateGlicko2(t *testing.T) {
+	// The example from Glickman's description of Glicko-2
+	r := rating.Rating{Rating: 1500, Deviation: 200, Volatility: 0.06}
+
+	results := []rating.Result{
+		{Opponent: rating.Rating{Rating: 1400, Deviation: 30}, Score: 1},
+		{Opponent: rating.Rating{Rating: 1550, Deviation: 100}, Score: 0},
+		{Opponent: rating.Rating{Rating: 1700, Deviation: 300}, Score: 0},
+	}
+
+	got := rating.UpdateGlicko2(r, results, 0.5)
+
+	if !near(got.Rating, 1464.06, 0.01) || !near(got.Deviation, 151.52, 0.01) || !near(got.Volatility, 0.05999, 0.00001) {
+		t.Errorf("want 1464.06 / 151.52 / 0.05999, got %.2f / %.2f / %.5f", got.Rating, got.Deviation, got.Volatility)
+	}
+
+	idle := rating.UpdateGlicko2(r, nil, 0.5)
+	if idle.Rating != r.Rating || idle.Deviation <= r.Deviation {
+		t.Errorf("want only the deviation to increase without games, got %+v", idle)
+	}
+}
+
+func TestUpdateElo(t *testing.T) {
+	tcs := []struct {
+		name     string
+		player   float64
+		opponent float64
+		score    float64
+		want     float64
+	}{
+		{name: "EvenWin", player: 1500, opponent: 1500, score: 1, want: 1516},
+		{name: "EvenDraw", player: 1500, opponent: 1500, score: 0.5, want: 1500},
+		{name: "UpsetWin", player: 1400, opponent: 1800, score: 1, want: 1429.09},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got := rating.UpdateElo(rating.Rating{Rating: tc.player}, rating.Rating{Rating: tc.opponent}, tc.score, 32)
+
+			if !near(got.Rating, tc.want, 0.01) {
+				t.Errorf("want %.2f, got %.2f", tc.want, got.Rating)
+			}
+		})
+	}
+}
+
+func TestPoolFor(t *testing.T) {
+	tcs := []struct {
+		name           string
+		initial        time.Duration
+		increment      time.Duration
+		correspondence bool
+		want           rating.Pool
+	}{
+		{name: "Bullet", initial: time.Minute, want: rating.PoolBullet},
+		{name: "BlitzFromIncrement", initial: 2 * time.Minute, increment: 2 * time.Second, want: rating.PoolBlitz},
+		{name: "Rapid", initial: 10 * time.Minute, want: rating.PoolRapid},
+		{name: "Classical", initial: 30 * time.Minute, want: rating.PoolClassical},
+		{name: "Untimed", want: rating.PoolCorrespondence},
+		{name: "Correspondence", correspondence: true, want: rating.PoolCorrespondence},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := rating.PoolFor(tc.initial, tc.increment, tc.correspondence); got != tc.want {
+				t.Errorf("want %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ratings.json")
+
+	table, err := rating.NewTable(rating.WithFile(path))
+	if err != nil {
+		t.Fatalf("unexpected error creating table: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := table.Record(rating.PoolBlitz, "alice", "bob", 1); err != nil {
+			t.Fatalf("unexpected error recording game: %v", err)
+		}
+	}
+
+	if err := table.Record(rating.PoolBlitz, "alice", "bob", 2); err == nil {
+		t.Errorf("want an error recording an invalid score")
+	}
+
+	// Games are not rated until the period is closed
+	if got := table.Get(rating.PoolBlitz, "alice"); got.Rating.Rating != rating.DefaultRating {
+		t.Errorf("want the default rating before the period is closed, got %v", got.Rating.Rating)
+	}
+
+	if err := table.ClosePeriod(); err != nil {
+		t.Fatalf("unexpected error closing period: %v", err)
+	}
+
+	// The ratings are kept when the server restarts
+	table, err = rating.NewTable(rating.WithFile(path))
+	if err != nil {
+		t.Fatalf("unexpected error loading table: %v", err)
+	}
+
+	board := table.Leaderboard(rating.PoolBlitz, 0)
+	if len(board) != 2 || board[0].Player != "alice" || board[0].Rating <= board[1].Rating {
+		t.Fatalf("want alice to lead, got %+v", board)
+	}
+
+	if !board[0].Provisional || board[0].Games != 3 {
+		t.Errorf("want alice to be provisional after 3 games, got %+v", board[0])
+	}
+
+	if got := table.Get(rating.PoolBlitz, "alice").History; len(got) != 1 {
+		t.Errorf("want a point in the history of alice, got %+v", got)
+	}
+
+	if _, err := rating.NewTable(rating.WithFile(path), rating.WithSystem(rating.Elo)); err == nil {
+		t.Errorf("want an error loading glicko-2 ratings as elo")
+	}
+}
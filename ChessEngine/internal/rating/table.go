@@ -0,0 +1,338 @@
+package rating
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/storage"
+)
+
+const (
+	// DefaultTau constrains how much the volatility of a Glicko-2 rating can change in a period
+	DefaultTau = 0.5
+	// DefaultK is how far a single game can move an Elo rating
+	DefaultK = 32.0
+	// EloProvisionalGames is the number of games an Elo rated player must play before their rating is reliable
+	EloProvisionalGames = 10
+)
+
+// ErrorInvalidScore is thrown when recording a score other than 0, 0.5 or 1
+var ErrorInvalidScore = errors.New("scores must be 0, 0.5 or 1")
+
+// Point is the rating of a player at a point in time
+type Point struct {
+	At        time.Time `json:"at"`
+	Rating    float64   `json:"rating"`
+	Deviation float64   `json:"deviation"`
+}
+
+// Player is the rating of a player in a pool, along with how it has changed
+type Player struct {
+	Rating
+	Games   int     `json:"games"`
+	History []Point `json:"history"`
+}
+
+// Standing is the place of a player on a leaderboard
+type Standing struct {
+	Player      string  `json:"player"`
+	Rating      float64 `json:"rating"`
+	Deviation   float64 `json:"deviation"`
+	Games       int     `json:"games"`
+	Provisional bool    `json:"provisional"`
+}
+
+type game struct {
+	White string  `json:"white"`
+	Black string  `json:"black"`
+	Score float64 `json:"score"`
+}
+
+// Table holds the ratings of players in each pool. With Glicko-2 the games of a rating period are rated together
+// when the period is closed, with Elo each game is rated as soon as it is recorded.
+type Table struct {
+	mu      sync.Mutex
+	path    string
+	system  System
+	tau     float64
+	k       float64
+	now     func() time.Time
+	players map[Pool]map[string]*Player
+	pending map[Pool][]game
+}
+
+type Option func(t *Table)
+
+// WithSystem sets the rating system of the table, Glicko-2 is used by default
+func WithSystem(s System) Option {
+	return func(t *Table) {
+		t.system = s
+	}
+}
+
+// WithFile saves the table to the file at the path provided, loading it if it already exists
+func WithFile(path string) Option {
+	return func(t *Table) {
+		t.path = path
+	}
+}
+
+// WithK sets how far a single game can move an Elo rating
+func WithK(k float64) Option {
+	return func(t *Table) {
+		t.k = k
+	}
+}
+
+// WithTimeSource overrides the time recorded in the history of ratings
+func WithTimeSource(now func() time.Time) Option {
+	return func(t *Table) {
+		t.now = now
+	}
+}
+
+func NewTable(opts ...Option) (*Table, error) {
+	t := &Table{
+		system:  Glicko2,
+		tau:     DefaultTau,
+		k:       DefaultK,
+		now:     time.Now,
+		players: make(map[Pool]map[string]*Player),
+		pending: make(map[Pool][]game),
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	if err := t.load(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (t *Table) System() System {
+	return t.system
+}
+
+// Record adds the result of a game between the players provided, the score is from white's point of view
+func (t *Table) Record(pool Pool, white, black string, score float64) error {
+	if score != 0 && score != 0.5 && score != 1 {
+		return fmt.Errorf("%w: %v", ErrorInvalidScore, score)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	w := t.player(pool, white)
+	b := t.player(pool, black)
+
+	w.Games++
+	b.Games++
+
+	if t.system == Elo {
+		wr, br := w.Rating, b.Rating
+
+		w.Rating = UpdateElo(wr, br, score, t.k)
+		b.Rating = UpdateElo(br, wr, 1-score, t.k)
+
+		now := t.now()
+		w.History = append(w.History, point(w.Rating, now))
+		b.History = append(b.History, point(b.Rating, now))
+	} else {
+		t.pending[pool] = append(t.pending[pool], game{White: white, Black: black, Score: score})
+	}
+
+	return t.save()
+}
+
+// ClosePeriod rates the games recorded since the last period was closed, players who have not played in the
+// period become less certain of their rating. Nothing happens with Elo as games are rated as they are recorded.
+func (t *Table) ClosePeriod() error {
+	if t.system != Glicko2 {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+
+	for pool, players := range t.players {
+		results := make(map[string][]Result)
+
+		// Every game in the period is rated against the ratings from the start of the period
+		for _, g := range t.pending[pool] {
+			w, b := players[g.White].Rating, players[g.Black].Rating
+
+			results[g.White] = append(results[g.White], Result{Opponent: b, Score: g.Score})
+			results[g.Black] = append(results[g.Black], Result{Opponent: w, Score: 1 - g.Score})
+		}
+
+		for name, p := range players {
+			p.Rating = UpdateGlicko2(p.Rating, results[name], t.tau)
+			p.History = append(p.History, point(p.Rating, now))
+		}
+	}
+
+	t.pending = make(map[Pool][]game)
+
+	return t.save()
+}
+
+// Get gets the rating of the player in the pool, a player who has not played in the pool has the default rating
+func (t *Table) Get(pool Pool, player string) Player {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, ok := t.players[pool][player]
+	if !ok {
+		return Player{Rating: NewRating(), History: []Point{}}
+	}
+
+	c := *p
+	c.History = append([]Point{}, p.History...)
+
+	return c
+}
+
+// Pools gets the pools that the player has a rating in
+func (t *Table) Pools(player string) []Pool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var pools []Pool
+	for pool, players := range t.players {
+		if _, ok := players[player]; ok {
+			pools = append(pools, pool)
+		}
+	}
+
+	sort.Slice(pools, func(i, j int) bool { return pools[i] < pools[j] })
+
+	return pools
+}
+
+// Leaderboard gets the players of the pool from the highest rated, with provisional players after the rest
+func (t *Table) Leaderboard(pool Pool, limit int) []Standing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	standings := []Standing{}
+
+	for name, p := range t.players[pool] {
+		standings = append(standings, Standing{
+			Player:      name,
+			Rating:      p.Rating.Rating,
+			Deviation:   p.Deviation,
+			Games:       p.Games,
+			Provisional: t.isProvisional(p),
+		})
+	}
+
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].Provisional != standings[j].Provisional {
+			return !standings[i].Provisional
+		}
+
+		if standings[i].Rating != standings[j].Rating {
+			return standings[i].Rating > standings[j].Rating
+		}
+
+		return standings[i].Player < standings[j].Player
+	})
+
+	if limit > 0 && len(standings) > limit {
+		standings = standings[:limit]
+	}
+
+	return standings
+}
+
+func (t *Table) isProvisional(p *Player) bool {
+	if t.system == Elo {
+		return p.Games < EloProvisionalGames
+	}
+
+	return p.IsProvisional()
+}
+
+// player gets the player in the pool, adding them with the default rating if they are new. t.mu must be held.
+func (t *Table) player(pool Pool, name string) *Player {
+	if t.players[pool] == nil {
+		t.players[pool] = make(map[string]*Player)
+	}
+
+	p, ok := t.players[pool][name]
+	if !ok {
+		p = &Player{Rating: NewRating(), History: []Point{}}
+		t.players[pool][name] = p
+	}
+
+	return p
+}
+
+func point(r Rating, at time.Time) Point {
+	return Point{At: at, Rating: r.Rating, Deviation: r.Deviation}
+}
+
+type file struct {
+	System  string                      `json:"system"`
+	Players map[Pool]map[string]*Player `json:"players"`
+	Pending map[Pool][]game             `json:"pending"`
+}
+
+func (t *Table) load() error {
+	if t.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(t.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+
+	if f.System != t.system.String() {
+		return fmt.Errorf("the ratings in %s use %s, not %s", t.path, f.System, t.system)
+	}
+
+	if f.Players != nil {
+		t.players = f.Players
+	}
+
+	if f.Pending != nil {
+		t.pending = f.Pending
+	}
+
+	return nil
+}
+
+// save writes the ratings and pending results to the file of the table. t.mu must be held.
+func (t *Table) save() error {
+	if t.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(file{System: t.system.String(), Players: t.players, Pending: t.pending})
+	if err != nil {
+		return err
+	}
+
+	return storage.WriteFile(t.path, data, 0o644)
+}
@@ -0,0 +1,315 @@
+// Package session runs analysis sessions, infinite searches of positions in the background that are read while they
+// run and stopped when they are no longer wanted.
+package session
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/engine"
+	"github.com/tomwatson6/chessbot/internal/storage"
+)
+
+// ErrorSessionNotFound is thrown when there is no session with the id provided
+var ErrorSessionNotFound = errors.New("there is no session with the id provided")
+
+// DefaultIdleTimeout is how long a session is left without being read before it is stopped
+const DefaultIdleTimeout = 5 * time.Minute
+
+// The states of a session
+const (
+	// Queued sessions are waiting for workers, as too few are free for every thread of the session
+	Queued = "queued"
+	// Running sessions are being searched
+	Running = "running"
+	// Stopped sessions were deleted or left idle, they keep the last depth completed
+	Stopped = "stopped"
+)
+
+// Snapshot is the state of a session, with the lines of the last depth completed, best first
+type Snapshot struct {
+	ID      string        `json:"id"`
+	FEN     string        `json:"fen"`
+	MultiPV int           `json:"multipv"`
+	Threads int           `json:"threads"`
+	Status  string        `json:"status"`
+	Started time.Time     `json:"started"`
+	Lines   []engine.Info `json:"lines"`
+}
+
+// Depth gets the last depth completed, 0 before the first depth is
+func (s Snapshot) Depth() int {
+	if len(s.Lines) == 0 {
+		return 0
+	}
+
+	return s.Lines[0].Depth
+}
+
+type session struct {
+	snapshot Snapshot
+	chess    chess.Chess
+	cancel   context.CancelFunc
+	idle     *time.Timer
+	// pending is the lines of the depth being searched, which replace those of the snapshot once it is completed
+	pending []engine.Info
+}
+
+// Manager runs sessions on a bounded number of workers, each thread of a session taking a worker. Sessions wait in a
+// queue for workers when too few are free. A session that is not read for the idle timeout is stopped, and removed
+// if it is left for another.
+type Manager struct {
+	mu       sync.Mutex
+	sessions map[string]*session
+	workers  chan struct{}
+	// turn is held by the session taking its workers, so that queued sessions never each hold part of what another
+	// is waiting for
+	turn chan struct{}
+	opts []engine.Option
+	idle time.Duration
+}
+
+type Option func(m *Manager)
+
+// WithWorkers sets how many threads can search sessions at once, the number of CPUs by default
+func WithWorkers(n int) Option {
+	return func(m *Manager) {
+		if n > 0 {
+			m.workers = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithEngineOptions searches sessions with engines made with the options provided, such as a tablebase
+func WithEngineOptions(opts ...engine.Option) Option {
+	return func(m *Manager) {
+		m.opts = opts
+	}
+}
+
+// WithIdleTimeout sets how long a session is left without being read before it is stopped
+func WithIdleTimeout(d time.Duration) Option {
+	return func(m *Manager) {
+		m.idle = d
+	}
+}
+
+func NewManager(opts ...Option) *Manager {
+	m := &Manager{
+		sessions: make(map[string]*session),
+		workers:  make(chan struct{}, runtime.NumCPU()),
+		turn:     make(chan struct{}, 1),
+		idle:     DefaultIdleTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Start starts a session searching the position for the best n moves with the number of threads provided until it
+// is stopped. A session takes a worker for each thread, so the threads are cut to the number of workers.
+func (m *Manager) Start(c chess.Chess, n, threads int) (Snapshot, error) {
+	if len(c.LegalMoves()) == 0 {
+		return Snapshot{}, engine.ErrorNoMoves
+	}
+
+	if n < 1 {
+		n = 1
+	}
+
+	if threads < 1 {
+		threads = 1
+	}
+
+	if threads > cap(m.workers) {
+		threads = cap(m.workers)
+	}
+
+	id, err := storage.NewID(8)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := &session{
+		snapshot: Snapshot{
+			ID:      id,
+			FEN:     c.FEN(),
+			MultiPV: n,
+			Threads: threads,
+			Status:  Queued,
+			Started: time.Now(),
+			Lines:   []engine.Info{},
+		},
+		chess:  c.Copy(),
+		cancel: cancel,
+	}
+
+	m.mu.Lock()
+	m.sessions[id] = s
+	s.idle = time.AfterFunc(m.idle, func() { m.expire(id) })
+	snapshot := s.snapshot
+	m.mu.Unlock()
+
+	go m.run(ctx, s)
+
+	return snapshot, nil
+}
+
+// Get gets the state of the session, reading it keeps it from being stopped for being idle
+func (m *Manager) Get(id string) (Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[id]
+	if !ok {
+		return Snapshot{}, ErrorSessionNotFound
+	}
+
+	s.idle.Reset(m.idle)
+
+	return s.view(), nil
+}
+
+// Stop stops the session and removes it, getting its last state
+func (m *Manager) Stop(id string) (Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[id]
+	if !ok {
+		return Snapshot{}, ErrorSessionNotFound
+	}
+
+	s.cancel()
+	s.idle.Stop()
+	delete(m.sessions, id)
+
+	s.snapshot.Status = Stopped
+
+	return s.view(), nil
+}
+
+// Close stops every session
+func (m *Manager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, s := range m.sessions {
+		s.cancel()
+		s.idle.Stop()
+		delete(m.sessions, id)
+	}
+}
+
+// run waits for a worker for each thread then searches the session until it is stopped
+func (m *Manager) run(ctx context.Context, s *session) {
+	threads := s.snapshot.Threads
+
+	if !m.acquire(ctx, threads) {
+		m.finish(s)
+		return
+	}
+
+	defer m.release(threads)
+
+	m.mu.Lock()
+	if s.snapshot.Status == Queued {
+		s.snapshot.Status = Running
+	}
+	m.mu.Unlock()
+
+	limits := engine.Limits{Infinite: true, MultiPV: s.snapshot.MultiPV}
+	e := engine.New(append(append([]engine.Option{}, m.opts...), engine.WithThreads(threads))...)
+
+	// The search only fails when the position has no moves, which Start has already checked
+	_, _ = e.Search(ctx, s.chess, limits, func(info engine.Info) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		if info.MultiPV <= 1 {
+			s.pending = nil
+		}
+
+		s.pending = append(s.pending, info)
+
+		// The lines are only replaced once every line of the depth is in, so that they are never of mixed depths
+		if len(s.pending) >= len(s.snapshot.Lines) {
+			s.snapshot.Lines = append([]engine.Info{}, s.pending...)
+		}
+	})
+
+	m.finish(s)
+}
+
+// acquire waits for n workers, returning false if the context is done first. The workers are taken in turn, the
+// session taking them holding the turn until it has them all.
+func (m *Manager) acquire(ctx context.Context, n int) bool {
+	select {
+	case m.turn <- struct{}{}:
+	case <-ctx.Done():
+		return false
+	}
+
+	defer func() { <-m.turn }()
+
+	for i := 0; i < n; i++ {
+		select {
+		case m.workers <- struct{}{}:
+		case <-ctx.Done():
+			m.release(i)
+			return false
+		}
+	}
+
+	return true
+}
+
+func (m *Manager) release(n int) {
+	for i := 0; i < n; i++ {
+		<-m.workers
+	}
+}
+
+func (m *Manager) finish(s *session) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s.snapshot.Status = Stopped
+}
+
+// expire stops the session when it has been left idle, and removes it when it is left idle once stopped
+func (m *Manager) expire(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[id]
+	if !ok {
+		return
+	}
+
+	if s.snapshot.Status == Stopped {
+		delete(m.sessions, id)
+		return
+	}
+
+	s.cancel()
+	s.snapshot.Status = Stopped
+	s.idle.Reset(m.idle)
+}
+
+// view gets a copy of the snapshot of the session, m.mu must be held by the caller
+func (s *session) view() Snapshot {
+	v := s.snapshot
+	v.Lines = append([]engine.Info{}, s.snapshot.Lines...)
+
+	return v
+}
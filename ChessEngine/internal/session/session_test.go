@@ -0,0 +1,145 @@
+package session_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/engine"
+	"github.com/tomwatson6/chessbot/internal/session"
+)
+
+// waitFor polls the session until the condition holds, failing the test if it does not within a few seconds
+func waitFor(t *testing.T, m *session.Manager, id string, cond func(s session.Snapshot) bool) session.Snapshot {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+		s, err := m.Get(id)
+		if err != nil {
+			t.Fatalf("unexpected error getting session: %v", err)
+		}
+
+		if cond(s) {
+			return s
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("session never reached the state wanted, got %+v", s)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestManager(t *testing.T) {
+	m := session.NewManager(session.WithWorkers(1))
+	defer m.Close()
+
+	first, err := m.Start(chess.New(colour.White), 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := waitFor(t, m, first.ID, func(s session.Snapshot) bool { return s.Depth() >= 2 })
+	if got.Status != session.Running || len(got.Lines) != 2 || got.Lines[0].PV[0] == got.Lines[1].PV[0] {
+		t.Errorf("want the two best lines of a running session, got %+v", got)
+	}
+
+	// There is a single worker, so the second session waits for the first
+	second, err := m.Start(chess.New(colour.White), 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := m.Get(second.ID); got.Status != session.Queued {
+		t.Errorf("want the second session queued, got %s", got.Status)
+	}
+
+	stopped, err := m.Stop(first.ID)
+	if err != nil || stopped.Status != session.Stopped || stopped.Depth() < 2 {
+		t.Errorf("want the session stopped with its last depth, got %+v with error %v", stopped, err)
+	}
+
+	if _, err := m.Get(first.ID); !errors.Is(err, session.ErrorSessionNotFound) {
+		t.Errorf("got error %v, want %v", err, session.ErrorSessionNotFound)
+	}
+
+	waitFor(t, m, second.ID, func(s session.Snapshot) bool { return s.Status == session.Running && s.Depth() >= 1 })
+
+	if _, err := m.Stop("missing"); !errors.Is(err, session.ErrorSessionNotFound) {
+		t.Errorf("got error %v, want %v", err, session.ErrorSessionNotFound)
+	}
+}
+
+func TestManager_Threads(t *testing.T) {
+	m := session.NewManager(session.WithWorkers(2))
+	defer m.Close()
+
+	// A session cannot search with more threads than there are workers
+	first, err := m.Start(chess.New(colour.White), 1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.Threads != 2 {
+		t.Errorf("want the threads cut to the 2 workers, got %d", first.Threads)
+	}
+
+	waitFor(t, m, first.ID, func(s session.Snapshot) bool { return s.Status == session.Running })
+
+	// Each thread of the first session takes a worker, leaving none for the second
+	second, err := m.Start(chess.New(colour.White), 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	if got, _ := m.Get(second.ID); got.Status != session.Queued {
+		t.Errorf("want the second session queued while the first takes every worker, got %s", got.Status)
+	}
+
+	if _, err := m.Stop(first.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor(t, m, second.ID, func(s session.Snapshot) bool { return s.Status == session.Running })
+}
+
+func TestManager_Idle(t *testing.T) {
+	m := session.NewManager(session.WithIdleTimeout(100 * time.Millisecond))
+	defer m.Close()
+
+	s, err := m.Start(chess.New(colour.White), 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+
+	// Reading the session keeps it for another timeout
+	if got, err := m.Get(s.ID); err != nil || got.Status != session.Stopped {
+		t.Fatalf("want the idle session stopped, got %+v with error %v", got, err)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+
+	if _, err := m.Get(s.ID); !errors.Is(err, session.ErrorSessionNotFound) {
+		t.Errorf("want the stopped session removed once idle, got error %v", err)
+	}
+}
+
+func TestManager_NoMoves(t *testing.T) {
+	c, err := chess.FromFEN("R5k1/5ppp/8/8/8/8/8/6K1 b - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := session.NewManager().Start(c, 1, 1); !errors.Is(err, engine.ErrorNoMoves) {
+		t.Errorf("got error %v, want %v", err, engine.ErrorNoMoves)
+	}
+}
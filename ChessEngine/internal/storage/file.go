@@ -0,0 +1,324 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+)
+
+const (
+	fileExtension = ".jsonl"
+	// quarantineExtension is added to the files of games that cannot be read
+	quarantineExtension = ".corrupt"
+)
+
+type entryType string
+
+const (
+	entryGame     entryType = "game"
+	entryMove     entryType = "move"
+	entryResult   entryType = "result"
+	entryPremoves entryType = "premoves"
+	entryDraw     entryType = "draw"
+)
+
+// entry is a line of the file of a game, the first line is the game followed by its moves and then its result
+type entry struct {
+	Type   entryType  `json:"type"`
+	Game   *Game      `json:"game,omitempty"`
+	Move   *Move      `json:"move,omitempty"`
+	Result *Result    `json:"result,omitempty"`
+	Draw   *DrawOffer `json:"draw,omitempty"`
+
+	Colour colour.Colour `json:"colour,omitempty"`
+	Lines  [][]move.Move `json:"lines,omitempty"`
+}
+
+// File stores each game as a JSON-lines file in a directory, syncing each line to disk as it is written
+type File struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewFile stores games in the directory provided, creating it if it does not exist
+func NewFile(dir string) (*File, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &File{dir: dir}, nil
+}
+
+func (s *File) Create(g Game) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path, err := s.path(g.ID)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		return ErrorGameExists
+	}
+
+	if err != nil {
+		return err
+	}
+
+	g.Moves = nil
+	g.Result = nil
+	g.DrawOffers = nil
+
+	return writeEntry(f, entry{Type: entryGame, Game: &g})
+}
+
+func (s *File) AppendMove(id string, m Move) error {
+	return s.append(id, entry{Type: entryMove, Move: &m})
+}
+
+func (s *File) Finish(id string, r Result) error {
+	return s.append(id, entry{Type: entryResult, Result: &r})
+}
+
+func (s *File) SetPremoves(id string, c colour.Colour, lines [][]move.Move) error {
+	return s.append(id, entry{Type: entryPremoves, Colour: c, Lines: lines})
+}
+
+func (s *File) AppendDrawOffer(id string, d DrawOffer) error {
+	return s.append(id, entry{Type: entryDraw, Draw: &d})
+}
+
+func (s *File) Load(id string) (Game, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path, err := s.path(id)
+	if err != nil {
+		return Game{}, err
+	}
+
+	return readGame(path)
+}
+
+func (s *File) List() ([]Game, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var games []Game
+
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != fileExtension {
+			continue
+		}
+
+		path := filepath.Join(s.dir, f.Name())
+
+		g, err := readGame(path)
+		if err != nil {
+			// A game that cannot be read is moved aside, so that it does not stop the other games from loading
+			log.Printf("Failed to read game %s, moving it to %s with error: %s\n", f.Name(), path+quarantineExtension, err)
+
+			if err := os.Rename(path, path+quarantineExtension); err != nil {
+				return nil, fmt.Errorf("failed to quarantine game %s: %w", f.Name(), err)
+			}
+
+			continue
+		}
+
+		games = append(games, g)
+	}
+
+	sort.SliceStable(games, func(i, j int) bool {
+		return games[i].CreatedAt.Before(games[j].CreatedAt)
+	})
+
+	return games, nil
+}
+
+func (s *File) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrorGameNotFound
+	}
+
+	return err
+}
+
+func (s *File) append(id string, e entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0o644)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrorGameNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := repair(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return writeEntry(f, e)
+}
+
+// repair truncates the file back to its last complete line, so that a line torn by the server stopping part way
+// through writing it is not joined to the next line written
+func repair(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if info.Size() == 0 {
+		return nil
+	}
+
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, info.Size()-1); err != nil {
+		return err
+	}
+
+	if last[0] == '\n' {
+		return nil
+	}
+
+	data := make([]byte, info.Size())
+	if _, err := f.ReadAt(data, 0); err != nil {
+		return err
+	}
+
+	return f.Truncate(int64(bytes.LastIndexByte(data, '\n') + 1))
+}
+
+// path gets the file of the game, rejecting ids that would point outside of the directory
+func (s *File) path(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return "", ErrorGameNotFound
+	}
+
+	return filepath.Join(s.dir, id+fileExtension), nil
+}
+
+// writeEntry writes the entry as a line to the file, then syncs and closes it
+func writeEntry(f *os.File, e entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func readGame(path string) (Game, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Game{}, ErrorGameNotFound
+	}
+
+	if err != nil {
+		return Game{}, err
+	}
+	defer f.Close()
+
+	var g *Game
+
+	r := bufio.NewReader(f)
+
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 && line[len(line)-1] != '\n' {
+			// The last line was not fully written before the server stopped, so the move was never acknowledged
+			break
+		}
+
+		if len(line) > 0 {
+			var e entry
+			if err := json.Unmarshal(line, &e); err != nil {
+				return Game{}, err
+			}
+
+			switch {
+			case e.Type == entryGame && e.Game != nil:
+				g = e.Game
+			case g == nil:
+				return Game{}, fmt.Errorf("the game must be the first entry, got %s", e.Type)
+			case e.Type == entryMove && e.Move != nil:
+				g.Moves = append(g.Moves, *e.Move)
+			case e.Type == entryResult && e.Result != nil:
+				g.Result = e.Result
+			case e.Type == entryDraw && e.Draw != nil:
+				g.DrawOffers = append(g.DrawOffers, *e.Draw)
+			case e.Type == entryPremoves:
+				if g.Premoves == nil {
+					g.Premoves = make(map[colour.Colour][][]move.Move)
+				}
+
+				g.Premoves[e.Colour] = e.Lines
+			default:
+				return Game{}, fmt.Errorf("invalid entry of type %s", e.Type)
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return Game{}, err
+		}
+	}
+
+	if g == nil {
+		return Game{}, fmt.Errorf("no game in %s", path)
+	}
+
+	return *g, nil
+}
@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+)
+
+// Memory stores games in memory, for tests and servers that do not need to keep games between restarts
+type Memory struct {
+	mu    sync.Mutex
+	games map[string]Game
+	order []string
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		games: make(map[string]Game),
+	}
+}
+
+func (s *Memory) Create(g Game) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.games[g.ID]; ok {
+		return ErrorGameExists
+	}
+
+	g.Moves = nil
+	g.Result = nil
+	g.DrawOffers = nil
+
+	s.games[g.ID] = copyGame(g)
+	s.order = append(s.order, g.ID)
+
+	return nil
+}
+
+func (s *Memory) AppendMove(id string, m Move) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.games[id]
+	if !ok {
+		return ErrorGameNotFound
+	}
+
+	g.Moves = append(g.Moves, m)
+	s.games[id] = g
+
+	return nil
+}
+
+func (s *Memory) Finish(id string, r Result) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.games[id]
+	if !ok {
+		return ErrorGameNotFound
+	}
+
+	g.Result = &r
+	s.games[id] = g
+
+	return nil
+}
+
+func (s *Memory) SetPremoves(id string, c colour.Colour, lines [][]move.Move) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.games[id]
+	if !ok {
+		return ErrorGameNotFound
+	}
+
+	premoves := make(map[colour.Colour][][]move.Move)
+	for col, l := range g.Premoves {
+		premoves[col] = l
+	}
+
+	premoves[c] = copyLines(lines)
+	g.Premoves = premoves
+	s.games[id] = g
+
+	return nil
+}
+
+func (s *Memory) AppendDrawOffer(id string, d DrawOffer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.games[id]
+	if !ok {
+		return ErrorGameNotFound
+	}
+
+	g.DrawOffers = append(g.DrawOffers, d)
+	s.games[id] = g
+
+	return nil
+}
+
+func (s *Memory) Load(id string) (Game, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.games[id]
+	if !ok {
+		return Game{}, ErrorGameNotFound
+	}
+
+	return copyGame(g), nil
+}
+
+func (s *Memory) List() ([]Game, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	games := make([]Game, len(s.order))
+	for i, id := range s.order {
+		games[i] = copyGame(s.games[id])
+	}
+
+	sort.SliceStable(games, func(i, j int) bool {
+		return games[i].CreatedAt.Before(games[j].CreatedAt)
+	})
+
+	return games, nil
+}
+
+func (s *Memory) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.games[id]; !ok {
+		return ErrorGameNotFound
+	}
+
+	delete(s.games, id)
+
+	for i, gid := range s.order {
+		if gid == id {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
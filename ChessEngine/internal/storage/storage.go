@@ -0,0 +1,188 @@
+// Package storage persists games so that they survive the server restarting
+package storage
+
+import (
+	"errors"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/piece"
+)
+
+var (
+	// ErrorGameNotFound is thrown when there is no stored game with the id provided
+	ErrorGameNotFound = errors.New("there is no stored game with the id provided")
+	// ErrorGameExists is thrown when creating a game with an id that is already stored
+	ErrorGameExists = errors.New("a game with the id provided is already stored")
+)
+
+// Store saves games and the moves made in them
+type Store interface {
+	// Create saves a new game, the moves and result of the game are ignored
+	Create(g Game) error
+	// AppendMove saves a move made in the game, returning once the move has been written durably
+	AppendMove(id string, m Move) error
+	// Finish saves the result of the game
+	Finish(id string, r Result) error
+	// SetPremoves saves the conditional moves of the colour provided, replacing those saved before
+	SetPremoves(id string, c colour.Colour, lines [][]move.Move) error
+	// AppendDrawOffer saves a draw being offered, declined or accepted in the game
+	AppendDrawOffer(id string, d DrawOffer) error
+	Load(id string) (Game, error)
+	// List gets all of the stored games in the order they were created
+	List() ([]Game, error)
+	Delete(id string) error
+}
+
+// Game is everything needed to restore a game, its starting position and the moves made since
+type Game struct {
+	ID        string        `json:"id"`
+	Variant   string        `json:"variant"`
+	Turn      colour.Colour `json:"turn"`
+	Width     int           `json:"width"`
+	Height    int           `json:"height"`
+	Pieces    []Piece       `json:"pieces"`
+	Clock     *Clock        `json:"clock,omitempty"`
+	CreatedAt time.Time     `json:"createdAt"`
+	Moves     []Move        `json:"moves,omitempty"`
+	Result    *Result       `json:"result,omitempty"`
+
+	White          string                          `json:"white,omitempty"`
+	Black          string                          `json:"black,omitempty"`
+	Correspondence *Correspondence                 `json:"correspondence,omitempty"`
+	Premoves       map[colour.Colour][][]move.Move `json:"premoves,omitempty"`
+	DrawOffers     []DrawOffer                     `json:"drawOffers,omitempty"`
+	Bot            *Bot                            `json:"bot,omitempty"`
+}
+
+type Piece struct {
+	Type     piece.PieceType `json:"type"`
+	Colour   colour.Colour   `json:"colour"`
+	Position move.Position   `json:"position"`
+	HasMoved bool            `json:"hasMoved,omitempty"`
+}
+
+type Clock struct {
+	Initial   time.Duration `json:"initial"`
+	Increment time.Duration `json:"increment"`
+}
+
+type Correspondence struct {
+	PerMove  time.Duration `json:"perMove"`
+	Vacation time.Duration `json:"vacation"`
+}
+
+// Bot is the engine playing a colour of the game, so that it is started again when the game is loaded
+type Bot struct {
+	Colour colour.Colour `json:"colour"`
+	Depth  int           `json:"depth,omitempty"`
+	Ponder bool          `json:"ponder"`
+}
+
+// Move is a move made in a game and when it was made, so clocks can be restored
+type Move struct {
+	Move move.Move `json:"move"`
+	At   time.Time `json:"at"`
+}
+
+// DrawOffer is a draw being offered, declined or accepted by a colour, Ply being how many moves had been made then
+// so that it is replayed between the right moves
+type DrawOffer struct {
+	Colour colour.Colour `json:"colour"`
+	State  string        `json:"state"`
+	Ply    int           `json:"ply"`
+	At     time.Time     `json:"at"`
+}
+
+type Result struct {
+	Status string         `json:"status"`
+	Winner *colour.Colour `json:"winner,omitempty"`
+	At     time.Time      `json:"at"`
+}
+
+// NewPieces converts the pieces of a board to be stored
+func NewPieces(ps map[move.Position]*piece.Piece) []Piece {
+	pieces := make([]Piece, 0, len(ps))
+
+	for _, p := range ps {
+		pieces = append(pieces, Piece{
+			Type:     p.GetPieceType(),
+			Colour:   p.Colour,
+			Position: p.Position,
+			HasMoved: p.HasMoved(),
+		})
+	}
+
+	return pieces
+}
+
+// ToPieces converts stored pieces back to the pieces of a board
+func ToPieces(ps []Piece) ([]*piece.Piece, error) {
+	pieces := make([]*piece.Piece, 0, len(ps))
+
+	for _, p := range ps {
+		pd, err := piece.NewPieceDetails(p.Type, p.Colour, p.HasMoved)
+		if err != nil {
+			return nil, err
+		}
+
+		pieces = append(pieces, &piece.Piece{
+			Colour:       p.Colour,
+			Position:     p.Position,
+			PieceDetails: pd,
+		})
+	}
+
+	return pieces, nil
+}
+
+// copyGame copies the game so the caller cannot change what has been stored
+func copyGame(g Game) Game {
+	g.Pieces = append([]Piece{}, g.Pieces...)
+	g.Moves = append([]Move{}, g.Moves...)
+
+	if g.DrawOffers != nil {
+		g.DrawOffers = append([]DrawOffer{}, g.DrawOffers...)
+	}
+
+	if g.Clock != nil {
+		c := *g.Clock
+		g.Clock = &c
+	}
+
+	if g.Result != nil {
+		r := *g.Result
+		g.Result = &r
+	}
+
+	if g.Correspondence != nil {
+		c := *g.Correspondence
+		g.Correspondence = &c
+	}
+
+	if g.Bot != nil {
+		b := *g.Bot
+		g.Bot = &b
+	}
+
+	if g.Premoves != nil {
+		premoves := make(map[colour.Colour][][]move.Move, len(g.Premoves))
+		for c, lines := range g.Premoves {
+			premoves[c] = copyLines(lines)
+		}
+
+		g.Premoves = premoves
+	}
+
+	return g
+}
+
+func copyLines(lines [][]move.Move) [][]move.Move {
+	c := make([][]move.Move, len(lines))
+	for i, l := range lines {
+		c[i] = append([]move.Move{}, l...)
+	}
+
+	return c
+}
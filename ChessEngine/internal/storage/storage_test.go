@@ -0,0 +1,272 @@
+package storage_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/piece"
+	"github.com/tomwatson6/chessbot/internal/storage"
+)
+
+func newGame(id string, createdAt time.Time) storage.Game {
+	return storage.Game{
+		ID:      id,
+		Variant: "Standard",
+		Turn:    colour.White,
+		Width:   8,
+		Height:  8,
+		Pieces: []storage.Piece{
+			{Type: piece.PieceTypeKing, Colour: colour.White, Position: move.Position{File: 4, Rank: 0}},
+			{Type: piece.PieceTypeKing, Colour: colour.Black, Position: move.Position{File: 4, Rank: 7}},
+		},
+		Clock:     &storage.Clock{Initial: time.Minute, Increment: time.Second},
+		CreatedAt: createdAt,
+	}
+}
+
+func TestStore(t *testing.T) {
+	stores := []struct {
+		name  string
+		store func(t *testing.T) storage.Store
+	}{
+		{
+			name: "Memory",
+			store: func(t *testing.T) storage.Store {
+				return storage.NewMemory()
+			},
+		},
+		{
+			name: "File",
+			store: func(t *testing.T) storage.Store {
+				s, err := storage.NewFile(t.TempDir())
+				if err != nil {
+					t.Fatalf("unexpected error creating store: %v", err)
+				}
+
+				return s
+			},
+		},
+	}
+
+	created := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	winner := colour.White
+
+	for _, sc := range stores {
+		t.Run(sc.name, func(t *testing.T) {
+			s := sc.store(t)
+
+			first := newGame("first", created)
+			second := newGame("second", created.Add(time.Hour))
+
+			for _, g := range []storage.Game{second, first} {
+				if err := s.Create(g); err != nil {
+					t.Fatalf("unexpected error creating game: %v", err)
+				}
+			}
+
+			if err := s.Create(first); !errors.Is(err, storage.ErrorGameExists) {
+				t.Errorf("want %v, got %v", storage.ErrorGameExists, err)
+			}
+
+			moves := []storage.Move{
+				{Move: move.Move{From: move.Position{File: 4, Rank: 0}, To: move.Position{File: 4, Rank: 1}}, At: created.Add(time.Minute)},
+				{Move: move.Move{From: move.Position{File: 4, Rank: 7}, To: move.Position{File: 4, Rank: 6}}, At: created.Add(2 * time.Minute)},
+			}
+
+			for _, m := range moves {
+				if err := s.AppendMove(first.ID, m); err != nil {
+					t.Fatalf("unexpected error appending move: %v", err)
+				}
+			}
+
+			premoves := [][]move.Move{{moves[0].Move, moves[1].Move}}
+			if err := s.SetPremoves(first.ID, colour.Black, premoves); err != nil {
+				t.Fatalf("unexpected error setting premoves: %v", err)
+			}
+
+			result := storage.Result{Status: "Resigned", Winner: &winner, At: created.Add(3 * time.Minute)}
+			if err := s.Finish(first.ID, result); err != nil {
+				t.Fatalf("unexpected error finishing game: %v", err)
+			}
+
+			want := first
+			want.Moves = moves
+			want.Result = &result
+			want.Premoves = map[colour.Colour][][]move.Move{colour.Black: premoves}
+
+			got, err := s.Load(first.ID)
+			if err != nil {
+				t.Fatalf("unexpected error loading game: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("want %+v, got %+v", want, got)
+			}
+
+			games, err := s.List()
+			if err != nil {
+				t.Fatalf("unexpected error listing games: %v", err)
+			}
+
+			if len(games) != 2 || games[0].ID != first.ID || games[1].ID != second.ID {
+				t.Errorf("want games in the order they were created, got %+v", games)
+			}
+
+			if err := s.Delete(first.ID); err != nil {
+				t.Fatalf("unexpected error deleting game: %v", err)
+			}
+
+			if _, err := s.Load(first.ID); !errors.Is(err, storage.ErrorGameNotFound) {
+				t.Errorf("want %v, got %v", storage.ErrorGameNotFound, err)
+			}
+
+			if err := s.AppendMove(first.ID, moves[0]); !errors.Is(err, storage.ErrorGameNotFound) {
+				t.Errorf("want %v, got %v", storage.ErrorGameNotFound, err)
+			}
+		})
+	}
+}
+
+func TestFile_TruncatedMove(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := storage.NewFile(dir)
+	if err != nil {
+		t.Fatalf("unexpected error creating store: %v", err)
+	}
+
+	g := newGame("game", time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC))
+	if err := s.Create(g); err != nil {
+		t.Fatalf("unexpected error creating game: %v", err)
+	}
+
+	// The server stopped part way through writing a move
+	f, err := os.OpenFile(filepath.Join(dir, "game.jsonl"), os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := f.WriteString(`{"type":"move","move":{"mo`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f.Close()
+
+	got, err := s.Load(g.ID)
+	if err != nil {
+		t.Fatalf("unexpected error loading game: %v", err)
+	}
+
+	if len(got.Moves) != 0 {
+		t.Errorf("want the partly written move to be ignored, got %+v", got.Moves)
+	}
+
+	if _, err := s.Load("../game"); !errors.Is(err, storage.ErrorGameNotFound) {
+		t.Errorf("want %v, got %v", storage.ErrorGameNotFound, err)
+	}
+}
+
+func TestFile_AppendAfterTornLine(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := storage.NewFile(dir)
+	if err != nil {
+		t.Fatalf("unexpected error creating store: %v", err)
+	}
+
+	g := newGame("game", time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC))
+	if err := s.Create(g); err != nil {
+		t.Fatalf("unexpected error creating game: %v", err)
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, "game.jsonl"), os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := f.WriteString(`{"type":"move","move":{"mo`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f.Close()
+
+	// The torn line is cut off before the next move is written after it
+	m := storage.Move{Move: move.Move{From: move.Position{File: 4, Rank: 0}, To: move.Position{File: 4, Rank: 1}}}
+	if err := s.AppendMove(g.ID, m); err != nil {
+		t.Fatalf("unexpected error appending move: %v", err)
+	}
+
+	reloaded, err := storage.NewFile(dir)
+	if err != nil {
+		t.Fatalf("unexpected error reopening store: %v", err)
+	}
+
+	games, err := reloaded.List()
+	if err != nil {
+		t.Fatalf("unexpected error listing games: %v", err)
+	}
+
+	if len(games) != 1 || len(games[0].Moves) != 1 || games[0].Moves[0].Move != m.Move {
+		t.Errorf("want the game with the move appended after the torn line, got %+v", games)
+	}
+}
+
+func TestFile_ListQuarantinesUnreadableGames(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := storage.NewFile(dir)
+	if err != nil {
+		t.Fatalf("unexpected error creating store: %v", err)
+	}
+
+	g := newGame("good", time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC))
+	if err := s.Create(g); err != nil {
+		t.Fatalf("unexpected error creating game: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "bad.jsonl"), []byte("{\"type\":\"game\",\"ga\n"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	games, err := s.List()
+	if err != nil {
+		t.Fatalf("unexpected error listing games: %v", err)
+	}
+
+	if len(games) != 1 || games[0].ID != "good" {
+		t.Errorf("want only the readable game, got %+v", games)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "bad.jsonl.corrupt")); err != nil {
+		t.Errorf("want the unreadable game to be moved aside, got %v", err)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dir", "file.json")
+
+	for _, data := range []string{"first", "second"} {
+		if err := storage.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(got) != data {
+			t.Errorf("got %q, want %q", got, data)
+		}
+	}
+
+	if _, err := os.Stat(path + ".tmp"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("want no temporary file left behind, got %v", err)
+	}
+}
@@ -0,0 +1,255 @@
+package tablebase
+
+import (
+	"sort"
+)
+
+// The flags of a position found while generating a table, from the moves that leave the table
+const (
+	// canDraw is set when a capture or promotion draws, so the position cannot be lost
+	canDraw uint8 = 1 << iota
+	// canWin is set when a capture or promotion wins, so the position cannot be lost
+	canWin
+)
+
+// conversion looks up the positions reached by a capture or promotion in the table of the material they lead to
+type conversion struct {
+	table *table
+	flip  bool
+	// from is the man of the material converted from that is at each man of the table converted to
+	from []int
+}
+
+func (s *Set) conversion(m *material, st step) *conversion {
+	var men []man
+	var from []int
+
+	for i, mn := range m.men {
+		if i == st.capture {
+			continue
+		}
+
+		if i == st.man && st.promotion >= 0 {
+			mn.kind = st.promotion
+		}
+
+		men = append(men, mn)
+		from = append(from, i)
+	}
+
+	var kinds [2][]int
+	for _, mn := range men[2:] {
+		if mn.white {
+			kinds[0] = append(kinds[0], mn.kind)
+		} else {
+			kinds[1] = append(kinds[1], mn.kind)
+		}
+	}
+
+	sort.Ints(kinds[0])
+	sort.Ints(kinds[1])
+
+	sub, flip, err := parseMaterial(materialName(kinds[0], kinds[1]))
+	if err != nil {
+		panic(err)
+	}
+
+	t, ok := s.table(sub.name)
+	if !ok {
+		panic("tablebase: " + sub.name + " is generated after a table that depends on it")
+	}
+
+	c := &conversion{table: t, flip: flip}
+
+	used := make([]bool, len(men))
+	for _, want := range sub.men {
+		for j, mn := range men {
+			if !used[j] && mn.kind == want.kind && (mn.white != flip) == want.white {
+				used[j] = true
+				c.from = append(c.from, from[j])
+				break
+			}
+		}
+	}
+
+	return c
+}
+
+// value gets the result of the position reached by the conversion, for the colour to move in it
+func (c *conversion) value(q position) uint8 {
+	var p position
+
+	p.white = q.white != c.flip
+	for i, j := range c.from {
+		p.sq[i] = q.sq[j]
+		if c.flip {
+			p.sq[i] ^= 56
+		}
+	}
+
+	m := c.table.material
+
+	return c.table.values[m.index(p)]
+}
+
+// generate builds the table of the material by retrograde analysis. Mates are found first, then positions are
+// resolved a half move further from mate at a time: a position is won once one of its moves reaches a lost
+// position, and lost once every move reaches a won position. Captures and promotions are looked up in the tables
+// of the materials they lead to, which must already have been generated. Positions never resolved are draws.
+func (s *Set) generate(m *material) *table {
+	n := m.size
+
+	values := make([]uint8, n)
+	resolved := make([]bool, n)
+	// remaining is the number of positions reached by moves within the table that have not been found to be won
+	remaining := make([]uint8, n)
+	// longest is the most half moves to mate of the won positions reached by moves from the position
+	longest := make([]uint8, n)
+	flags := make([]uint8, n)
+
+	// Positions waiting to be resolved at each number of half moves to mate, with the lowest bit set for losses
+	var queue [][]uint32
+
+	push := func(plies, idx int, loss bool) {
+		for len(queue) <= plies {
+			queue = append(queue, nil)
+		}
+
+		item := uint32(idx) << 1
+		if loss {
+			item |= 1
+		}
+
+		queue[plies] = append(queue[plies], item)
+	}
+
+	conversions := make(map[[3]int]*conversion)
+
+	// The buffers are reused for every position, as generating a table visits millions of them
+	var steps []step
+	var prevs []position
+	var next, prev []int
+
+	for idx := 0; idx < n; idx++ {
+		p := m.position(idx)
+
+		// Only the lowest index of each position and its mirror images is used
+		if !p.valid(m.men) || m.index(p) != idx {
+			resolved[idx] = true
+			continue
+		}
+
+		steps = p.steps(m.men, steps)
+		if len(steps) == 0 {
+			if p.inCheck(m.men, p.white) {
+				push(0, idx, true)
+			} else {
+				resolved[idx] = true
+			}
+
+			continue
+		}
+
+		next = next[:0]
+
+		for _, st := range steps {
+			q := p
+			q.sq[st.man] = st.to
+			q.white = !p.white
+
+			if st.capture < 0 && st.promotion < 0 {
+				next = append(next, m.index(q))
+				continue
+			}
+
+			key := [3]int{st.man, st.capture, st.promotion}
+			c, ok := conversions[key]
+			if !ok {
+				c = s.conversion(m, st)
+				conversions[key] = c
+			}
+
+			r := decode(c.value(q))
+
+			switch r.WDL {
+			case Draw:
+				flags[idx] |= canDraw
+			case Loss:
+				flags[idx] |= canWin
+				push(r.DTM+1, idx, false)
+			case Win:
+				if r.DTM > int(longest[idx]) {
+					longest[idx] = uint8(r.DTM)
+				}
+			}
+		}
+
+		remaining[idx] = uint8(len(unique(next)))
+
+		if remaining[idx] == 0 && flags[idx] == 0 {
+			push(int(longest[idx])+1, idx, true)
+		}
+	}
+
+	for plies := 0; plies < len(queue); plies++ {
+		for _, item := range queue[plies] {
+			idx, loss := int(item>>1), item&1 == 1
+			if resolved[idx] {
+				continue
+			}
+
+			resolved[idx] = true
+
+			if loss {
+				values[idx] = encodeLoss(plies)
+			} else {
+				values[idx] = encodeWin(plies)
+			}
+
+			prevs = m.position(idx).unsteps(m.men, prevs)
+
+			prev = prev[:0]
+			for _, q := range prevs {
+				prev = append(prev, m.index(q))
+			}
+
+			for _, q := range unique(prev) {
+				if resolved[q] {
+					continue
+				}
+
+				if loss {
+					push(plies+1, q, false)
+					continue
+				}
+
+				if plies > int(longest[q]) {
+					longest[q] = uint8(plies)
+				}
+
+				remaining[q]--
+				if remaining[q] == 0 && flags[q] == 0 {
+					push(int(longest[q])+1, q, true)
+				}
+			}
+		}
+
+		queue[plies] = nil
+	}
+
+	return &table{material: m, values: values}
+}
+
+// unique sorts the indexes and removes duplicates, the same position can be reached by moves to mirror images
+func unique(idx []int) []int {
+	sort.Ints(idx)
+
+	out := idx[:0]
+	for i, v := range idx {
+		if i == 0 || v != idx[i-1] {
+			out = append(out, v)
+		}
+	}
+
+	return out
+}
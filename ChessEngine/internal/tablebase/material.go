@@ -0,0 +1,296 @@
+package tablebase
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MaxPieces is the most men, kings included, that tables can be generated for
+const MaxPieces = 4
+
+// material is the men of a table, white king first then the black king, followed by the other white men and the
+// other black men from the most valuable. White has the stronger men, so that each material has a single table.
+type material struct {
+	name  string
+	men   []man
+	pawns bool
+
+	// The white king is kept to a region of the board by the symmetries of the material, the board can be
+	// mirrored left to right, and without pawns top to bottom and along the diagonal too
+	region     []int
+	slot       [64]int
+	symmetries int
+
+	// groups are the ranges of men of the same kind and colour, which are sorted by square so that swapping them
+	// gives the same index
+	groups [][2]int
+	size   int
+}
+
+// parseMaterial reads the name of a material e.g. KQvKR, returning the material of the table that holds it and
+// whether the colours of positions must be swapped to look them up in it
+func parseMaterial(name string) (*material, bool, error) {
+	sides := strings.Split(strings.ToUpper(name), "V")
+	if len(sides) != 2 {
+		return nil, false, fmt.Errorf("%w: %q", ErrorInvalidMaterial, name)
+	}
+
+	var kinds [2][]int
+
+	for i, side := range sides {
+		if !strings.HasPrefix(side, "K") {
+			return nil, false, fmt.Errorf("%w: each side needs a king: %q", ErrorInvalidMaterial, name)
+		}
+
+		for _, r := range side[1:] {
+			k := strings.IndexRune(letters, r)
+			if k <= king {
+				return nil, false, fmt.Errorf("%w: unknown man %q", ErrorInvalidMaterial, r)
+			}
+
+			kinds[i] = append(kinds[i], k)
+		}
+
+		sort.Ints(kinds[i])
+	}
+
+	if n := 2 + len(kinds[0]) + len(kinds[1]); n > MaxPieces {
+		return nil, false, fmt.Errorf("%w: %d men is more than %d", ErrorTooManyPieces, n, MaxPieces)
+	}
+
+	flip := stronger(kinds[1], kinds[0])
+	if flip {
+		kinds[0], kinds[1] = kinds[1], kinds[0]
+	}
+
+	return newMaterial(kinds[0], kinds[1]), flip, nil
+}
+
+// stronger reports whether the first men are stronger than the second, having more men or more valuable men
+func stronger(a, b []int) bool {
+	if len(a) != len(b) {
+		return len(a) > len(b)
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+
+	return false
+}
+
+func materialName(white, black []int) string {
+	var sb strings.Builder
+
+	sb.WriteByte('K')
+	for _, k := range white {
+		sb.WriteByte(letters[k])
+	}
+
+	sb.WriteString("vK")
+	for _, k := range black {
+		sb.WriteByte(letters[k])
+	}
+
+	return sb.String()
+}
+
+func newMaterial(white, black []int) *material {
+	m := &material{
+		name: materialName(white, black),
+		men:  []man{{kind: king, white: true}, {kind: king, white: false}},
+	}
+
+	for _, k := range white {
+		m.men = append(m.men, man{kind: k, white: true})
+	}
+
+	for _, k := range black {
+		m.men = append(m.men, man{kind: k, white: false})
+	}
+
+	for i := 2; i < len(m.men); {
+		j := i + 1
+		for j < len(m.men) && m.men[j] == m.men[i] {
+			j++
+		}
+
+		if j-i > 1 {
+			m.groups = append(m.groups, [2]int{i, j})
+		}
+
+		i = j
+	}
+
+	for _, mn := range m.men {
+		m.pawns = m.pawns || mn.kind == pawn
+	}
+
+	m.symmetries = 8
+	if m.pawns {
+		m.symmetries = 2
+	}
+
+	for sq := 0; sq < 64; sq++ {
+		f, r := sq&7, sq>>3
+
+		m.slot[sq] = -1
+		if f <= 3 && (m.pawns || r <= f) {
+			m.slot[sq] = len(m.region)
+			m.region = append(m.region, sq)
+		}
+	}
+
+	m.size = len(m.region) * 2
+	for i := 1; i < len(m.men); i++ {
+		m.size *= 64
+	}
+
+	return m
+}
+
+// transforms holds each square mirrored by each combination of the symmetries of the board
+var transforms [8][64]int
+
+func init() {
+	for t := range transforms {
+		for sq := range transforms[t] {
+			transforms[t][sq] = transform(sq, t)
+		}
+	}
+}
+
+// transform mirrors the square left to right, top to bottom and along the a1-h8 diagonal, as set in t
+func transform(sq, t int) int {
+	if t&1 != 0 {
+		sq ^= 7
+	}
+
+	if t&2 != 0 {
+		sq ^= 56
+	}
+
+	if t&4 != 0 {
+		sq = (sq&7)<<3 | sq>>3
+	}
+
+	return sq
+}
+
+// index gets the index of the position in the table, the lowest of the indexes of its mirror images
+func (m *material) index(p position) int {
+	best := -1
+
+	for t := 0; t < m.symmetries; t++ {
+		q := p
+		for i := range m.men {
+			q.sq[i] = transforms[t][p.sq[i]]
+		}
+
+		if m.slot[q.sq[0]] < 0 {
+			continue
+		}
+
+		// Men of the same kind and colour are sorted by square, there are few enough to sort by insertion
+		for _, g := range m.groups {
+			for i := g[0] + 1; i < g[1]; i++ {
+				for j := i; j > g[0] && q.sq[j] < q.sq[j-1]; j-- {
+					q.sq[j], q.sq[j-1] = q.sq[j-1], q.sq[j]
+				}
+			}
+		}
+
+		if idx := m.rawIndex(q); best < 0 || idx < best {
+			best = idx
+		}
+	}
+
+	return best
+}
+
+func (m *material) rawIndex(p position) int {
+	idx := m.slot[p.sq[0]]
+	for i := 1; i < len(m.men); i++ {
+		idx = idx*64 + p.sq[i]
+	}
+
+	idx *= 2
+	if !p.white {
+		idx++
+	}
+
+	return idx
+}
+
+// position gets the position at the index of the table
+func (m *material) position(idx int) position {
+	var p position
+
+	p.white = idx%2 == 0
+	idx /= 2
+
+	for i := len(m.men) - 1; i >= 1; i-- {
+		p.sq[i] = idx % 64
+		idx /= 64
+	}
+
+	p.sq[0] = m.region[idx]
+
+	return p
+}
+
+// conversions gets the names of the materials the material can become by a capture or promotion
+func (m *material) conversions() []string {
+	var white, black []int
+	for _, mn := range m.men[2:] {
+		if mn.white {
+			white = append(white, mn.kind)
+		} else {
+			black = append(black, mn.kind)
+		}
+	}
+
+	var names []string
+
+	without := func(kinds []int, i int) []int {
+		return append(append([]int{}, kinds[:i]...), kinds[i+1:]...)
+	}
+
+	for i, k := range white {
+		names = append(names, materialName(without(white, i), black))
+
+		if k == pawn {
+			for _, p := range []int{queen, rook, bishop, knight} {
+				promoted := append(without(white, i), p)
+				sort.Ints(promoted)
+				names = append(names, materialName(promoted, black))
+
+				// Capturing a man while promoting
+				for j := range black {
+					names = append(names, materialName(promoted, without(black, j)))
+				}
+			}
+		}
+	}
+
+	for i, k := range black {
+		names = append(names, materialName(white, without(black, i)))
+
+		if k == pawn {
+			for _, p := range []int{queen, rook, bishop, knight} {
+				promoted := append(without(black, i), p)
+				sort.Ints(promoted)
+				names = append(names, materialName(white, promoted))
+
+				for j := range white {
+					names = append(names, materialName(without(white, j), promoted))
+				}
+			}
+		}
+	}
+
+	return names
+}
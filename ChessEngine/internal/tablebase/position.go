@@ -0,0 +1,305 @@
+package tablebase
+
+import (
+	"math/bits"
+)
+
+// The kinds of men, ordered so that the more valuable come first in the name of a material
+const (
+	king = iota
+	queen
+	rook
+	bishop
+	knight
+	pawn
+)
+
+// letters are the letters of each kind of man in the name of a material
+const letters = "KQRBNP"
+
+// man is a piece of a material, the board package is far too slow to generate millions of positions with so the
+// generator works on squares numbered from 0 for a1 to 63 for h8
+type man struct {
+	kind  int
+	white bool
+}
+
+// position is a position of a material, with the square of each of its men in the order of the material
+type position struct {
+	sq    [MaxPieces]int
+	white bool
+}
+
+// step is a move from one square to another, with the kind of man a pawn promotes to
+type step struct {
+	man, to   int
+	capture   int
+	promotion int
+}
+
+var (
+	kingAttacks   [64]uint64
+	knightAttacks [64]uint64
+
+	rookDirections   = [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+	bishopDirections = [][2]int{{1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+)
+
+func init() {
+	for sq := 0; sq < 64; sq++ {
+		f, r := sq&7, sq>>3
+
+		for _, d := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}, {1, 1}, {1, -1}, {-1, 1}, {-1, -1}} {
+			if onBoard(f+d[0], r+d[1]) {
+				kingAttacks[sq] |= 1 << ((r+d[1])*8 + f + d[0])
+			}
+		}
+
+		for _, d := range [][2]int{{1, 2}, {2, 1}, {2, -1}, {1, -2}, {-1, -2}, {-2, -1}, {-2, 1}, {-1, 2}} {
+			if onBoard(f+d[0], r+d[1]) {
+				knightAttacks[sq] |= 1 << ((r+d[1])*8 + f + d[0])
+			}
+		}
+	}
+}
+
+func onBoard(f, r int) bool {
+	return f >= 0 && f < 8 && r >= 0 && r < 8
+}
+
+func slide(sq int, occ uint64, dirs [][2]int) uint64 {
+	var a uint64
+
+	for _, d := range dirs {
+		f, r := sq&7+d[0], sq>>3+d[1]
+		for onBoard(f, r) {
+			a |= 1 << (r*8 + f)
+			if occ&(1<<(r*8+f)) != 0 {
+				break
+			}
+
+			f, r = f+d[0], r+d[1]
+		}
+	}
+
+	return a
+}
+
+// attacks gets the squares the man attacks from the square provided
+func attacks(m man, sq int, occ uint64) uint64 {
+	switch m.kind {
+	case king:
+		return kingAttacks[sq]
+	case knight:
+		return knightAttacks[sq]
+	case rook:
+		return slide(sq, occ, rookDirections)
+	case bishop:
+		return slide(sq, occ, bishopDirections)
+	case queen:
+		return slide(sq, occ, rookDirections) | slide(sq, occ, bishopDirections)
+	default:
+		f, r := sq&7, sq>>3
+		dr := 1
+		if !m.white {
+			dr = -1
+		}
+
+		var a uint64
+		for _, df := range []int{-1, 1} {
+			if onBoard(f+df, r+dr) {
+				a |= 1 << ((r+dr)*8 + f + df)
+			}
+		}
+
+		return a
+	}
+}
+
+func (p position) occupied(men []man) uint64 {
+	var occ uint64
+	for i := range men {
+		occ |= 1 << p.sq[i]
+	}
+
+	return occ
+}
+
+// attacked reports whether the square is attacked by the men of the colour provided. The man at skip, if any, has
+// been captured and is left out.
+func (p position) attacked(men []man, sq int, white bool, skip int) bool {
+	occ := p.occupied(men)
+
+	for i, m := range men {
+		if i == skip || m.white != white {
+			continue
+		}
+
+		if attacks(m, p.sq[i], occ)&(1<<sq) != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// inCheck reports whether the king of the colour provided is attacked, the kings are the first two men
+func (p position) inCheck(men []man, white bool) bool {
+	k := 0
+	if !white {
+		k = 1
+	}
+
+	return p.attacked(men, p.sq[k], !white, -1)
+}
+
+// valid reports whether the position can be reached: every man is on its own square, pawns are not on the first
+// or last rank, and the colour that has just moved is not in check
+func (p position) valid(men []man) bool {
+	var occ uint64
+
+	for i, m := range men {
+		bit := uint64(1) << p.sq[i]
+		if occ&bit != 0 {
+			return false
+		}
+
+		occ |= bit
+
+		if m.kind == pawn && (p.sq[i] < 8 || p.sq[i] >= 56) {
+			return false
+		}
+	}
+
+	return !p.inCheck(men, !p.white)
+}
+
+// steps gets the legal moves of the colour to move, appended to the buffer provided
+func (p position) steps(men []man, buf []step) []step {
+	steps := buf[:0]
+
+	occ := p.occupied(men)
+
+	var own uint64
+	var at [64]int
+	for i, m := range men {
+		at[p.sq[i]] = i + 1
+		if m.white == p.white {
+			own |= 1 << p.sq[i]
+		}
+	}
+
+	add := func(i, to int) {
+		capture := at[to] - 1
+
+		promotion := -1
+		if men[i].kind == pawn && (to < 8 || to >= 56) {
+			promotion = queen
+		}
+
+		next := p
+		next.sq[i] = to
+
+		// The king must not be left in check, without the captured man, which can no longer attack it
+		k := 0
+		if !p.white {
+			k = 1
+		}
+
+		if next.attacked(men, next.sq[k], !p.white, capture) {
+			return
+		}
+
+		if promotion < 0 {
+			steps = append(steps, step{man: i, to: to, capture: capture, promotion: -1})
+			return
+		}
+
+		for _, kind := range []int{queen, rook, bishop, knight} {
+			steps = append(steps, step{man: i, to: to, capture: capture, promotion: kind})
+		}
+	}
+
+	for i, m := range men {
+		if m.white != p.white {
+			continue
+		}
+
+		sq := p.sq[i]
+
+		if m.kind != pawn {
+			for targets := attacks(m, sq, occ) &^ own; targets != 0; targets &= targets - 1 {
+				add(i, bits.TrailingZeros64(targets))
+			}
+
+			continue
+		}
+
+		for targets := attacks(m, sq, occ) & occ &^ own; targets != 0; targets &= targets - 1 {
+			add(i, bits.TrailingZeros64(targets))
+		}
+
+		forward, start := 8, 1
+		if !m.white {
+			forward, start = -8, 6
+		}
+
+		if one := sq + forward; occ&(1<<one) == 0 {
+			add(i, one)
+
+			if two := one + forward; sq>>3 == start && occ&(1<<two) == 0 {
+				add(i, two)
+			}
+		}
+	}
+
+	return steps
+}
+
+// unsteps gets the positions the position can be reached from by a move that is not a capture or promotion, made
+// by the colour that is not to move, appended to the buffer provided
+func (p position) unsteps(men []man, buf []position) []position {
+	prev := buf[:0]
+
+	occ := p.occupied(men)
+	mover := !p.white
+
+	for i, m := range men {
+		if m.white != mover {
+			continue
+		}
+
+		sq := p.sq[i]
+
+		var origins uint64
+		if m.kind != pawn {
+			origins = attacks(m, sq, occ) &^ occ
+		} else {
+			back, start := -8, 3
+			if !m.white {
+				back, start = 8, 4
+			}
+
+			if one := sq + back; one >= 8 && one < 56 && occ&(1<<one) == 0 {
+				origins |= 1 << one
+
+				if two := one + back; sq>>3 == start && occ&(1<<two) == 0 {
+					origins |= 1 << two
+				}
+			}
+		}
+
+		for ; origins != 0; origins &= origins - 1 {
+			q := p
+			q.sq[i] = bits.TrailingZeros64(origins)
+			q.white = mover
+
+			// The colour that was not to move cannot have been left in check
+			if !q.inCheck(men, p.white) {
+				prev = append(prev, q)
+			}
+		}
+	}
+
+	return prev
+}
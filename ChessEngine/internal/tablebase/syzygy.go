@@ -0,0 +1,1408 @@
+package tablebase
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/piece"
+	"github.com/tomwatson6/chessbot/internal/variant"
+)
+
+// The extensions of Syzygy files, which hold the win, draw or loss of positions and their distance to zeroing
+const (
+	SyzygyWDL = ".rtbw"
+	SyzygyDTZ = ".rtbz"
+)
+
+// syzygyPieces is the most men, kings included, that Syzygy tables are made for
+const syzygyPieces = 7
+
+// Every Syzygy file starts with a magic number, which differs between WDL and DTZ files
+var (
+	wdlMagic = []byte{0x71, 0xE8, 0x23, 0x5D}
+	dtzMagic = []byte{0xD7, 0x66, 0x0C, 0xA5}
+)
+
+// The flags of a table of a Syzygy file
+const (
+	// dtzSTM is the colour to move the positions of a DTZ table are stored for, as they are only stored for one
+	dtzSTM = 1
+	// dtzMapped is set when the values of a DTZ table are indexes into a map of the distances
+	dtzMapped = 2
+	// dtzWinPlies and dtzLossPlies are set when the distances of wins and losses are in half moves, not moves
+	dtzWinPlies  = 4
+	dtzLossPlies = 8
+	// dtzWide is set when the map of a DTZ table holds 16 bit distances
+	dtzWide = 16
+	// singleValue is set when every position of the table has the same value
+	singleValue = 128
+)
+
+// The results Syzygy tables store, a cursed win is a win the fifty move rule turns into a draw and a blessed loss a
+// loss it saves
+const (
+	syzygyLoss        = -2
+	syzygyBlessedLoss = -1
+	syzygyDraw        = 0
+	syzygyCursedWin   = 1
+	syzygyWin         = 2
+)
+
+// The maps used to number the squares of men, in the order the Syzygy generator numbers them
+var (
+	// mapPawns numbers the squares a2 to h7 from 47 down, the files nearest the edges first, so that the leading
+	// pawn is the one with the highest number
+	mapPawns [64]int
+	// leadPawnIdx and leadPawnsSize number the squares of the leading pawns for each number of them and file
+	leadPawnIdx   [6][64]uint64
+	leadPawnsSize [6][4]uint64
+	// mapB1H1H7 numbers the squares below the a1-h8 diagonal
+	mapB1H1H7 [64]int
+	// mapA1D1D4 numbers the squares of the a1-d1-d4 triangle, the squares of the diagonal last
+	mapA1D1D4 [64]int
+	// mapKK numbers the 462 ways to place two kings with the first in the a1-d1-d4 triangle
+	mapKK [10][64]uint64
+	// binomial holds the number of ways to choose k of n squares
+	binomial [6][64]uint64
+)
+
+func init() {
+	code := 0
+	for sq := 0; sq < 64; sq++ {
+		if offDiagonal(sq) < 0 {
+			mapB1H1H7[sq] = code
+			code++
+		}
+	}
+
+	var diagonal []int
+
+	code = 0
+	for sq := 0; sq <= 27; sq++ {
+		if sq&7 > 3 {
+			continue
+		}
+
+		switch {
+		case offDiagonal(sq) < 0:
+			mapA1D1D4[sq] = code
+			code++
+		case offDiagonal(sq) == 0:
+			diagonal = append(diagonal, sq)
+		}
+	}
+
+	for _, sq := range diagonal {
+		mapA1D1D4[sq] = code
+		code++
+	}
+
+	// Kings both on the diagonal are numbered last
+	var both [][2]int
+
+	code = 0
+	for idx := 0; idx < 10; idx++ {
+		for s1 := 0; s1 <= 27; s1++ {
+			if s1&7 > 3 || mapA1D1D4[s1] != idx || (idx == 0 && s1 != 1) {
+				continue
+			}
+
+			for s2 := 0; s2 < 64; s2++ {
+				switch {
+				case s1 == s2 || kingAttacks[s1]&(1<<s2) != 0:
+				case offDiagonal(s1) == 0 && offDiagonal(s2) > 0:
+				case offDiagonal(s1) == 0 && offDiagonal(s2) == 0:
+					both = append(both, [2]int{idx, s2})
+				default:
+					mapKK[idx][s2] = uint64(code)
+					code++
+				}
+			}
+		}
+	}
+
+	for _, b := range both {
+		mapKK[b[0]][b[1]] = uint64(code)
+		code++
+	}
+
+	binomial[0][0] = 1
+	for n := 1; n < 64; n++ {
+		for k := 0; k < 6 && k <= n; k++ {
+			if k > 0 {
+				binomial[k][n] += binomial[k-1][n-1]
+			}
+
+			if k < n {
+				binomial[k][n] += binomial[k][n-1]
+			}
+		}
+	}
+
+	available := 47
+	for lead := 1; lead <= 5; lead++ {
+		for f := 0; f < 4; f++ {
+			var idx uint64
+
+			for r := 1; r <= 6; r++ {
+				sq := r*8 + f
+
+				if lead == 1 {
+					mapPawns[sq] = available
+					mapPawns[sq^7] = available - 1
+					available -= 2
+				}
+
+				leadPawnIdx[lead][sq] = idx
+				idx += binomial[lead-1][mapPawns[sq]]
+			}
+
+			leadPawnsSize[lead][f] = idx
+		}
+	}
+}
+
+// offDiagonal gets how far the square is above the a1-h8 diagonal, negative below it
+func offDiagonal(sq int) int {
+	return sq>>3 - sq&7
+}
+
+// syzygyMan is a man as Syzygy files hold them, with a code of 1 to 6 for a white pawn, knight, bishop, rook, queen
+// or king and 9 to 14 for black, and a square from 0 for a1 to 63 for h8
+type syzygyMan struct {
+	code, sq int
+}
+
+func syzygyCode(kind int, white bool) int {
+	code := 6 - kind
+	if !white {
+		code |= 8
+	}
+
+	return code
+}
+
+// pairs is a table of a Syzygy file, the values of the positions of one colour to move and, with pawns, one file of
+// the leading pawn. The values are compressed by recursive pairing then Huffman coded in blocks.
+type pairs struct {
+	flags    int
+	pieces   [syzygyPieces]int
+	groupLen [syzygyPieces + 1]int
+	groupIdx [syzygyPieces + 1]uint64
+
+	// single is the value of every position when the singleValue flag is set
+	single    int
+	blockSize int64
+	span      uint64
+	numBlocks int
+	minLen    int
+	// lowest is the lowest symbol of each length of code, base the lowest code of each length padded to 64 bits
+	lowest []int
+	base   []uint64
+	// symlen is one less than the number of values each symbol expands to, the pair of symbols it stands for is in
+	// tree
+	symlen []int
+	tree   []byte
+	// sparse is the block and offset of every span-th value, from which its block is found
+	sparse      []byte
+	blockLength []int
+	data        int64
+	// mapIdx is where the distances of each result start in the map of a DTZ file
+	mapIdx [4]int
+}
+
+// syzygyTable is a Syzygy file, opened the first time a position of its material is probed
+type syzygyTable struct {
+	path string
+	dtz  bool
+
+	once sync.Once
+	err  error
+	f    *os.File
+
+	count     int
+	pawns     bool
+	unique    bool
+	symmetric bool
+	// pawnCount is the number of pawns of the leading colour, then of the other
+	pawnCount [2]int
+	// sides holds the tables of each colour to move, just one for DTZ files and materials the same for both colours
+	sides  [2][4]*pairs
+	nsides int
+	dtzMap []byte
+}
+
+// Syzygy probes the Syzygy tables in a directory, safe for concurrent use. Files are opened the first time a
+// position of their material is probed.
+type Syzygy struct {
+	wdl    map[string]*syzygyTable
+	dtz    map[string]*syzygyTable
+	pieces int
+}
+
+// OpenSyzygy finds the Syzygy files in the directory at the path provided
+func OpenSyzygy(dir string) (*Syzygy, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Syzygy{wdl: make(map[string]*syzygyTable), dtz: make(map[string]*syzygyTable)}
+
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != SyzygyWDL && ext != SyzygyDTZ) {
+			continue
+		}
+
+		name := strings.TrimSuffix(entry.Name(), ext)
+
+		count, err := syzygyCount(name)
+		if err != nil {
+			return nil, err
+		}
+
+		t := &syzygyTable{path: filepath.Join(dir, entry.Name()), dtz: ext == SyzygyDTZ}
+		if t.dtz {
+			s.dtz[name] = t
+		} else {
+			s.wdl[name] = t
+		}
+
+		if count > s.pieces {
+			s.pieces = count
+		}
+	}
+
+	if len(s.wdl) == 0 {
+		return nil, fmt.Errorf("%w: no Syzygy files in %s", ErrorTableNotFound, dir)
+	}
+
+	return s, nil
+}
+
+// syzygyCount checks the name of a Syzygy file e.g. KQvKR, getting the number of men of its material
+func syzygyCount(name string) (int, error) {
+	sides := strings.Split(name, "v")
+	if len(sides) != 2 {
+		return 0, fmt.Errorf("%w: %q", ErrorInvalidMaterial, name)
+	}
+
+	count := 0
+	for _, side := range sides {
+		if !strings.HasPrefix(side, "K") || strings.Count(side, "K") != 1 {
+			return 0, fmt.Errorf("%w: each side needs a king: %q", ErrorInvalidMaterial, name)
+		}
+
+		for _, r := range side {
+			if !strings.ContainsRune(letters, r) {
+				return 0, fmt.Errorf("%w: unknown man %q", ErrorInvalidMaterial, r)
+			}
+		}
+
+		count += len(side)
+	}
+
+	if count > syzygyPieces {
+		return 0, fmt.Errorf("%w: %d men is more than %d", ErrorTooManyPieces, count, syzygyPieces)
+	}
+
+	return count, nil
+}
+
+// Materials gets the names of the materials there are WDL files for
+func (s *Syzygy) Materials() []string {
+	var names []string
+	for name := range s.wdl {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Pieces gets the most men, kings included, of the materials there are files for
+func (s *Syzygy) Pieces() int {
+	return s.pieces
+}
+
+// Close closes the files that have been opened
+func (s *Syzygy) Close() error {
+	var err error
+
+	for _, tables := range []map[string]*syzygyTable{s.wdl, s.dtz} {
+		for _, t := range tables {
+			if t.f != nil {
+				if e := t.f.Close(); e != nil && err == nil {
+					err = e
+				}
+			}
+		}
+	}
+
+	return err
+}
+
+// Probe gets the result of the position for the colour to move, with the number of half moves until a capture or a
+// pawn move that keeps it, which is as far as Syzygy tables go towards the mate. Both the WDL and DTZ files of the
+// material are needed. The fifty move rule is ignored, as it is by the generated tables, so the wins and losses it
+// would make draws are still wins and losses.
+func (s *Syzygy) Probe(c chess.Chess) (Result, error) {
+	b := c.Board
+	if b.Width != 8 || b.Height != 8 || b.Variant != variant.Standard {
+		return Result{}, ErrorNotApplicable
+	}
+
+	if len(b.Pieces) > s.pieces {
+		return Result{}, ErrorTooManyPieces
+	}
+
+	if fields := strings.Fields(c.FEN()); fields[2] != "-" {
+		return Result{}, fmt.Errorf("%w: castling rights", ErrorNotApplicable)
+	}
+
+	wdl, zeroing, err := s.search(c, true)
+	if err != nil {
+		return Result{}, err
+	}
+
+	r := Result{WDL: Draw}
+	switch {
+	case wdl > syzygyDraw:
+		r.WDL = Win
+	case wdl < syzygyDraw:
+		r.WDL = Loss
+	default:
+		return r, nil
+	}
+
+	dtz, err := s.probeDTZ(c, wdl, zeroing)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if dtz < 0 {
+		dtz = -dtz
+	}
+
+	r.DTZ = dtz
+
+	return r, nil
+}
+
+// BestMove gets the move that zeroes quickest while winning, or failing that draws, or failing that zeroes slowest
+func (s *Syzygy) BestMove(c chess.Chess) (move.Move, piece.PieceType, Result, error) {
+	return BestMove(s, c)
+}
+
+// legalMove is a move of a position, with the piece a pawn promotes to and whether it is a capture or pawn move,
+// which zeroes the count of the fifty move rule
+type legalMove struct {
+	move      move.Move
+	promotion piece.PieceType
+	zeroing   bool
+	capture   bool
+}
+
+func legalMoves(c chess.Chess) []legalMove {
+	var moves []legalMove
+
+	for _, m := range c.LegalMoves() {
+		promotions := []piece.PieceType{piece.PieceTypePawn}
+		if c.IsPromotion(m) {
+			promotions = []piece.PieceType{piece.PieceTypeQueen, piece.PieceTypeRook, piece.PieceTypeBishop, piece.PieceTypeKnight}
+		}
+
+		for _, promotion := range promotions {
+			moves = append(moves, newLegalMove(c, m, promotion))
+		}
+	}
+
+	return moves
+}
+
+func newLegalMove(c chess.Chess, m move.Move, promotion piece.PieceType) legalMove {
+	pawn := c.Board.Pieces[m.From].GetPieceType() == piece.PieceTypePawn
+
+	// A pawn moving to another file without a man on the square it moves to is taking en passant
+	_, taken := c.Board.Pieces[m.To]
+	capture := taken || (pawn && m.From.File != m.To.File)
+
+	return legalMove{move: m, promotion: promotion, zeroing: capture || pawn, capture: capture}
+}
+
+func play(c chess.Chess, m legalMove) (chess.Chess, error) {
+	next := c.Copy()
+	if _, err := next.MakeMoveWithPromotion(m.move, m.promotion); err != nil {
+		return chess.Chess{}, err
+	}
+
+	return next, nil
+}
+
+// search gets the result of the position, searching the captures, and the pawn moves too when checkZeroing is set,
+// as the tables do not hold the right value when one of them is the best move. zeroing is set when the best move is
+// one of them, or every move was searched.
+func (s *Syzygy) search(c chess.Chess, checkZeroing bool) (int, bool, error) {
+	best := syzygyLoss
+
+	moves := legalMoves(c)
+	searched := 0
+
+	for _, m := range moves {
+		if !m.capture && (!checkZeroing || !m.zeroing) {
+			continue
+		}
+
+		searched++
+
+		next, err := play(c, m)
+		if err != nil {
+			return 0, false, err
+		}
+
+		v, _, err := s.search(next, false)
+		if err != nil {
+			return 0, false, err
+		}
+
+		if v = -v; v > best {
+			best = v
+
+			if v >= syzygyWin {
+				return v, true, nil
+			}
+		}
+	}
+
+	// Once every move has been searched the table is not needed, and may be wrong as it ignores en passant
+	all := searched > 0 && searched == len(moves)
+
+	v := best
+	if !all {
+		value, _, err := s.probeTable(c, false, 0)
+		if err != nil {
+			return 0, false, err
+		}
+
+		v = value
+	}
+
+	if best >= v {
+		return best, best > syzygyDraw || all, nil
+	}
+
+	return v, false, nil
+}
+
+// beforeZeroing gets the distance to zeroing of a position whose best move is a capture or pawn move
+func beforeZeroing(wdl int) int {
+	switch wdl {
+	case syzygyWin:
+		return 1
+	case syzygyCursedWin:
+		return 101
+	case syzygyBlessedLoss:
+		return -101
+	case syzygyLoss:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// probeDTZ gets the distance to zeroing of the position with the result provided, negative when it is lost
+func (s *Syzygy) probeDTZ(c chess.Chess, wdl int, zeroing bool) (int, error) {
+	if wdl == syzygyDraw {
+		return 0, nil
+	}
+
+	if zeroing {
+		return beforeZeroing(wdl), nil
+	}
+
+	dtz, changeSTM, err := s.probeTable(c, true, wdl)
+	if err != nil {
+		return 0, err
+	}
+
+	if !changeSTM {
+		if wdl == syzygyCursedWin || wdl == syzygyBlessedLoss {
+			dtz += 100
+		}
+
+		if wdl < 0 {
+			dtz = -dtz
+		}
+
+		return dtz, nil
+	}
+
+	// The table holds the other colour to move, so the distance is found from the moves of the position
+	best := 0xFFFF
+	for _, m := range legalMoves(c) {
+		next, err := play(c, m)
+		if err != nil {
+			return 0, err
+		}
+
+		v, z, err := s.search(next, !m.zeroing)
+		if err != nil {
+			return 0, err
+		}
+
+		if m.zeroing {
+			dtz = -beforeZeroing(v)
+		} else {
+			d, err := s.probeDTZ(next, v, z)
+			if err != nil {
+				return 0, err
+			}
+
+			dtz = -d
+		}
+
+		// A mate is always the quickest way to zero
+		if dtz == 1 && next.Board.InCheck(next.Turn) && len(next.LegalMoves()) == 0 {
+			best = 1
+		}
+
+		if !m.zeroing {
+			dtz += sign(dtz)
+		}
+
+		if dtz < best && sign(dtz) == sign(wdl) {
+			best = dtz
+		}
+	}
+
+	// Without moves the colour to move is mated
+	if best == 0xFFFF {
+		return -1, nil
+	}
+
+	return best, nil
+}
+
+func sign(v int) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// probeTable gets the value of the position in its WDL or DTZ file, changeSTM is set when the DTZ file holds the
+// other colour to move
+func (s *Syzygy) probeTable(c chess.Chess, dtz bool, wdl int) (int, bool, error) {
+	var counts [2][6]int
+	var men []syzygyMan
+
+	for pos, p := range c.Board.Pieces {
+		k, white := kindOf(p.GetPieceType()), p.Colour == colour.White
+		men = append(men, syzygyMan{code: syzygyCode(k, white), sq: pos.Rank*8 + pos.File})
+
+		if white {
+			counts[0][k]++
+		} else {
+			counts[1][k]++
+		}
+	}
+
+	// Only the bare kings have no file
+	if len(men) == 2 {
+		return syzygyDraw, false, nil
+	}
+
+	tables := s.wdl
+	if dtz {
+		tables = s.dtz
+	}
+
+	white, black := syzygyName(counts[0]), syzygyName(counts[1])
+
+	t, ok := tables[white+"v"+black]
+	blackStronger := false
+	if !ok {
+		t, ok = tables[black+"v"+white]
+		blackStronger = true
+	}
+
+	if !ok {
+		return 0, false, fmt.Errorf("%w: %sv%s", ErrorTableNotFound, white, black)
+	}
+
+	if err := t.open(); err != nil {
+		return 0, false, err
+	}
+
+	d, file, idx, changeSTM := t.locate(men, c.Turn == colour.Black, blackStronger)
+	if changeSTM {
+		return 0, true, nil
+	}
+
+	v, err := d.value(t.f, idx)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if !dtz {
+		return v - 2, false, nil
+	}
+
+	v, err = t.mapScore(file, v, wdl)
+
+	return v, false, err
+}
+
+// syzygyName gets one side of the name of a material from the number of men of each kind
+func syzygyName(counts [6]int) string {
+	var sb strings.Builder
+
+	for k, n := range counts {
+		sb.WriteString(strings.Repeat(string(letters[k]), n))
+	}
+
+	return sb.String()
+}
+
+// dtzMapIdx is which of the maps of a DTZ file holds the distances of each result, from a loss up
+var dtzMapIdx = [5]int{1, 3, 0, 2, 0}
+
+// mapScore gets the distance to zeroing from the value of a DTZ file
+func (t *syzygyTable) mapScore(file, v, wdl int) (int, error) {
+	d := t.sides[0][file]
+
+	if d.flags&dtzMapped != 0 {
+		i := d.mapIdx[dtzMapIdx[wdl+2]] + v
+
+		if d.flags&dtzWide != 0 {
+			if 2*i+2 > len(t.dtzMap) {
+				return 0, ErrorInvalidFile
+			}
+
+			v = int(binary.LittleEndian.Uint16(t.dtzMap[2*i:]))
+		} else {
+			if i >= len(t.dtzMap) {
+				return 0, ErrorInvalidFile
+			}
+
+			v = int(t.dtzMap[i])
+		}
+	}
+
+	if (wdl == syzygyWin && d.flags&dtzWinPlies == 0) || (wdl == syzygyLoss && d.flags&dtzLossPlies == 0) ||
+		wdl == syzygyCursedWin || wdl == syzygyBlessedLoss {
+		v *= 2
+	}
+
+	return v + 1, nil
+}
+
+// locate gets the table of the men, colours and squares swapped when black has the stronger men or the material is
+// the same for both and black is to move, and the index of them in it. changeSTM is set instead when the position
+// is of a DTZ file that holds the other colour to move.
+func (t *syzygyTable) locate(men []syzygyMan, blackToMove, blackStronger bool) (*pairs, int, uint64, bool) {
+	flip := blackStronger || (t.symmetric && blackToMove)
+
+	stm := 0
+	if flip != blackToMove {
+		stm = 1
+	}
+
+	var codes, squares [syzygyPieces]int
+
+	size, lead, file := 0, 0, 0
+
+	if t.pawns {
+		pawn := t.sides[0][0].pieces[0]
+
+		for _, m := range men {
+			if code, sq := flipMan(m, flip); code == pawn {
+				codes[size], squares[size] = code, sq
+				size++
+			}
+		}
+
+		lead = size
+
+		// The leading pawn is the one nearest the edge, and the lowest of those
+		top := 0
+		for i := 1; i < lead; i++ {
+			if mapPawns[squares[i]] > mapPawns[squares[top]] {
+				top = i
+			}
+		}
+
+		squares[0], squares[top] = squares[top], squares[0]
+
+		file = squares[0] & 7
+		if file > 3 {
+			file = 7 - file
+		}
+	}
+
+	if t.dtz && t.sides[0][file].flags&dtzSTM != stm && !(t.symmetric && !t.pawns) {
+		return nil, 0, 0, true
+	}
+
+	for _, m := range men {
+		if code, sq := flipMan(m, flip); !t.pawns || code != t.sides[0][0].pieces[0] {
+			codes[size], squares[size] = code, sq
+			size++
+		}
+	}
+
+	d := t.sides[stm%t.nsides][file]
+
+	// The men are put in the order of the table, which is the one it compresses best in
+	for i := lead; i < size-1; i++ {
+		for j := i + 1; j < size; j++ {
+			if d.pieces[i] == codes[j] {
+				codes[i], codes[j] = codes[j], codes[i]
+				squares[i], squares[j] = squares[j], squares[i]
+				break
+			}
+		}
+	}
+
+	sq := squares[:size]
+
+	if sq[0]&7 > 3 {
+		for i := range sq {
+			sq[i] ^= 7
+		}
+	}
+
+	var idx uint64
+
+	if t.pawns {
+		idx = leadPawnIdx[lead][sq[0]]
+
+		rest := sq[1:lead]
+		sort.SliceStable(rest, func(i, j int) bool { return mapPawns[rest[i]] < mapPawns[rest[j]] })
+
+		for i := 1; i < lead; i++ {
+			idx += binomial[i][mapPawns[sq[i]]]
+		}
+	} else {
+		idx = t.leadingPieces(d, sq)
+	}
+
+	idx *= d.groupIdx[0]
+
+	remainingPawns := t.pawns && t.pawnCount[1] > 0
+	start := d.groupLen[0]
+
+	for next := 1; d.groupLen[next] != 0; next++ {
+		group := sq[start : start+d.groupLen[next]]
+		sort.Ints(group)
+
+		var n uint64
+		for i, s := range group {
+			// Squares are numbered without those taken by the men of the groups before
+			adjust := 0
+			for _, before := range sq[:start] {
+				if s > before {
+					adjust++
+				}
+			}
+
+			if remainingPawns {
+				adjust += 8
+			}
+
+			n += binomial[i+1][s-adjust]
+		}
+
+		remainingPawns = false
+		idx += n * d.groupIdx[next]
+		start += d.groupLen[next]
+	}
+
+	return d, file, idx, false
+}
+
+func flipMan(m syzygyMan, flip bool) (int, int) {
+	if flip {
+		return m.code ^ 8, m.sq ^ 56
+	}
+
+	return m.code, m.sq
+}
+
+// leadingPieces gets the index of the leading men of a table without pawns, mirroring the board so that the first is
+// in the a1-d1-d4 triangle and the first off the diagonal is below it
+func (t *syzygyTable) leadingPieces(d *pairs, sq []int) uint64 {
+	if sq[0]>>3 > 3 {
+		for i := range sq {
+			sq[i] ^= 56
+		}
+	}
+
+	for i := 0; i < d.groupLen[0]; i++ {
+		off := offDiagonal(sq[i])
+		if off == 0 {
+			continue
+		}
+
+		if off > 0 {
+			for j := i; j < len(sq); j++ {
+				sq[j] = (sq[j]>>3 | sq[j]<<3) & 63
+			}
+		}
+
+		break
+	}
+
+	if !t.unique {
+		return mapKK[mapA1D1D4[sq[0]]][sq[1]]
+	}
+
+	adjust1 := 0
+	if sq[1] > sq[0] {
+		adjust1 = 1
+	}
+
+	adjust2 := 0
+	if sq[2] > sq[0] {
+		adjust2++
+	}
+
+	if sq[2] > sq[1] {
+		adjust2++
+	}
+
+	var idx int
+	switch {
+	case offDiagonal(sq[0]) != 0:
+		idx = (mapA1D1D4[sq[0]]*63+sq[1]-adjust1)*62 + sq[2] - adjust2
+	case offDiagonal(sq[1]) != 0:
+		idx = (6*63+(sq[0]>>3)*28+mapB1H1H7[sq[1]])*62 + sq[2] - adjust2
+	case offDiagonal(sq[2]) != 0:
+		idx = 6*63*62 + 4*28*62 + (sq[0]>>3)*7*28 + (sq[1]>>3-adjust1)*28 + mapB1H1H7[sq[2]]
+	default:
+		idx = 6*63*62 + 4*28*62 + 4*7*28 + (sq[0]>>3)*7*6 + (sq[1]>>3-adjust1)*6 + sq[2]>>3 - adjust2
+	}
+
+	return uint64(idx)
+}
+
+// value gets the value at the index of the table, reading the block it is in from the file
+func (d *pairs) value(r io.ReaderAt, idx uint64) (int, error) {
+	if d.flags&singleValue != 0 {
+		return d.single, nil
+	}
+
+	k := idx / d.span
+	if 6*k+6 > uint64(len(d.sparse)) {
+		return 0, ErrorInvalidFile
+	}
+
+	block := int(binary.LittleEndian.Uint32(d.sparse[6*k:]))
+	offset := int(binary.LittleEndian.Uint16(d.sparse[6*k+4:]))
+	offset += int(idx%d.span) - int(d.span/2)
+
+	for offset < 0 {
+		if block--; block < 0 {
+			return 0, ErrorInvalidFile
+		}
+
+		offset += d.blockLength[block] + 1
+	}
+
+	for block < len(d.blockLength) && offset > d.blockLength[block] {
+		offset -= d.blockLength[block] + 1
+		block++
+	}
+
+	if block >= d.numBlocks {
+		return 0, ErrorInvalidFile
+	}
+
+	// The symbols are read 32 bits at a time, a block is read with a little more as the last may be read past
+	buf := make([]byte, d.blockSize+8)
+	if _, err := r.ReadAt(buf, d.data+int64(block)*d.blockSize); err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+
+	bits := binary.BigEndian.Uint64(buf)
+	size, next := 64, 8
+
+	var sym int
+	for {
+		// Longer codes have lower values, so the length of the code is the first whose lowest code it is not below
+		l := 0
+		for bits < d.base[l] {
+			l++
+		}
+
+		sym = int((bits-d.base[l])>>(64-l-d.minLen)) + d.lowest[l]
+		if sym >= len(d.symlen) {
+			return 0, ErrorInvalidFile
+		}
+
+		if offset < d.symlen[sym]+1 {
+			break
+		}
+
+		offset -= d.symlen[sym] + 1
+
+		l += d.minLen
+		bits <<= l
+		size -= l
+
+		if size <= 32 {
+			if next+4 > len(buf) {
+				return 0, ErrorInvalidFile
+			}
+
+			size += 32
+			bits |= uint64(binary.BigEndian.Uint32(buf[next:])) << (64 - size)
+			next += 4
+		}
+	}
+
+	// The symbol stands for a pair of symbols, which are expanded until the one of the value is reached
+	for d.symlen[sym] != 0 {
+		left := d.left(sym)
+
+		if offset < d.symlen[left]+1 {
+			sym = left
+		} else {
+			offset -= d.symlen[left] + 1
+			sym = d.right(sym)
+		}
+	}
+
+	return d.left(sym), nil
+}
+
+func (d *pairs) left(sym int) int {
+	return int(d.tree[3*sym+1]&0xF)<<8 | int(d.tree[3*sym])
+}
+
+func (d *pairs) right(sym int) int {
+	return int(d.tree[3*sym+2])<<4 | int(d.tree[3*sym+1])>>4
+}
+
+// open reads the layout of the file the first time it is probed
+func (t *syzygyTable) open() error {
+	t.once.Do(func() {
+		f, err := os.Open(t.path)
+		if err != nil {
+			t.err = err
+			return
+		}
+
+		if err := t.read(f); err != nil {
+			f.Close()
+			t.err = fmt.Errorf("%w: %s: %s", ErrorInvalidFile, filepath.Base(t.path), err)
+
+			return
+		}
+
+		t.f = f
+	})
+
+	return t.err
+}
+
+// fileReader reads the layout of a Syzygy file from its start
+type fileReader struct {
+	r   io.ReaderAt
+	off int64
+	err error
+}
+
+func (r *fileReader) bytes(n int) []byte {
+	b := make([]byte, n)
+	if r.err != nil {
+		return b
+	}
+
+	if _, err := r.r.ReadAt(b, r.off); err != nil {
+		r.err = err
+	}
+
+	r.off += int64(n)
+
+	return b
+}
+
+func (r *fileReader) byte() int {
+	return int(r.bytes(1)[0])
+}
+
+func (r *fileReader) uint16() int {
+	return int(binary.LittleEndian.Uint16(r.bytes(2)))
+}
+
+func (r *fileReader) uint32() int {
+	return int(binary.LittleEndian.Uint32(r.bytes(4)))
+}
+
+// align skips to the next multiple of n bytes from the start of the file
+func (r *fileReader) align(n int64) {
+	r.off = (r.off + n - 1) / n * n
+}
+
+// setMaterial sets what the name of the material of the file says about its tables
+func (t *syzygyTable) setMaterial(name string) {
+	sides := strings.Split(name, "v")
+
+	var counts [2][6]int
+	for i, side := range sides {
+		for _, r := range side {
+			counts[i][strings.IndexRune(letters, r)]++
+		}
+	}
+
+	t.count = len(sides[0]) + len(sides[1])
+	t.pawns = counts[0][pawn]+counts[1][pawn] > 0
+	t.symmetric = sides[0] == sides[1]
+
+	for _, side := range counts {
+		for k := queen; k <= pawn; k++ {
+			t.unique = t.unique || side[k] == 1
+		}
+	}
+
+	// The colour with fewer pawns leads, or white when they have as many
+	lead := counts[1][pawn] == 0 || (counts[0][pawn] > 0 && counts[1][pawn] >= counts[0][pawn])
+	if lead {
+		t.pawnCount = [2]int{counts[0][pawn], counts[1][pawn]}
+	} else {
+		t.pawnCount = [2]int{counts[1][pawn], counts[0][pawn]}
+	}
+
+	t.nsides = 1
+	if !t.dtz && !t.symmetric {
+		t.nsides = 2
+	}
+}
+
+// read reads the layout of the file, which is in the order the tables of every file of the leading pawn and colour
+// to move are given: the men, then the sizes, the DTZ maps, the sparse indexes, the block lengths and the data
+func (t *syzygyTable) read(f io.ReaderAt) error {
+	t.setMaterial(strings.TrimSuffix(filepath.Base(t.path), filepath.Ext(t.path)))
+
+	r := &fileReader{r: f}
+
+	magic := wdlMagic
+	if t.dtz {
+		magic = dtzMagic
+	}
+
+	if got := r.bytes(4); r.err != nil || string(got) != string(magic) {
+		return errors.New("bad magic number")
+	}
+
+	flags := r.byte()
+	if (flags&2 != 0) != t.pawns || (flags&1 != 0) == t.symmetric {
+		return errors.New("the file does not match its material")
+	}
+
+	files := 1
+	if t.pawns {
+		files = 4
+	}
+
+	pp := t.pawns && t.pawnCount[1] > 0
+
+	for file := 0; file < files; file++ {
+		for i := 0; i < t.nsides; i++ {
+			t.sides[i][file] = &pairs{}
+		}
+
+		b := r.byte()
+		order := [2][2]int{{b & 0xF, 0xF}, {b >> 4, 0xF}}
+
+		if pp {
+			b := r.byte()
+			order[0][1], order[1][1] = b&0xF, b>>4
+		}
+
+		for k := 0; k < t.count; k++ {
+			b := r.byte()
+			for i := 0; i < t.nsides; i++ {
+				if i == 0 {
+					t.sides[i][file].pieces[k] = b & 0xF
+				} else {
+					t.sides[i][file].pieces[k] = b >> 4
+				}
+			}
+		}
+
+		for i := 0; i < t.nsides; i++ {
+			if err := t.groups(t.sides[i][file], order[i], file); err != nil {
+				return err
+			}
+		}
+	}
+
+	r.align(2)
+
+	for file := 0; file < files; file++ {
+		for i := 0; i < t.nsides; i++ {
+			if err := r.sizes(t.sides[i][file]); err != nil {
+				return err
+			}
+		}
+	}
+
+	if t.dtz {
+		start := r.off
+
+		for file := 0; file < files; file++ {
+			d := t.sides[0][file]
+			if d.flags&dtzMapped == 0 {
+				continue
+			}
+
+			if d.flags&dtzWide != 0 {
+				r.align(2)
+
+				for i := range d.mapIdx {
+					d.mapIdx[i] = int(r.off-start)/2 + 1
+					r.off += 2 * int64(r.uint16())
+				}
+
+				continue
+			}
+
+			for i := range d.mapIdx {
+				d.mapIdx[i] = int(r.off-start) + 1
+				r.off += int64(r.byte())
+			}
+		}
+
+		end := r.off
+		r.off = start
+		t.dtzMap = r.bytes(int(end - start))
+		r.align(2)
+	}
+
+	for file := 0; file < files; file++ {
+		for i := 0; i < t.nsides; i++ {
+			d := t.sides[i][file]
+			if d.flags&singleValue == 0 {
+				d.sparse = r.bytes(6 * int((d.groupIdx[d.groups()]+d.span-1)/d.span))
+			}
+		}
+	}
+
+	for file := 0; file < files; file++ {
+		for i := 0; i < t.nsides; i++ {
+			d := t.sides[i][file]
+			if d.flags&singleValue != 0 {
+				continue
+			}
+
+			for j := range d.blockLength {
+				d.blockLength[j] = r.uint16()
+			}
+		}
+	}
+
+	for file := 0; file < files; file++ {
+		for i := 0; i < t.nsides; i++ {
+			r.align(64)
+
+			d := t.sides[i][file]
+			d.data = r.off
+			r.off += int64(d.numBlocks) * d.blockSize
+		}
+	}
+
+	if r.err != nil && !errors.Is(r.err, io.EOF) {
+		return r.err
+	}
+
+	return nil
+}
+
+// groups gets the number of groups of the table
+func (d *pairs) groups() int {
+	n := 0
+	for d.groupLen[n] != 0 {
+		n++
+	}
+
+	return n
+}
+
+// groups splits the men of the table into groups, men of the same kind and colour being placed together, and sets
+// the size of each group in the order they are encoded in, which is given by the file
+func (t *syzygyTable) groups(d *pairs, order [2]int, file int) error {
+	first := 2
+	switch {
+	case t.pawns:
+		first = 0
+	case t.unique:
+		first = 3
+	}
+
+	n := 0
+	d.groupLen[0] = 1
+
+	for i := 1; i < t.count; i++ {
+		if first--; first > 0 || d.pieces[i] == d.pieces[i-1] {
+			d.groupLen[n]++
+		} else {
+			n++
+			d.groupLen[n] = 1
+		}
+	}
+
+	n++
+	d.groupLen[n] = 0
+
+	pp := t.pawns && t.pawnCount[1] > 0
+
+	next := 1
+	free := 64 - d.groupLen[0]
+	if pp {
+		next = 2
+		free -= d.groupLen[1]
+	}
+
+	idx := uint64(1)
+
+	for k := 0; next < n || k == order[0] || k == order[1]; k++ {
+		if k > syzygyPieces {
+			return errors.New("bad order of groups")
+		}
+
+		switch k {
+		case order[0]:
+			d.groupIdx[0] = idx
+
+			switch {
+			case t.pawns:
+				idx *= leadPawnsSize[d.groupLen[0]][file]
+			case t.unique:
+				idx *= 31332
+			default:
+				idx *= 462
+			}
+		case order[1]:
+			d.groupIdx[1] = idx
+			idx *= binomial[d.groupLen[1]][48-d.groupLen[0]]
+		default:
+			d.groupIdx[next] = idx
+			idx *= binomial[d.groupLen[next]][free]
+			free -= d.groupLen[next]
+			next++
+		}
+	}
+
+	d.groupIdx[n] = idx
+
+	return nil
+}
+
+// sizes reads how the values of the table are compressed
+func (r *fileReader) sizes(d *pairs) error {
+	d.flags = r.byte()
+	if d.flags&singleValue != 0 {
+		d.single = r.byte()
+		return r.err
+	}
+
+	d.blockSize = 1 << r.byte()
+	d.span = 1 << r.byte()
+	padding := r.byte()
+	d.numBlocks = r.uint32()
+	d.blockLength = make([]int, d.numBlocks+padding)
+
+	maxLen, minLen := r.byte(), r.byte()
+	if r.err != nil || minLen < 1 || maxLen < minLen || maxLen > 32 {
+		return errors.New("bad symbol lengths")
+	}
+
+	d.minLen = minLen
+	d.lowest = make([]int, maxLen-minLen+1)
+	for i := range d.lowest {
+		d.lowest[i] = r.uint16()
+	}
+
+	// The codes are canonical, with longer codes having lower values, so the lowest code of each length is found
+	// from the number of symbols of the lengths above it
+	d.base = make([]uint64, len(d.lowest))
+	for i := len(d.base) - 2; i >= 0; i-- {
+		d.base[i] = (d.base[i+1] + uint64(d.lowest[i]) - uint64(d.lowest[i+1])) / 2
+	}
+
+	for i := range d.base {
+		d.base[i] <<= 64 - i - minLen
+	}
+
+	n := r.uint16()
+	d.tree = r.bytes(3 * n)
+	if n&1 != 0 {
+		r.byte()
+	}
+
+	if r.err != nil {
+		return r.err
+	}
+
+	d.symlen = make([]int, n)
+	visited := make([]bool, n)
+
+	for sym := 0; sym < n; sym++ {
+		if !visited[sym] {
+			length, err := d.setSymlen(sym, visited)
+			if err != nil {
+				return err
+			}
+
+			d.symlen[sym] = length
+		}
+	}
+
+	return nil
+}
+
+// setSymlen gets one less than the number of values the symbol expands to
+func (d *pairs) setSymlen(sym int, visited []bool) (int, error) {
+	visited[sym] = true
+
+	right := d.right(sym)
+	if right == 0xFFF {
+		return 0, nil
+	}
+
+	left := d.left(sym)
+	if left >= len(d.symlen) || right >= len(d.symlen) {
+		return 0, errors.New("bad symbol")
+	}
+
+	for _, child := range []int{left, right} {
+		if !visited[child] {
+			length, err := d.setSymlen(child, visited)
+			if err != nil {
+				return 0, err
+			}
+
+			d.symlen[child] = length
+		}
+	}
+
+	return d.symlen[left] + d.symlen[right] + 1, nil
+}
@@ -0,0 +1,256 @@
+package tablebase_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/tablebase"
+)
+
+// openSyzygy opens the Syzygy files of testdata, which are the files published by the Syzygy generator, see
+// testdata/syzygy/README.md
+func openSyzygy(t *testing.T) *tablebase.Syzygy {
+	t.Helper()
+
+	s, err := tablebase.OpenSyzygy(filepath.Join("testdata", "syzygy"))
+	if errors.Is(err, tablebase.ErrorTableNotFound) {
+		t.Skip("the Syzygy files of testdata/syzygy are missing")
+	} else if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { s.Close() })
+
+	return s
+}
+
+func TestSyzygyProbe(t *testing.T) {
+	s := openSyzygy(t)
+
+	tests := []struct {
+		name string
+		fen  string
+		want tablebase.Result
+	}{
+		{
+			name: "mate in one",
+			fen:  "7k/8/6K1/8/8/8/8/1Q6 w - - 0 1",
+			want: tablebase.Result{WDL: tablebase.Win, DTZ: 1},
+		},
+		{
+			name: "mate in one with the colours swapped",
+			fen:  "1q6/8/8/8/8/6k1/8/7K b - - 0 1",
+			want: tablebase.Result{WDL: tablebase.Win, DTZ: 1},
+		},
+		{
+			name: "mated",
+			fen:  "Q6k/8/6K1/8/8/8/8/8 b - - 0 1",
+			want: tablebase.Result{WDL: tablebase.Loss, DTZ: 1},
+		},
+		{
+			name: "stalemate",
+			fen:  "7k/5Q2/6K1/8/8/8/8/8 b - - 0 1",
+			want: tablebase.Result{WDL: tablebase.Draw},
+		},
+		{
+			name: "the queen is taken",
+			fen:  "8/8/8/8/8/2k5/8/Kq6 w - - 0 1",
+			want: tablebase.Result{WDL: tablebase.Draw},
+		},
+		{
+			name: "the pawn queens",
+			fen:  "8/4P1k1/8/8/8/8/8/1K6 w - - 0 1",
+			want: tablebase.Result{WDL: tablebase.Win, DTZ: 1},
+		},
+		{
+			name: "the king takes the pawn",
+			fen:  "8/8/8/8/8/8/3kP3/7K b - - 0 1",
+			want: tablebase.Result{WDL: tablebase.Draw},
+		},
+		{
+			name: "the king takes the knight",
+			fen:  "8/8/8/8/8/3k4/3N4/K6B b - - 0 1",
+			want: tablebase.Result{WDL: tablebase.Draw},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := chess.FromFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := s.Probe(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSyzygyMatchesGenerated checks the Syzygy files agree with the tables generated here. Without pawns neither
+// side can zero while keeping the result, so the distance to zeroing is the distance to mate.
+func TestSyzygyMatchesGenerated(t *testing.T) {
+	s := openSyzygy(t)
+	set := generate(t, "KQvK", "KRvK", "KPvK", "KBNvK")
+
+	tests := []struct {
+		men  []string
+		step int
+		dtm  bool
+	}{
+		{men: []string{"K", "k", "Q"}, step: 97, dtm: true},
+		{men: []string{"K", "k", "R"}, step: 97, dtm: true},
+		{men: []string{"K", "k", "P"}, step: 31},
+		{men: []string{"K", "k", "B", "N"}, step: 4099, dtm: true},
+	}
+
+	for _, tt := range tests {
+		size := 1
+		for range tt.men {
+			size *= 64
+		}
+
+		// Every few positions are checked to keep the test quick
+		for i := 0; i < size; i += tt.step {
+			men := make(map[int]string)
+			for j, m := range tt.men {
+				men[i>>(6*j)%64] = m
+			}
+
+			if len(men) < len(tt.men) {
+				continue
+			}
+
+			for _, turn := range []string{"w", "b"} {
+				fen := placement(men) + " " + turn + " - - 0 1"
+
+				c, err := chess.FromFEN(fen)
+				if err != nil {
+					continue
+				}
+
+				want, err := set.Probe(c)
+				if errors.Is(err, tablebase.ErrorNotApplicable) {
+					continue
+				} else if err != nil {
+					t.Fatalf("%s: %v", fen, err)
+				}
+
+				got, err := s.Probe(c)
+				if err != nil {
+					t.Fatalf("%s: %v", fen, err)
+				}
+
+				dtz := want.DTM
+				if want.WDL != tablebase.Draw && dtz == 0 {
+					dtz = 1
+				}
+
+				if got.WDL != want.WDL || (tt.dtm && want.WDL != tablebase.Draw && got.DTZ != dtz) {
+					t.Errorf("%s: got %+v, want %+v", fen, got, want)
+				}
+			}
+		}
+	}
+}
+
+// placement gets the placement of a FEN of the men on the squares provided, a1 being 0
+func placement(men map[int]string) string {
+	var ranks []string
+
+	for rank := 7; rank >= 0; rank-- {
+		var b strings.Builder
+
+		empty := 0
+		for file := 0; file < 8; file++ {
+			m, ok := men[rank*8+file]
+			if !ok {
+				empty++
+				continue
+			}
+
+			if empty > 0 {
+				fmt.Fprint(&b, empty)
+				empty = 0
+			}
+
+			b.WriteString(m)
+		}
+
+		if empty > 0 {
+			fmt.Fprint(&b, empty)
+		}
+
+		ranks = append(ranks, b.String())
+	}
+
+	return strings.Join(ranks, "/")
+}
+
+func TestSyzygyBestMove(t *testing.T) {
+	s := openSyzygy(t)
+
+	c, err := chess.FromFEN("8/8/8/8/8/4P1k1/8/1K6 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, _, res, err := s.BestMove(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.UCI() != "b1c2" || res.WDL != tablebase.Win {
+		t.Errorf("got %s with %+v, want b1c2 winning", m.UCI(), res)
+	}
+}
+
+func TestSyzygyProbeErrors(t *testing.T) {
+	s := openSyzygy(t)
+
+	tests := []struct {
+		name string
+		fen  string
+		want error
+	}{
+		{
+			name: "no table",
+			fen:  "8/8/8/3k4/8/8/8/1Q1QK3 w - - 0 1",
+			want: tablebase.ErrorTableNotFound,
+		},
+		{
+			name: "too many pieces",
+			fen:  "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			want: tablebase.ErrorTooManyPieces,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := chess.FromFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := s.Probe(c); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenSyzygyErrors(t *testing.T) {
+	if _, err := tablebase.OpenSyzygy(t.TempDir()); !errors.Is(err, tablebase.ErrorTableNotFound) {
+		t.Errorf("got %v, want %v", err, tablebase.ErrorTableNotFound)
+	}
+}
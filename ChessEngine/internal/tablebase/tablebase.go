@@ -0,0 +1,574 @@
+// Package tablebase generates and probes distance-to-mate endgame tables for positions with few men, built by
+// retrograde analysis so that no tables have to be downloaded
+package tablebase
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/piece"
+	"github.com/tomwatson6/chessbot/internal/storage"
+	"github.com/tomwatson6/chessbot/internal/variant"
+)
+
+var (
+	// ErrorInvalidMaterial is thrown when the name of a material cannot be read e.g. KQvKR
+	ErrorInvalidMaterial = errors.New("invalid material")
+	// ErrorTooManyPieces is thrown when a position or material has more men than tables are generated for
+	ErrorTooManyPieces = errors.New("too many pieces for the tablebase")
+	// ErrorTableNotFound is thrown when the table of the material of a position has not been generated
+	ErrorTableNotFound = errors.New("the table for the material has not been generated")
+	// ErrorNotApplicable is thrown when probing a position the tables do not cover, such as one with castling rights
+	ErrorNotApplicable = errors.New("the tablebase does not cover the position")
+	// ErrorInvalidFile is thrown when a tablebase file cannot be read
+	ErrorInvalidFile = errors.New("invalid tablebase file")
+)
+
+// magic starts every tablebase file
+const magic = "CBTB1"
+
+// WDL is whether the colour to move wins, draws or loses with perfect play
+type WDL int
+
+const (
+	Loss WDL = -1
+	Draw WDL = 0
+	Win  WDL = 1
+)
+
+func (w WDL) String() string {
+	switch w {
+	case Win:
+		return "win"
+	case Loss:
+		return "loss"
+	default:
+		return "draw"
+	}
+}
+
+func (w WDL) MarshalText() ([]byte, error) {
+	return []byte(w.String()), nil
+}
+
+func (w *WDL) UnmarshalText(text []byte) error {
+	for _, v := range []WDL{Loss, Draw, Win} {
+		if v.String() == string(text) {
+			*w = v
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown result %q", text)
+}
+
+// Result is the result of a position for the colour to move, with the number of half moves until mate when it is
+// not a draw. Syzygy tables do not know the distance to mate, they give the number of half moves until a capture or
+// pawn move that keeps the result as DTZ instead, leaving DTM 0. Tables ignore the fifty move rule.
+type Result struct {
+	WDL WDL `json:"wdl"`
+	DTM int `json:"dtm"`
+	DTZ int `json:"dtz,omitempty"`
+}
+
+// MateIn gets the number of moves of the winning side until mate, negative when the colour to move is mated and 0
+// when the distance to mate is not known
+func (r Result) MateIn() int {
+	if r.DTZ > 0 {
+		return 0
+	}
+
+	switch r.WDL {
+	case Win:
+		return (r.DTM + 1) / 2
+	case Loss:
+		return -r.DTM / 2
+	default:
+		return 0
+	}
+}
+
+// Better reports whether the result is better than the other for the colour it is for: a win over a draw over a
+// loss, then the quickest win and the slowest loss. A known distance to mate is quicker than a distance to zeroing.
+func (r Result) Better(other Result) bool {
+	if r.WDL != other.WDL {
+		return r.WDL > other.WDL
+	}
+
+	switch r.WDL {
+	case Win:
+		if r.DTZ != other.DTZ {
+			return other.DTZ != 0 && (r.DTZ == 0 || r.DTZ < other.DTZ)
+		}
+
+		return r.DTM < other.DTM
+	case Loss:
+		if r.DTZ != other.DTZ {
+			return r.DTZ != 0 && (other.DTZ == 0 || r.DTZ > other.DTZ)
+		}
+
+		return r.DTM > other.DTM
+	default:
+		return false
+	}
+}
+
+// Prober gives the exact result of positions with few men
+type Prober interface {
+	Probe(c chess.Chess) (Result, error)
+}
+
+// Chain probes each of its probers in turn, getting the result of the first that covers the position, so that the
+// generated tables, which know the distance to mate, can be tried before Syzygy files
+type Chain []Prober
+
+// Probe gets the result of the position from the first prober that covers it
+func (ch Chain) Probe(c chess.Chess) (Result, error) {
+	err := fmt.Errorf("%w: there are no tables", ErrorTableNotFound)
+
+	for _, p := range ch {
+		r, e := p.Probe(c)
+		if e == nil {
+			return r, nil
+		}
+
+		if !errors.Is(e, ErrorTableNotFound) && !errors.Is(e, ErrorTooManyPieces) && !errors.Is(e, ErrorNotApplicable) {
+			return Result{}, e
+		}
+
+		err = e
+	}
+
+	return Result{}, err
+}
+
+// BestMove gets the move that wins quickest, or failing that draws, or failing that loses slowest
+func (ch Chain) BestMove(c chess.Chess) (move.Move, piece.PieceType, Result, error) {
+	return BestMove(ch, c)
+}
+
+// The results of positions are stored in a byte, 0 for a draw, 1 to 127 for a win in 2v-1 half moves and 128 to 255
+// for a loss in 2(v-128) half moves
+func encodeWin(plies int) uint8 {
+	return uint8((plies + 1) / 2)
+}
+
+func encodeLoss(plies int) uint8 {
+	return uint8(128 + plies/2)
+}
+
+func decode(v uint8) Result {
+	switch {
+	case v == 0:
+		return Result{WDL: Draw}
+	case v < 128:
+		return Result{WDL: Win, DTM: 2*int(v) - 1}
+	default:
+		return Result{WDL: Loss, DTM: 2 * int(v-128)}
+	}
+}
+
+type table struct {
+	material *material
+	values   []uint8
+}
+
+// Set is a set of generated tables, safe for concurrent use
+type Set struct {
+	mu     sync.RWMutex
+	tables map[string]*table
+}
+
+func New() *Set {
+	return &Set{tables: make(map[string]*table)}
+}
+
+// Materials gets the names of the materials of the tables in the set
+func (s *Set) Materials() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var names []string
+	for name := range s.tables {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func (s *Set) table(name string) (*table, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.tables[name]
+	return t, ok
+}
+
+// Generate generates the table of the material e.g. KQvKR, along with the tables of the materials it can become
+// by a capture or promotion, unless they are already in the set
+func (s *Set) Generate(name string) error {
+	m, _, err := parseMaterial(name)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := s.table(m.name); ok {
+		return nil
+	}
+
+	for _, sub := range m.conversions() {
+		if err := s.Generate(sub); err != nil {
+			return err
+		}
+	}
+
+	t := s.generate(m)
+
+	s.mu.Lock()
+	s.tables[m.name] = t
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Longest gets the most half moves to mate of the won positions in the table of the material
+func (s *Set) Longest(name string) (int, error) {
+	m, _, err := parseMaterial(name)
+	if err != nil {
+		return 0, err
+	}
+
+	t, ok := s.table(m.name)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrorTableNotFound, m.name)
+	}
+
+	longest := 0
+	for _, v := range t.values {
+		if r := decode(v); r.WDL == Win && r.DTM > longest {
+			longest = r.DTM
+		}
+	}
+
+	return longest, nil
+}
+
+// Probe gets the result of the position for the colour to move. En passant captures are not considered.
+func (s *Set) Probe(c chess.Chess) (Result, error) {
+	b := c.Board
+	if b.Width != 8 || b.Height != 8 || b.Variant != variant.Standard {
+		return Result{}, ErrorNotApplicable
+	}
+
+	if len(b.Pieces) > MaxPieces {
+		return Result{}, ErrorTooManyPieces
+	}
+
+	if fields := strings.Fields(c.FEN()); fields[2] != "-" {
+		return Result{}, fmt.Errorf("%w: castling rights", ErrorNotApplicable)
+	}
+
+	var men []man
+	var squares []int
+
+	for pos, p := range b.Pieces {
+		men = append(men, man{kind: kindOf(p.GetPieceType()), white: p.Colour == colour.White})
+		squares = append(squares, pos.Rank*8+pos.File)
+	}
+
+	v, err := s.lookup(men, squares, c.Turn == colour.White)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return decode(v), nil
+}
+
+// BestMove gets the move that wins quickest, or failing that draws, or failing that loses slowest
+func (s *Set) BestMove(c chess.Chess) (move.Move, piece.PieceType, Result, error) {
+	return BestMove(s, c)
+}
+
+// BestMove gets the move with the best result in the tables of the prober
+func BestMove(p Prober, c chess.Chess) (move.Move, piece.PieceType, Result, error) {
+	var best legalMove
+	var bestResult Result
+
+	found := false
+
+	for _, m := range legalMoves(c) {
+		r, err := probeMove(p, c, m)
+		if err != nil {
+			return move.Move{}, 0, Result{}, err
+		}
+
+		if !found || r.Better(bestResult) {
+			best, bestResult, found = m, r, true
+		}
+	}
+
+	if !found {
+		return move.Move{}, 0, Result{}, chess.ErrorIllegalMove
+	}
+
+	return best.move, best.promotion, bestResult, nil
+}
+
+// ProbeMove gets the result of the legal move for the colour making it, from the result of the position it leads to
+func ProbeMove(p Prober, c chess.Chess, m move.Move, promotion piece.PieceType) (Result, error) {
+	if _, ok := c.Board.Pieces[m.From]; !ok {
+		return Result{}, chess.ErrorIllegalMove
+	}
+
+	return probeMove(p, c, newLegalMove(c, m, promotion))
+}
+
+func probeMove(p Prober, c chess.Chess, m legalMove) (Result, error) {
+	next, err := play(c, m)
+	if err != nil {
+		return Result{}, err
+	}
+
+	r, err := p.Probe(next)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// The result of the move is the result of the position after it, for the other colour, a half move later
+	switch {
+	case r.WDL == Draw:
+		return Result{WDL: Draw}, nil
+	case r.DTZ == 0:
+		return Result{WDL: -r.WDL, DTM: r.DTM + 1}, nil
+	case m.zeroing || (r.DTZ == 1 && r.WDL == Loss && len(next.LegalMoves()) == 0):
+		// Captures and pawn moves zero the distance themselves, as does a mate
+		return Result{WDL: -r.WDL, DTZ: 1}, nil
+	default:
+		return Result{WDL: -r.WDL, DTZ: r.DTZ + 1}, nil
+	}
+}
+
+func kindOf(t piece.PieceType) int {
+	switch t {
+	case piece.PieceTypeKing:
+		return king
+	case piece.PieceTypeQueen:
+		return queen
+	case piece.PieceTypeRook:
+		return rook
+	case piece.PieceTypeBishop:
+		return bishop
+	case piece.PieceTypeKnight:
+		return knight
+	default:
+		return pawn
+	}
+}
+
+// lookup gets the stored result of the men on the squares provided, in any order
+func (s *Set) lookup(men []man, squares []int, white bool) (uint8, error) {
+	var kinds [2][]int
+	for _, mn := range men {
+		if mn.kind == king {
+			continue
+		}
+
+		if mn.white {
+			kinds[0] = append(kinds[0], mn.kind)
+		} else {
+			kinds[1] = append(kinds[1], mn.kind)
+		}
+	}
+
+	sort.Ints(kinds[0])
+	sort.Ints(kinds[1])
+
+	m, flip, err := parseMaterial(materialName(kinds[0], kinds[1]))
+	if err != nil {
+		return 0, err
+	}
+
+	t, ok := s.table(m.name)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrorTableNotFound, m.name)
+	}
+
+	p, ok := place(m, men, squares, white, flip)
+	if !ok || !p.valid(m.men) {
+		return 0, fmt.Errorf("%w: the position cannot be reached", ErrorNotApplicable)
+	}
+
+	return t.values[m.index(p)], nil
+}
+
+// place puts the men on their squares in the order of the material, swapping their colours and mirroring the
+// board top to bottom when the material has the colours the other way around
+func place(m *material, men []man, squares []int, white, flip bool) (position, bool) {
+	p := position{white: white != flip}
+	used := make([]bool, len(men))
+
+	for i, want := range m.men {
+		found := false
+
+		for j, mn := range men {
+			if used[j] || mn.kind != want.kind || (mn.white != flip) != want.white {
+				continue
+			}
+
+			sq := squares[j]
+			if flip {
+				sq ^= 56
+			}
+
+			p.sq[i], used[j], found = sq, true, true
+
+			break
+		}
+
+		if !found {
+			return position{}, false
+		}
+	}
+
+	return p, true
+}
+
+// Save writes the tables of the set, compressed
+func (s *Set) Save(w io.Writer) error {
+	zw := gzip.NewWriter(w)
+	bw := bufio.NewWriter(zw)
+
+	names := s.Materials()
+
+	if _, err := bw.WriteString(magic); err != nil {
+		return err
+	}
+
+	if err := binary.Write(bw, binary.BigEndian, uint32(len(names))); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		t, _ := s.table(name)
+
+		if err := bw.WriteByte(byte(len(name))); err != nil {
+			return err
+		}
+
+		if _, err := bw.WriteString(name); err != nil {
+			return err
+		}
+
+		if err := binary.Write(bw, binary.BigEndian, uint32(len(t.values))); err != nil {
+			return err
+		}
+
+		if _, err := bw.Write(t.values); err != nil {
+			return err
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// Load reads tables written by Save into the set
+func (s *Set) Load(r io.Reader) error {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrorInvalidFile, err)
+	}
+	defer zr.Close()
+
+	br := bufio.NewReader(zr)
+
+	header := make([]byte, len(magic))
+	if _, err := io.ReadFull(br, header); err != nil || string(header) != magic {
+		return ErrorInvalidFile
+	}
+
+	var count uint32
+	if err := binary.Read(br, binary.BigEndian, &count); err != nil {
+		return fmt.Errorf("%w: %s", ErrorInvalidFile, err)
+	}
+
+	for i := uint32(0); i < count; i++ {
+		n, err := br.ReadByte()
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrorInvalidFile, err)
+		}
+
+		name := make([]byte, n)
+		if _, err := io.ReadFull(br, name); err != nil {
+			return fmt.Errorf("%w: %s", ErrorInvalidFile, err)
+		}
+
+		m, flip, err := parseMaterial(string(name))
+		if err != nil || flip {
+			return fmt.Errorf("%w: table %q", ErrorInvalidFile, name)
+		}
+
+		var size uint32
+		if err := binary.Read(br, binary.BigEndian, &size); err != nil {
+			return fmt.Errorf("%w: %s", ErrorInvalidFile, err)
+		}
+
+		if int(size) != m.size {
+			return fmt.Errorf("%w: table %s has %d positions, want %d", ErrorInvalidFile, name, size, m.size)
+		}
+
+		values := make([]uint8, size)
+		if _, err := io.ReadFull(br, values); err != nil {
+			return fmt.Errorf("%w: %s", ErrorInvalidFile, err)
+		}
+
+		s.mu.Lock()
+		s.tables[m.name] = &table{material: m, values: values}
+		s.mu.Unlock()
+	}
+
+	return nil
+}
+
+// Open reads the tables in the file at the path provided
+func Open(path string) (*Set, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s := New()
+	if err := s.Load(f); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// SaveFile writes the tables of the set to the file at the path provided, replacing it once it has been written
+func (s *Set) SaveFile(path string) error {
+	var buf bytes.Buffer
+	if err := s.Save(&buf); err != nil {
+		return err
+	}
+
+	return storage.WriteFile(path, buf.Bytes(), 0o644)
+}
@@ -0,0 +1,215 @@
+package tablebase_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/tablebase"
+)
+
+func generate(t *testing.T, names ...string) *tablebase.Set {
+	t.Helper()
+
+	s := tablebase.New()
+	for _, name := range names {
+		if err := s.Generate(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return s
+}
+
+func TestProbe(t *testing.T) {
+	s := generate(t, "KQvK", "KRvK")
+
+	tests := []struct {
+		name string
+		fen  string
+		want tablebase.Result
+	}{
+		{
+			name: "mate in one",
+			fen:  "7k/8/6K1/8/8/8/8/1Q6 w - - 0 1",
+			want: tablebase.Result{WDL: tablebase.Win, DTM: 1},
+		},
+		{
+			name: "mate in one with the colours swapped",
+			fen:  "1q6/8/8/8/8/6k1/8/7K b - - 0 1",
+			want: tablebase.Result{WDL: tablebase.Win, DTM: 1},
+		},
+		{
+			name: "mated",
+			fen:  "Q6k/8/6K1/8/8/8/8/8 b - - 0 1",
+			want: tablebase.Result{WDL: tablebase.Loss, DTM: 0},
+		},
+		{
+			name: "stalemate",
+			fen:  "7k/5Q2/6K1/8/8/8/8/8 b - - 0 1",
+			want: tablebase.Result{WDL: tablebase.Draw},
+		},
+		{
+			name: "the queen is taken",
+			fen:  "8/8/8/8/8/2k5/8/Kq6 w - - 0 1",
+			want: tablebase.Result{WDL: tablebase.Draw},
+		},
+		{
+			name: "rook mate in one",
+			fen:  "6k1/8/6K1/8/8/8/8/1R6 w - - 0 1",
+			want: tablebase.Result{WDL: tablebase.Win, DTM: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := chess.FromFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := s.Probe(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBestMove(t *testing.T) {
+	s := generate(t, "KQvK")
+
+	c, err := chess.FromFEN("7k/8/6K1/8/8/8/8/1Q6 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, _, res, err := s.BestMove(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.UCI() != "b1b8" || res.MateIn() != 1 {
+		t.Errorf("got %s with %+v, want b1b8 mating in one", m.UCI(), res)
+	}
+}
+
+func TestProbeErrors(t *testing.T) {
+	s := generate(t, "KQvK")
+
+	tests := []struct {
+		name string
+		fen  string
+		want error
+	}{
+		{
+			name: "too many pieces",
+			fen:  "4k3/8/8/8/8/8/PPP5/4K3 w - - 0 1",
+			want: tablebase.ErrorTooManyPieces,
+		},
+		{
+			name: "castling rights",
+			fen:  "4k3/8/8/8/8/8/8/R3K3 w Q - 0 1",
+			want: tablebase.ErrorNotApplicable,
+		},
+		{
+			name: "the colour not to move is in check",
+			fen:  "7k/8/6K1/8/8/8/8/Q7 w - - 0 1",
+			want: tablebase.ErrorNotApplicable,
+		},
+		{
+			name: "missing table",
+			fen:  "4k3/8/8/8/8/8/8/R3K3 w - - 0 1",
+			want: tablebase.ErrorTableNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := chess.FromFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := s.Probe(c); !errors.Is(err, tt.want) {
+				t.Errorf("got error %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name    string
+		longest int
+		err     error
+	}{
+		{name: "KQvK", longest: 19},
+		{name: "KRvK", longest: 31},
+		{name: "KPvK", longest: 55},
+		{name: "KvKQ", longest: 19},
+		{name: "KQvQ", err: tablebase.ErrorInvalidMaterial},
+		{name: "KQRvKR", err: tablebase.ErrorTooManyPieces},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tablebase.New()
+
+			err := s.Generate(tt.name)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+
+			if tt.err != nil {
+				return
+			}
+
+			got, err := s.Longest(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.longest {
+				t.Errorf("got longest mate of %d half moves, want %d", got, tt.longest)
+			}
+		})
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	s := generate(t, "KRvK")
+
+	var buf bytes.Buffer
+	if err := s.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := tablebase.New()
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// The table of the bare kings is generated too, for the positions where the rook is taken
+	if got := loaded.Materials(); len(got) != 2 || got[0] != "KRvK" || got[1] != "KvK" {
+		t.Errorf("got materials %v, want KRvK and KvK", got)
+	}
+
+	c, err := chess.FromFEN("6k1/8/6K1/8/8/8/8/1R6 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res, err := loaded.Probe(c); err != nil || res.WDL != tablebase.Win || res.DTM != 1 {
+		t.Errorf("got %+v, %v, want a win in 1 half move", res, err)
+	}
+
+	if err := loaded.Load(bytes.NewReader([]byte("not a tablebase"))); !errors.Is(err, tablebase.ErrorInvalidFile) {
+		t.Errorf("got error %v, want %v", err, tablebase.ErrorInvalidFile)
+	}
+}
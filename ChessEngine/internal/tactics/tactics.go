@@ -0,0 +1,497 @@
+// Package tactics finds the tactical motifs of a position, such as pins, forks and hanging pieces, along with the
+// squares of the pieces involved in them
+package tactics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tomwatson6/chessbot/internal/board"
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/piece"
+)
+
+// Kind is the type of a tactical motif
+type Kind int
+
+const (
+	Pin Kind = iota
+	Skewer
+	Fork
+	DiscoveredAttack
+	DiscoveredCheck
+	DoubleCheck
+	OverloadedDefender
+	HangingPiece
+)
+
+var kinds = []string{
+	Pin:                "pin",
+	Skewer:             "skewer",
+	Fork:               "fork",
+	DiscoveredAttack:   "discovered-attack",
+	DiscoveredCheck:    "discovered-check",
+	DoubleCheck:        "double-check",
+	OverloadedDefender: "overloaded-defender",
+	HangingPiece:       "hanging-piece",
+}
+
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kinds) {
+		return "unknown"
+	}
+
+	return kinds[k]
+}
+
+func (k Kind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *Kind) UnmarshalText(text []byte) error {
+	for i, name := range kinds {
+		if name == string(text) {
+			*k = Kind(i)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown motif %q", text)
+}
+
+// Motif is a tactic in a position. The piece carrying out the tactic comes first in the squares, apart from
+// hanging pieces and overloaded defenders where the piece that is weak comes first.
+type Motif struct {
+	Kind Kind `json:"kind"`
+	// Colour is the side the motif favours
+	Colour  colour.Colour   `json:"colour"`
+	Squares []move.Position `json:"squares"`
+	// Absolute is set for pins and skewers against the king
+	Absolute    bool   `json:"absolute,omitempty"`
+	Description string `json:"description"`
+}
+
+func (m Motif) key() string {
+	return fmt.Sprint(m.Kind, m.Colour, m.Squares)
+}
+
+// Find gets the motifs in the position for both colours. Checks are only said to be discovered when the last move
+// of the game is known.
+func Find(c chess.Chess) []Motif {
+	b := c.Board
+
+	motifs := checks(c)
+
+	for _, col := range []colour.Colour{colour.White, colour.Black} {
+		motifs = append(motifs, lines(b, col)...)
+		motifs = append(motifs, forks(b, col)...)
+		motifs = append(motifs, hanging(b, col)...)
+		motifs = append(motifs, overloaded(b, col)...)
+	}
+
+	return motifs
+}
+
+// Move gets the motifs that a move creates for the side making it, including the attacks it discovers
+func Move(c chess.Chess, m move.Move, promotion piece.PieceType) ([]Motif, error) {
+	before := make(map[string]bool)
+	for _, mo := range Find(c) {
+		before[mo.key()] = true
+	}
+
+	next := c.Copy()
+	if _, err := next.MakeMoveWithPromotion(m, promotion); err != nil {
+		return nil, err
+	}
+
+	motifs := []Motif{}
+	for _, mo := range Find(next) {
+		if mo.Colour == c.Turn && !before[mo.key()] {
+			motifs = append(motifs, mo)
+		}
+	}
+
+	return append(motifs, discovered(c.Board, next.Board, m, c.Turn)...), nil
+}
+
+// checks finds double checks, and discovered checks given by the last move, against the colour to move
+func checks(c chess.Chess) []Motif {
+	b := c.Board
+
+	k, err := b.GetKing(c.Turn)
+	if err != nil || !b.Variant.HasRoyalKing() {
+		return nil
+	}
+
+	checkers := attackers(b, k.Position, c.Turn.Opposite())
+
+	switch {
+	case len(checkers) >= 2:
+		var names []string
+		squares := []move.Position{}
+
+		for _, p := range checkers {
+			names = append(names, describe(p))
+			squares = append(squares, p.Position)
+		}
+
+		return []Motif{{
+			Kind:        DoubleCheck,
+			Colour:      c.Turn.Opposite(),
+			Squares:     append(squares, k.Position),
+			Description: fmt.Sprintf("The %s is in check from %s", describe(k), list(names)),
+		}}
+	case len(checkers) == 1:
+		last := c.LastMove()
+		if last == nil || checkers[0].Position == last.To {
+			return nil
+		}
+
+		moved, ok := b.Pieces[last.To]
+		if !ok || (moved.GetPieceType() == piece.PieceTypeKing && last.Distance() == 2) {
+			// The rook giving check after castling is not discovered
+			return nil
+		}
+
+		return []Motif{{
+			Kind:    DiscoveredCheck,
+			Colour:  c.Turn.Opposite(),
+			Squares: []move.Position{checkers[0].Position, last.To, k.Position},
+			Description: fmt.Sprintf("The %s moving to %s discovers check from the %s", describe(moved),
+				last.To.Notation(), describe(checkers[0])),
+		}}
+	}
+
+	return nil
+}
+
+// lines finds the pins and skewers of the pieces of the colour that slide
+func lines(b board.Board, col colour.Colour) []Motif {
+	var motifs []Motif
+
+	for _, s := range pieces(b, col) {
+		var dirs [][2]int
+
+		switch s.GetPieceType() {
+		case piece.PieceTypeBishop:
+			dirs = diagonals
+		case piece.PieceTypeRook:
+			dirs = straights
+		case piece.PieceTypeQueen:
+			dirs = append(append(dirs, straights...), diagonals...)
+		default:
+			continue
+		}
+
+		for _, d := range dirs {
+			front, behind := ray(b, s.Position, d)
+			if front == nil || behind == nil || front.Colour == col || behind.Colour == col {
+				continue
+			}
+
+			switch {
+			case behind.GetPieceType() == piece.PieceTypeKing:
+				motifs = append(motifs, Motif{
+					Kind:        Pin,
+					Colour:      col,
+					Squares:     []move.Position{s.Position, front.Position, behind.Position},
+					Absolute:    true,
+					Description: fmt.Sprintf("The %s pins the %s to the %s", describe(s), describe(front), describe(behind)),
+				})
+			case front.GetPieceType() == piece.PieceTypeKing:
+				if wins(b, s, behind) {
+					motifs = append(motifs, Motif{
+						Kind:     Skewer,
+						Colour:   col,
+						Squares:  []move.Position{s.Position, front.Position, behind.Position},
+						Absolute: true,
+						Description: fmt.Sprintf("The %s skewers the %s and the %s", describe(s), describe(front),
+							describe(behind)),
+					})
+				}
+			case value(behind) > value(front):
+				motifs = append(motifs, Motif{
+					Kind:        Pin,
+					Colour:      col,
+					Squares:     []move.Position{s.Position, front.Position, behind.Position},
+					Description: fmt.Sprintf("The %s pins the %s to the %s", describe(s), describe(front), describe(behind)),
+				})
+			case value(front) > value(behind) && wins(b, s, front) && wins(b, s, behind):
+				motifs = append(motifs, Motif{
+					Kind:    Skewer,
+					Colour:  col,
+					Squares: []move.Position{s.Position, front.Position, behind.Position},
+					Description: fmt.Sprintf("The %s skewers the %s and the %s", describe(s), describe(front),
+						describe(behind)),
+				})
+			}
+		}
+	}
+
+	return motifs
+}
+
+// forks finds the pieces of the colour that attack two or more pieces that can be won: the king, pieces worth more
+// than the attacker and pieces that are not defended
+func forks(b board.Board, col colour.Colour) []Motif {
+	var motifs []Motif
+
+	for _, f := range pieces(b, col) {
+		var targets []*piece.Piece
+
+		for _, sq := range sorted(b.Attacks(f.Position)) {
+			t, ok := b.Pieces[sq]
+			if !ok || t.Colour == col {
+				continue
+			}
+
+			if t.GetPieceType() == piece.PieceTypeKing || wins(b, f, t) {
+				targets = append(targets, t)
+			}
+		}
+
+		if len(targets) < 2 {
+			continue
+		}
+
+		var names []string
+		squares := []move.Position{f.Position}
+
+		for _, t := range targets {
+			names = append(names, describe(t))
+			squares = append(squares, t.Position)
+		}
+
+		motifs = append(motifs, Motif{
+			Kind:        Fork,
+			Colour:      col,
+			Squares:     squares,
+			Description: fmt.Sprintf("The %s forks %s", describe(f), list(names)),
+		})
+	}
+
+	return motifs
+}
+
+// hanging finds the pieces of the other colour that the colour can win, because they are not defended or are
+// attacked by a piece worth less than them
+func hanging(b board.Board, col colour.Colour) []Motif {
+	var motifs []Motif
+
+	for _, p := range pieces(b, col.Opposite()) {
+		if p.GetPieceType() == piece.PieceTypeKing {
+			continue
+		}
+
+		by := attackers(b, p.Position, col)
+		if len(by) == 0 {
+			continue
+		}
+
+		cheapest := by[0]
+		for _, a := range by[1:] {
+			if value(a) < value(cheapest) {
+				cheapest = a
+			}
+		}
+
+		if !wins(b, cheapest, p) {
+			continue
+		}
+
+		motifs = append(motifs, Motif{
+			Kind:        HangingPiece,
+			Colour:      col,
+			Squares:     []move.Position{p.Position, cheapest.Position},
+			Description: fmt.Sprintf("The %s can be won by the %s", describe(p), describe(cheapest)),
+		})
+	}
+
+	return motifs
+}
+
+// overloaded finds the pieces of the other colour that are the only defender of two or more attacked pieces
+func overloaded(b board.Board, col colour.Colour) []Motif {
+	defends := make(map[move.Position][]*piece.Piece)
+
+	for _, p := range pieces(b, col.Opposite()) {
+		if p.GetPieceType() == piece.PieceTypeKing || len(attackers(b, p.Position, col)) == 0 {
+			continue
+		}
+
+		if ds := attackers(b, p.Position, col.Opposite()); len(ds) == 1 {
+			defends[ds[0].Position] = append(defends[ds[0].Position], p)
+		}
+	}
+
+	var motifs []Motif
+
+	for _, d := range pieces(b, col.Opposite()) {
+		defended := defends[d.Position]
+		if len(defended) < 2 {
+			continue
+		}
+
+		var names []string
+		squares := []move.Position{d.Position}
+
+		for _, p := range defended {
+			names = append(names, describe(p))
+			squares = append(squares, p.Position)
+		}
+
+		motifs = append(motifs, Motif{
+			Kind:        OverloadedDefender,
+			Colour:      col,
+			Squares:     squares,
+			Description: fmt.Sprintf("The %s is the only defender of %s", describe(d), list(names)),
+		})
+	}
+
+	return motifs
+}
+
+// discovered finds the pieces of the colour that the move uncovers an attack on a piece that can be won by
+func discovered(before, after board.Board, m move.Move, col colour.Colour) []Motif {
+	var motifs []Motif
+
+	moved := after.Pieces[m.To]
+
+	for _, s := range pieces(after, col) {
+		if s.Position == m.To || !slides(s) {
+			continue
+		}
+
+		had := make(map[move.Position]bool)
+		for _, sq := range before.Attacks(s.Position) {
+			had[sq] = true
+		}
+
+		for _, sq := range sorted(after.Attacks(s.Position)) {
+			t, ok := after.Pieces[sq]
+			if had[sq] || !ok || t.Colour == col || t.GetPieceType() == piece.PieceTypeKing || !wins(after, s, t) {
+				continue
+			}
+
+			motifs = append(motifs, Motif{
+				Kind:    DiscoveredAttack,
+				Colour:  col,
+				Squares: []move.Position{s.Position, m.To, t.Position},
+				Description: fmt.Sprintf("The %s moving to %s discovers an attack by the %s on the %s", describe(moved),
+					m.To.Notation(), describe(s), describe(t)),
+			})
+		}
+	}
+
+	return motifs
+}
+
+var (
+	straights = [][2]int{{1, 0}, {0, 1}, {-1, 0}, {0, -1}}
+	diagonals = [][2]int{{1, 1}, {-1, 1}, {-1, -1}, {1, -1}}
+)
+
+// ray gets the first two pieces in the direction from the position
+func ray(b board.Board, from move.Position, d [2]int) (*piece.Piece, *piece.Piece) {
+	var found []*piece.Piece
+
+	for f, r := from.File+d[0], from.Rank+d[1]; f >= 0 && f < b.Width && r >= 0 && r < b.Height; f, r = f+d[0], r+d[1] {
+		if p, ok := b.Pieces[move.Position{File: f, Rank: r}]; ok {
+			found = append(found, p)
+
+			if len(found) == 2 {
+				return found[0], found[1]
+			}
+		}
+	}
+
+	if len(found) == 1 {
+		return found[0], nil
+	}
+
+	return nil, nil
+}
+
+// wins reports whether the attacker would win material by taking the target, as it is worth more than the attacker
+// or is not defended
+func wins(b board.Board, attacker, target *piece.Piece) bool {
+	return value(target) > value(attacker) || len(attackers(b, target.Position, target.Colour)) == 0
+}
+
+// attackers gets the pieces of the colour that attack the position, ordered by square
+func attackers(b board.Board, pos move.Position, by colour.Colour) []*piece.Piece {
+	var ps []*piece.Piece
+
+	for _, p := range pieces(b, by) {
+		for _, sq := range b.Attacks(p.Position) {
+			if sq == pos {
+				ps = append(ps, p)
+				break
+			}
+		}
+	}
+
+	return ps
+}
+
+// pieces gets the pieces of the colour, ordered by square so that motifs are found in the same order each time
+func pieces(b board.Board, col colour.Colour) []*piece.Piece {
+	var ps []*piece.Piece
+
+	for _, p := range b.Pieces {
+		if p.Colour == col {
+			ps = append(ps, p)
+		}
+	}
+
+	sort.Slice(ps, func(i, j int) bool {
+		return less(ps[i].Position, ps[j].Position)
+	})
+
+	return ps
+}
+
+func sorted(squares []move.Position) []move.Position {
+	sort.Slice(squares, func(i, j int) bool {
+		return less(squares[i], squares[j])
+	})
+
+	return squares
+}
+
+func less(a, b move.Position) bool {
+	return a.Rank < b.Rank || (a.Rank == b.Rank && a.File < b.File)
+}
+
+func slides(p *piece.Piece) bool {
+	switch p.GetPieceType() {
+	case piece.PieceTypeBishop, piece.PieceTypeRook, piece.PieceTypeQueen:
+		return true
+	default:
+		return false
+	}
+}
+
+func value(p *piece.Piece) int {
+	return int(p.GetPiecePoints())
+}
+
+// describe names the piece and its square e.g. white knight on f3
+func describe(p *piece.Piece) string {
+	return fmt.Sprintf("%s on %s", strings.ToLower(p.String()), p.Position.Notation())
+}
+
+// list joins the names with commas and a final and
+func list(names []string) string {
+	switch len(names) {
+	case 0:
+		return ""
+	case 1:
+		return "the " + names[0]
+	}
+
+	return "the " + strings.Join(names[:len(names)-1], ", the ") + " and the " + names[len(names)-1]
+}
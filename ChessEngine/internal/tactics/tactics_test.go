@@ -0,0 +1,172 @@
+package tactics_test
+
+import (
+	"testing"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/piece"
+	"github.com/tomwatson6/chessbot/internal/tactics"
+)
+
+func squares(t *testing.T, names ...string) []move.Position {
+	t.Helper()
+
+	var ps []move.Position
+	for _, n := range names {
+		p, err := move.ParsePosition(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ps = append(ps, p)
+	}
+
+	return ps
+}
+
+func contains(motifs []tactics.Motif, want tactics.Motif) bool {
+	for _, m := range motifs {
+		if m.Kind != want.Kind || m.Colour != want.Colour || m.Absolute != want.Absolute || len(m.Squares) != len(want.Squares) {
+			continue
+		}
+
+		same := true
+		for i := range m.Squares {
+			same = same && m.Squares[i] == want.Squares[i]
+		}
+
+		if same {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		want tactics.Motif
+		// only is set when the motif should be the only one found
+		only bool
+	}{
+		{
+			name: "knight fork",
+			fen:  "r3k3/2N5/8/8/8/8/8/4K3 b - - 0 1",
+			want: tactics.Motif{Kind: tactics.Fork, Colour: colour.White, Squares: squares(t, "c7", "a8", "e8")},
+		},
+		{
+			name: "pawn fork",
+			fen:  "4k3/8/2n1b3/3P4/8/8/8/4K3 b - - 0 1",
+			want: tactics.Motif{Kind: tactics.Fork, Colour: colour.White, Squares: squares(t, "d5", "c6", "e6")},
+		},
+		{
+			name: "absolute pin",
+			fen:  "4k3/1p6/2n5/1B6/8/8/8/4K3 w - - 0 1",
+			want: tactics.Motif{Kind: tactics.Pin, Colour: colour.White, Squares: squares(t, "b5", "c6", "e8"), Absolute: true},
+			only: true,
+		},
+		{
+			name: "relative pin",
+			fen:  "3qk3/8/8/3n4/8/8/8/3RK3 w - - 0 1",
+			want: tactics.Motif{Kind: tactics.Pin, Colour: colour.White, Squares: squares(t, "d1", "d5", "d8")},
+		},
+		{
+			name: "skewer through the king",
+			fen:  "q7/8/8/8/k7/8/8/R3K3 b - - 0 1",
+			want: tactics.Motif{Kind: tactics.Skewer, Colour: colour.White, Squares: squares(t, "a1", "a4", "a8"), Absolute: true},
+		},
+		{
+			name: "hanging piece",
+			fen:  "4k3/8/8/3n4/8/8/3R4/4K3 b - - 0 1",
+			want: tactics.Motif{Kind: tactics.HangingPiece, Colour: colour.White, Squares: squares(t, "d5", "d2")},
+			only: true,
+		},
+		{
+			name: "overloaded defender",
+			fen:  "k2q4/8/3n1b2/1N6/6N1/8/8/7K w - - 0 1",
+			want: tactics.Motif{Kind: tactics.OverloadedDefender, Colour: colour.White, Squares: squares(t, "d8", "d6", "f6")},
+		},
+		{
+			name: "double check",
+			fen:  "4k3/8/5N2/8/8/8/8/K3R3 b - - 0 1",
+			want: tactics.Motif{Kind: tactics.DoubleCheck, Colour: colour.White, Squares: squares(t, "e1", "f6", "e8")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := chess.FromFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := tactics.Find(c)
+			if !contains(got, tt.want) {
+				t.Errorf("got motifs %+v, want %+v", got, tt.want)
+			}
+
+			if tt.only && len(got) != 1 {
+				t.Errorf("got motifs %+v, want only %+v", got, tt.want)
+			}
+		})
+	}
+
+	if got := tactics.Find(chess.New(0)); len(got) != 0 {
+		t.Errorf("got motifs %+v in the starting position, want none", got)
+	}
+}
+
+func TestMove(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		move string
+		want tactics.Motif
+	}{
+		{
+			name: "discovered check",
+			fen:  "4k3/8/8/8/4N3/8/8/K3R3 w - - 0 1",
+			move: "e4c5",
+			want: tactics.Motif{Kind: tactics.DiscoveredCheck, Colour: colour.White, Squares: squares(t, "e1", "c5", "e8")},
+		},
+		{
+			name: "discovered attack",
+			fen:  "7k/6r1/8/8/3N4/8/1B6/K7 w - - 0 1",
+			move: "d4b5",
+			want: tactics.Motif{Kind: tactics.DiscoveredAttack, Colour: colour.White, Squares: squares(t, "b2", "b5", "g7")},
+		},
+		{
+			name: "fork",
+			fen:  "r3k3/8/8/1N6/8/8/8/4K3 w - - 0 1",
+			move: "b5c7",
+			want: tactics.Motif{Kind: tactics.Fork, Colour: colour.White, Squares: squares(t, "c7", "a8", "e8")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := chess.FromFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			m, _, err := move.ParseUCI(tt.move)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := tactics.Move(c, m, piece.PieceTypePawn)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !contains(got, tt.want) {
+				t.Errorf("got motifs %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
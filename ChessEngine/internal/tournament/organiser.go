@@ -0,0 +1,264 @@
+package tournament
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/tomwatson6/chessbot/internal/storage"
+)
+
+// ErrorTournamentNotFound is thrown when there is no tournament with the id provided
+var ErrorTournamentNotFound = errors.New("there is no tournament with the id provided")
+
+// Starter starts the game of a pairing, returning the id of the game so that its result can be reported
+type Starter func(t Tournament, p Pairing) (string, error)
+
+// Organiser runs tournaments, starting the games of each round and pairing the next round once they have finished
+type Organiser struct {
+	mu          sync.Mutex
+	path        string
+	start       Starter
+	tournaments map[string]*Tournament
+	order       []string
+	games       map[string]string
+}
+
+type OrganiserOption func(o *Organiser)
+
+// WithFile saves the tournaments to the file at the path provided, loading it if it already exists
+func WithFile(path string) OrganiserOption {
+	return func(o *Organiser) {
+		o.path = path
+	}
+}
+
+func NewOrganiser(start Starter, opts ...OrganiserOption) (*Organiser, error) {
+	o := &Organiser{
+		start:       start,
+		tournaments: make(map[string]*Tournament),
+		games:       make(map[string]string),
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if err := o.load(); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// Create enters the players provided into a new tournament, which is played once it is started
+func (o *Organiser) Create(name string, f Format, players []string, rounds int, tc TimeControl) (Tournament, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	id, err := storage.NewID(4)
+	if err != nil {
+		return Tournament{}, err
+	}
+
+	for _, ok := o.tournaments[id]; ok; _, ok = o.tournaments[id] {
+		if id, err = storage.NewID(4); err != nil {
+			return Tournament{}, err
+		}
+	}
+
+	t, err := New(id, name, f, players, rounds, tc)
+	if err != nil {
+		return Tournament{}, err
+	}
+
+	o.tournaments[id] = t
+	o.order = append(o.order, id)
+
+	if err := o.save(); err != nil {
+		return Tournament{}, err
+	}
+
+	return t.Copy(), nil
+}
+
+// Start pairs the first round of the tournament and starts its games. Starting a tournament that is already
+// running starts any games of the current round that failed to start before.
+func (o *Organiser) Start(id string) (Tournament, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	t, ok := o.tournaments[id]
+	if !ok {
+		return Tournament{}, ErrorTournamentNotFound
+	}
+
+	if t.Status == StatusFinished {
+		return Tournament{}, ErrorFinished
+	}
+
+	if err := o.advance(t); err != nil {
+		return Tournament{}, err
+	}
+
+	return t.Copy(), nil
+}
+
+// Report records the result of a game, from white's point of view. Once every game of the round has finished the
+// next round is paired and started.
+func (o *Organiser) Report(gameID string, score float64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	id, ok := o.games[gameID]
+	if !ok {
+		return ErrorGameNotFound
+	}
+
+	t := o.tournaments[id]
+
+	if err := t.Record(gameID, score); err != nil {
+		return err
+	}
+
+	return o.advance(t)
+}
+
+// Restart starts the game of a pairing again, for games that ended without a result such as aborted games
+func (o *Organiser) Restart(gameID string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	id, ok := o.games[gameID]
+	if !ok {
+		return ErrorGameNotFound
+	}
+
+	t := o.tournaments[id]
+
+	if err := t.unassign(gameID); err != nil {
+		return err
+	}
+
+	delete(o.games, gameID)
+
+	return o.advance(t)
+}
+
+// advance pairs rounds until one has games to be played, then starts them
+func (o *Organiser) advance(t *Tournament) error {
+	for t.RoundComplete() {
+		if _, err := t.NextRound(); err != nil {
+			if errors.Is(err, ErrorFinished) {
+				return o.save()
+			}
+
+			return err
+		}
+	}
+
+	r := &t.Rounds[len(t.Rounds)-1]
+
+	var errs []error
+	for _, i := range t.Unstarted() {
+		gameID, err := o.start(t.Copy(), r.Pairings[i])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		r.Pairings[i].GameID = gameID
+		o.games[gameID] = t.ID
+	}
+
+	if err := o.save(); err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to start %d games of round %d: %w", len(errs), r.Number, errs[0])
+	}
+
+	return nil
+}
+
+func (o *Organiser) Get(id string) (Tournament, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	t, ok := o.tournaments[id]
+	if !ok {
+		return Tournament{}, ErrorTournamentNotFound
+	}
+
+	return t.Copy(), nil
+}
+
+// List gets all tournaments in the order they were created
+func (o *Organiser) List() []Tournament {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	list := make([]Tournament, len(o.order))
+	for i, id := range o.order {
+		list[i] = o.tournaments[id].Copy()
+	}
+
+	return list
+}
+
+func (o *Organiser) load() error {
+	if o.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(o.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var list []*Tournament
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+
+	for _, t := range list {
+		o.tournaments[t.ID] = t
+		o.order = append(o.order, t.ID)
+
+		for _, r := range t.Rounds {
+			for _, p := range r.Pairings {
+				if p.GameID != "" {
+					o.games[p.GameID] = t.ID
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (o *Organiser) save() error {
+	if o.path == "" {
+		return nil
+	}
+
+	list := make([]*Tournament, len(o.order))
+	for i, id := range o.order {
+		list[i] = o.tournaments[id]
+	}
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+
+	return storage.WriteFile(o.path, data, 0o644)
+}
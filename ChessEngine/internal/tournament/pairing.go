@@ -0,0 +1,235 @@
+package tournament
+
+import (
+	"sort"
+)
+
+// BergerRound gets the pairings of the round provided, from 0, of a round robin between the players using the
+// circle method of the Berger tables. With an odd number of players the player left over has a bye.
+func BergerRound(players []string, round int) []Pairing {
+	ps := append([]string{}, players...)
+	if len(ps)%2 != 0 {
+		ps = append(ps, "")
+	}
+
+	n := len(ps)
+	if n < 2 {
+		return []Pairing{}
+	}
+
+	round %= n - 1
+	fixed := ps[n-1]
+
+	var pairings []Pairing
+
+	// The fixed player alternates colours each round, while the others alternate by their distance from the pivot
+	if round%2 == 0 {
+		pairings = append(pairings, newPairing(ps[round], fixed))
+	} else {
+		pairings = append(pairings, newPairing(fixed, ps[round]))
+	}
+
+	for i := 1; i < n/2; i++ {
+		a := ps[(round+i)%(n-1)]
+		b := ps[(round-i+n-1)%(n-1)]
+
+		if i%2 == 0 {
+			pairings = append(pairings, newPairing(a, b))
+		} else {
+			pairings = append(pairings, newPairing(b, a))
+		}
+	}
+
+	return pairings
+}
+
+// newPairing pairs the players, a pairing against no one is a bye for the other player
+func newPairing(white, black string) Pairing {
+	if white == "" {
+		return Pairing{White: black}
+	}
+
+	return Pairing{White: white, Black: black}
+}
+
+// SwissRound pairs the players for the next round of a Swiss tournament in the style of the Dutch system.
+// Players are ranked by score then seed, and within each score group the top half is paired against the bottom
+// half. Players who have already met are not paired again, floating players down to the next score group when
+// needed. With an odd number of players the lowest ranked player without a bye has one.
+func SwissRound(t *Tournament) []Pairing {
+	scores := t.scores()
+
+	ranked := append([]string{}, t.Players...)
+	seed := t.seeds()
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if scores[ranked[i]] != scores[ranked[j]] {
+			return scores[ranked[i]] > scores[ranked[j]]
+		}
+
+		return seed[ranked[i]] < seed[ranked[j]]
+	})
+
+	var pairings []Pairing
+
+	if len(ranked)%2 != 0 {
+		byes := t.byes()
+
+		bye := len(ranked) - 1
+		for i := len(ranked) - 1; i >= 0; i-- {
+			if !byes[ranked[i]] {
+				bye = i
+				break
+			}
+		}
+
+		pairings = append(pairings, Pairing{White: ranked[bye]})
+		ranked = append(ranked[:bye], ranked[bye+1:]...)
+	}
+
+	met := t.opponents()
+
+	pairs, ok := pairDutch(ranked, scores, met)
+	if !ok {
+		// Everyone has met everyone they could, so repeat pairings are allowed rather than stopping the tournament
+		pairs, _ = pairDutch(ranked, scores, map[string]map[string]bool{})
+	}
+
+	colours := t.colours()
+
+	for _, p := range pairs {
+		pairings = append(pairings, allocateColours(p[0], p[1], colours, seed))
+	}
+
+	return pairings
+}
+
+// pairDutch pairs the ranked players, backtracking until no players are paired who have already met
+func pairDutch(ranked []string, scores map[string]float64, met map[string]map[string]bool) ([][2]string, bool) {
+	if len(ranked) == 0 {
+		return nil, true
+	}
+
+	p := ranked[0]
+	rest := ranked[1:]
+
+	for _, i := range candidates(ranked, scores) {
+		o := rest[i]
+		if met[p][o] {
+			continue
+		}
+
+		remaining := append(append([]string{}, rest[:i]...), rest[i+1:]...)
+
+		if pairs, ok := pairDutch(remaining, scores, met); ok {
+			return append([][2]string{{p, o}}, pairs...), true
+		}
+	}
+
+	return nil, false
+}
+
+// candidates orders the opponents of the first ranked player from the most preferred, as indexes of ranked[1:].
+// The preferred opponent is the one in the same place of the bottom half of the score group, followed by the
+// rest of the bottom half, then the top half from the bottom up, then everyone in lower score groups.
+func candidates(ranked []string, scores map[string]float64) []int {
+	group := 1
+	for group < len(ranked) && scores[ranked[group]] == scores[ranked[0]] {
+		group++
+	}
+
+	half := group / 2
+
+	var order []int
+
+	for i := half; i < group; i++ {
+		order = append(order, i-1)
+	}
+
+	for i := half - 1; i >= 1; i-- {
+		order = append(order, i-1)
+	}
+
+	for i := group; i < len(ranked); i++ {
+		order = append(order, i-1)
+	}
+
+	// A player alone in their score group floats down to the best placed player of the next group
+	if group == 1 {
+		order = order[:0]
+		for i := 1; i < len(ranked); i++ {
+			order = append(order, i-1)
+		}
+	}
+
+	return order
+}
+
+// allocateColours gives white to the player who has had it least, then to the player who had black last,
+// then to the higher seed
+func allocateColours(a, b string, colours map[string][]bool, seed map[string]int) Pairing {
+	balance := func(p string) int {
+		d := 0
+		for _, white := range colours[p] {
+			if white {
+				d++
+			} else {
+				d--
+			}
+		}
+
+		return d
+	}
+
+	if ba, bb := balance(a), balance(b); ba != bb {
+		if ba < bb {
+			return Pairing{White: a, Black: b}
+		}
+
+		return Pairing{White: b, Black: a}
+	}
+
+	la, lb := colours[a], colours[b]
+	if len(la) > 0 && len(lb) > 0 && la[len(la)-1] != lb[len(lb)-1] {
+		if !la[len(la)-1] {
+			return Pairing{White: a, Black: b}
+		}
+
+		return Pairing{White: b, Black: a}
+	}
+
+	if seed[a] < seed[b] {
+		return Pairing{White: a, Black: b}
+	}
+
+	return Pairing{White: b, Black: a}
+}
+
+// BracketOrder orders the seeds of a knockout bracket so that the top seeds can only meet in the last rounds,
+// e.g. 1, 8, 4, 5, 2, 7, 3, 6 for eight players. Seeds beyond the number of players are byes, shown as -1.
+func BracketOrder(players int) []int {
+	size := 1
+	for size < players {
+		size *= 2
+	}
+
+	order := []int{1}
+	for len(order) < size {
+		n := len(order) * 2
+
+		var next []int
+		for _, s := range order {
+			next = append(next, s, n+1-s)
+		}
+
+		order = next
+	}
+
+	for i, s := range order {
+		if s > players {
+			order[i] = -1
+		}
+	}
+
+	return order
+}
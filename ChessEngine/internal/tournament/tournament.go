@@ -0,0 +1,617 @@
+// Package tournament runs round robin, Swiss and knockout tournaments, pairing each round once the last has finished
+package tournament
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrorUnknownFormat is thrown when creating a tournament of a format that does not exist
+	ErrorUnknownFormat = errors.New("unknown tournament format")
+	// ErrorTooFewPlayers is thrown when creating a tournament with fewer than two players
+	ErrorTooFewPlayers = errors.New("a tournament needs at least two players")
+	// ErrorDuplicatePlayer is thrown when creating a tournament with a player entered more than once
+	ErrorDuplicatePlayer = errors.New("a player can only be entered once")
+	// ErrorRoundInProgress is thrown when pairing the next round before every game of the current round has finished
+	ErrorRoundInProgress = errors.New("the current round has not finished")
+	// ErrorFinished is thrown when pairing a round of a tournament that has finished
+	ErrorFinished = errors.New("the tournament has finished")
+	// ErrorGameNotFound is thrown when recording the result of a game that is not part of the tournament
+	ErrorGameNotFound = errors.New("the game is not part of the tournament")
+	// ErrorAlreadyRecorded is thrown when recording the result of a game a second time
+	ErrorAlreadyRecorded = errors.New("the result of the game has already been recorded")
+)
+
+type Format string
+
+const (
+	FormatRoundRobin        Format = "round-robin"
+	FormatSwiss             Format = "swiss"
+	FormatKnockout          Format = "knockout"
+	FormatDoubleElimination Format = "double-elimination"
+)
+
+func ParseFormat(s string) (Format, error) {
+	for _, f := range []Format{FormatRoundRobin, FormatSwiss, FormatKnockout, FormatDoubleElimination} {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrorUnknownFormat, s)
+}
+
+// lives is how many games a player can lose before they are knocked out, 0 if players are never knocked out
+func (f Format) lives() int {
+	switch f {
+	case FormatKnockout:
+		return 1
+	case FormatDoubleElimination:
+		return 2
+	default:
+		return 0
+	}
+}
+
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusRunning  Status = "running"
+	StatusFinished Status = "finished"
+)
+
+// TimeControl is the clock the games of the tournament are played with, games are untimed without an initial time
+type TimeControl struct {
+	Initial   time.Duration `json:"initial"`
+	Increment time.Duration `json:"increment"`
+}
+
+// Pairing is a game of a round, a pairing without a black player is a bye worth a point to the white player
+type Pairing struct {
+	White  string   `json:"white"`
+	Black  string   `json:"black,omitempty"`
+	GameID string   `json:"gameId,omitempty"`
+	Result *float64 `json:"result,omitempty"`
+	// Replay is set on knockout games played because the game before it between the players was drawn
+	Replay bool `json:"replay,omitempty"`
+}
+
+func (p Pairing) IsBye() bool {
+	return p.Black == ""
+}
+
+// IsFinished reports whether the result of the pairing is known
+func (p Pairing) IsFinished() bool {
+	return p.IsBye() || p.Result != nil
+}
+
+// score gets the points the player scored in the pairing
+func (p Pairing) score(player string) float64 {
+	switch {
+	case p.IsBye():
+		return 1
+	case p.Result == nil:
+		return 0
+	case player == p.White:
+		return *p.Result
+	default:
+		return 1 - *p.Result
+	}
+}
+
+type Round struct {
+	Number   int       `json:"number"`
+	Pairings []Pairing `json:"pairings"`
+}
+
+type Tournament struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Format      Format      `json:"format"`
+	Players     []string    `json:"players"`
+	TotalRounds int         `json:"totalRounds,omitempty"`
+	TimeControl TimeControl `json:"timeControl"`
+	Status      Status      `json:"status"`
+	Rounds      []Round     `json:"rounds"`
+}
+
+// New creates a tournament between the players provided, who are seeded in the order given. The number of rounds
+// is only used by Swiss tournaments, defaulting to enough rounds to find a single winner.
+func New(id, name string, f Format, players []string, rounds int, tc TimeControl) (*Tournament, error) {
+	if _, err := ParseFormat(string(f)); err != nil {
+		return nil, err
+	}
+
+	if len(players) < 2 {
+		return nil, ErrorTooFewPlayers
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range players {
+		if p == "" || seen[p] {
+			return nil, fmt.Errorf("%w: %q", ErrorDuplicatePlayer, p)
+		}
+
+		seen[p] = true
+	}
+
+	t := &Tournament{
+		ID:          id,
+		Name:        name,
+		Format:      f,
+		Players:     append([]string{}, players...),
+		TimeControl: tc,
+		Status:      StatusPending,
+		Rounds:      []Round{},
+	}
+
+	switch f {
+	case FormatRoundRobin:
+		t.TotalRounds = len(players) - 1
+		if len(players)%2 != 0 {
+			t.TotalRounds = len(players)
+		}
+	case FormatSwiss:
+		t.TotalRounds = rounds
+		if t.TotalRounds <= 0 {
+			t.TotalRounds = int(math.Ceil(math.Log2(float64(len(players)))))
+		}
+	}
+
+	return t, nil
+}
+
+// RoundComplete reports whether every game of the current round has finished
+func (t *Tournament) RoundComplete() bool {
+	if len(t.Rounds) == 0 {
+		return true
+	}
+
+	for _, p := range t.Rounds[len(t.Rounds)-1].Pairings {
+		if !p.IsFinished() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// NextRound pairs the next round, once every game of the current round has finished
+func (t *Tournament) NextRound() (Round, error) {
+	if t.Status == StatusFinished {
+		return Round{}, ErrorFinished
+	}
+
+	if !t.RoundComplete() {
+		return Round{}, ErrorRoundInProgress
+	}
+
+	if t.isOver() {
+		t.Status = StatusFinished
+		return Round{}, ErrorFinished
+	}
+
+	var pairings []Pairing
+
+	switch t.Format {
+	case FormatRoundRobin:
+		pairings = BergerRound(t.Players, len(t.Rounds))
+	case FormatSwiss:
+		pairings = SwissRound(t)
+	default:
+		pairings = t.knockoutRound()
+	}
+
+	r := Round{Number: len(t.Rounds) + 1, Pairings: pairings}
+
+	t.Rounds = append(t.Rounds, r)
+	t.Status = StatusRunning
+
+	return r, nil
+}
+
+// Record sets the result of the game, the score is from white's point of view. A drawn knockout game is replayed
+// with the colours reversed until one of the players wins.
+func (t *Tournament) Record(gameID string, score float64) error {
+	for r := range t.Rounds {
+		for i, p := range t.Rounds[r].Pairings {
+			if p.GameID != gameID || p.IsBye() {
+				continue
+			}
+
+			if p.Result != nil {
+				return ErrorAlreadyRecorded
+			}
+
+			t.Rounds[r].Pairings[i].Result = &score
+
+			if score == 0.5 && t.Format.lives() > 0 {
+				t.Rounds[r].Pairings = append(t.Rounds[r].Pairings, Pairing{White: p.Black, Black: p.White, Replay: true})
+			}
+
+			return nil
+		}
+	}
+
+	return ErrorGameNotFound
+}
+
+// unassign removes the game from its pairing so that a new game can be started for it
+func (t *Tournament) unassign(gameID string) error {
+	for r := range t.Rounds {
+		for i, p := range t.Rounds[r].Pairings {
+			if p.GameID != gameID || p.IsBye() {
+				continue
+			}
+
+			if p.Result != nil {
+				return ErrorAlreadyRecorded
+			}
+
+			t.Rounds[r].Pairings[i].GameID = ""
+
+			return nil
+		}
+	}
+
+	return ErrorGameNotFound
+}
+
+// isOver reports whether the tournament has no more rounds to play
+func (t *Tournament) isOver() bool {
+	if t.Format.lives() == 0 {
+		return len(t.Rounds) >= t.TotalRounds
+	}
+
+	return len(t.alive()) <= 1
+}
+
+// Unstarted gets the indexes of the pairings of the current round that need a game to be started
+func (t *Tournament) Unstarted() []int {
+	if len(t.Rounds) == 0 {
+		return nil
+	}
+
+	var unstarted []int
+	for i, p := range t.Rounds[len(t.Rounds)-1].Pairings {
+		if !p.IsBye() && p.GameID == "" {
+			unstarted = append(unstarted, i)
+		}
+	}
+
+	return unstarted
+}
+
+// knockoutRound pairs the players who have not been knocked out. Players are paired in bracket order with others
+// who have lost as many games, and any player left over in a bracket plays the one left over in the next bracket.
+func (t *Tournament) knockoutRound() []Pairing {
+	if len(t.Rounds) == 0 {
+		var pairings []Pairing
+
+		order := BracketOrder(len(t.Players))
+		for i := 0; i < len(order); i += 2 {
+			a, b := order[i], order[i+1]
+
+			switch {
+			case b == -1:
+				pairings = append(pairings, Pairing{White: t.Players[a-1]})
+			case a == -1:
+				pairings = append(pairings, Pairing{White: t.Players[b-1]})
+			default:
+				pairings = append(pairings, Pairing{White: t.Players[a-1], Black: t.Players[b-1]})
+			}
+		}
+
+		return pairings
+	}
+
+	losses := t.losses()
+	bracket := t.bracket()
+
+	var pairings []Pairing
+	var leftover string
+
+	for l := 0; l < t.Format.lives(); l++ {
+		var group []string
+		for _, p := range bracket {
+			if losses[p] == l {
+				group = append(group, p)
+			}
+		}
+
+		if leftover != "" {
+			group = append([]string{leftover}, group...)
+			leftover = ""
+		}
+
+		for i := 0; i+1 < len(group); i += 2 {
+			pairings = append(pairings, Pairing{White: group[i], Black: group[i+1]})
+		}
+
+		if len(group)%2 != 0 {
+			leftover = group[len(group)-1]
+		}
+	}
+
+	if leftover != "" {
+		pairings = append(pairings, Pairing{White: leftover})
+	}
+
+	return pairings
+}
+
+// bracket gets the players who have not been knocked out in the order of the bracket
+func (t *Tournament) bracket() []string {
+	alive := make(map[string]bool)
+	for _, p := range t.alive() {
+		alive[p] = true
+	}
+
+	var bracket []string
+	for _, s := range BracketOrder(len(t.Players)) {
+		if s != -1 && alive[t.Players[s-1]] {
+			bracket = append(bracket, t.Players[s-1])
+		}
+	}
+
+	return bracket
+}
+
+// alive gets the players who have not been knocked out
+func (t *Tournament) alive() []string {
+	losses := t.losses()
+
+	var alive []string
+	for _, p := range t.Players {
+		if losses[p] < t.Format.lives() {
+			alive = append(alive, p)
+		}
+	}
+
+	return alive
+}
+
+func (t *Tournament) losses() map[string]int {
+	losses := make(map[string]int)
+
+	t.eachGame(func(p Pairing) {
+		switch *p.Result {
+		case 1:
+			losses[p.Black]++
+		case 0:
+			losses[p.White]++
+		}
+	})
+
+	return losses
+}
+
+// eachGame calls the func provided with every game that has a result
+func (t *Tournament) eachGame(fn func(p Pairing)) {
+	for _, r := range t.Rounds {
+		for _, p := range r.Pairings {
+			if !p.IsBye() && p.Result != nil {
+				fn(p)
+			}
+		}
+	}
+}
+
+func (t *Tournament) scores() map[string]float64 {
+	scores := make(map[string]float64)
+
+	for _, r := range t.Rounds {
+		for _, p := range r.Pairings {
+			if !p.IsFinished() {
+				continue
+			}
+
+			scores[p.White] += p.score(p.White)
+			if !p.IsBye() {
+				scores[p.Black] += p.score(p.Black)
+			}
+		}
+	}
+
+	return scores
+}
+
+func (t *Tournament) seeds() map[string]int {
+	seeds := make(map[string]int)
+	for i, p := range t.Players {
+		seeds[p] = i
+	}
+
+	return seeds
+}
+
+func (t *Tournament) byes() map[string]bool {
+	byes := make(map[string]bool)
+
+	for _, r := range t.Rounds {
+		for _, p := range r.Pairings {
+			if p.IsBye() {
+				byes[p.White] = true
+			}
+		}
+	}
+
+	return byes
+}
+
+// opponents gets who each player has been paired against
+func (t *Tournament) opponents() map[string]map[string]bool {
+	met := make(map[string]map[string]bool)
+
+	for _, r := range t.Rounds {
+		for _, p := range r.Pairings {
+			if p.IsBye() {
+				continue
+			}
+
+			if met[p.White] == nil {
+				met[p.White] = make(map[string]bool)
+			}
+
+			if met[p.Black] == nil {
+				met[p.Black] = make(map[string]bool)
+			}
+
+			met[p.White][p.Black] = true
+			met[p.Black][p.White] = true
+		}
+	}
+
+	return met
+}
+
+// colours gets the colours each player has played in order, true for white
+func (t *Tournament) colours() map[string][]bool {
+	colours := make(map[string][]bool)
+
+	for _, r := range t.Rounds {
+		for _, p := range r.Pairings {
+			if p.IsBye() {
+				continue
+			}
+
+			colours[p.White] = append(colours[p.White], true)
+			colours[p.Black] = append(colours[p.Black], false)
+		}
+	}
+
+	return colours
+}
+
+// Copy makes a deep copy of the tournament
+func (t *Tournament) Copy() Tournament {
+	c := *t
+	c.Players = append([]string{}, t.Players...)
+	c.Rounds = make([]Round, len(t.Rounds))
+
+	for i, r := range t.Rounds {
+		c.Rounds[i] = Round{Number: r.Number, Pairings: append([]Pairing{}, r.Pairings...)}
+	}
+
+	return c
+}
+
+// Standing is the place of a player in a tournament
+type Standing struct {
+	Rank            int     `json:"rank"`
+	Player          string  `json:"player"`
+	Score           float64 `json:"score"`
+	Buchholz        float64 `json:"buchholz"`
+	SonnebornBerger float64 `json:"sonnebornBerger"`
+	HeadToHead      float64 `json:"headToHead"`
+	Losses          int     `json:"losses,omitempty"`
+	KnockedOut      bool    `json:"knockedOut,omitempty"`
+}
+
+// Standings ranks the players by score, then the tiebreaks of the format. Round robins are decided by
+// head-to-head then Sonneborn-Berger then Buchholz, and Swiss by Buchholz then Sonneborn-Berger then head-to-head.
+// Knockout players are ranked by how far they got, those still in first.
+func (t *Tournament) Standings() []Standing {
+	scores := t.scores()
+	losses := t.losses()
+	seeds := t.seeds()
+
+	standings := make([]Standing, len(t.Players))
+	index := make(map[string]int)
+
+	for i, p := range t.Players {
+		standings[i] = Standing{Player: p, Score: scores[p]}
+		index[p] = i
+
+		if lives := t.Format.lives(); lives > 0 {
+			standings[i].Losses = losses[p]
+			standings[i].KnockedOut = losses[p] >= lives
+		}
+	}
+
+	t.eachGame(func(p Pairing) {
+		w, b := &standings[index[p.White]], &standings[index[p.Black]]
+
+		w.Buchholz += scores[p.Black]
+		b.Buchholz += scores[p.White]
+
+		w.SonnebornBerger += *p.Result * scores[p.Black]
+		b.SonnebornBerger += (1 - *p.Result) * scores[p.White]
+
+		if scores[p.White] == scores[p.Black] {
+			w.HeadToHead += *p.Result
+			b.HeadToHead += 1 - *p.Result
+		}
+	})
+
+	var tiebreaks []func(s Standing) float64
+
+	switch t.Format {
+	case FormatRoundRobin:
+		tiebreaks = []func(s Standing) float64{headToHead, sonnebornBerger, buchholz}
+	case FormatSwiss:
+		tiebreaks = []func(s Standing) float64{buchholz, sonnebornBerger, headToHead}
+	default:
+		tiebreaks = []func(s Standing) float64{
+			func(s Standing) float64 { return -float64(s.Losses) },
+			func(s Standing) float64 { return s.Score },
+		}
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+
+		if t.Format.lives() == 0 && a.Score != b.Score {
+			return a.Score > b.Score
+		}
+
+		for _, tb := range tiebreaks {
+			if tb(a) != tb(b) {
+				return tb(a) > tb(b)
+			}
+		}
+
+		return seeds[a.Player] < seeds[b.Player]
+	})
+
+	for i := range standings {
+		standings[i].Rank = i + 1
+
+		if i > 0 && sameRank(standings[i], standings[i-1], tiebreaks) && t.Format.lives() == 0 {
+			standings[i].Rank = standings[i-1].Rank
+		}
+	}
+
+	return standings
+}
+
+func sameRank(a, b Standing, tiebreaks []func(s Standing) float64) bool {
+	if a.Score != b.Score {
+		return false
+	}
+
+	for _, tb := range tiebreaks {
+		if tb(a) != tb(b) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func headToHead(s Standing) float64 {
+	return s.HeadToHead
+}
+
+func sonnebornBerger(s Standing) float64 {
+	return s.SonnebornBerger
+}
+
+func buchholz(s Standing) float64 {
+	return s.Buchholz
+}
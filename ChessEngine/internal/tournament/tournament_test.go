@@ -0,0 +1,402 @@
+package tournament_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/internal/tournament"
+)
+
+func players(n int) []string {
+	ps := make([]string, n)
+	for i := range ps {
+		ps[i] = fmt.Sprintf("p%d", i+1)
+	}
+
+	return ps
+}
+
+func TestBergerRound(t *testing.T) {
+	for _, n := range []int{3, 4, 5, 6, 8} {
+		t.Run(fmt.Sprintf("%d players", n), func(t *testing.T) {
+			ps := players(n)
+
+			rounds := n - 1
+			if n%2 != 0 {
+				rounds = n
+			}
+
+			met := make(map[[2]string]int)
+			whites := make(map[string]int)
+			byes := make(map[string]int)
+
+			for r := 0; r < rounds; r++ {
+				seen := make(map[string]bool)
+
+				for _, p := range tournament.BergerRound(ps, r) {
+					for _, q := range []string{p.White, p.Black} {
+						if q != "" && seen[q] {
+							t.Fatalf("round %d: %s is paired twice", r, q)
+						}
+
+						seen[q] = true
+					}
+
+					if p.IsBye() {
+						byes[p.White]++
+						continue
+					}
+
+					a, b := p.White, p.Black
+					if a > b {
+						a, b = b, a
+					}
+
+					met[[2]string{a, b}]++
+					whites[p.White]++
+				}
+			}
+
+			for i, a := range ps {
+				for _, b := range ps[i+1:] {
+					if met[[2]string{a, b}] != 1 {
+						t.Errorf("%s and %s met %d times, want 1", a, b, met[[2]string{a, b}])
+					}
+				}
+			}
+
+			for _, p := range ps {
+				games := n - 1
+				if diff := 2*whites[p] - games; diff > 1 || diff < -1 {
+					t.Errorf("%s had white %d times in %d games", p, whites[p], games)
+				}
+
+				if n%2 != 0 && byes[p] != 1 {
+					t.Errorf("%s had %d byes, want 1", p, byes[p])
+				}
+			}
+		})
+	}
+}
+
+func TestBracketOrder(t *testing.T) {
+	tests := []struct {
+		players int
+		want    []int
+	}{
+		{players: 2, want: []int{1, 2}},
+		{players: 4, want: []int{1, 4, 2, 3}},
+		{players: 6, want: []int{1, -1, 4, 5, 2, -1, 3, 6}},
+		{players: 8, want: []int{1, 8, 4, 5, 2, 7, 3, 6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d players", tt.players), func(t *testing.T) {
+			if got := tournament.BracketOrder(tt.players); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BracketOrder(%d) = %v, want %v", tt.players, got, tt.want)
+			}
+		})
+	}
+}
+
+// play records every game of the current round, the higher seed winning unless the result func says otherwise
+func play(t *testing.T, tr *tournament.Tournament, result func(p tournament.Pairing) float64) {
+	t.Helper()
+
+	r := &tr.Rounds[len(tr.Rounds)-1]
+
+	for i := 0; i < len(r.Pairings); i++ {
+		p := r.Pairings[i]
+		if p.IsBye() || p.Result != nil {
+			continue
+		}
+
+		id := fmt.Sprintf("r%d-%d", r.Number, i)
+		r.Pairings[i].GameID = id
+
+		if err := tr.Record(id, result(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func higherSeed(p tournament.Pairing) float64 {
+	if p.White < p.Black {
+		return 1
+	}
+
+	return 0
+}
+
+func TestSwissDoesNotRepeatPairings(t *testing.T) {
+	for _, n := range []int{6, 7, 10} {
+		t.Run(fmt.Sprintf("%d players", n), func(t *testing.T) {
+			tr, err := tournament.New("t", "Swiss", tournament.FormatSwiss, players(n), 5, tournament.TimeControl{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			met := make(map[[2]string]bool)
+			byes := make(map[string]bool)
+
+			for {
+				r, err := tr.NextRound()
+				if errors.Is(err, tournament.ErrorFinished) {
+					break
+				}
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for _, p := range r.Pairings {
+					if p.IsBye() {
+						if byes[p.White] {
+							t.Errorf("round %d: %s had a second bye", r.Number, p.White)
+						}
+
+						byes[p.White] = true
+						continue
+					}
+
+					key := [2]string{p.White, p.Black}
+					if key[0] > key[1] {
+						key[0], key[1] = key[1], key[0]
+					}
+
+					if met[key] {
+						t.Errorf("round %d: %s and %s met again", r.Number, p.White, p.Black)
+					}
+
+					met[key] = true
+				}
+
+				play(t, tr, higherSeed)
+			}
+
+			if len(tr.Rounds) != 5 {
+				t.Errorf("played %d rounds, want 5", len(tr.Rounds))
+			}
+		})
+	}
+}
+
+func TestNextRoundBeforeRoundFinished(t *testing.T) {
+	tr, err := tournament.New("t", "", tournament.FormatRoundRobin, players(4), 0, tournament.TimeControl{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tr.NextRound(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tr.NextRound(); !errors.Is(err, tournament.ErrorRoundInProgress) {
+		t.Errorf("got error %v, want %v", err, tournament.ErrorRoundInProgress)
+	}
+}
+
+func TestKnockout(t *testing.T) {
+	tests := []struct {
+		name   string
+		format tournament.Format
+		n      int
+		rounds int
+	}{
+		{name: "single elimination", format: tournament.FormatKnockout, n: 8, rounds: 3},
+		{name: "single elimination with byes", format: tournament.FormatKnockout, n: 6, rounds: 3},
+		{name: "double elimination", format: tournament.FormatDoubleElimination, n: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, err := tournament.New("t", "", tt.format, players(tt.n), 0, tournament.TimeControl{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for {
+				if _, err := tr.NextRound(); errors.Is(err, tournament.ErrorFinished) {
+					break
+				} else if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(tr.Rounds) > 2*tt.n {
+					t.Fatal("the tournament did not finish")
+				}
+
+				play(t, tr, higherSeed)
+			}
+
+			if tt.rounds != 0 && len(tr.Rounds) != tt.rounds {
+				t.Errorf("played %d rounds, want %d", len(tr.Rounds), tt.rounds)
+			}
+
+			standings := tr.Standings()
+			if standings[0].Player != "p1" || standings[0].KnockedOut {
+				t.Errorf("got winner %+v, want p1", standings[0])
+			}
+
+			for _, s := range standings[1:] {
+				if !s.KnockedOut {
+					t.Errorf("%s was not knocked out", s.Player)
+				}
+			}
+		})
+	}
+}
+
+func TestKnockoutDrawIsReplayed(t *testing.T) {
+	tr, err := tournament.New("t", "", tournament.FormatKnockout, players(2), 0, tournament.TimeControl{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := tr.NextRound()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr.Rounds[0].Pairings[0].GameID = "a"
+	if err := tr.Record("a", 0.5); err != nil {
+		t.Fatal(err)
+	}
+
+	if tr.RoundComplete() {
+		t.Fatal("the round finished with a draw")
+	}
+
+	replay := tr.Rounds[0].Pairings[1]
+	if replay.White != r.Pairings[0].Black || replay.Black != r.Pairings[0].White || !replay.Replay {
+		t.Errorf("got replay %+v, want the colours reversed", replay)
+	}
+
+	if err := tr.Record("a", 1); !errors.Is(err, tournament.ErrorAlreadyRecorded) {
+		t.Errorf("got error %v, want %v", err, tournament.ErrorAlreadyRecorded)
+	}
+}
+
+func TestStandings(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  tournament.Format
+		results map[[2]string]float64
+		want    []tournament.Standing
+	}{
+		{
+			// p1 and p2 finish level as do p3 and p4, the winners of their games are ahead on head-to-head
+			name:   "round robin head-to-head",
+			format: tournament.FormatRoundRobin,
+			results: map[[2]string]float64{
+				{"p1", "p2"}: 1, {"p1", "p3"}: 0, {"p1", "p4"}: 1,
+				{"p2", "p3"}: 1, {"p2", "p4"}: 1, {"p3", "p4"}: 0,
+			},
+			want: []tournament.Standing{
+				{Rank: 1, Player: "p1", Score: 2, Buchholz: 4, SonnebornBerger: 3, HeadToHead: 1},
+				{Rank: 2, Player: "p2", Score: 2, Buchholz: 4, SonnebornBerger: 2},
+				{Rank: 3, Player: "p4", Score: 1, Buchholz: 5, SonnebornBerger: 1, HeadToHead: 1},
+				{Rank: 4, Player: "p3", Score: 1, Buchholz: 5, SonnebornBerger: 2},
+			},
+		},
+		{
+			// Each player has a bye worth a point, so everyone is level on every tiebreak
+			name:   "all drawn",
+			format: tournament.FormatRoundRobin,
+			results: map[[2]string]float64{
+				{"p1", "p2"}: 0.5, {"p1", "p3"}: 0.5, {"p2", "p3"}: 0.5,
+			},
+			want: []tournament.Standing{
+				{Rank: 1, Player: "p1", Score: 2, Buchholz: 4, SonnebornBerger: 2, HeadToHead: 1},
+				{Rank: 1, Player: "p2", Score: 2, Buchholz: 4, SonnebornBerger: 2, HeadToHead: 1},
+				{Rank: 1, Player: "p3", Score: 2, Buchholz: 4, SonnebornBerger: 2, HeadToHead: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := len(tt.want)
+
+			tr, err := tournament.New("t", "", tt.format, players(n), 0, tournament.TimeControl{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for {
+				if _, err := tr.NextRound(); errors.Is(err, tournament.ErrorFinished) {
+					break
+				} else if err != nil {
+					t.Fatal(err)
+				}
+
+				play(t, tr, func(p tournament.Pairing) float64 {
+					if s, ok := tt.results[[2]string{p.White, p.Black}]; ok {
+						return s
+					}
+
+					return 1 - tt.results[[2]string{p.Black, p.White}]
+				})
+			}
+
+			if got := tr.Standings(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got standings\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrganiser(t *testing.T) {
+	started := make(map[string]tournament.Pairing)
+
+	o, err := tournament.NewOrganiser(func(tr tournament.Tournament, p tournament.Pairing) (string, error) {
+		id := fmt.Sprintf("g%d", len(started))
+		started[id] = p
+
+		return id, nil
+	}, tournament.WithFile(t.TempDir()+"/tournaments.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr, err := o.Create("Club", tournament.FormatRoundRobin, players(4), 0, tournament.TimeControl{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tr, err = o.Start(tr.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	for reported := make(map[string]bool); len(reported) < len(started); {
+		for id, p := range started {
+			if reported[id] {
+				continue
+			}
+
+			reported[id] = true
+
+			if err := o.Report(id, higherSeed(p)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if len(started) != 6 {
+		t.Errorf("started %d games, want 6", len(started))
+	}
+
+	if tr, err = o.Get(tr.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if tr.Status != tournament.StatusFinished {
+		t.Errorf("got status %s, want %s", tr.Status, tournament.StatusFinished)
+	}
+
+	if err := o.Report("unknown", 1); !errors.Is(err, tournament.ErrorGameNotFound) {
+		t.Errorf("got error %v, want %v", err, tournament.ErrorGameNotFound)
+	}
+}
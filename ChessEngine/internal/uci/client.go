@@ -0,0 +1,355 @@
+// Package uci talks to chess engines over the Universal Chess Interface
+package uci
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/engine"
+	"github.com/tomwatson6/chessbot/internal/move"
+)
+
+var (
+	// ErrorEngineExited is thrown when the engine process stops before answering
+	ErrorEngineExited = errors.New("the engine exited")
+	// ErrorTimeout is thrown when the engine does not answer in time
+	ErrorTimeout = errors.New("the engine did not answer in time")
+)
+
+// handshakeTimeout is how long an engine has to answer uci and isready
+const handshakeTimeout = 10 * time.Second
+
+// Client runs an engine as a local process, it is not safe for concurrent use
+type Client struct {
+	Name   string
+	Author string
+
+	cmd   *exec.Cmd
+	in    io.WriteCloser
+	lines chan string
+}
+
+// Start runs the engine at the path provided and waits for it to be ready
+func Start(path string, args ...string) (*Client, error) {
+	cmd := exec.Command(path, args...)
+
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start engine %s: %w", path, err)
+	}
+
+	c := &Client{Name: path, cmd: cmd, in: in, lines: make(chan string, 64)}
+
+	go func() {
+		defer close(c.lines)
+
+		s := bufio.NewScanner(out)
+		for s.Scan() {
+			c.lines <- s.Text()
+		}
+	}()
+
+	if err := c.send("uci"); err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	err = c.await("uciok", handshakeTimeout, func(line string) {
+		switch {
+		case strings.HasPrefix(line, "id name "):
+			c.Name = strings.TrimPrefix(line, "id name ")
+		case strings.HasPrefix(line, "id author "):
+			c.Author = strings.TrimPrefix(line, "id author ")
+		}
+	})
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	if err := c.IsReady(); err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// SetOption sets an option of the engine, e.g. Hash or Threads
+func (c *Client) SetOption(name, value string) error {
+	return c.send(fmt.Sprintf("setoption name %s value %s", name, value))
+}
+
+// NewGame tells the engine the next position is from a different game
+func (c *Client) NewGame() error {
+	if err := c.send("ucinewgame"); err != nil {
+		return err
+	}
+
+	return c.IsReady()
+}
+
+// IsReady waits for the engine to finish what it is doing
+func (c *Client) IsReady() error {
+	if err := c.send("isready"); err != nil {
+		return err
+	}
+
+	return c.await("readyok", handshakeTimeout, nil)
+}
+
+// Result is the best move found by the engine, along with the last info it sent
+type Result struct {
+	Move      move.Move
+	Promotion rune
+	Ponder    string
+	engine.Info
+}
+
+// Go searches the position reached by playing the moves, in UCI notation, from the FEN provided. The search is
+// stopped if the context is cancelled, in which case the best move found so far is returned.
+func (c *Client) Go(ctx context.Context, fen string, moves []string, l engine.Limits, onInfo func(engine.Info)) (Result, error) {
+	position := "position fen " + fen
+	if len(moves) > 0 {
+		position += " moves " + strings.Join(moves, " ")
+	}
+
+	if err := c.send(position); err != nil {
+		return Result{}, err
+	}
+
+	if err := c.send(goCommand(l)); err != nil {
+		return Result{}, err
+	}
+
+	var res Result
+	stopped := false
+
+	for {
+		var line string
+		var ok bool
+
+		select {
+		case line, ok = <-c.lines:
+		case <-ctx.Done():
+			if !stopped {
+				stopped = true
+				if err := c.send("stop"); err != nil {
+					return Result{}, err
+				}
+			}
+
+			select {
+			case line, ok = <-c.lines:
+			case <-time.After(handshakeTimeout):
+				return Result{}, ErrorTimeout
+			}
+		}
+
+		if !ok {
+			return Result{}, ErrorEngineExited
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "info":
+			if info, ok := ParseInfo(fields[1:]); ok {
+				res.Info = info
+				if onInfo != nil {
+					onInfo(info)
+				}
+			}
+		case "bestmove":
+			if len(fields) < 2 {
+				return Result{}, fmt.Errorf("invalid bestmove: %q", line)
+			}
+
+			m, promotion, err := move.ParseUCI(fields[1])
+			if err != nil {
+				return Result{}, err
+			}
+
+			res.Move = m
+			res.Promotion = promotion
+
+			if len(fields) >= 4 && fields[2] == "ponder" {
+				res.Ponder = fields[3]
+			}
+
+			return res, nil
+		}
+	}
+}
+
+// goCommand builds the go command for the limits of a search
+func goCommand(l engine.Limits) string {
+	parts := []string{"go"}
+
+	if l.Infinite {
+		return "go infinite"
+	}
+
+	if l.WhiteTime > 0 || l.BlackTime > 0 {
+		parts = append(parts,
+			"wtime", strconv.FormatInt(l.WhiteTime.Milliseconds(), 10),
+			"btime", strconv.FormatInt(l.BlackTime.Milliseconds(), 10),
+			"winc", strconv.FormatInt(l.WhiteIncrement.Milliseconds(), 10),
+			"binc", strconv.FormatInt(l.BlackIncrement.Milliseconds(), 10),
+		)
+	}
+
+	if l.MoveTime > 0 {
+		parts = append(parts, "movetime", strconv.FormatInt(l.MoveTime.Milliseconds(), 10))
+	}
+
+	if l.Depth > 0 {
+		parts = append(parts, "depth", strconv.Itoa(l.Depth))
+	}
+
+	if l.Nodes > 0 {
+		parts = append(parts, "nodes", strconv.FormatInt(l.Nodes, 10))
+	}
+
+	// The moves to search come last, as there can be any number of them
+	if len(l.SearchMoves) > 0 {
+		parts = append(parts, "searchmoves")
+		for _, m := range l.SearchMoves {
+			parts = append(parts, m.UCI())
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// ParseInfo reads the fields of an info line after "info", false if the line has no score, e.g. currmove updates
+func ParseInfo(fields []string) (engine.Info, bool) {
+	var info engine.Info
+	scored := false
+
+	for i := 0; i < len(fields); i++ {
+		next := func() int64 {
+			if i+1 >= len(fields) {
+				return 0
+			}
+
+			i++
+			n, _ := strconv.ParseInt(fields[i], 10, 64)
+
+			return n
+		}
+
+		switch fields[i] {
+		case "multipv":
+			info.MultiPV = int(next())
+		case "depth":
+			info.Depth = int(next())
+		case "nodes":
+			info.Nodes = next()
+		case "time":
+			info.Time = time.Duration(next()) * time.Millisecond
+		case "score":
+			if i+2 >= len(fields) {
+				continue
+			}
+
+			kind := fields[i+1]
+			i++
+			n := next()
+			scored = true
+
+			switch kind {
+			case "cp":
+				info.Score = engine.Score(n)
+			case "mate":
+				info.Score = engine.MateScore(int(n))
+			}
+		case "pv":
+			for _, s := range fields[i+1:] {
+				m, _, err := move.ParseUCI(s)
+				if err != nil {
+					break
+				}
+
+				info.PV = append(info.PV, m)
+			}
+
+			i = len(fields)
+		case "string":
+			i = len(fields)
+		}
+	}
+
+	return info, scored
+}
+
+// Close asks the engine to quit, killing it if it has not exited shortly after
+func (c *Client) Close() error {
+	_ = c.send("quit")
+	_ = c.in.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(time.Second):
+		_ = c.cmd.Process.Kill()
+		return <-done
+	}
+}
+
+func (c *Client) send(cmd string) error {
+	if _, err := io.WriteString(c.in, cmd+"\n"); err != nil {
+		return fmt.Errorf("failed to send %q to the engine: %w", cmd, err)
+	}
+
+	return nil
+}
+
+// await reads lines until the one provided, calling fn with each line before it
+func (c *Client) await(want string, timeout time.Duration, fn func(line string)) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case line, ok := <-c.lines:
+			if !ok {
+				return ErrorEngineExited
+			}
+
+			if strings.TrimSpace(line) == want {
+				return nil
+			}
+
+			if fn != nil {
+				fn(line)
+			}
+		case <-timer.C:
+			return fmt.Errorf("%w: waiting for %s", ErrorTimeout, want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package uci_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/engine"
+	"github.com/tomwatson6/chessbot/internal/uci"
+)
+
+// TestMain runs the test binary as a fake engine when it is started by a test as one
+func TestMain(m *testing.M) {
+	if os.Getenv("UCI_FAKE_ENGINE") == "1" {
+		fakeEngine()
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+func fakeEngine() {
+	s := bufio.NewScanner(os.Stdin)
+
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "uci":
+			fmt.Println("id name Fake")
+			fmt.Println("id author Tests")
+			fmt.Println("uciok")
+		case "isready":
+			fmt.Println("readyok")
+		case "go":
+			fmt.Println("info depth 1 currmove e2e4")
+			fmt.Println("info depth 2 score cp 12 nodes 40 time 3 pv e2e4 e7e5")
+			fmt.Println("bestmove e2e4 ponder e7e5")
+		case "quit":
+			return
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	t.Setenv("UCI_FAKE_ENGINE", "1")
+
+	c, err := uci.Start(os.Args[0], "-test.run=^$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if c.Name != "Fake" || c.Author != "Tests" {
+		t.Errorf("got engine %q by %q, want Fake by Tests", c.Name, c.Author)
+	}
+
+	if err := c.NewGame(); err != nil {
+		t.Fatal(err)
+	}
+
+	var infos []engine.Info
+
+	res, err := c.Go(context.Background(), chess.StartingFEN, nil, engine.Limits{Depth: 2}, func(i engine.Info) {
+		infos = append(infos, i)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Move.UCI() != "e2e4" || res.Ponder != "e7e5" {
+		t.Errorf("got best move %s pondering %s, want e2e4 pondering e7e5", res.Move.UCI(), res.Ponder)
+	}
+
+	if len(infos) != 1 || res.Depth != 2 || res.Score != 12 || len(res.PV) != 2 {
+		t.Errorf("got info %+v from %d updates, want depth 2 scoring 12 with a pv of 2 moves", res.Info, len(infos))
+	}
+}
+
+func TestParseInfo(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    engine.Score
+		depth   int
+		multipv int
+	}{
+		{line: "depth 12 seldepth 18 score cp -35 nodes 1000 pv d7d5", want: -35, depth: 12},
+		{line: "depth 8 score mate 3 pv a1a8", want: engine.Mate - 5, depth: 8},
+		{line: "depth 9 score mate -2 pv g8h8", want: -engine.Mate + 4, depth: 9},
+		{line: "depth 10 score cp 20 lowerbound nodes 5", want: 20, depth: 10},
+		{line: "depth 7 multipv 2 score cp 15 pv e2e4", want: 15, depth: 7, multipv: 2},
+	}
+
+	for _, tt := range tests {
+		info, ok := uci.ParseInfo(strings.Fields(tt.line))
+		if !ok || info.Score != tt.want || info.Depth != tt.depth || info.MultiPV != tt.multipv {
+			t.Errorf("ParseInfo(%q) = %+v, %v, want depth %d scoring %s", tt.line, info, ok, tt.depth, tt.want)
+		}
+	}
+}
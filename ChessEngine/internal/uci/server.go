@@ -0,0 +1,445 @@
+package uci
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/engine"
+	"github.com/tomwatson6/chessbot/internal/piece"
+	"github.com/tomwatson6/chessbot/internal/polyglot"
+)
+
+// The bounds of the options of the server
+const (
+	MaxThreads = 512
+	MaxHash    = 4096
+	MaxMultiPV = 256
+)
+
+// Server plays the engine over the Universal Chess Interface, reading the commands of a GUI and writing the replies
+// of the engine. Searches run in the background so that the GUI can stop them. A search started with go ponder
+// searches the position after the move the engine expects on the time of its opponent, going on to play the move it
+// finds on ponderhit, or being stopped by the GUI when the opponent plays another move.
+type Server struct {
+	name    string
+	author  string
+	opts    []engine.Option
+	book    *polyglot.Book
+	threads int
+	hash    int
+	multiPV int
+
+	position chess.Chess
+
+	mu  sync.Mutex
+	out io.Writer
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	// hit is closed on ponderhit, nil unless the search is pondering
+	hit chan struct{}
+}
+
+type ServerOption func(s *Server)
+
+// WithID sets the name and author the server gives the GUI
+func WithID(name, author string) ServerOption {
+	return func(s *Server) {
+		s.name, s.author = name, author
+	}
+}
+
+// WithEngineOptions sets the options every engine of the server is made with, such as a tablebase. The options set
+// by the GUI are applied after them.
+func WithEngineOptions(opts ...engine.Option) ServerOption {
+	return func(s *Server) {
+		s.opts = opts
+	}
+}
+
+// WithBook plays moves from the opening book while it has them, before searching
+func WithBook(b *polyglot.Book) ServerOption {
+	return func(s *Server) {
+		s.book = b
+	}
+}
+
+func NewServer(out io.Writer, opts ...ServerOption) *Server {
+	s := &Server{
+		name:     "chessbot",
+		author:   "the chessbot authors",
+		threads:  1,
+		hash:     engine.DefaultHash,
+		multiPV:  1,
+		position: chess.New(colour.White),
+		out:      out,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Run reads commands until quit or the end of the input, stopping any search before it returns
+func (s *Server) Run(in io.Reader) error {
+	defer s.stop()
+
+	sc := bufio.NewScanner(in)
+	for sc.Scan() {
+		if quit := s.handle(strings.Fields(sc.Text())); quit {
+			return nil
+		}
+	}
+
+	return sc.Err()
+}
+
+// handle runs a command, reporting whether it was quit. Unknown commands are ignored, as the protocol requires.
+func (s *Server) handle(fields []string) bool {
+	if len(fields) == 0 {
+		return false
+	}
+
+	switch fields[0] {
+	case "uci":
+		s.send("id name " + s.name)
+		s.send("id author " + s.author)
+		s.send(fmt.Sprintf("option name Threads type spin default 1 min 1 max %d", MaxThreads))
+		s.send(fmt.Sprintf("option name Hash type spin default %d min 1 max %d", engine.DefaultHash, MaxHash))
+		s.send(fmt.Sprintf("option name MultiPV type spin default 1 min 1 max %d", MaxMultiPV))
+		s.send("option name Ponder type check default false")
+		s.send("uciok")
+	case "isready":
+		s.send("readyok")
+	case "setoption":
+		s.setOption(fields[1:])
+	case "ucinewgame":
+		s.stop()
+		s.position = chess.New(colour.White)
+	case "position":
+		s.stop()
+
+		c, err := parsePosition(fields[1:])
+		if err != nil {
+			s.send("info string " + err.Error())
+			return false
+		}
+
+		s.position = c
+	case "go":
+		s.stop()
+		s.goSearch(fields[1:])
+	case "ponderhit":
+		s.ponderHit()
+	case "stop":
+		s.stop()
+	case "quit":
+		return true
+	}
+
+	return false
+}
+
+// setOption sets the option named before value to the number after it, names can have spaces in them
+func (s *Server) setOption(fields []string) {
+	var name, value []string
+	var into *[]string
+
+	for _, f := range fields {
+		switch f {
+		case "name":
+			into = &name
+		case "value":
+			into = &value
+		default:
+			if into != nil {
+				*into = append(*into, f)
+			}
+		}
+	}
+
+	// The GUI only says whether it will ponder, which it does with go ponder, so there is nothing to set
+	if strings.EqualFold(strings.Join(name, " "), "ponder") {
+		return
+	}
+
+	n, err := strconv.Atoi(strings.Join(value, " "))
+	if err != nil {
+		s.send(fmt.Sprintf("info string invalid value for %s: %s", strings.Join(name, " "), strings.Join(value, " ")))
+		return
+	}
+
+	clamp := func(n, max int) int {
+		if n < 1 {
+			return 1
+		}
+
+		if n > max {
+			return max
+		}
+
+		return n
+	}
+
+	switch strings.ToLower(strings.Join(name, " ")) {
+	case "threads":
+		s.threads = clamp(n, MaxThreads)
+	case "hash":
+		s.hash = clamp(n, MaxHash)
+	case "multipv":
+		s.multiPV = clamp(n, MaxMultiPV)
+	default:
+		s.send("info string unknown option " + strings.Join(name, " "))
+	}
+}
+
+// goSearch starts searching the position with the limits of the go command, sending bestmove once it is done
+func (s *Server) goSearch(fields []string) {
+	l, ponder, err := parseGo(s.position, fields)
+	if err != nil {
+		s.send("info string " + err.Error())
+		return
+	}
+
+	l.MultiPV = s.multiPV
+
+	if ponder {
+		s.hit = make(chan struct{})
+		l.Ponder = s.hit
+	}
+
+	opts := append(append([]engine.Option{}, s.opts...), engine.WithThreads(s.threads), engine.WithHash(s.hash))
+	e := engine.New(opts...)
+	c := s.position.Copy()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	s.cancel, s.done = cancel, done
+
+	if s.book != nil {
+		if m, ok := s.book.Probe(c); ok {
+			go func() {
+				defer close(done)
+
+				// A book move found while pondering is still held until the ponderhit or stop
+				if l.Ponder != nil {
+					select {
+					case <-l.Ponder:
+					case <-ctx.Done():
+					}
+				}
+
+				s.send("bestmove " + c.UCI(m.Move, m.Promotion))
+			}()
+
+			return
+		}
+	}
+
+	go func() {
+		defer close(done)
+
+		res, err := e.Search(ctx, c, l, func(info engine.Info) {
+			s.send(infoLine(c, info))
+		})
+		if err != nil {
+			// There is no move to play, which the protocol writes as the null move
+			s.send("bestmove 0000")
+			return
+		}
+
+		best := "bestmove " + c.UCI(res.Move, piece.PieceTypeQueen)
+
+		next := c.Copy()
+		if _, err := next.MakeMoveWithPromotion(res.Move, piece.PieceTypeQueen); err == nil && len(res.PV) > 1 {
+			best += " ponder " + next.UCI(res.PV[1], piece.PieceTypeQueen)
+		}
+
+		s.send(best)
+	}()
+}
+
+// ponderHit tells the search pondering that the opponent played the move expected, so that it goes on to search
+// the position as if it had been started with the limits of its go command
+func (s *Server) ponderHit() {
+	if s.hit == nil {
+		return
+	}
+
+	close(s.hit)
+	s.hit = nil
+}
+
+// stop stops the search, if there is one, waiting for it to send its best move
+func (s *Server) stop() {
+	if s.cancel == nil {
+		return
+	}
+
+	s.cancel()
+	<-s.done
+
+	s.cancel, s.done, s.hit = nil, nil, nil
+}
+
+func (s *Server) send(line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fmt.Fprintln(s.out, line)
+}
+
+// parsePosition reads the position command, startpos or fen followed by the six fields of the FEN, then the moves
+// played from it
+func parsePosition(fields []string) (chess.Chess, error) {
+	if len(fields) == 0 {
+		return chess.Chess{}, fmt.Errorf("position needs startpos or fen")
+	}
+
+	var c chess.Chess
+	var err error
+	rest := fields[1:]
+
+	switch fields[0] {
+	case "startpos":
+		c = chess.New(colour.White)
+	case "fen":
+		end := len(rest)
+		for i, f := range rest {
+			if f == "moves" {
+				end = i
+				break
+			}
+		}
+
+		if c, err = chess.FromFEN(strings.Join(rest[:end], " ")); err != nil {
+			return chess.Chess{}, err
+		}
+
+		rest = rest[end:]
+	default:
+		return chess.Chess{}, fmt.Errorf("unknown position %s", fields[0])
+	}
+
+	if len(rest) == 0 || rest[0] != "moves" {
+		return c, nil
+	}
+
+	for _, s := range rest[1:] {
+		m, promotion, err := c.ParseUCI(s)
+		if err != nil {
+			return chess.Chess{}, err
+		}
+
+		if _, err := c.MakeMoveWithPromotion(m, promotion); err != nil {
+			return chess.Chess{}, err
+		}
+	}
+
+	return c, nil
+}
+
+// parseGo reads the limits of the go command, the moves to search being checked against the position, and whether
+// the search is to ponder
+func parseGo(c chess.Chess, fields []string) (engine.Limits, bool, error) {
+	var l engine.Limits
+	ponder := false
+
+	for i := 0; i < len(fields); i++ {
+		next := func() (int64, error) {
+			if i+1 >= len(fields) {
+				return 0, fmt.Errorf("%s needs a value", fields[i])
+			}
+
+			i++
+
+			return strconv.ParseInt(fields[i], 10, 64)
+		}
+
+		var n int64
+		var err error
+
+		switch fields[i] {
+		case "infinite":
+			l.Infinite = true
+		case "ponder":
+			ponder = true
+		case "searchmoves":
+			for i+1 < len(fields) {
+				m, _, err := c.ParseUCI(fields[i+1])
+				if err != nil {
+					break
+				}
+
+				l.SearchMoves = append(l.SearchMoves, m)
+				i++
+			}
+		case "depth":
+			n, err = next()
+			l.Depth = int(n)
+		case "nodes":
+			n, err = next()
+			l.Nodes = n
+		case "movetime":
+			n, err = next()
+			l.MoveTime = time.Duration(n) * time.Millisecond
+		case "wtime":
+			n, err = next()
+			l.WhiteTime = time.Duration(n) * time.Millisecond
+		case "btime":
+			n, err = next()
+			l.BlackTime = time.Duration(n) * time.Millisecond
+		case "winc":
+			n, err = next()
+			l.WhiteIncrement = time.Duration(n) * time.Millisecond
+		case "binc":
+			n, err = next()
+			l.BlackIncrement = time.Duration(n) * time.Millisecond
+		case "movestogo", "mate":
+			_, err = next()
+		}
+
+		if err != nil {
+			return engine.Limits{}, false, err
+		}
+	}
+
+	return l, ponder, nil
+}
+
+// infoLine writes the info of a search as the protocol does, with the moves of the principal variation in UCI
+func infoLine(c chess.Chess, info engine.Info) string {
+	score := fmt.Sprintf("cp %d", info.Score)
+	if info.Score.IsMate() {
+		score = fmt.Sprintf("mate %d", info.Score.MateIn())
+	}
+
+	ms := info.Time.Milliseconds()
+	nps := int64(0)
+	if ms > 0 {
+		nps = info.Nodes * 1000 / ms
+	}
+
+	line := fmt.Sprintf("info depth %d multipv %d score %s nodes %d nps %d time %d pv", info.Depth, info.MultiPV, score, info.Nodes, nps, ms)
+
+	pos := c.Copy()
+	for _, m := range info.PV {
+		line += " " + pos.UCI(m, piece.PieceTypeQueen)
+
+		if _, err := pos.MakeMoveWithPromotion(m, piece.PieceTypeQueen); err != nil {
+			break
+		}
+	}
+
+	return line
+}
@@ -0,0 +1,202 @@
+package uci_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomwatson6/chessbot/internal/chess"
+	"github.com/tomwatson6/chessbot/internal/colour"
+	"github.com/tomwatson6/chessbot/internal/move"
+	"github.com/tomwatson6/chessbot/internal/polyglot"
+	"github.com/tomwatson6/chessbot/internal/uci"
+)
+
+// serve runs a server on pipes, getting a function that sends it a command and the lines it writes
+func serve(t *testing.T, opts ...uci.ServerOption) (func(string), <-chan string) {
+	t.Helper()
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- uci.NewServer(outW, opts...).Run(inR)
+		outW.Close()
+	}()
+
+	lines := make(chan string, 1024)
+	go func() {
+		defer close(lines)
+
+		s := bufio.NewScanner(outR)
+		for s.Scan() {
+			lines <- s.Text()
+		}
+	}()
+
+	t.Cleanup(func() {
+		fmt.Fprintln(inW, "quit")
+		inW.Close()
+
+		if err := <-done; err != nil {
+			t.Errorf("unexpected error running server: %v", err)
+		}
+	})
+
+	return func(cmd string) { fmt.Fprintln(inW, cmd) }, lines
+}
+
+// await reads lines until one starts with the prefix, failing the test if none does in time
+func await(t *testing.T, lines <-chan string, prefix string) (string, []string) {
+	t.Helper()
+
+	var before []string
+	timeout := time.After(10 * time.Second)
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatalf("server stopped before writing %q, wrote %v", prefix, before)
+			}
+
+			if strings.HasPrefix(line, prefix) {
+				return line, before
+			}
+
+			before = append(before, line)
+		case <-timeout:
+			t.Fatalf("server did not write %q in time, wrote %v", prefix, before)
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	send, lines := serve(t)
+
+	send("uci")
+	_, options := await(t, lines, "uciok")
+
+	if !strings.Contains(strings.Join(options, "\n"), "option name Threads type spin") {
+		t.Errorf("want the Threads option, got %v", options)
+	}
+
+	send("setoption name Threads value 2")
+	send("setoption name MultiPV value 2")
+	send("isready")
+	await(t, lines, "readyok")
+
+	// The rook mates on the back rank
+	send("position fen 6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1")
+	send("go depth 2")
+
+	best, infos := await(t, lines, "bestmove")
+	if best != "bestmove a1a8" {
+		t.Errorf("got %q, want bestmove a1a8", best)
+	}
+
+	if all := strings.Join(infos, "\n"); !strings.Contains(all, "depth 2 multipv 1 score mate 1") || !strings.Contains(all, "depth 2 multipv 2") {
+		t.Errorf("want two lines with the mate first, got %v", infos)
+	}
+
+	send("setoption name MultiPV value 1")
+	send("position startpos moves e2e4 e7e5")
+	send("go infinite")
+	await(t, lines, "info depth 2")
+
+	send("stop")
+	if best, _ := await(t, lines, "bestmove"); !strings.Contains(best, "ponder") {
+		t.Errorf("want a move to ponder on once stopped, got %q", best)
+	}
+
+	send("position startpos moves e2e5")
+	if line, _ := await(t, lines, "info string"); !strings.Contains(line, "e2e5") {
+		t.Errorf("want the illegal move reported, got %q", line)
+	}
+}
+
+func TestServer_Ponder(t *testing.T) {
+	send, lines := serve(t)
+
+	send("uci")
+	if _, options := await(t, lines, "uciok"); !strings.Contains(strings.Join(options, "\n"), "option name Ponder type check") {
+		t.Errorf("want the Ponder option, got %v", options)
+	}
+
+	send("setoption name Ponder value true")
+	send("isready")
+	if _, before := await(t, lines, "readyok"); len(before) > 0 {
+		t.Errorf("want the Ponder option to be set quietly, got %v", before)
+	}
+
+	// The engine ponders on the reply it expects to its move, holding its move until the ponderhit
+	send("position startpos moves e2e4 e7e5")
+	send("go ponder depth 1")
+	await(t, lines, "info depth 1")
+
+	select {
+	case line := <-lines:
+		t.Fatalf("got %q before the ponderhit", line)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	send("ponderhit")
+	await(t, lines, "bestmove")
+
+	// When the opponent plays another move the GUI stops the search, the move found being thrown away
+	send("go ponder depth 1")
+	await(t, lines, "info depth 1")
+
+	send("stop")
+	await(t, lines, "bestmove")
+
+	// A ponderhit without a search pondering is ignored
+	send("ponderhit")
+	send("isready")
+	if _, before := await(t, lines, "readyok"); len(before) > 0 {
+		t.Errorf("want nothing written for the ponderhit, got %v", before)
+	}
+}
+
+func TestServer_Book(t *testing.T) {
+	start := chess.New(colour.White)
+	d4 := move.Move{From: move.Position{File: 3, Rank: 1}, To: move.Position{File: 3, Rank: 3}}
+
+	book := polyglot.New([]polyglot.Entry{{Key: polyglot.Key(start), Move: polyglot.EncodeMove(start, d4, 0), Weight: 1}})
+
+	send, lines := serve(t, uci.WithBook(book))
+
+	// The search is far deeper than could be finished in the test, so the move must come from the book
+	send("position startpos")
+	send("go depth 20")
+
+	if best, _ := await(t, lines, "bestmove"); best != "bestmove d2d4" {
+		t.Errorf("got %q, want the book move bestmove d2d4", best)
+	}
+
+	// A book move is held until the ponderhit like any other
+	send("go ponder depth 20")
+
+	select {
+	case line := <-lines:
+		t.Fatalf("got %q before the ponderhit", line)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	send("ponderhit")
+	if best, _ := await(t, lines, "bestmove"); best != "bestmove d2d4" {
+		t.Errorf("got %q, want the book move bestmove d2d4", best)
+	}
+
+	// Out of the book the engine searches
+	send("position fen 6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1")
+	send("go depth 2")
+
+	if best, _ := await(t, lines, "bestmove"); best != "bestmove a1a8" {
+		t.Errorf("got %q, want bestmove a1a8", best)
+	}
+}
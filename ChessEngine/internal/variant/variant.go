@@ -0,0 +1,48 @@
+package variant
+
+import "fmt"
+
+type Variant byte
+
+const (
+	Standard Variant = iota
+	Antichess
+	Horde
+)
+
+// Parse converts the name of a variant into the Variant it represents
+func Parse(s string) (Variant, error) {
+	switch s {
+	case "", "standard", "Standard":
+		return Standard, nil
+	case "antichess", "Antichess", "giveaway", "Giveaway":
+		return Antichess, nil
+	case "horde", "Horde":
+		return Horde, nil
+	default:
+		return Standard, fmt.Errorf("unknown variant: %s", s)
+	}
+}
+
+func (v Variant) String() string {
+	switch v {
+	case Standard:
+		return "Standard"
+	case Antichess:
+		return "Antichess"
+	case Horde:
+		return "Horde"
+	default:
+		return "Unknown"
+	}
+}
+
+// HasRoyalKing reports whether the king is subject to check rules in the variant
+func (v Variant) HasRoyalKing() bool {
+	return v != Antichess
+}
+
+// CanPromoteToKing reports whether a pawn may be promoted to a king
+func (v Variant) CanPromoteToKing() bool {
+	return v == Antichess
+}
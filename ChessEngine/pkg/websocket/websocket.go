@@ -0,0 +1,403 @@
+// Package websocket is a minimal implementation of the WebSocket protocol (RFC 6455) using only the standard library
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+const (
+	OpContinuation byte = 0x0
+	OpText         byte = 0x1
+	OpBinary       byte = 0x2
+	OpClose        byte = 0x8
+	OpPing         byte = 0x9
+	OpPong         byte = 0xA
+)
+
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseMessageTooLarge = 1009
+)
+
+// MaxMessageSize is the largest message that will be read from a connection
+const MaxMessageSize = 1 << 20
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var (
+	// ErrorNotWebSocket is returned when upgrading a request that is not a WebSocket handshake
+	ErrorNotWebSocket = errors.New("the request is not a websocket handshake")
+	// ErrorBadHandshake is returned when the server does not accept the handshake of the client
+	ErrorBadHandshake = errors.New("the server did not accept the websocket handshake")
+	// ErrorProtocol is returned when a frame is received that breaks the WebSocket protocol
+	ErrorProtocol = errors.New("websocket protocol error")
+	// ErrorMessageTooLarge is returned when a message is larger than MaxMessageSize
+	ErrorMessageTooLarge = errors.New("websocket message too large")
+	// ErrorClosed is returned when the connection has been closed
+	ErrorClosed = errors.New("websocket connection closed")
+)
+
+// Conn is a WebSocket connection, reads must come from one goroutine but writes are safe for concurrent use
+type Conn struct {
+	conn     net.Conn
+	r        *bufio.Reader
+	isClient bool
+
+	wmu    sync.Mutex
+	closed bool
+}
+
+// Upgrade completes the handshake of a WebSocket request, taking over the connection of the request
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	if r.Method != http.MethodGet ||
+		!headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") {
+		http.Error(w, ErrorNotWebSocket.Error(), http.StatusBadRequest)
+		return nil, ErrorNotWebSocket
+	}
+
+	if r.Header.Get("Sec-Websocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported websocket version", http.StatusUpgradeRequired)
+		return nil, ErrorNotWebSocket
+	}
+
+	key := r.Header.Get("Sec-Websocket-Key")
+	if key == "" {
+		http.Error(w, "missing websocket key", http.StatusBadRequest)
+		return nil, ErrorNotWebSocket
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, fmt.Errorf("response writer does not support hijacking")
+	}
+
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n"
+
+	if _, err := conn.Write([]byte(response)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &Conn{conn: conn, r: rw.Reader}, nil
+}
+
+// Dial opens a client connection to the ws:// url provided
+func Dial(rawURL string) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "ws" {
+		return nil, fmt.Errorf("unsupported websocket scheme: %s", u.Scheme)
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		host += ":80"
+	}
+
+	conn, err := net.Dial("tcp", host)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req := "GET " + u.RequestURI() + " HTTP/1.1\r\n" +
+		"Host: " + u.Host + "\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: " + key + "\r\n" +
+		"Sec-WebSocket-Version: 13\r\n\r\n"
+
+	if _, err := conn.Write([]byte(req)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	r := bufio.NewReader(conn)
+
+	resp, err := http.ReadResponse(r, &http.Request{Method: http.MethodGet})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-Websocket-Accept") != acceptKey(key) {
+		conn.Close()
+		return nil, fmt.Errorf("%w: status %d", ErrorBadHandshake, resp.StatusCode)
+	}
+
+	return &Conn{conn: conn, r: r, isClient: true}, nil
+}
+
+// ReadMessage reads the next text or binary message, answering pings and closes as they arrive
+func (c *Conn) ReadMessage() (byte, []byte, error) {
+	var op byte
+	var message []byte
+
+	for {
+		fin, frameOp, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch frameOp {
+		case OpPing:
+			if err := c.writeFrame(OpPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case OpPong:
+			continue
+		case OpClose:
+			code := CloseNormal
+			if len(payload) >= 2 {
+				code = int(binary.BigEndian.Uint16(payload))
+			}
+
+			c.closeWithCode(code)
+
+			return 0, nil, io.EOF
+		case OpText, OpBinary:
+			if message != nil {
+				c.closeWithCode(CloseProtocolError)
+				return 0, nil, ErrorProtocol
+			}
+
+			op = frameOp
+			message = payload
+		case OpContinuation:
+			if message == nil {
+				c.closeWithCode(CloseProtocolError)
+				return 0, nil, ErrorProtocol
+			}
+
+			message = append(message, payload...)
+		default:
+			c.closeWithCode(CloseProtocolError)
+			return 0, nil, ErrorProtocol
+		}
+
+		if len(message) > MaxMessageSize {
+			c.closeWithCode(CloseMessageTooLarge)
+			return 0, nil, ErrorMessageTooLarge
+		}
+
+		if fin {
+			return op, message, nil
+		}
+	}
+}
+
+// WriteMessage writes the data provided as a single frame with the opcode provided
+func (c *Conn) WriteMessage(op byte, data []byte) error {
+	return c.writeFrame(op, data)
+}
+
+func (c *Conn) ReadJSON(v any) error {
+	_, data, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func (c *Conn) WriteJSON(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return c.WriteMessage(OpText, data)
+}
+
+// Close sends a close frame and closes the underlying connection
+func (c *Conn) Close() error {
+	return c.closeWithCode(CloseNormal)
+}
+
+func (c *Conn) closeWithCode(code int) error {
+	payload := make([]byte, 2)
+	binary.BigEndian.PutUint16(payload, uint16(code))
+
+	// The close frame is best effort, the connection may already be gone
+	_ = c.writeFrame(OpClose, payload)
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	c.closed = true
+
+	return c.conn.Close()
+}
+
+func (c *Conn) readFrame() (bool, byte, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(c.r, header); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin := header[0]&0x80 != 0
+	op := header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, ErrorProtocol
+	}
+
+	// Clients must mask their frames and servers must not
+	if masked == c.isClient {
+		return false, 0, nil, ErrorProtocol
+	}
+
+	switch length {
+	case 126:
+		ext := make([]byte, 2)
+		if _, err := io.ReadFull(c.r, ext); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		if _, err := io.ReadFull(c.r, ext); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext)
+	}
+
+	if op >= OpClose && (length > 125 || !fin) {
+		return false, 0, nil, ErrorProtocol
+	}
+
+	if length > MaxMessageSize {
+		return false, 0, nil, ErrorMessageTooLarge
+	}
+
+	var mask []byte
+	if masked {
+		mask = make([]byte, 4)
+		if _, err := io.ReadFull(c.r, mask); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return false, 0, nil, err
+	}
+
+	if masked {
+		maskBytes(mask, payload)
+	}
+
+	return fin, op, payload, nil
+}
+
+func (c *Conn) writeFrame(op byte, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	if c.closed {
+		return ErrorClosed
+	}
+
+	frame := []byte{0x80 | op}
+
+	maskBit := byte(0)
+	if c.isClient {
+		maskBit = 0x80
+	}
+
+	switch {
+	case len(payload) <= 125:
+		frame = append(frame, maskBit|byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		frame = append(frame, maskBit|126, 0, 0)
+		binary.BigEndian.PutUint16(frame[2:], uint16(len(payload)))
+	default:
+		frame = append(frame, maskBit|127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(frame[2:], uint64(len(payload)))
+	}
+
+	data := payload
+
+	if c.isClient {
+		mask := make([]byte, 4)
+		if _, err := rand.Read(mask); err != nil {
+			return err
+		}
+
+		data = make([]byte, len(payload))
+		copy(data, payload)
+		maskBytes(mask, data)
+
+		frame = append(frame, mask...)
+	}
+
+	frame = append(frame, data...)
+
+	_, err := c.conn.Write(frame)
+
+	return err
+}
+
+func maskBytes(mask, data []byte) {
+	for i := range data {
+		data[i] ^= mask[i%4]
+	}
+}
+
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func headerContains(h http.Header, name, value string) bool {
+	for _, v := range h.Values(name) {
+		for _, s := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(s), value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,91 @@
+package websocket_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tomwatson6/chessbot/pkg/websocket"
+)
+
+func echoServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			op, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			if err := conn.WriteMessage(op, data); err != nil {
+				return
+			}
+		}
+	}))
+}
+
+func TestDial_Echo(t *testing.T) {
+	srv := echoServer(t)
+	defer srv.Close()
+
+	conn, err := websocket.Dial("ws" + strings.TrimPrefix(srv.URL, "http"))
+	if err != nil {
+		t.Fatalf("unexpected error dialing: %v", err)
+	}
+	defer conn.Close()
+
+	tcs := []struct {
+		name string
+		msg  string
+	}{
+		{name: "Small", msg: "hello"},
+		{name: "Medium", msg: strings.Repeat("a", 300)},
+		{name: "Large", msg: strings.Repeat("b", 70000)},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := conn.WriteMessage(websocket.OpText, []byte(tc.msg)); err != nil {
+				t.Fatalf("unexpected error writing: %v", err)
+			}
+
+			op, data, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatalf("unexpected error reading: %v", err)
+			}
+
+			if op != websocket.OpText || string(data) != tc.msg {
+				t.Errorf("want text message of length %d, got op %d with length %d", len(tc.msg), op, len(data))
+			}
+		})
+	}
+}
+
+func TestUpgrade_NotWebSocket(t *testing.T) {
+	srv := echoServer(t)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("want status %d, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+
+	plain := httptest.NewServer(http.NotFoundHandler())
+	defer plain.Close()
+
+	if _, err := websocket.Dial("ws" + strings.TrimPrefix(plain.URL, "http")); !errors.Is(err, websocket.ErrorBadHandshake) {
+		t.Errorf("want %v, got %v", websocket.ErrorBadHandshake, err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/tomwatson6/chessbot/internal/board"
 	"github.com/tomwatson6/chessbot/internal/move"
 	"github.com/tomwatson6/chessbot/internal/piece"
+	"github.com/tomwatson6/chessbot/internal/variant"
 )
 
 type BoardOption func(b *board.Board) error
@@ -73,3 +74,10 @@ func BoardWithHistory(history []board.Turn) BoardOption {
 		return nil
 	}
 }
+
+func BoardWithVariant(v variant.Variant) BoardOption {
+	return func(b *board.Board) error {
+		b.Variant = v
+		return nil
+	}
+}